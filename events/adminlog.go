@@ -1,7 +1,10 @@
 package events
 
-// 操作日志事件
+import "time"
+
+// 操作日志事件，LogID 为 0 时由后台日志模块写入，否则表示日志已经写入，只通知告警等订阅者
 type EventAddOperationLog struct {
+	LogID      uint
 	UserID     uint
 	Username   string
 	IP         string
@@ -10,4 +13,5 @@ type EventAddOperationLog struct {
 	Resource   string
 	ResourceID uint
 	Details    string
+	CreatedAt  time.Time // 为空时使用处理事件的时间
 }
@@ -0,0 +1,30 @@
+package events
+
+// 代码评审操作
+const (
+	ReviewActionCreate         = "create"
+	ReviewActionAssign         = "assign"
+	ReviewActionUpdate         = "update" // 更新评审的版本范围，状态重置为待评审
+	ReviewActionApprove        = "approve"
+	ReviewActionRequestChanges = "request_changes"
+	ReviewActionClose          = "close"
+	ReviewActionReopen         = "reopen"
+)
+
+// 代码评审状态变更事件
+type EventCodeReviewChanged struct {
+	ReviewID     uint
+	Title        string
+	Source       string
+	Repo         string
+	FromRev      string
+	ToRev        string
+	Action       string
+	OldStatus    string // 创建时为空
+	Status       string
+	AuthorID     uint
+	ReviewerID   uint
+	OperatorID   uint
+	OperatorName string
+	Comment      string
+}
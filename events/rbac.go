@@ -0,0 +1,6 @@
+package events
+
+// 角色的权限或资源范围变更事件，用于清除角色权限缓存，RoleID 为 0 表示所有角色
+type EventRoleChanged struct {
+	RoleID uint
+}
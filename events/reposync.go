@@ -0,0 +1,18 @@
+package events
+
+import "time"
+
+// 仓库提交记录事件，刷新提交记录或同步成功后发布
+type EventRepoCommits struct {
+	Profile string // 同步方案
+	Commits []RepoCommit
+}
+
+// 仓库提交记录
+type RepoCommit struct {
+	Revision string
+	Author   string
+	Message  string
+	Time     time.Time
+	Synced   bool // 是否已同步到目标仓库
+}
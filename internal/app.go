@@ -1,9 +1,13 @@
 package internal
 
 import (
+	"errors"
+	"log"
 	"time"
 
+	"github.com/andycai/goapi/events"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/blob"
 	"github.com/andycai/goapi/pkg/event"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -19,6 +23,9 @@ type App struct {
 	RouterAdminApi  fiber.Router
 	RouterAdmin     fiber.Router
 	Bus             *event.EventBus
+	Blob            blob.Store
+	Vault           *Vault
+	roles           *roleCache
 }
 
 func NewApp() *App {
@@ -32,9 +39,15 @@ func (a *App) Start(dbs []*gorm.DB, fiberApp *fiber.App) {
 	a.FiberApp = fiberApp
 	a.Bus = event.NewEventBus()
 
+	// 角色权限缓存，角色或权限变更时清除
+	a.roles = newRoleCache()
+	event.Subscribe(a.Bus, event.EventHandler[events.EventRoleChanged](a.roles.onRoleChanged))
+
 	sqlDb, _ := a.DB.DB()
 	expiry := time.Duration(a.Config.Auth.TokenExpire) * time.Second
 	SessionSetup(config.Database.Driver, sqlDb, config.Database.DSN, "sessions", expiry)
+	// 记录登录会话所属的用户，用于查看和强制下线
+	sessions = newSessionTracker(a.DB)
 
 	// 注册静态路由
 	serverConfig := a.Config.Server
@@ -42,6 +55,13 @@ func (a *App) Start(dbs []*gorm.DB, fiberApp *fiber.App) {
 		fiberApp.Static(staticPath.Route, staticPath.Path)
 	}
 
+	// 初始化凭据库，对象存储等配置中的密钥可以引用凭据库中的凭据
+	a.Vault = openVault(a.DB, a.Config.Vault)
+
+	// 初始化对象存储
+	a.Blob = openBlobStore(a.Config.Storage, a.Vault)
+	fiberApp.Get("/blob/*", a.serveBlob)
+
 	InitModules(a)
 
 	// 初始化公共路由
@@ -51,11 +71,11 @@ func (a *App) Start(dbs []*gorm.DB, fiberApp *fiber.App) {
 
 	// 初始化API路由
 	a.RouterAdminApi = fiberApp.Group("/api/admin")
-	a.RouterAdminApi.Use(AuthMiddleware)
+	a.RouterAdminApi.Use(a.AuthMiddleware)
 
 	// 初始化管理员路由
 	a.RouterAdmin = fiberApp.Group("/admin")
-	a.RouterAdmin.Use(AuthMiddleware)
+	a.RouterAdmin.Use(a.AuthMiddleware)
 	InitAuthRouters()
 }
 
@@ -63,20 +83,29 @@ func (a *App) HasPermission(permissionCode string) fiber.Handler {
 	return HasPermission(permissionCode, a.CurrentUser)
 }
 
-// Current 获取当前用户
+// Current 获取当前用户，使用 API 令牌时为令牌所属的用户，同一请求只加载一次
 func (a *App) CurrentUser(c *fiber.Ctx) *models.User {
-	isAuthenticated, userID := GetSession(c)
+	if p, ok := c.Locals(principalLocalKey).(*Principal); ok {
+		return p.User
+	}
 
-	if !isAuthenticated {
-		return nil
+	var userID uint
+	if token := CurrentToken(c); token != nil {
+		userID = token.UserID
+	} else {
+		isAuthenticated, id := GetSession(c)
+		if !isAuthenticated {
+			return nil
+		}
+		userID = id
 	}
 
-	var vo models.User
-	if err := a.DB.Preload("Role.Permissions").First(&vo, userID).Error; err != nil {
+	user := a.LoadUser(userID)
+	if user == nil {
 		return nil
 	}
-
-	return &vo
+	c.Locals(principalLocalKey, NewPrincipal(user, CurrentToken(c)))
+	return user
 }
 
 // IsInitializedModule 如果模块已初始化，则跳过
@@ -86,3 +115,50 @@ func (a *App) IsInitializedModule(module string) bool {
 	}
 	return true
 }
+
+// openBlobStore 根据配置创建对象存储，失败时退回本地存储
+func openBlobStore(conf StorageConfig, v *Vault) blob.Store {
+	secretKey, err := v.Resolve(conf.SecretKey, "storage:secret_key")
+	if err != nil {
+		log.Printf("读取对象存储密钥失败: %v", err)
+	}
+
+	store, err := blob.Open(blob.Config{
+		Driver:    conf.Driver,
+		Dir:       conf.Dir,
+		PublicURL: conf.PublicURL,
+		Endpoint:  conf.Endpoint,
+		Region:    conf.Region,
+		Bucket:    conf.Bucket,
+		AccessKey: conf.AccessKey,
+		SecretKey: secretKey,
+		PathStyle: conf.PathStyle,
+	})
+	if err == nil {
+		return store
+	}
+
+	log.Printf("初始化对象存储失败，使用本地存储: %v", err)
+	local, err := blob.NewLocal(conf.Dir, conf.PublicURL)
+	if err != nil {
+		log.Fatalf("初始化本地存储失败: %v", err)
+	}
+	return local
+}
+
+// serveBlob 读取对象存储中的文件
+func (a *App) serveBlob(c *fiber.Ctx) error {
+	rc, obj, err := a.Blob.Get(c.Context(), c.Params("*"))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	if obj.ContentType != "" {
+		c.Set(fiber.HeaderContentType, obj.ContentType)
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	return c.SendStream(rc, int(obj.Size))
+}
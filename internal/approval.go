@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/event"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const approvalLocalKey = "approval"
+
+// ApprovalTTL 审批单的有效期，过期后请求内容可能已经过时，需要重新提交
+const ApprovalTTL = 24 * time.Hour
+
+// ApprovalAction 可以要求审批的操作
+type ApprovalAction struct {
+	Code       string `json:"code"`       // 操作编码
+	Name       string `json:"name"`       // 操作名称
+	Permission string `json:"permission"` // 执行操作需要的权限，审批人也必须拥有该权限
+}
+
+var approvalActions []ApprovalAction
+
+// ApprovalActions 注册的所有可以要求审批的操作
+func ApprovalActions() []ApprovalAction {
+	return approvalActions
+}
+
+// FindApprovalAction 按编码查找操作
+func FindApprovalAction(code string) (ApprovalAction, bool) {
+	for _, action := range approvalActions {
+		if action.Code == code {
+			return action, true
+		}
+	}
+	return ApprovalAction{}, false
+}
+
+// RequireApproval 审批中间件，放在权限检查之后。操作启用审批时保存请求并返回 202，审批通过后重新执行请求
+func (a *App) RequireApproval(code, name, permission string) fiber.Handler {
+	if _, ok := FindApprovalAction(code); !ok {
+		approvalActions = append(approvalActions, ApprovalAction{Code: code, Name: name, Permission: permission})
+	}
+
+	return func(c *fiber.Ctx) error {
+		// 审批通过后重新执行的请求
+		if req := CurrentApproval(c); req != nil && req.Action == code {
+			return c.Next()
+		}
+
+		var policy models.ApprovalPolicy
+		if err := a.DB.Where("action = ?", code).Limit(1).Find(&policy).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "读取审批配置失败"})
+		}
+		if !policy.Enabled {
+			return c.Next()
+		}
+
+		user := a.CurrentUser(c)
+		if user == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "未授权访问")
+		}
+
+		now := time.Now()
+		req := models.ApprovalRequest{
+			Action:      code,
+			ActionName:  name,
+			Method:      c.Method(),
+			Path:        c.OriginalURL(),
+			ContentType: c.Get(fiber.HeaderContentType),
+			Payload:     string(c.Body()),
+			RequesterID: user.ID,
+			Requester:   user.Username,
+			IP:          c.IP(),
+			UserAgent:   truncate(c.Get(fiber.HeaderUserAgent), 255),
+			Status:      models.ApprovalPending,
+			ExpiresAt:   now.Add(ApprovalTTL),
+		}
+		if err := a.DB.Create(&req).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "提交审批失败"})
+		}
+
+		event.Publish(a.Bus, context.Background(), events.EventAddOperationLog{
+			UserID:     user.ID,
+			Username:   user.Username,
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+			Action:     "submit",
+			Resource:   "approval",
+			ResourceID: req.ID,
+			Details:    fmt.Sprintf("提交审批：%s", name),
+			CreatedAt:  now,
+		})
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message":     fmt.Sprintf("%s需要审批，已提交审批单 #%d，批准后自动执行", name, req.ID),
+			"pending":     true,
+			"approval_id": req.ID,
+		})
+	}
+}
+
+// CurrentApproval 重新执行的请求对应的审批单，普通请求返回 nil
+func CurrentApproval(c *fiber.Ctx) *models.ApprovalRequest {
+	req, _ := c.Locals(approvalLocalKey).(*models.ApprovalRequest)
+	return req
+}
+
+// ExecuteApproval 以申请人的身份重新执行审批单中保存的请求，经过完整的路由、认证和权限检查，返回响应的状态码和内容
+func (a *App) ExecuteApproval(req *models.ApprovalRequest) (int, string, error) {
+	user := a.LoadUser(req.RequesterID)
+	if user == nil || user.Status != 1 {
+		return 0, "", errors.New("申请人已删除或禁用")
+	}
+
+	httpReq := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(httpReq)
+	httpReq.Header.SetMethod(req.Method)
+	httpReq.SetRequestURI(req.Path)
+	httpReq.Header.SetUserAgent(req.UserAgent)
+	if req.ContentType != "" {
+		httpReq.Header.SetContentType(req.ContentType)
+	}
+	httpReq.SetBodyString(req.Payload)
+
+	var ctx fasthttp.RequestCtx
+	ctx.Init(httpReq, &net.TCPAddr{IP: net.ParseIP(req.IP)}, nil)
+	// Locals 保存在请求上下文中，网络请求无法设置，认证中间件据此识别重新执行的请求
+	ctx.SetUserValue(approvalLocalKey, req)
+	ctx.SetUserValue(principalLocalKey, NewPrincipal(user, nil))
+
+	a.FiberApp.Handler()(&ctx)
+
+	return ctx.Response.StatusCode(), string(ctx.Response.Body()), nil
+}
@@ -7,13 +7,20 @@ import (
 )
 
 type Config struct {
-	App       AppConfig      `toml:"app"`
-	Server    ServerConfig   `toml:"server"`
-	Database  DatabaseConfig `toml:"database"`
-	JSONPaths JSONPathConfig `toml:"json_paths"`
-	FTP       FTPConfig      `toml:"ftp"`
-	Auth      AuthConfig     `toml:"auth"`
-	Cors      CorsConfig     `toml:"cors"`
+	App       AppConfig       `toml:"app"`
+	Server    ServerConfig    `toml:"server"`
+	Database  DatabaseConfig  `toml:"database"`
+	JSONPaths JSONPathConfig  `toml:"json_paths"`
+	FTP       FTPConfig       `toml:"ftp"`
+	Auth      AuthConfig      `toml:"auth"`
+	Cors      CorsConfig      `toml:"cors"`
+	GameLog   GameLogConfig   `toml:"gamelog"`
+	Storage   StorageConfig   `toml:"storage"`
+	Retention RetentionConfig `toml:"retention"`
+	Vault     VaultConfig     `toml:"vault"`
+	SSO       SSOConfig       `toml:"sso"`
+	AdminLog  AdminLogConfig  `toml:"adminlog"`
+	SVN       SVNConfig       `toml:"svn"`
 }
 
 type ServerConfig struct {
@@ -36,14 +43,12 @@ type DatabaseConfig struct {
 }
 
 type FTPConfig struct {
-	Host       string `toml:"host"`
-	Port       string `toml:"port"`
-	User       string `toml:"user"`
-	Password   string `toml:"password"`
-	APKPath    string `toml:"apk_path"`
-	ZIPPath    string `toml:"zip_path"`
-	LogDir     string `toml:"log_dir"`
-	MaxLogSize int64  `toml:"max_log_size"`
+	Host     string `toml:"host"`
+	Port     string `toml:"port"`
+	User     string `toml:"user"`
+	Password string `toml:"password"`
+	APKPath  string `toml:"apk_path"`
+	ZIPPath  string `toml:"zip_path"`
 }
 
 type JSONPathConfig struct {
@@ -60,8 +65,116 @@ type StaticPathConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret   string `toml:"jwt_secret"`
-	TokenExpire int    `toml:"token_expire"`
+	JWTSecret         string `toml:"jwt_secret"`
+	TokenExpire       int    `toml:"token_expire"`
+	MaxFailures       int    `toml:"max_failures"`        // 账号连续登录失败多少次后锁定
+	LockMinutes       int    `toml:"lock_minutes"`        // 账号和 IP 的锁定时间（分钟）
+	IPMaxFailures     int    `toml:"ip_max_failures"`     // 同一 IP 一小时内最多失败次数，超过后锁定该 IP
+	PasswordMinLength int    `toml:"password_min_length"` // 密码最小长度
+	PasswordClasses   int    `toml:"password_classes"`    // 密码至少包含大写字母、小写字母、数字、符号中的几类
+	PasswordHistory   int    `toml:"password_history"`    // 新密码不能与最近几次使用过的密码相同
+}
+
+type GameLogConfig struct {
+	MaxBodySize   int    `toml:"max_body_size"`  // 单次请求解压后最大字节数
+	BufferSize    int    `toml:"buffer_size"`    // 内存缓冲队列容量
+	BatchSize     int    `toml:"batch_size"`     // 每批写入数据库的条数
+	FlushInterval int    `toml:"flush_interval"` // 缓冲刷新间隔（毫秒）
+	Workers       int    `toml:"workers"`        // 写入数据库的协程数
+	RateLimit     int    `toml:"rate_limit"`     // 每台设备每分钟允许的日志条数
+	DedupeWindow  int    `toml:"dedupe_window"`  // 重复日志过滤窗口（秒）
+	SpillDir      string `toml:"spill_dir"`      // 缓冲溢出时的落盘目录
+
+	StripAddresses   bool `toml:"strip_addresses"`    // 聚合异常时去掉堆栈中的内存地址
+	StripLineNumbers bool `toml:"strip_line_numbers"` // 聚合异常时去掉堆栈中的行号
+	StackDepth       int  `toml:"stack_depth"`        // 参与计算指纹的堆栈帧数
+
+	SymbolDir           string `toml:"symbol_dir"`            // 符号文件存放目录
+	SymbolicateOnIngest bool   `toml:"symbolicate_on_ingest"` // 入库前还原堆栈
+	SymbolMaxSize       int    `toml:"symbol_max_size"`       // 符号文件上传大小上限（字节）
+}
+
+type StorageConfig struct {
+	Driver    string `toml:"driver"`     // 存储驱动：local 或 s3
+	Dir       string `toml:"dir"`        // 本地存储根目录
+	PublicURL string `toml:"public_url"` // 对象访问地址前缀，为空时通过 /blob 路由读取
+	Endpoint  string `toml:"endpoint"`   // S3 服务地址
+	Region    string `toml:"region"`
+	Bucket    string `toml:"bucket"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	PathStyle bool   `toml:"path_style"` // MinIO 等使用 path-style 地址
+}
+
+type RetentionConfig struct {
+	Schedule   string `toml:"schedule"`    // 数据保留任务的执行时间（cron 表达式）
+	ArchiveDir string `toml:"archive_dir"` // 归档文件目录
+	ChunkSize  int    `toml:"chunk_size"`  // 每次删除的行数
+	ChunkPause int    `toml:"chunk_pause"` // 每批删除后的暂停时间（毫秒），避免长时间占用数据库
+}
+
+type AdminLogConfig struct {
+	AlertWebhook string `toml:"alert_webhook"` // 告警规则默认的 Webhook 地址，支持 secret:// 引用
+	AlertTimeout int    `toml:"alert_timeout"` // 发送告警的超时时间（秒）
+}
+
+type VaultConfig struct {
+	KeyEnv  string `toml:"key_env"`  // 主密钥所在的环境变量，hex 或 base64 编码的 32 字节
+	KeyFile string `toml:"key_file"` // 环境变量未设置时读取的密钥文件，不存在时自动生成
+}
+
+type SSOConfig struct {
+	LDAP LDAPConfig `toml:"ldap"`
+	OIDC OIDCConfig `toml:"oidc"`
+}
+
+// SSORoleConfig 外部用户组到角色的映射，按顺序匹配
+type SSORoleConfig struct {
+	Group string `toml:"group"`
+	Role  string `toml:"role"` // 角色名称
+}
+
+type LDAPConfig struct {
+	Enabled            bool            `toml:"enabled"`
+	URL                string          `toml:"url"`
+	StartTLS           bool            `toml:"start_tls"`
+	InsecureSkipVerify bool            `toml:"insecure_skip_verify"`
+	BindDN             string          `toml:"bind_dn"`
+	BindPassword       string          `toml:"bind_password"` // 支持 secret:// 引用
+	BaseDN             string          `toml:"base_dn"`
+	UserFilter         string          `toml:"user_filter"`
+	UsernameAttr       string          `toml:"username_attr"`
+	NameAttr           string          `toml:"name_attr"`
+	EmailAttr          string          `toml:"email_attr"`
+	GroupAttr          string          `toml:"group_attr"`
+	Roles              []SSORoleConfig `toml:"roles"`
+	DefaultRole        string          `toml:"default_role"` // 没有匹配的用户组时使用的角色，为空时拒绝登录
+}
+
+type OIDCConfig struct {
+	Enabled       bool            `toml:"enabled"`
+	Name          string          `toml:"name"` // 登录按钮上显示的名称
+	Issuer        string          `toml:"issuer"`
+	ClientID      string          `toml:"client_id"`
+	ClientSecret  string          `toml:"client_secret"` // 支持 secret:// 引用
+	RedirectURL   string          `toml:"redirect_url"`
+	Scopes        []string        `toml:"scopes"`
+	UsernameClaim string          `toml:"username_claim"`
+	NameClaim     string          `toml:"name_claim"`
+	GroupsClaim   string          `toml:"groups_claim"`
+	Roles         []SSORoleConfig `toml:"roles"`
+	DefaultRole   string          `toml:"default_role"`
+}
+
+type SVNConfig struct {
+	Credentials []SVNCredentialConfig `toml:"credentials"`
+}
+
+// SVNCredentialConfig 仓库地址对应的账号，工作副本按 URL 最长前缀匹配
+type SVNCredentialConfig struct {
+	URL      string `toml:"url"`
+	Username string `toml:"username"`
+	Password string `toml:"password"` // 支持 secret:// 引用
 }
 
 type AppConfig struct {
@@ -114,6 +227,90 @@ func LoadConfig() error {
 		config.Database.ConnMaxLifetime = 3600 // 默认连接生命周期为1小时
 	}
 
+	if config.Auth.MaxFailures == 0 {
+		config.Auth.MaxFailures = 5
+	}
+	if config.Auth.LockMinutes == 0 {
+		config.Auth.LockMinutes = 15
+	}
+	if config.Auth.IPMaxFailures == 0 {
+		config.Auth.IPMaxFailures = 20
+	}
+	if config.Auth.PasswordMinLength == 0 {
+		config.Auth.PasswordMinLength = 8
+	}
+	if config.Auth.PasswordClasses == 0 {
+		config.Auth.PasswordClasses = 3
+	}
+	if config.Auth.PasswordHistory == 0 {
+		config.Auth.PasswordHistory = 5
+	}
+
+	if config.GameLog.MaxBodySize == 0 {
+		config.GameLog.MaxBodySize = 4 * 1024 * 1024 // 默认解压后最大4MB
+	}
+	if config.GameLog.BufferSize == 0 {
+		config.GameLog.BufferSize = 10000
+	}
+	if config.GameLog.BatchSize == 0 {
+		config.GameLog.BatchSize = 500
+	}
+	if config.GameLog.FlushInterval == 0 {
+		config.GameLog.FlushInterval = 1000
+	}
+	if config.GameLog.Workers == 0 {
+		config.GameLog.Workers = 2
+	}
+	if config.GameLog.RateLimit == 0 {
+		config.GameLog.RateLimit = 600
+	}
+	if config.GameLog.DedupeWindow == 0 {
+		config.GameLog.DedupeWindow = 60
+	}
+	if config.GameLog.SpillDir == "" {
+		config.GameLog.SpillDir = "data/gamelog_spill"
+	}
+	if config.GameLog.StackDepth == 0 {
+		config.GameLog.StackDepth = 10
+	}
+	if config.GameLog.SymbolDir == "" {
+		config.GameLog.SymbolDir = "data/symbols"
+	}
+	if config.GameLog.SymbolMaxSize == 0 {
+		config.GameLog.SymbolMaxSize = 512 * 1024 * 1024
+	}
+
+	if config.Storage.Driver == "" {
+		config.Storage.Driver = "local"
+	}
+	if config.Storage.Dir == "" {
+		config.Storage.Dir = "./uploads"
+	}
+
+	if config.Retention.Schedule == "" {
+		config.Retention.Schedule = "0 3 * * *" // 默认每天凌晨3点
+	}
+	if config.Retention.ArchiveDir == "" {
+		config.Retention.ArchiveDir = "data/archives"
+	}
+	if config.Retention.ChunkSize == 0 {
+		config.Retention.ChunkSize = 1000
+	}
+	if config.Retention.ChunkPause == 0 {
+		config.Retention.ChunkPause = 50
+	}
+
+	if config.Vault.KeyEnv == "" {
+		config.Vault.KeyEnv = "GOAPI_MASTER_KEY"
+	}
+	if config.Vault.KeyFile == "" {
+		config.Vault.KeyFile = "data/vault.key"
+	}
+
+	if config.SSO.OIDC.Name == "" {
+		config.SSO.OIDC.Name = "单点登录"
+	}
+
 	// 命令行参数覆盖配置文件
 	if *host != "" {
 		config.Server.Host = *host
@@ -182,6 +379,30 @@ func GetCorsConfig() CorsConfig {
 	return config.Cors
 }
 
+func GetGameLogConfig() GameLogConfig {
+	return config.GameLog
+}
+
+func GetStorageConfig() StorageConfig {
+	return config.Storage
+}
+
+func GetRetentionConfig() RetentionConfig {
+	return config.Retention
+}
+
+func GetVaultConfig() VaultConfig {
+	return config.Vault
+}
+
+func GetSSOConfig() SSOConfig {
+	return config.SSO
+}
+
+func GetSVNConfig() SVNConfig {
+	return config.SVN
+}
+
 func UpdateServerConfig(newConfig ServerConfig) {
 	config.Server = newConfig
 }
@@ -19,15 +19,39 @@ func SkipAuthRoute(path string) {
 	skipAuthRoutes[path] = true
 }
 
-func AuthMiddleware(c *fiber.Ctx) error {
+// AuthMiddleware 认证中间件，支持会话和 Authorization: Bearer 请求头中的 API 令牌
+func (a *App) AuthMiddleware(c *fiber.Ctx) error {
 	// 检查是否是不需要认证的路由
 	if skipAuthRoutes[c.Path()] {
 		return c.Next()
 	}
 
-	isAuthenticated, _ := GetSession(c)
+	// 审批通过后以申请人身份重新执行的请求，权限主体已经设置
+	if CurrentApproval(c) != nil {
+		if _, ok := c.Locals(principalLocalKey).(*Principal); ok {
+			return c.Next()
+		}
+	}
+
+	if raw, ok := bearerToken(c); ok {
+		if err := a.authenticateToken(c, raw); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Next()
+	}
+
+	isAuthenticated, userID := GetSession(c)
 
 	if isAuthenticated {
+		// 加载当前用户和权限，后续的权限检查和处理函数直接使用
+		user := a.LoadUser(userID)
+		if user == nil || user.Status != 1 {
+			// 用户已删除或禁用，会话立即失效
+			DestroySession(c)
+			return fiber.NewError(fiber.StatusUnauthorized, "未授权访问")
+		}
+		sessions.touch(c, user.ID)
+		c.Locals(principalLocalKey, NewPrincipal(user, nil))
 		return c.Next()
 	}
 
@@ -101,25 +125,32 @@ func AuthMiddleware2(db *gorm.DB, JWTSecret string) fiber.Handler {
 	}
 }
 
-// HasPermission 权限检查中间件
+// HasPermission 权限检查中间件，只检查是否拥有权限，资源范围由处理函数使用 Can 或 Scope 检查
 func HasPermission(permissionCode string, userFunc func(c *fiber.Ctx) *models.User) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		if userFunc == nil || userFunc(c) == nil {
+		principal := CurrentPrincipal(c, userFunc)
+		if principal == nil {
 			return errors.New("请先登录")
 		}
-		currentUser := userFunc(c)
+		currentUser := principal.User
 
 		// 检查用户权限
-		hasPermission := false
-		for _, perm := range currentUser.Role.Permissions {
-			if perm.Code == permissionCode {
-				hasPermission = true
-				break
-			}
+		if _, ok := principal.scopes[permissionCode]; !ok {
+			return fiber.NewError(fiber.StatusForbidden, "没有权限")
 		}
 
-		if !hasPermission {
-			return fiber.NewError(fiber.StatusForbidden, "没有权限")
+		// API 令牌只能使用创建时选择的权限
+		token := principal.Token
+		if token != nil && !TokenHasScope(token, permissionCode) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API 令牌没有 " + permissionCode + " 权限"})
+		}
+
+		// 角色要求两步验证的用户，启用前只能访问个人信息页面
+		if token == nil && TwoFactorRequired(currentUser) && !currentUser.TOTPEnabled {
+			if c.Method() == fiber.MethodGet && !strings.HasPrefix(c.Path(), "/api/") {
+				return c.Redirect("/admin/profile?setup2fa=1")
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "角色要求启用两步验证，请先在个人信息页面启用"})
 		}
 
 		return c.Next()
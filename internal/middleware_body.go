@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// largeBodyRoutes 允许超过全局请求体上限的路由及其上限，请求体以流的方式读取
+var largeBodyRoutes = make(map[string]int)
+
+// LargeBodyRoute 为上传大文件的路由单独设置请求体上限，处理函数需要自行流式读取请求体
+func LargeBodyRoute(path string, limit int) {
+	largeBodyRoutes[path] = limit
+}
+
+// BodyLimitMiddleware 请求体大小限制中间件，开启 StreamRequestBody 后 fasthttp 不再拒绝超限的请求体，
+// 由这里按路由检查：普通路由仍使用全局上限，大文件路由使用 LargeBodyRoute 设置的上限
+func BodyLimitMiddleware(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		length := req.Header.ContentLength()
+
+		if routeLimit, ok := largeBodyRoutes[c.Path()]; ok {
+			// 分块传输无法预先判断大小，大文件路由要求提供 Content-Length
+			if length < 0 {
+				return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{"error": "缺少 Content-Length"})
+			}
+			if length > routeLimit {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "请求体过大"})
+			}
+			return c.Next()
+		}
+
+		if length > limit {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "请求体过大"})
+		}
+
+		// 分块传输的请求体在这里按全局上限读入内存，后续处理函数照常使用 c.Body()
+		if length < 0 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "读取请求体失败"})
+			}
+			if len(body) > limit {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "请求体过大"})
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andycai/goapi/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const principalLocalKey = "principal"
+
+// Resource 需要检查权限的资源，ID 和标签至少有一个与权限范围匹配即可操作
+type Resource struct {
+	ID   uint
+	Tags []string
+}
+
+// Scope 某个权限可以操作的资源范围
+type Scope struct {
+	All  bool     // 可以操作所有资源
+	IDs  []uint   // 可以操作的资源 ID
+	Tags []string // 可以操作的资源标签
+}
+
+// Allows 资源是否在范围内
+func (s Scope) Allows(res Resource) bool {
+	if s.All {
+		return true
+	}
+	if res.ID != 0 && slices.Contains(s.IDs, res.ID) {
+		return true
+	}
+	for _, tag := range res.Tags {
+		if slices.Contains(s.Tags, NormalizeTag(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty 没有任何可以操作的资源
+func (s Scope) Empty() bool {
+	return !s.All && len(s.IDs) == 0 && len(s.Tags) == 0
+}
+
+// Apply 按资源 ID 过滤查询，column 为资源 ID 所在的列，只适用于按 ID 配置范围的资源
+func (s Scope) Apply(db *gorm.DB, column string) *gorm.DB {
+	if s.All {
+		return db
+	}
+	if len(s.IDs) == 0 {
+		return db.Where("1 = 0")
+	}
+	return db.Where(column+" IN ?", s.IDs)
+}
+
+// Principal 当前请求的用户，以及用户所有角色合并后的权限和资源范围
+type Principal struct {
+	User   *models.User
+	Token  *models.APIToken
+	scopes map[string]*Scope
+}
+
+// NewPrincipal 合并用户主角色和其他角色的权限，同一权限在任一角色中没有配置范围时可以操作所有资源
+func NewPrincipal(user *models.User, token *models.APIToken) *Principal {
+	p := &Principal{User: user, Token: token, scopes: make(map[string]*Scope)}
+	for _, role := range UserRoles(user) {
+		limited := make(map[string][]models.RoleScope)
+		for _, rs := range role.Scopes {
+			limited[rs.Permission] = append(limited[rs.Permission], rs)
+		}
+
+		for _, perm := range role.Permissions {
+			scope, ok := p.scopes[perm.Code]
+			if !ok {
+				scope = &Scope{}
+				p.scopes[perm.Code] = scope
+			}
+			rows, ok := limited[perm.Code]
+			if !ok {
+				scope.All = true
+				continue
+			}
+			for _, rs := range rows {
+				if rs.ResourceID != 0 && !slices.Contains(scope.IDs, rs.ResourceID) {
+					scope.IDs = append(scope.IDs, rs.ResourceID)
+				}
+				if tag := NormalizeTag(rs.Tag); tag != "" && !slices.Contains(scope.Tags, tag) {
+					scope.Tags = append(scope.Tags, tag)
+				}
+			}
+		}
+	}
+	return p
+}
+
+// Has 是否拥有权限，不考虑资源范围，使用 API 令牌时还需要令牌允许该权限
+func (p *Principal) Has(code string) bool {
+	if _, ok := p.scopes[code]; !ok {
+		return false
+	}
+	return p.Token == nil || TokenHasScope(p.Token, code)
+}
+
+// Scope 权限可以操作的资源范围，没有权限时返回空范围
+func (p *Principal) Scope(code string) Scope {
+	if !p.Has(code) {
+		return Scope{}
+	}
+	return *p.scopes[code]
+}
+
+// Can 是否可以对资源使用权限
+func (p *Principal) Can(code string, res Resource) bool {
+	return p.Scope(code).Allows(res)
+}
+
+// Codes 拥有的所有权限编码，已排序
+func (p *Principal) Codes() []string {
+	codes := make([]string, 0, len(p.scopes))
+	for code := range p.scopes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Filter 过滤出可以使用权限的资源，用于不分页的列表
+func Filter[T any](p *Principal, code string, items []T, resource func(T) Resource) []T {
+	scope := p.Scope(code)
+	if scope.All {
+		return items
+	}
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if scope.Allows(resource(item)) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// CurrentPrincipal 当前请求的权限主体，同一请求只加载一次
+func CurrentPrincipal(c *fiber.Ctx, userFunc func(c *fiber.Ctx) *models.User) *Principal {
+	if p, ok := c.Locals(principalLocalKey).(*Principal); ok {
+		return p
+	}
+	if userFunc == nil {
+		return nil
+	}
+	user := userFunc(c)
+	if user == nil {
+		return nil
+	}
+	p := NewPrincipal(user, CurrentToken(c))
+	c.Locals(principalLocalKey, p)
+	return p
+}
+
+// Principal 当前请求的权限主体，未登录时返回 nil
+func (a *App) Principal(c *fiber.Ctx) *Principal {
+	return CurrentPrincipal(c, a.CurrentUser)
+}
+
+// Can 当前用户是否可以对资源使用权限
+func (a *App) Can(c *fiber.Ctx, code string, res Resource) bool {
+	p := a.Principal(c)
+	return p != nil && p.Can(code, res)
+}
+
+// Scope 当前用户使用权限可以操作的资源范围
+func (a *App) Scope(c *fiber.Ctx, code string) Scope {
+	p := a.Principal(c)
+	if p == nil {
+		return Scope{}
+	}
+	return p.Scope(code)
+}
+
+// UserRoles 用户的主角色和其他角色，按 ID 去重
+func UserRoles(user *models.User) []models.Role {
+	roles := make([]models.Role, 0, len(user.Roles)+1)
+	if user.Role.ID != 0 {
+		roles = append(roles, user.Role)
+	}
+	for _, role := range user.Roles {
+		if role.ID != user.Role.ID {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// ParseTags 解析逗号分隔的标签
+func ParseTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = NormalizeTag(tag); tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// NormalizeTag 标签不区分大小写
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"sync"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+// roleCache 角色及其权限和资源范围的缓存，角色或权限变更时通过事件总线清除
+type roleCache struct {
+	mu      sync.RWMutex
+	roles   map[uint]models.Role
+	version uint64 // 每次清除时加一，避免把清除前查询的旧数据写回缓存
+}
+
+func newRoleCache() *roleCache {
+	return &roleCache{roles: make(map[uint]models.Role)}
+}
+
+// get 获取角色，缓存中没有的从数据库加载，返回的角色只读
+func (rc *roleCache) get(db *gorm.DB, ids []uint) (map[uint]models.Role, error) {
+	result := make(map[uint]models.Role, len(ids))
+	var missing []uint
+
+	rc.mu.RLock()
+	version := rc.version
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if role, ok := rc.roles[id]; ok {
+			result[id] = role
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	rc.mu.RUnlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	var roles []models.Role
+	if err := db.Preload("Permissions").Preload("Scopes").Find(&roles, missing).Error; err != nil {
+		return nil, err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, role := range roles {
+		result[role.ID] = role
+		if rc.version == version {
+			rc.roles[role.ID] = role
+		}
+	}
+	return result, nil
+}
+
+// invalidate 清除角色的缓存，roleID 为 0 时清除所有角色
+func (rc *roleCache) invalidate(roleID uint) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.version++
+	if roleID == 0 {
+		rc.roles = make(map[uint]models.Role)
+		return
+	}
+	delete(rc.roles, roleID)
+}
+
+// onRoleChanged 角色变更事件
+func (rc *roleCache) onRoleChanged(ctx context.Context, event events.EventRoleChanged) error {
+	rc.invalidate(event.RoleID)
+	return nil
+}
+
+// LoadUser 加载用户和用户的所有角色，角色的权限和资源范围从缓存读取，用户不存在时返回 nil
+func (a *App) LoadUser(userID uint) *models.User {
+	var user models.User
+	if err := a.DB.First(&user, userID).Error; err != nil {
+		return nil
+	}
+
+	var roleIDs []uint
+	if err := a.DB.Model(&models.UserRole{}).Where("user_id = ?", userID).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil
+	}
+
+	roles, err := a.roles.get(a.DB, append([]uint{user.RoleID}, roleIDs...))
+	if err != nil {
+		return nil
+	}
+	user.Role = roles[user.RoleID]
+	for _, id := range roleIDs {
+		if role, ok := roles[id]; ok {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	return &user
+}
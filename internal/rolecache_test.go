@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newCacheTestApp 创建使用内存数据库的 App：用户 1 的主角色有 20 个权限，另有一个带资源范围的附加角色
+func newCacheTestApp(tb testing.TB) *App {
+	tb.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", tb.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.UserRole{}, &models.RoleScope{}); err != nil {
+		tb.Fatal(err)
+	}
+
+	var permissions []models.Permission
+	for i := 0; i < 20; i++ {
+		permissions = append(permissions, models.Permission{
+			Name: fmt.Sprintf("权限%d", i),
+			Code: fmt.Sprintf("module%d:view", i),
+		})
+	}
+	main := models.Role{Name: "主角色", Permissions: permissions}
+	extra := models.Role{
+		Name:        "附加角色",
+		Permissions: []models.Permission{{Name: "执行任务", Code: "citask:run"}},
+		Scopes:      []models.RoleScope{{Permission: "citask:run", Tag: "android"}},
+	}
+	if err := db.Create(&main).Error; err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Create(&extra).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	user := models.User{Username: "alice", RoleID: main.ID}
+	if err := db.Create(&user).Error; err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Create(&models.UserRole{UserID: user.ID, RoleID: extra.ID}).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	return &App{DB: db, roles: newRoleCache()}
+}
+
+func TestLoadUser(t *testing.T) {
+	a := newCacheTestApp(t)
+
+	user := a.LoadUser(1)
+	if user == nil {
+		t.Fatal("LoadUser returned nil")
+	}
+	if len(user.Role.Permissions) != 20 || len(user.Roles) != 1 {
+		t.Fatalf("role = %d permissions, %d extra roles", len(user.Role.Permissions), len(user.Roles))
+	}
+
+	p := NewPrincipal(user, nil)
+	if !p.Has("module0:view") || !p.Has("citask:run") || p.Has("user:delete") {
+		t.Error("principal permissions do not match the roles")
+	}
+	if scope := p.Scope("citask:run"); scope.All {
+		t.Error("scoped permission was granted on all resources")
+	}
+
+	if a.LoadUser(99) != nil {
+		t.Error("LoadUser returned a missing user")
+	}
+}
+
+func TestRoleCacheInvalidate(t *testing.T) {
+	a := newCacheTestApp(t)
+	if user := a.LoadUser(1); len(user.Role.Permissions) != 20 {
+		t.Fatalf("got %d permissions", len(user.Role.Permissions))
+	}
+
+	// 直接修改数据库，缓存仍然返回旧的权限
+	var role models.Role
+	a.DB.Preload("Permissions").First(&role, 1)
+	if err := a.DB.Model(&role).Association("Permissions").Delete(role.Permissions[:5]); err != nil {
+		t.Fatal(err)
+	}
+	if user := a.LoadUser(1); len(user.Role.Permissions) != 20 {
+		t.Fatalf("cached role has %d permissions, want 20", len(user.Role.Permissions))
+	}
+
+	// 角色变更事件清除该角色，其他角色仍在缓存中
+	if err := a.roles.onRoleChanged(context.Background(), events.EventRoleChanged{RoleID: role.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.roles.roles[2]; !ok {
+		t.Error("invalidating one role dropped the others")
+	}
+	if user := a.LoadUser(1); len(user.Role.Permissions) != 15 {
+		t.Errorf("reloaded role has %d permissions, want 15", len(user.Role.Permissions))
+	}
+
+	a.roles.invalidate(0)
+	if len(a.roles.roles) != 0 {
+		t.Error("invalidate(0) kept cached roles")
+	}
+}
+
+func TestRoleCacheStaleLoad(t *testing.T) {
+	a := newCacheTestApp(t)
+
+	// 加载过程中发生清除时，查询结果照常返回但不写回缓存
+	db := a.DB.Session(&gorm.Session{})
+	db.Callback().Query().After("gorm:query").Register("test:invalidate", func(*gorm.DB) {
+		a.roles.invalidate(0)
+	})
+
+	roles, err := a.roles.get(db, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := roles[1]; !ok {
+		t.Fatal("role 1 was not loaded")
+	}
+	if len(a.roles.roles) != 0 {
+		t.Error("a load that raced with an invalidation was cached")
+	}
+}
+
+// BenchmarkLoadUserPreload 缓存前的加载方式，每次都预加载角色和权限
+func BenchmarkLoadUserPreload(b *testing.B) {
+	a := newCacheTestApp(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var user models.User
+		if err := a.DB.Preload("Role.Permissions").Preload("Role.Scopes").
+			Preload("Roles.Permissions").Preload("Roles.Scopes").
+			First(&user, 1).Error; err != nil {
+			b.Fatal(err)
+		}
+		NewPrincipal(&user, nil)
+	}
+}
+
+// BenchmarkLoadUserCold 每次加载前清除角色缓存
+func BenchmarkLoadUserCold(b *testing.B) {
+	a := newCacheTestApp(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.roles.invalidate(0)
+		if user := a.LoadUser(1); user == nil {
+			b.Fatal("LoadUser returned nil")
+		} else {
+			NewPrincipal(user, nil)
+		}
+	}
+}
+
+// BenchmarkLoadUserCached 角色命中缓存，只查询用户和用户的角色列表
+func BenchmarkLoadUserCached(b *testing.B) {
+	a := newCacheTestApp(b)
+	a.LoadUser(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if user := a.LoadUser(1); user == nil {
+			b.Fatal("LoadUser returned nil")
+		} else {
+			NewPrincipal(user, nil)
+		}
+	}
+}
+
+// BenchmarkRoleCacheParallel 并发读取缓存的角色
+func BenchmarkRoleCacheParallel(b *testing.B) {
+	a := newCacheTestApp(b)
+	ids := []uint{1, 2}
+	if _, err := a.roles.get(a.DB, ids); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := a.roles.get(a.DB, ids); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkPrincipalHas 同一请求内的权限检查不再访问数据库
+func BenchmarkPrincipalHas(b *testing.B) {
+	a := newCacheTestApp(b)
+	p := NewPrincipal(a.LoadUser(1), nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !p.Has("module19:view") {
+			b.Fatal("permission missing")
+		}
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/glebarez/sqlite"
@@ -19,8 +20,24 @@ var (
 const (
 	sessionName = "unitool_session"
 	userIDKey   = "user_id"
+
+	pendingUserIDKey   = "mfa_user_id"
+	pendingRememberKey = "mfa_remember"
+	pendingExpiresKey  = "mfa_expires"
+	pendingAttemptsKey = "mfa_attempts"
+
+	// pendingLoginExpiry 密码验证通过后输入两步验证码的时间
+	pendingLoginExpiry = 5 * time.Minute
+	// pendingLoginAttempts 两步验证码最多输入错误的次数，超过后需要重新输入密码
+	pendingLoginAttempts = 5
 )
 
+// PendingLogin 密码已验证、等待两步验证的登录
+type PendingLogin struct {
+	UserID   uint
+	Remember bool
+}
+
 // FiberSession Fiber默认的会话表结构
 type FiberSession struct {
 	K string `gorm:"column:k;primaryKey"` // key
@@ -155,15 +172,30 @@ func SessionSetup(dbDriver string, db *sql.DB, dsn, tableName string, expiry tim
 	})
 }
 
-// StoreSession 存储用户认证信息
-func StoreSession(c *fiber.Ctx, userID uint) error {
+// StoreSession 存储用户认证信息并记录会话，expiry 为 0 时使用默认过期时间
+func StoreSession(c *fiber.Ctx, userID uint, expiry time.Duration) error {
 	sess, err := store.Get(c)
 	if err != nil {
 		return err
 	}
 
+	// 登录后使用新的会话 ID，避免登录前的会话 ID 被他人利用
+	if err := sess.Regenerate(); err != nil {
+		return err
+	}
+	if expiry <= 0 {
+		expiry = store.Expiration
+	}
+	sess.SetExpiry(expiry)
 	sess.Set(userIDKey, userID)
-	return sess.Save()
+
+	// Save 之后不能再使用 sess
+	sessionID := sess.ID()
+	if err := sess.Save(); err != nil {
+		return err
+	}
+	sessions.record(c, sessionID, userID, expiry)
+	return nil
 }
 
 // GetSession 获取用户认证信息
@@ -198,7 +230,12 @@ func DestroySession(c *fiber.Ctx) error {
 		return err
 	}
 
-	return sess.Destroy()
+	sessionID := sess.ID()
+	if err := sess.Destroy(); err != nil {
+		return err
+	}
+	sessions.remove(sessionID)
+	return nil
 }
 
 // SetSessionExpiration 设置session过期时间
@@ -211,3 +248,63 @@ func SetSessionExpiration(c *fiber.Ctx, duration time.Duration) error {
 	sess.SetExpiry(duration)
 	return sess.Save()
 }
+
+// StorePendingLogin 保存等待两步验证的登录，此时会话还没有登录
+func StorePendingLogin(c *fiber.Ctx, userID uint, remember bool) error {
+	sess, err := store.Get(c)
+	if err != nil {
+		return err
+	}
+
+	sess.Delete(userIDKey)
+	sess.Set(pendingUserIDKey, userID)
+	sess.Set(pendingRememberKey, remember)
+	sess.Set(pendingExpiresKey, time.Now().Add(pendingLoginExpiry).Unix())
+	sess.Set(pendingAttemptsKey, 0)
+	return sess.Save()
+}
+
+// GetPendingLogin 获取等待两步验证的登录，每次获取计为一次尝试，超时或尝试次数过多时清除
+func GetPendingLogin(c *fiber.Ctx) (*PendingLogin, error) {
+	sess, err := store.Get(c)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, ok := sess.Get(pendingUserIDKey).(uint)
+	if !ok {
+		return nil, errors.New("登录已过期，请重新登录")
+	}
+	expires, _ := sess.Get(pendingExpiresKey).(int64)
+	attempts, _ := sess.Get(pendingAttemptsKey).(int)
+	if time.Now().Unix() > expires || attempts >= pendingLoginAttempts {
+		clearPendingLogin(sess)
+		sess.Save()
+		return nil, errors.New("登录已过期，请重新登录")
+	}
+
+	remember, _ := sess.Get(pendingRememberKey).(bool)
+	sess.Set(pendingAttemptsKey, attempts+1)
+	if err := sess.Save(); err != nil {
+		return nil, err
+	}
+	return &PendingLogin{UserID: userID, Remember: remember}, nil
+}
+
+// ClearPendingLogin 清除等待两步验证的登录
+func ClearPendingLogin(c *fiber.Ctx) error {
+	sess, err := store.Get(c)
+	if err != nil {
+		return err
+	}
+
+	clearPendingLogin(sess)
+	return sess.Save()
+}
+
+func clearPendingLogin(sess *session.Session) {
+	sess.Delete(pendingUserIDKey)
+	sess.Delete(pendingRememberKey)
+	sess.Delete(pendingExpiresKey)
+	sess.Delete(pendingAttemptsKey)
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// TokenPrefix API 令牌的前缀，便于识别泄露到日志或代码中的令牌
+	TokenPrefix = "gat_"
+
+	tokenLocalKey = "api_token"
+
+	// tokenTouchInterval 最后使用时间的更新间隔，避免每个请求都写数据库
+	tokenTouchInterval = time.Minute
+)
+
+// GenerateAPIToken 生成 API 令牌，返回明文、用于展示的前缀和保存到数据库的哈希
+func GenerateAPIToken() (raw, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	raw = TokenPrefix + hex.EncodeToString(buf)
+	return raw, raw[:len(TokenPrefix)+8], HashAPIToken(raw), nil
+}
+
+// HashAPIToken 计算令牌的哈希，令牌是随机生成的，不需要加盐
+func HashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenScopes 解析令牌允许使用的权限编码
+func TokenScopes(token *models.APIToken) []string {
+	var scopes []string
+	for _, scope := range strings.Split(token.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// TokenHasScope 令牌是否允许使用该权限
+func TokenHasScope(token *models.APIToken, permissionCode string) bool {
+	for _, scope := range TokenScopes(token) {
+		if scope == permissionCode {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentToken 当前请求使用的 API 令牌，通过会话登录时返回 nil
+func CurrentToken(c *fiber.Ctx) *models.APIToken {
+	token, _ := c.Locals(tokenLocalKey).(*models.APIToken)
+	return token
+}
+
+// bearerToken 从 Authorization 请求头读取 API 令牌
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, raw, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	raw = strings.TrimSpace(raw)
+	return raw, raw != ""
+}
+
+// authenticateToken 校验 API 令牌，通过后保存到请求上下文
+func (a *App) authenticateToken(c *fiber.Ctx, raw string) error {
+	if !strings.HasPrefix(raw, TokenPrefix) {
+		return errors.New("无效的 API 令牌")
+	}
+
+	var token models.APIToken
+	if err := a.DB.Where("token_hash = ? AND revoked_at IS NULL", HashAPIToken(raw)).First(&token).Error; err != nil {
+		return errors.New("无效的 API 令牌")
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return errors.New("API 令牌已过期")
+	}
+
+	user := a.LoadUser(token.UserID)
+	if user == nil || user.Status != 1 {
+		return errors.New("令牌所属用户不存在或已禁用")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > tokenTouchInterval {
+		a.DB.Model(&token).UpdateColumns(map[string]any{
+			"last_used_at": now,
+			"last_used_ip": c.IP(),
+		})
+	}
+
+	c.Locals(tokenLocalKey, &token)
+	c.Locals(principalLocalKey, NewPrincipal(user, &token))
+	return nil
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet 恢复码使用的字符，去掉了容易混淆的 0、1、i、l、o
+	recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+)
+
+var ErrInvalidTwoFactorCode = errors.New("验证码错误")
+
+// TwoFactorRequired 用户的任一角色是否要求启用两步验证，服务账号只能使用 API 令牌，不受限制
+func TwoFactorRequired(user *models.User) bool {
+	if user.IsService {
+		return false
+	}
+	for _, role := range UserRoles(user) {
+		if role.Require2FA {
+			return true
+		}
+	}
+	return false
+}
+
+// totpSecretName 两步验证密钥加密时的附加数据，密钥只能用于对应的用户
+func totpSecretName(userID uint) string {
+	return fmt.Sprintf("totp:%d", userID)
+}
+
+// EncryptTOTPSecret 加密用户的两步验证密钥
+func (a *App) EncryptTOTPSecret(userID uint, secret string) (string, error) {
+	return a.Vault.Encrypt(totpSecretName(userID), secret)
+}
+
+// VerifyTOTP 校验身份验证器应用的验证码，通过后保存验证码所在的周期，同一验证码不能再次使用
+func (a *App) VerifyTOTP(user *models.User, code string) error {
+	if user.TOTPSecret == "" {
+		return errors.New("未设置两步验证")
+	}
+	secret, err := a.Vault.Decrypt(totpSecretName(user.ID), user.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("读取两步验证密钥失败: %v", err)
+	}
+
+	counter, ok := totp.Validate(secret, code, time.Now())
+	if !ok || counter <= user.TOTPCounter {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// 条件更新，并发提交同一验证码时只有一个成功
+	result := a.DB.Model(&models.User{}).
+		Where("id = ? AND totp_counter < ?", user.ID, counter).
+		Update("totp_counter", counter)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	user.TOTPCounter = counter
+	return nil
+}
+
+// VerifyTwoFactor 校验验证码或恢复码，usedRecovery 表示使用了恢复码
+func (a *App) VerifyTwoFactor(user *models.User, code string) (usedRecovery bool, err error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return false, a.VerifyTOTP(user, code)
+	}
+
+	hash := HashRecoveryCode(code)
+	result := a.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+// ResetRecoveryCodes 删除用户原有的恢复码并生成新的恢复码，返回明文，tx 可以是事务
+func ResetRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: HashRecoveryCode(code)})
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RemainingRecoveryCodes 用户未使用的恢复码数量
+func (a *App) RemainingRecoveryCodes(userID uint) int64 {
+	var count int64
+	a.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// HashRecoveryCode 计算恢复码的哈希，忽略大小写、空格和短横线
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCode 生成 xxxxx-xxxxx 格式的恢复码
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for i, b := range buf {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
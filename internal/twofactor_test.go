@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/totp"
+	"github.com/andycai/goapi/pkg/vault"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTwoFactorTestApp 创建使用内存数据库和固定主密钥的 App，用户 1 已启用两步验证
+func newTwoFactorTestApp(t *testing.T) (*App, *models.User, string) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.User{}, &models.RecoveryCode{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cipher, err := vault.NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{DB: db, Vault: &Vault{db: db, cipher: cipher}}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: "alice", TOTPEnabled: true}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.TOTPSecret, err = a.EncryptTOTPSecret(user.ID, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Save(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return a, user, secret
+}
+
+func TestVerifyTOTPRejectsReuse(t *testing.T) {
+	a, user, secret := newTwoFactorTestApp(t)
+	code, _ := totp.Code(secret, totp.Counter(time.Now()))
+
+	if err := a.VerifyTOTP(user, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := a.VerifyTOTP(user, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("reused code: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	// 另一个请求读取的用户还是旧的周期，由数据库的条件更新拒绝
+	var stale models.User
+	a.DB.First(&stale, user.ID)
+	stale.TOTPCounter = 0
+	if err := a.VerifyTOTP(&stale, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("reused code with a stale user: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestVerifyTOTPRejectsEarlierCode(t *testing.T) {
+	a, user, secret := newTwoFactorTestApp(t)
+	current := totp.Counter(time.Now())
+
+	// 使用下一个周期的验证码后，当前周期的验证码虽然仍在时间窗口内也不能再使用
+	next, _ := totp.Code(secret, current+1)
+	if err := a.VerifyTOTP(user, next); err != nil {
+		t.Fatalf("next code: %v", err)
+	}
+	if user.TOTPCounter != current+1 {
+		t.Errorf("TOTPCounter = %d, want %d", user.TOTPCounter, current+1)
+	}
+
+	code, _ := totp.Code(secret, current)
+	if err := a.VerifyTOTP(user, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("earlier code: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestVerifyTwoFactorRecoveryCode(t *testing.T) {
+	a, user, _ := newTwoFactorTestApp(t)
+	codes, err := ResetRecoveryCodes(a.DB, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 恢复码忽略大小写和首尾空白，只能使用一次
+	if used, err := a.VerifyTwoFactor(user, " "+strings.ToUpper(codes[0])+" "); err != nil || !used {
+		t.Fatalf("recovery code: used = %v, err = %v", used, err)
+	}
+	if _, err := a.VerifyTwoFactor(user, codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("reused recovery code: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if n := a.RemainingRecoveryCodes(user.ID); n != int64(len(codes)-1) {
+		t.Errorf("RemainingRecoveryCodes = %d, want %d", n, len(codes)-1)
+	}
+}
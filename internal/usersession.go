@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval 会话最后访问时间的更新间隔，避免每个请求都写数据库
+const sessionTouchInterval = time.Minute
+
+var sessions *sessionTracker
+
+// sessionTracker 记录登录会话所属的用户、IP 和访问时间
+type sessionTracker struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	touched map[string]time.Time // 会话最后一次更新访问时间的时间
+}
+
+func newSessionTracker(db *gorm.DB) *sessionTracker {
+	t := &sessionTracker{db: db, touched: make(map[string]time.Time)}
+	go t.gcLoop()
+	return t
+}
+
+// gcLoop 定期清除过期的会话记录
+func (t *sessionTracker) gcLoop() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		now := time.Now()
+		t.db.Where("expires_at <= ?", now).Delete(&models.UserSession{})
+
+		t.mu.Lock()
+		for id, last := range t.touched {
+			if now.Sub(last) > sessionTouchInterval {
+				delete(t.touched, id)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// record 登录成功后记录会话
+func (t *sessionTracker) record(c *fiber.Ctx, sessionID string, userID uint, expiry time.Duration) {
+	now := time.Now()
+	session := models.UserSession{
+		SessionID:  sessionID,
+		UserID:     userID,
+		IP:         c.IP(),
+		UserAgent:  truncate(c.Get(fiber.HeaderUserAgent), 255),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(expiry),
+	}
+	if err := t.db.Create(&session).Error; err != nil {
+		log.Printf("[会话]保存会话记录失败: %v", err)
+	}
+}
+
+// touch 更新会话的最后访问时间和 IP，间隔内只更新一次
+func (t *sessionTracker) touch(c *fiber.Ctx, userID uint) {
+	sessionID := c.Cookies(sessionName)
+	if sessionID == "" {
+		return
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	if last, ok := t.touched[sessionID]; ok && now.Sub(last) < sessionTouchInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.touched[sessionID] = now
+	t.mu.Unlock()
+
+	result := t.db.Model(&models.UserSession{}).Where("session_id = ?", sessionID).UpdateColumns(map[string]any{
+		"last_seen_at": now,
+		"ip":           c.IP(),
+	})
+	if result.Error == nil && result.RowsAffected == 0 {
+		// 启用会话记录之前登录的会话没有记录，过期时间按默认值计算
+		t.record(c, sessionID, userID, store.Expiration)
+	}
+}
+
+// remove 删除会话记录
+func (t *sessionTracker) remove(sessionID string) {
+	t.db.Where("session_id = ?", sessionID).Delete(&models.UserSession{})
+
+	t.mu.Lock()
+	delete(t.touched, sessionID)
+	t.mu.Unlock()
+}
+
+// revoke 删除会话存储和会话记录，会话的下一个请求需要重新登录
+func (t *sessionTracker) revoke(list []models.UserSession) error {
+	for _, session := range list {
+		if err := store.Delete(session.SessionID); err != nil {
+			return err
+		}
+		t.remove(session.SessionID)
+	}
+	return nil
+}
+
+// CurrentSessionID 当前请求的会话 ID，使用 API 令牌时为空
+func CurrentSessionID(c *fiber.Ctx) string {
+	if CurrentToken(c) != nil {
+		return ""
+	}
+	return c.Cookies(sessionName)
+}
+
+// ListUserSessions 获取用户未过期的会话，最近访问的排在前面
+func ListUserSessions(userID uint) ([]models.UserSession, error) {
+	var list []models.UserSession
+	err := sessions.db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// RevokeSession 强制会话下线
+func RevokeSession(userID uint, id any) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := sessions.db.Where("user_id = ?", userID).First(&session, id).Error; err != nil {
+		return nil, errors.New("会话不存在")
+	}
+	if err := sessions.revoke([]models.UserSession{session}); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeUserSessions 强制用户的所有会话下线，except 为需要保留的会话 ID，返回下线的会话数
+func RevokeUserSessions(userID uint, except string) (int, error) {
+	query := sessions.db.Where("user_id = ?", userID)
+	if except != "" {
+		query = query.Where("session_id <> ?", except)
+	}
+
+	var list []models.UserSession
+	if err := query.Find(&list).Error; err != nil {
+		return 0, err
+	}
+	if err := sessions.revoke(list); err != nil {
+		return 0, err
+	}
+	return len(list), nil
+}
+
+// truncate 截断过长的字符串，按字符截断避免产生无效的 UTF-8
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/vault"
+	"gorm.io/gorm"
+)
+
+var ErrSecretNotFound = errors.New("凭据不存在")
+
+// Vault 凭据库，凭据使用主密钥加密后保存在数据库中，其他模块通过 secret://name 引用
+type Vault struct {
+	db     *gorm.DB
+	cipher *vault.Cipher
+	err    error // 主密钥不可用的原因
+}
+
+// openVault 加载主密钥并创建凭据库，主密钥不可用时凭据库不可读写，但不影响使用明文凭据
+func openVault(db *gorm.DB, conf VaultConfig) *Vault {
+	v := &Vault{db: db}
+
+	key, generated, err := vault.LoadKey(conf.KeyEnv, conf.KeyFile)
+	if err == nil {
+		v.cipher, err = vault.NewCipher(key)
+	}
+	if err != nil {
+		v.err = fmt.Errorf("主密钥不可用: %v", err)
+		log.Printf("[凭据库]%v", v.err)
+		return v
+	}
+	if generated {
+		log.Printf("[凭据库]已生成主密钥 %s，请妥善备份，丢失后凭据无法解密", conf.KeyFile)
+	}
+
+	return v
+}
+
+// Err 返回主密钥不可用的原因
+func (v *Vault) Err() error {
+	return v.err
+}
+
+// Resolve 解析凭据，value 为 secret://name 引用时从凭据库读取并记录访问，其他值原样返回
+// consumer 说明读取方，如 reposync:default:source，写入访问记录
+func (v *Vault) Resolve(value, consumer string) (string, error) {
+	name, ok := vault.ParseRef(value)
+	if !ok {
+		return value, nil
+	}
+
+	plaintext, secretID, err := v.read(name)
+
+	access := models.SecretAccessLog{
+		SecretID:  secretID,
+		Name:      name,
+		Consumer:  consumer,
+		Success:   err == nil,
+		CreatedAt: time.Now(),
+	}
+	if err != nil {
+		access.Error = err.Error()
+	}
+	if logErr := v.db.Create(&access).Error; logErr != nil {
+		log.Printf("[凭据库]记录凭据 %s 的访问失败: %v", name, logErr)
+	}
+	if err == nil {
+		v.db.Model(&models.Secret{}).Where("id = ?", secretID).Update("last_used_at", access.CreatedAt)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("读取凭据 %s 失败: %w", name, err)
+	}
+	return plaintext, nil
+}
+
+// read 读取并解密凭据
+func (v *Vault) read(name string) (string, uint, error) {
+	if v.err != nil {
+		return "", 0, v.err
+	}
+
+	var secret models.Secret
+	if err := v.db.Where("name = ?", name).First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, ErrSecretNotFound
+		}
+		return "", 0, err
+	}
+
+	plaintext, err := v.cipher.Decrypt(secret.Name, secret.Value)
+	if err != nil {
+		return "", secret.ID, err
+	}
+	return plaintext, secret.ID, nil
+}
+
+// Put 加密保存凭据，同名凭据存在时替换其值，description 为空时保留原有说明
+func (v *Vault) Put(name, value, description, operator string) (*models.Secret, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	if err := vault.ValidName(name); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := v.cipher.Encrypt(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret models.Secret
+	err = v.db.Where("name = ?", name).First(&secret).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		secret = models.Secret{
+			Name:        name,
+			Value:       encrypted,
+			Description: description,
+			CreatedBy:   operator,
+			UpdatedBy:   operator,
+			RotatedAt:   time.Now(),
+		}
+		return &secret, v.db.Create(&secret).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{
+		"value":      encrypted,
+		"updated_by": operator,
+		"rotated_at": time.Now(),
+	}
+	if description != "" {
+		updates["description"] = description
+	}
+	if err := v.db.Model(&secret).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// Exists 判断凭据是否存在
+func (v *Vault) Exists(name string) bool {
+	var count int64
+	v.db.Model(&models.Secret{}).Where("name = ?", name).Count(&count)
+	return count > 0
+}
+
+// Encrypt 使用主密钥加密由其他模块自行保存的数据，如两步验证的密钥
+// name 作为附加数据参与加密，解密时必须相同，防止密文被挪用到其他记录
+func (v *Vault) Encrypt(name, plaintext string) (string, error) {
+	if v.cipher == nil {
+		return "", v.err
+	}
+	return v.cipher.Encrypt(name, plaintext)
+}
+
+// Decrypt 解密 Encrypt 加密的数据
+func (v *Vault) Decrypt(name, ciphertext string) (string, error) {
+	if v.cipher == nil {
+		return "", v.err
+	}
+	return v.cipher.Decrypt(name, ciphertext)
+}
+
+// DeriveKey 从主密钥派生用于其他用途的密钥，如操作日志哈希链的 HMAC 密钥
+func (v *Vault) DeriveKey(purpose string) ([]byte, error) {
+	if v.cipher == nil {
+		return nil, v.err
+	}
+	return v.cipher.DeriveKey(purpose), nil
+}
@@ -91,6 +91,9 @@ func main() {
 	// 创建 Fiber 应用，并配置模板引擎
 	fiberApp := fiber.New(fiber.Config{
 		Views: engine,
+		// 请求体以流的方式读取，大小由 BodyLimitMiddleware 按路由检查，符号文件等大文件上传不受全局上限限制
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -113,6 +116,8 @@ func main() {
 		},
 	})
 
+	fiberApp.Use(internal.BodyLimitMiddleware(fiber.DefaultBodyLimit))
+
 	// 配置 CORS 中间件
 	corsConfig := internal.GetCorsConfig()
 	if corsConfig.Enabled {
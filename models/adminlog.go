@@ -2,16 +2,71 @@ package models
 
 import "time"
 
-// AdminLog 管理员操作日志
+// AdminLog 管理员操作日志，每条日志的哈希包含上一条日志的哈希，修改或删除日志后可以检测出来
 type AdminLog struct {
 	ID         uint      `json:"id" gorm:"primaryKey"`
-	UserID     uint      `json:"user_id"`     // 操作用户ID
-	Username   string    `json:"username"`    // 操作用户名
-	Action     string    `json:"action"`      // 操作类型
-	Resource   string    `json:"resource"`    // 资源类型
-	ResourceID uint      `json:"resource_id"` // 资源ID
-	Details    string    `json:"details"`     // 操作详情
-	IP         string    `json:"ip"`          // 操作IP
-	UserAgent  string    `json:"user_agent"`  // 用户代理
+	UserID     uint      `json:"user_id"`                  // 操作用户ID
+	Username   string    `json:"username"`                 // 操作用户名
+	Action     string    `json:"action"`                   // 操作类型
+	Resource   string    `json:"resource"`                 // 资源类型
+	ResourceID uint      `json:"resource_id"`              // 资源ID
+	Details    string    `json:"details"`                  // 操作详情
+	Changes    string    `json:"changes" gorm:"type:text"` // 修改前后的字段，JSON 格式
+	IP         string    `json:"ip"`                       // 操作IP
+	UserAgent  string    `json:"user_agent"`               // 用户代理
+	PrevHash   string    `json:"prev_hash" gorm:"size:64"` // 上一条日志的哈希，第一条日志为空
+	Hash       string    `json:"hash" gorm:"size:64"`      // 本条日志内容和 PrevHash 的哈希
 	CreatedAt  time.Time `json:"created_at" gorm:"index"`
 }
+
+// AdminLogCheckpoint 归档后删除操作日志的记录，校验时用于确认被删除的最后一条日志的哈希
+type AdminLogCheckpoint struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	LastID    uint      `json:"last_id"`                        // 删除的最后一条日志 ID
+	LastHash  string    `json:"last_hash" gorm:"size:64;index"` // 删除的最后一条日志的哈希
+	Deleted   int64     `json:"deleted"`
+	Sig       string    `json:"-" gorm:"size:64"` // LastID 和 LastHash 的签名，防止伪造删除记录
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminLogHead 最新一条操作日志的 ID 和哈希，与日志分开保存，校验时用于发现最新的日志被删除
+type AdminLogHead struct {
+	ID        uint      `json:"id" gorm:"primaryKey"` // 只有一行，固定为 1
+	LastID    uint      `json:"last_id"`
+	LastHash  string    `json:"last_hash" gorm:"size:64"`
+	Sig       string    `json:"-" gorm:"size:64"` // LastID 和 LastHash 的签名
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AdminLogAlertRule 操作日志告警规则，匹配条件为空时不限制，多个值用逗号分隔
+type AdminLogAlertRule struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"size:100"`
+	Enabled     bool       `json:"enabled"`
+	Actions     string     `json:"actions" gorm:"size:255"`     // 操作类型，如 delete,update
+	Resources   string     `json:"resources" gorm:"size:255"`   // 资源类型，如 shell,task
+	Usernames   string     `json:"usernames" gorm:"size:255"`   // 操作用户名
+	Keyword     string     `json:"keyword" gorm:"size:100"`     // 操作详情包含的关键字
+	OffHours    bool       `json:"off_hours"`                   // 只在工作时间之外触发，周末全天为工作时间之外
+	WorkStart   string     `json:"work_start" gorm:"size:5"`    // 工作时间开始，如 09:00
+	WorkEnd     string     `json:"work_end" gorm:"size:5"`      // 工作时间结束，如 18:00
+	Threshold   int        `json:"threshold"`                   // 时间窗口内匹配的次数达到阈值时告警，小于等于 1 时每次匹配都告警
+	Window      int        `json:"window"`                      // 时间窗口（秒）
+	Cooldown    int        `json:"cooldown"`                    // 告警后多长时间内不再告警（秒）
+	WebhookURL  string     `json:"webhook_url" gorm:"size:500"` // 为空时使用配置的默认地址，支持 secret:// 引用
+	LastFiredAt *time.Time `json:"last_fired_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AdminLogAlert 触发的告警和通知结果
+type AdminLogAlert struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RuleID    uint      `json:"rule_id" gorm:"index"`
+	RuleName  string    `json:"rule_name" gorm:"size:100"`
+	Count     int       `json:"count"`                    // 时间窗口内匹配的次数
+	Message   string    `json:"message" gorm:"type:text"` // 通知内容
+	Delivered bool      `json:"delivered"`                // 是否已发送到 Webhook
+	Error     string    `json:"error" gorm:"size:500"`    // 发送失败的原因
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// 审批单状态
+const (
+	ApprovalPending   = "pending"   // 待审批
+	ApprovalApproved  = "approved"  // 已批准，正在执行
+	ApprovalExecuted  = "executed"  // 已批准并执行成功
+	ApprovalFailed    = "failed"    // 已批准但执行失败
+	ApprovalRejected  = "rejected"  // 已驳回
+	ApprovalCancelled = "cancelled" // 申请人已撤回
+	ApprovalExpired   = "expired"   // 超过有效期未审批
+)
+
+// ApprovalPolicy 操作是否需要审批，没有记录时不需要审批
+type ApprovalPolicy struct {
+	Action    string    `json:"action" gorm:"primaryKey;size:100"` // 操作编码，如 patch:apply
+	Enabled   bool      `json:"enabled"`
+	UpdatedBy string    `json:"updated_by" gorm:"size:50"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ApprovalRequest 审批单，保存提交时的完整请求，批准后以申请人的身份重新执行
+type ApprovalRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Action      string     `json:"action" gorm:"size:100;index"` // 操作编码
+	ActionName  string     `json:"action_name" gorm:"size:100"`
+	Method      string     `json:"method" gorm:"size:10"`
+	Path        string     `json:"path" gorm:"size:500"` // 请求路径，包含查询参数
+	ContentType string     `json:"content_type" gorm:"size:100"`
+	Payload     string     `json:"payload" gorm:"type:text"` // 请求体
+	RequesterID uint       `json:"requester_id" gorm:"index"`
+	Requester   string     `json:"requester" gorm:"size:50"`
+	IP          string     `json:"ip" gorm:"size:50"`
+	UserAgent   string     `json:"user_agent" gorm:"size:255"`
+	Status      string     `json:"status" gorm:"size:20;index"`
+	ReviewerID  uint       `json:"reviewer_id"`
+	Reviewer    string     `json:"reviewer" gorm:"size:50"`
+	Comment     string     `json:"comment" gorm:"size:500"` // 审批意见
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ResultCode  int        `json:"result_code"`             // 执行结果的 HTTP 状态码
+	Result      string     `json:"result" gorm:"type:text"` // 执行结果的响应内容
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -50,3 +50,17 @@ type Comment struct {
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
+
+// IssueCommit 问题关联的提交，由仓库同步刷新或同步提交记录时根据提交消息自动生成
+type IssueCommit struct {
+	ID          int64     `json:"id" gorm:"primaryKey"`
+	IssueID     int64     `json:"issue_id" gorm:"not null;uniqueIndex:idx_issue_commit"`
+	Profile     string    `json:"profile" gorm:"size:50;not null;uniqueIndex:idx_issue_commit"` // 仓库同步方案
+	Revision    string    `json:"revision" gorm:"size:40;not null;uniqueIndex:idx_issue_commit;index"`
+	Author      string    `json:"author" gorm:"size:100"`
+	Message     string    `json:"message" gorm:"type:text"`
+	Fixed       bool      `json:"fixed"`  // 提交消息中使用了 fixes #123 等关键字
+	Synced      bool      `json:"synced"` // 提交已同步到目标仓库
+	CommittedAt time.Time `json:"committed_at"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// 代码评审状态
+const (
+	ReviewStatusOpen             = "open"              // 待评审
+	ReviewStatusApproved         = "approved"          // 已通过
+	ReviewStatusChangesRequested = "changes_requested" // 需要修改
+	ReviewStatusClosed           = "closed"            // 已关闭
+)
+
+// 代码评审仓库来源
+const (
+	ReviewSourceRepoSync = "reposync" // 仓库同步方案的源仓库，Repo 为方案名称
+	ReviewSourceGit      = "git"      // 本地 git 工作副本，Repo 为本地路径
+)
+
+// 评审记录类型
+const (
+	ReviewCommentNormal         = "comment"         // 普通评论
+	ReviewCommentApprove        = "approve"         // 通过
+	ReviewCommentRequestChanges = "request_changes" // 要求修改
+)
+
+// CodeReview 代码评审请求，评审 from（不含）到 to 之间的提交
+type CodeReview struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Title        string     `json:"title" gorm:"size:200;not null"`
+	Description  string     `json:"description" gorm:"type:text"`
+	Source       string     `json:"source" gorm:"size:20;not null"`
+	Repo         string     `json:"repo" gorm:"size:255;not null;index"`
+	FromRev      string     `json:"from_rev" gorm:"size:40"` // 为空表示只评审 to 本身
+	ToRev        string     `json:"to_rev" gorm:"size:40;not null"`
+	Status       string     `json:"status" gorm:"size:20;not null;index"`
+	AuthorID     uint       `json:"author_id" gorm:"index"`
+	AuthorName   string     `json:"author_name" gorm:"size:50"`
+	ReviewerID   uint       `json:"reviewer_id" gorm:"index"` // 0 表示未指定评审人
+	ReviewerName string     `json:"reviewer_name" gorm:"size:50"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CodeReviewComment 评审评论，Path 为空表示整体评论，Line 为 0 表示针对整个文件
+type CodeReviewComment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"review_id" gorm:"not null;index"`
+	Kind      string    `json:"kind" gorm:"size:20;not null"`
+	Path      string    `json:"path" gorm:"size:255"`
+	Line      int       `json:"line"`
+	Revision  string    `json:"revision" gorm:"size:40"` // 评论时评审的 to 版本，用于判断评论是否已过期
+	Content   string    `json:"content" gorm:"type:text"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username" gorm:"size:50"`
+	Resolved  bool      `json:"resolved"`
+	CreatedAt time.Time `json:"created_at"`
+}
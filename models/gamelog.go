@@ -1,14 +1,110 @@
 package models
 
+import "time"
+
 type GameLog struct {
 	ID         uint   `json:"id" gorm:"primaryKey"`
-	AppID      string `json:"app_id"`
-	Package    string `json:"package"`
+	AppID      string `json:"app_id" gorm:"size:64;index"`
+	Package    string `json:"package" gorm:"size:128;index"`
 	RoleName   string `json:"role_name" gorm:"index:idx_role_name_message, priority:1"`
-	Device     string `json:"device"`
+	Device     string `json:"device" gorm:"size:128;index"`
 	LogMessage string `json:"log_message" gorm:"index:idx_role_name_message, priority:2"`
 	LogTime    int64  `json:"log_time" gorm:"index"`
-	LogType    string `json:"log_type"`
+	LogType    string `json:"log_type" gorm:"size:20;index"`
 	LogStack   string `json:"log_stack"`
+	AppVersion string `json:"app_version" gorm:"size:50;index"`
+	IssueID    uint   `json:"issue_id" gorm:"index"`
 	CreateAt   int64  `json:"create_at" gorm:"index"`
 }
+
+// GameLogApp 允许上报游戏日志的应用及其密钥
+type GameLogApp struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	AppID       string    `json:"app_id" gorm:"size:64;uniqueIndex"`
+	Name        string    `json:"name" gorm:"size:100"`
+	APIKey      string    `json:"api_key" gorm:"size:64;uniqueIndex"`
+	Enabled     bool      `json:"enabled" gorm:"default:true"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// 日志问题状态
+const (
+	GameLogIssueOpen      = "open"      // 未处理
+	GameLogIssueClosed    = "closed"    // 已关闭
+	GameLogIssueRegressed = "regressed" // 关闭后再次出现
+)
+
+// GameLogIssue 按堆栈指纹聚合的异常问题
+type GameLogIssue struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	Fingerprint   string `json:"fingerprint" gorm:"size:64;uniqueIndex"`
+	AppID         string `json:"app_id" gorm:"size:64;index"`
+	LogType       string `json:"log_type" gorm:"size:20"`
+	Title         string `json:"title" gorm:"size:500"`
+	Stack         string `json:"stack" gorm:"type:text"` // 归一化后的堆栈
+	Status        string `json:"status" gorm:"size:20;index;default:open"`
+	Count         int64  `json:"count"`
+	DeviceCount   int64  `json:"device_count"`
+	RoleCount     int64  `json:"role_count"`
+	FirstVersion  string `json:"first_version" gorm:"size:50"`
+	LastVersion   string `json:"last_version" gorm:"size:50"`
+	FirstSeen     int64  `json:"first_seen"`
+	LastSeen      int64  `json:"last_seen" gorm:"index"`
+	ClosedAt      int64  `json:"closed_at"`
+	ClosedVersion string `json:"closed_version" gorm:"size:50"` // 修复版本，低于该版本的上报不算回归
+	RegressedAt   int64  `json:"regressed_at"`
+	BugIssueID    int64  `json:"bug_issue_id" gorm:"index"` // 关联的缺陷
+	CreatedAt     int64  `json:"created_at" gorm:"autoCreateTime:milli"`
+	UpdatedAt     int64  `json:"updated_at" gorm:"autoUpdateTime:milli"`
+}
+
+// 问题标签类型
+const (
+	GameLogIssueTagDevice  = "device"
+	GameLogIssueTagRole    = "role"
+	GameLogIssueTagVersion = "version"
+)
+
+// GameLogIssueTag 问题影响的设备、角色和版本
+type GameLogIssueTag struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	IssueID  uint   `json:"issue_id" gorm:"uniqueIndex:idx_issue_tag,priority:1"`
+	Kind     string `json:"kind" gorm:"size:20;uniqueIndex:idx_issue_tag,priority:2"`
+	Value    string `json:"value" gorm:"size:191;uniqueIndex:idx_issue_tag,priority:3"`
+	Count    int64  `json:"count"`
+	LastSeen int64  `json:"last_seen"`
+}
+
+// GameLogSavedSearch 用户保存的日志查询
+type GameLogSavedSearch struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Name      string    `json:"name" gorm:"size:100"`
+	Query     string    `json:"query" gorm:"size:1000"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 符号文件类型
+const (
+	SymbolKindIl2CppMapping = "il2cpp" // Il2CPP LineNumberMappings.json
+	SymbolKindELF           = "elf"    // Android .so.sym / ELF 符号表
+	SymbolKindTextMap       = "text"   // 由 iOS dSYM 精简得到的文本映射
+)
+
+// GameLogSymbol 用于还原堆栈的符号文件
+type GameLogSymbol struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	AppID      string    `json:"app_id" gorm:"size:64;index:idx_symbol_app_version,priority:1"`
+	AppVersion string    `json:"app_version" gorm:"size:50;index:idx_symbol_app_version,priority:2"`
+	BuildID    string    `json:"build_id" gorm:"size:64;index"`
+	Platform   string    `json:"platform" gorm:"size:20"`
+	Kind       string    `json:"kind" gorm:"size:20"`
+	Module     string    `json:"module" gorm:"size:100"`
+	Filename   string    `json:"filename" gorm:"size:255"`
+	Path       string    `json:"-" gorm:"size:500"`
+	Size       int64     `json:"size"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
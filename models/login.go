@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// LoginAttempt 登录记录，包括成功和失败的登录
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"size:50;index"`
+	UserID    uint      `json:"user_id" gorm:"index"` // 用户名不存在时为 0
+	IP        string    `json:"ip" gorm:"size:50;index"`
+	UserAgent string    `json:"user_agent" gorm:"size:255"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason" gorm:"size:100"` // 失败原因
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// PasswordHistory 用户使用过的密码，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Password  string    `json:"-" gorm:"size:100"` // bcrypt 哈希
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserSession 登录会话，记录会话所属的用户和访问情况，用于查看和强制下线
+type UserSession struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	SessionID  string    `json:"-" gorm:"size:64;uniqueIndex"` // 会话存储中的键，即会话 cookie 的值
+	UserID     uint      `json:"user_id" gorm:"index"`
+	IP         string    `json:"ip" gorm:"size:50"` // 最后访问的 IP
+	UserAgent  string    `json:"user_agent" gorm:"size:255"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+}
@@ -6,13 +6,25 @@ import (
 
 type RepoSyncRecord struct {
 	ID             int64     `json:"id" gorm:"primaryKey"`
+	Profile        string    `json:"profile" gorm:"size:50;not null;default:default;index"` // 同步方案
 	Revision       string    `json:"revision" gorm:"size:40;not null;index"`
 	Comment        string    `json:"comment" gorm:"type:text"`
 	Author         string    `json:"author" gorm:"size:100"`
 	SyncTime       time.Time `json:"sync_time" gorm:"not null"`
-	Status         int       `json:"status" gorm:"not null;default:1"` // 1: Synced, 2: Failed
+	Status         int       `json:"status" gorm:"not null;default:0"` // 0: Pending, 1: Synced, 2: Failed
 	AffectedIssues string    `json:"affected_issues" gorm:"size:255"`  // 受影响的问题列表
 	AffectedFiles  string    `json:"affected_files" gorm:"type:text"`  // 受影响的文件列表，格式: A:file1.txt,M:file2.txt,D:file3.txt
 	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
+
+// RepoSyncFile 目标仓库中已同步文件的内容哈希，用于检测目标仓库的本地修改
+type RepoSyncFile struct {
+	ID        int64     `json:"id" gorm:"primaryKey"`
+	Profile   string    `json:"profile" gorm:"size:50;not null;uniqueIndex:idx_repo_sync_file"` // 同步方案
+	Target    string    `json:"target" gorm:"size:255;not null;uniqueIndex:idx_repo_sync_file"` // 目标仓库URL
+	Path      string    `json:"path" gorm:"size:255;not null;uniqueIndex:idx_repo_sync_file"`   // 目标仓库中的相对路径
+	Hash      string    `json:"hash" gorm:"size:64;not null"`                                   // 最后一次同步写入的内容哈希
+	Revision  string    `json:"revision" gorm:"size:40"`                                        // 最后一次同步的版本号
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
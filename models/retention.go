@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 数据保留方式
+const (
+	RetentionModeDays       = "days"       // 保留最近 N 天的明细和汇总数据
+	RetentionModeAggregates = "aggregates" // 明细汇总后即可清理，汇总数据永久保留
+)
+
+// RetentionPolicy 数据保留策略
+type RetentionPolicy struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:50;uniqueIndex"` // 对应的数据类型，如 gamelog、stats
+	KeepDays  int       `json:"keep_days"`
+	Mode      string    `json:"mode" gorm:"size:20;default:days"`
+	Archive   bool      `json:"archive" gorm:"default:true"` // 删除前导出归档
+	Enabled   bool      `json:"enabled"`
+	LastRunAt int64     `json:"last_run_at"`
+	LastError string    `json:"last_error" gorm:"size:500"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RetentionArchive 过期数据的归档文件
+type RetentionArchive struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Policy     string    `json:"policy" gorm:"size:50;index"`
+	Table      string    `json:"table" gorm:"column:table_name;size:100"`
+	Path       string    `json:"-" gorm:"size:500"`
+	Rows       int64     `json:"rows"`
+	Size       int64     `json:"size"`
+	MinTime    int64     `json:"min_time"` // 归档数据的最早时间（毫秒）
+	MaxTime    int64     `json:"max_time"` // 归档数据的最晚时间（毫秒）
+	RestoredAt int64     `json:"restored_at"`
+	HoldUntil  int64     `json:"hold_until"` // 恢复后在此时间前不再清理（毫秒）
+	CreatedAt  time.Time `json:"created_at"`
+}
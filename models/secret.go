@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Secret 凭据库中的凭据，其他模块通过 secret://name 引用，值使用主密钥加密保存
+type Secret struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"size:100;uniqueIndex"`
+	Value       string     `json:"-" gorm:"type:text"` // 加密后的值，任何接口都不返回
+	Description string     `json:"description" gorm:"size:500"`
+	CreatedBy   string     `json:"created_by" gorm:"size:50"`
+	UpdatedBy   string     `json:"updated_by" gorm:"size:50"`
+	RotatedAt   time.Time  `json:"rotated_at"`   // 最后一次修改值的时间
+	LastUsedAt  *time.Time `json:"last_used_at"` // 最后一次被读取的时间
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// SecretAccessLog 凭据读取记录
+type SecretAccessLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SecretID  uint      `json:"secret_id" gorm:"index"` // 凭据不存在时为 0
+	Name      string    `json:"name" gorm:"size:100;index"`
+	Consumer  string    `json:"consumer" gorm:"size:200"` // 读取方，如 reposync:default:source
+	Success   bool      `json:"success"`
+	Error     string    `json:"error" gorm:"size:500"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
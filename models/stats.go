@@ -1,11 +1,14 @@
 package models
 
+import "time"
+
 type StatsRecord struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	LoginID     int64  `json:"login_id" gorm:"index:idx_role_at,priority:1,uniqueIndex"`
 	AppID       int    `json:"app_id"`
 	Package     string `json:"package_name"`
 	ProductName string `json:"product_name"`
+	AppVersion  string `json:"app_version" gorm:"size:50"`
 	RoleName    string `json:"role_name" gorm:"index:idx_role_at,priority:2"`
 	Device      string `json:"device_name"`
 	CPU         string `json:"system_cpu"`
@@ -32,6 +35,7 @@ type StatsInfo struct {
 	TextAsset   int                      `json:"text_asset"`
 	Shader      int                      `json:"shader"`
 	Pic         string                   `json:"pic"`
+	PicKey      string                   `json:"pic_key" gorm:"size:255;index"` // 截图在对象存储中的键
 	Process2    []map[string]interface{} `json:"list" gorm:"-"`
 	Process     string                   `json:"process"`
 	StatTime    int64                    `json:"mtime" gorm:"index"`
@@ -42,3 +46,25 @@ type ProcessItem struct {
 	Name string   `json:"name"`
 	List []string `json:"list"`
 }
+
+// StatsRollup 按小时、版本、设备和显卡汇总的性能数据
+type StatsRollup struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Bucket     int64  `json:"bucket" gorm:"uniqueIndex:idx_stats_rollup_key,priority:1"` // 小时起始时间（毫秒）
+	AppID      int    `json:"app_id" gorm:"uniqueIndex:idx_stats_rollup_key,priority:2"`
+	AppVersion string `json:"app_version" gorm:"size:50;uniqueIndex:idx_stats_rollup_key,priority:3"`
+	Device     string `json:"device" gorm:"size:128;uniqueIndex:idx_stats_rollup_key,priority:4"`
+	GPU        string `json:"gpu" gorm:"size:128;uniqueIndex:idx_stats_rollup_key,priority:5"`
+	Samples    int64  `json:"samples"`
+	FPSSum     int64  `json:"fps_sum"`
+	MemSum     int64  `json:"mem_sum"`
+	FPSHist    string `json:"fps_hist" gorm:"type:text"` // FPS 直方图（JSON）
+	MemHist    string `json:"mem_hist" gorm:"type:text"` // 内存直方图（JSON）
+}
+
+// StatsRollupState 汇总任务进度
+type StatsRollupState struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	LastInfoID uint      `json:"last_info_id"` // 已汇总的最大 StatsInfo.ID
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIToken API 访问令牌，只保存令牌的哈希，明文只在创建时返回一次
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" gorm:"size:100"`
+	Prefix     string     `json:"prefix" gorm:"size:20"` // 令牌开头几位，用于识别令牌
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Scopes     string     `json:"scopes" gorm:"type:text"` // 允许使用的权限编码，逗号分隔
+	ExpiresAt  *time.Time `json:"expires_at"`              // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:50"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  string     `json:"created_by" gorm:"size:50"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
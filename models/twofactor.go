@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode 两步验证的恢复码，只保存哈希，每个恢复码只能使用一次
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-" gorm:"size:64"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// 上传状态
+const (
+	UploadStatusRunning = "running"
+	UploadStatusSuccess = "success"
+	UploadStatusFailed  = "failed"
+)
+
+// UploadTarget 文件浏览的上传目标
+type UploadTarget struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:50;uniqueIndex"`
+	Title     string    `json:"title" gorm:"size:100"`
+	Kind      string    `json:"kind" gorm:"size:20"` // ftp、sftp、s3、local、rsync
+	Host      string    `json:"host" gorm:"size:255"`
+	Port      int       `json:"port"`
+	Username  string    `json:"username" gorm:"size:100"`
+	Password  string    `json:"-" gorm:"size:200"`         // secret:// 引用，明文密码保存时转存到凭据库
+	Bucket    string    `json:"bucket" gorm:"size:100"`    // s3 存储桶
+	Region    string    `json:"region" gorm:"size:50"`     // s3 区域
+	PathStyle bool      `json:"path_style"`                // s3 使用 path-style 地址
+	HostKey   string    `json:"host_key" gorm:"size:100"`  // sftp 服务器公钥指纹
+	Dir       string    `json:"dir" gorm:"size:500"`       // 默认远程目录
+	ExtDirs   string    `json:"ext_dirs" gorm:"type:text"` // 按扩展名指定远程目录，每行一个，如 apk=/PublisherDQ2
+	Verify    bool      `json:"verify"`                    // 上传后读回校验 sha256
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UploadRecord 上传记录
+type UploadRecord struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TargetID   uint       `json:"target_id" gorm:"index"`
+	TargetName string     `json:"target_name" gorm:"size:50"`
+	Kind       string     `json:"kind" gorm:"size:20"`
+	LocalPath  string     `json:"local_path" gorm:"size:500"` // 相对于输出目录的路径
+	RemotePath string     `json:"remote_path" gorm:"size:500"`
+	Size       int64      `json:"size"`
+	Offset     int64      `json:"offset"`                  // 续传的起始位置
+	Checksum   string     `json:"checksum" gorm:"size:64"` // 本地文件 sha256
+	Verified   bool       `json:"verified"`
+	Status     string     `json:"status" gorm:"size:20;index"`
+	Error      string     `json:"error" gorm:"size:1000"`
+	UserID     uint       `json:"user_id"`
+	Username   string     `json:"username" gorm:"size:50"`
+	Duration   int64      `json:"duration"` // 耗时（毫秒）
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
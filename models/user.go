@@ -14,9 +14,18 @@ type User struct {
 	Nickname      string         `gorm:"size:50" json:"nickname"`
 	RoleID        uint           `json:"role_id"`
 	Role          Role           `gorm:"foreignKey:RoleID" json:"role"`
-	Status        int            `gorm:"default:1" json:"status"` // 1:启用 0:禁用
+	Roles         []Role         `gorm:"many2many:user_roles;" json:"roles"` // 除主角色外的其他角色
+	Status        int            `gorm:"default:1" json:"status"`            // 1:启用 0:禁用
 	LastLogin     time.Time      `json:"last_login"`
 	HasChangedPwd bool           `gorm:"default:false" json:"has_changed_pwd"` // 是否已修改初始密码
+	IsService     bool           `gorm:"default:false" json:"is_service"`      // 服务账号，只能通过 API 令牌访问
+	Source        string         `gorm:"size:20;default:local" json:"source"`  // 账号来源：local、ldap、oidc
+	Subject       string         `gorm:"size:255;index" json:"-"`              // 外部身份的唯一标识，ldap 为用户 DN，oidc 为 sub
+	TOTPSecret    string         `gorm:"size:200" json:"-"`                    // 两步验证密钥，使用主密钥加密
+	TOTPEnabled   bool           `gorm:"default:false" json:"totp_enabled"`    // 是否已启用两步验证
+	TOTPCounter   int64          `json:"-"`                                    // 最后使用的验证码周期，防止验证码重放
+	FailedLogins  int            `gorm:"default:0" json:"failed_logins"`       // 连续登录失败次数，登录成功后清零
+	LockedUntil   *time.Time     `json:"locked_until"`                         // 登录失败次数过多时锁定到该时间
 	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -27,7 +36,9 @@ type Role struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	Name        string         `gorm:"uniqueIndex;size:50" json:"name"`
 	Description string         `gorm:"type:text" json:"description"`
+	Require2FA  bool           `gorm:"column:require_2fa;default:false" json:"require_2fa"` // 角色的用户必须启用两步验证
 	Permissions []Permission   `gorm:"many2many:role_permissions;" json:"permissions"`
+	Scopes      []RoleScope    `gorm:"foreignKey:RoleID" json:"scopes"` // 权限的资源范围
 	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -50,6 +61,22 @@ type RolePermission struct {
 	PermissionID uint `gorm:"primaryKey"`
 }
 
+// UserRole 用户-角色关联表，用户的其他角色
+type UserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey"`
+}
+
+// RoleScope 角色权限的资源范围，角色的权限配置了范围后只能操作范围内的资源，没有配置时可以操作所有资源
+type RoleScope struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RoleID     uint      `gorm:"index" json:"role_id"`
+	Permission string    `gorm:"size:50;index" json:"permission"` // 权限编码
+	ResourceID uint      `json:"resource_id"`                     // 资源 ID，为 0 时按标签匹配
+	Tag        string    `gorm:"size:50" json:"tag"`              // 资源标签，如任务的 android
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
 // ModuleInit 模块是否初始化的数据库表
 type ModuleInit struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
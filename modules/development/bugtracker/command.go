@@ -0,0 +1,120 @@
+package bugtracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/event"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 问题状态
+const (
+	issueStatusResolved = 4
+)
+
+var (
+	// issueRefRegex 匹配 #123、BUG-123、ISSUE-123、TASK-123 格式的问题编号，JIRA 编号属于外部系统不关联
+	issueRefRegex = regexp.MustCompile(`(?:#|\b(?:BUG|ISSUE|TASK)-)(\d+)`)
+	// issueFixRegex 匹配 fixes #123、closes #1, #2、修复 #123 等表示修复的写法
+	issueFixRegex = regexp.MustCompile(`(?i)(?:\b(?:fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)\b|修复|解决)[\s:：]*((?:(?:#|(?:BUG|ISSUE|TASK)-)\d+(?:\s*(?:,|，|、|and|&)\s*)?)+)`)
+)
+
+func subscribeEvents(bus *event.EventBus) {
+	event.Subscribe(bus, event.EventHandler[events.EventRepoCommits](commandLinkCommits))
+}
+
+// commandLinkCommits 根据提交消息关联问题，带修复关键字的提交将问题改为已解决
+func commandLinkCommits(ctx context.Context, e events.EventRepoCommits) error {
+	var errs []error
+	for _, commit := range e.Commits {
+		for id, fixed := range parseIssueRefs(commit.Message) {
+			if err := linkCommit(e.Profile, commit, id, fixed); err != nil {
+				errs = append(errs, fmt.Errorf("关联问题 %d 和提交 %s 失败: %v", id, commit.Revision, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseIssueRefs 解析提交消息中引用的问题编号，值表示是否为修复
+func parseIssueRefs(message string) map[int64]bool {
+	refs := make(map[int64]bool)
+	for _, match := range issueRefRegex.FindAllStringSubmatch(message, -1) {
+		if id, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			refs[id] = false
+		}
+	}
+	for _, fix := range issueFixRegex.FindAllStringSubmatch(message, -1) {
+		for _, match := range issueRefRegex.FindAllStringSubmatch(fix[1], -1) {
+			if id, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+				refs[id] = true
+			}
+		}
+	}
+	return refs
+}
+
+// linkCommit 保存问题和提交的关联，首次关联修复提交时将问题改为已解决并自动添加评论
+func linkCommit(profile string, commit events.RepoCommit, issueID int64, fixed bool) error {
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		var issue models.Issue
+		if err := tx.First(&issue, issueID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // 引用了不存在的问题，忽略
+			}
+			return err
+		}
+
+		link := models.IssueCommit{
+			IssueID:     issueID,
+			Profile:     profile,
+			Revision:    commit.Revision,
+			Author:      commit.Author,
+			Message:     commit.Message,
+			Fixed:       fixed,
+			Synced:      commit.Synced,
+			CommittedAt: commit.Time,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&link)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 已关联，同步成功后更新同步状态
+			if commit.Synced {
+				return tx.Model(&models.IssueCommit{}).
+					Where("issue_id = ? AND profile = ? AND revision = ?", issueID, profile, commit.Revision).
+					Update("synced", true).Error
+			}
+			return nil
+		}
+
+		if !fixed || issue.Status >= issueStatusResolved {
+			return nil
+		}
+
+		if err := tx.Model(&issue).Update("status", issueStatusResolved).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Comment{
+			IssueID: issueID,
+			Content: fmt.Sprintf("提交 %s（%s，%s）修复了此问题，状态自动改为已解决。\n%s",
+				shortRevision(commit.Revision), profile, commit.Author, commit.Message),
+		}).Error
+	})
+}
+
+// shortRevision git 哈希显示前7位，svn 版本号原样显示
+func shortRevision(revision string) string {
+	if len(revision) == 40 {
+		return revision[:7]
+	}
+	return revision
+}
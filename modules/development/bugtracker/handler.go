@@ -267,6 +267,26 @@ func getIssueHandler(c *fiber.Ctx) error {
 	})
 }
 
+// listIssueCommitsHandler list commits linked to issue
+func listIssueCommitsHandler(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid issue ID",
+		})
+	}
+
+	commits, err := getIssueCommits(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"commits": commits,
+	})
+}
+
 // listCommentsHandler list comments by issue ID
 func listCommentsHandler(c *fiber.Ctx) error {
 	issueID, err := strconv.ParseInt(c.Params("id"), 10, 64)
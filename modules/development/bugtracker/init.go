@@ -18,6 +18,7 @@ func autoMigrate() error {
 		&models.Iteration{},
 		&models.Issue{},
 		&models.Comment{},
+		&models.IssueCommit{},
 	)
 }
 
@@ -29,12 +29,15 @@ func (m *bugtrackerModule) Start() error {
 		return err
 	}
 
+	// 订阅仓库提交记录事件，自动关联问题
+	subscribeEvents(app.Bus)
+
 	return nil
 }
 
 func (m *bugtrackerModule) AddAuthRouters() error {
 	// admin page
-	app.RouterAdmin.Get("/bugtracker", app.HasPermission("bugtracker:project:view"), func(c *fiber.Ctx) error {
+	app.RouterAdmin.Get("/bugtracker", app.HasPermission("bugtracker:view"), func(c *fiber.Ctx) error {
 		return c.Render("admin/bugtracker", fiber.Map{
 			"Title": "Bug Tracker",
 			"Scripts": []string{
@@ -44,26 +47,27 @@ func (m *bugtrackerModule) AddAuthRouters() error {
 	})
 
 	// Project routes
-	app.RouterAdminApi.Get("/bugtracker/projects", app.HasPermission("bugtracker:project:view"), listProjectsHandler)
-	app.RouterAdminApi.Post("/bugtracker/projects", app.HasPermission("bugtracker:project:create"), createProjectHandler)
-	app.RouterAdminApi.Put("/bugtracker/projects/:id", app.HasPermission("bugtracker:project:update"), updateProjectHandler)
-	app.RouterAdminApi.Get("/bugtracker/projects/:id", app.HasPermission("bugtracker:project:view"), getProjectHandler)
+	app.RouterAdminApi.Get("/bugtracker/projects", app.HasPermission("bugtracker:view"), listProjectsHandler)
+	app.RouterAdminApi.Post("/bugtracker/projects", app.HasPermission("bugtracker:create"), createProjectHandler)
+	app.RouterAdminApi.Put("/bugtracker/projects/:id", app.HasPermission("bugtracker:update"), updateProjectHandler)
+	app.RouterAdminApi.Get("/bugtracker/projects/:id", app.HasPermission("bugtracker:view"), getProjectHandler)
 
 	// Iteration routes
-	app.RouterAdminApi.Get("/bugtracker/iterations", app.HasPermission("bugtracker:iteration:view"), listIterationsHandler)
-	app.RouterAdminApi.Post("/bugtracker/iterations", app.HasPermission("bugtracker:iteration:create"), createIterationHandler)
-	app.RouterAdminApi.Put("/bugtracker/iterations/:id", app.HasPermission("bugtracker:iteration:update"), updateIterationHandler)
-	app.RouterAdminApi.Get("/bugtracker/iterations/:id", app.HasPermission("bugtracker:iteration:view"), getIterationHandler)
+	app.RouterAdminApi.Get("/bugtracker/iterations", app.HasPermission("bugtracker:view"), listIterationsHandler)
+	app.RouterAdminApi.Post("/bugtracker/iterations", app.HasPermission("bugtracker:create"), createIterationHandler)
+	app.RouterAdminApi.Put("/bugtracker/iterations/:id", app.HasPermission("bugtracker:update"), updateIterationHandler)
+	app.RouterAdminApi.Get("/bugtracker/iterations/:id", app.HasPermission("bugtracker:view"), getIterationHandler)
 
 	// Issue routes
-	app.RouterAdminApi.Get("/bugtracker/issues", app.HasPermission("bugtracker:issue:view"), listIssuesHandler)
-	app.RouterAdminApi.Post("/bugtracker/issues", app.HasPermission("bugtracker:issue:create"), createIssueHandler)
-	app.RouterAdminApi.Put("/bugtracker/issues/:id", app.HasPermission("bugtracker:issue:update"), updateIssueHandler)
-	app.RouterAdminApi.Get("/bugtracker/issues/:id", app.HasPermission("bugtracker:issue:view"), getIssueHandler)
+	app.RouterAdminApi.Get("/bugtracker/issues", app.HasPermission("bugtracker:view"), listIssuesHandler)
+	app.RouterAdminApi.Post("/bugtracker/issues", app.HasPermission("bugtracker:create"), createIssueHandler)
+	app.RouterAdminApi.Put("/bugtracker/issues/:id", app.HasPermission("bugtracker:update"), updateIssueHandler)
+	app.RouterAdminApi.Get("/bugtracker/issues/:id", app.HasPermission("bugtracker:view"), getIssueHandler)
+	app.RouterAdminApi.Get("/bugtracker/issues/:id/commits", app.HasPermission("bugtracker:view"), listIssueCommitsHandler)
 
 	// Comment routes
-	app.RouterAdminApi.Get("/bugtracker/issues/:id/comments", app.HasPermission("bugtracker:comment:view"), listCommentsHandler)
-	app.RouterAdminApi.Post("/bugtracker/issues/:id/comments", app.HasPermission("bugtracker:comment:create"), createCommentHandler)
+	app.RouterAdminApi.Get("/bugtracker/issues/:id/comments", app.HasPermission("bugtracker:view"), listCommentsHandler)
+	app.RouterAdminApi.Post("/bugtracker/issues/:id/comments", app.HasPermission("bugtracker:create"), createCommentHandler)
 
 	return nil
 }
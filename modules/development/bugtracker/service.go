@@ -107,3 +107,12 @@ func getIssueComments(issueID int64) ([]models.Comment, error) {
 	}
 	return comments, nil
 }
+
+// getIssueCommits Commit link methods
+func getIssueCommits(issueID int64) ([]models.IssueCommit, error) {
+	var commits []models.IssueCommit
+	if err := app.DB.Where("issue_id = ?", issueID).Order("committed_at DESC").Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
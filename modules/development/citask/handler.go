@@ -36,7 +36,7 @@ func listTasksHandler(c *fiber.Ctx) error {
 			"error": fmt.Sprintf("获取任务列表失败: %v", err),
 		})
 	}
-	return c.JSON(tasks)
+	return c.JSON(filterTasks(c, tasks))
 }
 
 // createTaskHandler 创建任务
@@ -48,6 +48,14 @@ func createTaskHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// 权限范围按标签配置时，只能创建范围内标签的任务
+	task.Tags = normalizeTags(task.Tags)
+	if !canTask(c, "citask:create", &task) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "没有创建该标签任务的权限",
+		})
+	}
+
 	// 如果启用了定时执行，验证cron表达式
 	if task.EnableCron == 1 {
 		if task.CronExpr == "" {
@@ -89,6 +97,9 @@ func getTaskHandler(c *fiber.Ctx) error {
 			"error": fmt.Sprintf("任务不存在: %v", err),
 		})
 	}
+	if !canTask(c, "citask:view", &task) {
+		return taskForbidden(c)
+	}
 	return c.JSON(task)
 }
 
@@ -116,6 +127,12 @@ func updateTaskHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// 修改前后的任务都需要在权限范围内
+	updates.Tags = normalizeTags(updates.Tags)
+	if !canTask(c, "citask:update", &task) || !canTask(c, "citask:update", &models.Task{ID: task.ID, Tags: updates.Tags}) {
+		return taskForbidden(c)
+	}
+
 	// 检查定时任务状态变化
 	cronChanged := task.EnableCron != updates.EnableCron ||
 		(updates.EnableCron == 1 && task.CronExpr != updates.CronExpr)
@@ -135,7 +152,7 @@ func updateTaskHandler(c *fiber.Ctx) error {
 	}
 
 	// 更新任务信息
-	if err := app.DB.Model(&task).Updates(updates).Update("enable_cron", updates.EnableCron).Error; err != nil {
+	if err := app.DB.Model(&task).Updates(updates).Update("enable_cron", updates.EnableCron).Update("tags", updates.Tags).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": fmt.Sprintf("更新任务失败: %v", err),
 		})
@@ -179,6 +196,9 @@ func deleteTaskHandler(c *fiber.Ctx) error {
 			"error": fmt.Sprintf("任务不存在: %v", err),
 		})
 	}
+	if !canTask(c, "citask:delete", &task) {
+		return taskForbidden(c)
+	}
 
 	if err := app.DB.Delete(&task).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
@@ -201,6 +221,9 @@ func runTaskHandler(c *fiber.Ctx) error {
 			"error": fmt.Sprintf("任务不存在: %v", err),
 		})
 	}
+	if !canTask(c, "citask:run", &task) {
+		return taskForbidden(c)
+	}
 
 	// 创建任务日志
 	taskLog := models.TaskLog{
@@ -239,6 +262,16 @@ func runTaskHandler(c *fiber.Ctx) error {
 // getTaskLogsHandler 获取任务日志
 func getTaskLogsHandler(c *fiber.Ctx) error {
 	taskID := c.Params("id")
+	var task models.Task
+	if err := app.DB.First(&task, taskID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": fmt.Sprintf("任务不存在: %v", err),
+		})
+	}
+	if !canTask(c, "citask:view", &task) {
+		return taskForbidden(c)
+	}
+
 	var logs []models.TaskLog
 	if err := app.DB.Where("task_id = ?", taskID).Order("created_at desc").Find(&logs).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
@@ -277,6 +310,10 @@ func getTaskProgressHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if task, ok := logTask(uint(id)); ok && !canTask(c, "citask:view", task) {
+		return taskForbidden(c)
+	}
+
 	progressMutex.RLock()
 	progress, exists := taskProgressMap[uint(id)]
 	progressMutex.RUnlock()
@@ -813,6 +850,10 @@ func stopTaskHandler(c *fiber.Ctx) error {
 	}
 	taskId := uint(id)
 
+	if task, ok := logTask(taskId); ok && !canTask(c, "citask:run", task) {
+		return taskForbidden(c)
+	}
+
 	// 获取进度信息
 	progressMutex.Lock()
 	progress, exists := taskProgressMap[taskId]
@@ -888,9 +929,9 @@ func listRunningTasksHandler(c *fiber.Ctx) error {
 	var runningTasks []fiber.Map
 	for id, progress := range taskProgressMap {
 		if progress.Status == "running" {
-			// 查询任务信息
-			var taskLog models.TaskLog
-			if err := app.DB.First(&taskLog, id).Error; err != nil {
+			// 查询任务信息，只返回有权限查看的任务
+			task, ok := logTask(id)
+			if !ok || !canTask(c, "citask:view", task) {
 				continue
 			}
 
@@ -959,11 +1000,16 @@ func searchTasksHandler(c *fiber.Ctx) error {
 	var tasks []models.Task
 	if err := app.DB.Where("name LIKE ?", "%"+keyword+"%").
 		Order("created_at desc").
-		Limit(10).
 		Find(&tasks).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": fmt.Sprintf("搜索任务失败: %v", err),
 		})
 	}
+
+	// 按权限范围过滤后取前 10 个
+	tasks = filterTasks(c, tasks)
+	if len(tasks) > 10 {
+		tasks = tasks[:10]
+	}
 	return c.JSON(tasks)
 }
@@ -42,6 +42,9 @@ func (m *taskModule) AddAuthRouters() error {
 		}, "admin/layout")
 	})
 
+	// 执行任务会运行脚本，可以设置为需要审批
+	runApproval := app.RequireApproval("citask:run", "执行任务脚本", "citask:run")
+
 	// api
 	app.RouterAdminApi.Get("/citask", app.HasPermission("citask:view"), listTasksHandler)                       // 获取任务列表
 	app.RouterAdminApi.Post("/citask", app.HasPermission("citask:create"), createTaskHandler)                   // 创建任务
@@ -51,7 +54,7 @@ func (m *taskModule) AddAuthRouters() error {
 	app.RouterAdminApi.Get("/citask/:id", app.HasPermission("citask:view"), getTaskHandler)                     // 获取任务详情
 	app.RouterAdminApi.Put("/citask/:id", app.HasPermission("citask:update"), updateTaskHandler)                // 更新任务
 	app.RouterAdminApi.Delete("/citask/:id", app.HasPermission("citask:delete"), deleteTaskHandler)             // 删除任务
-	app.RouterAdminApi.Post("/citask/run/:id", app.HasPermission("citask:run"), runTaskHandler)                 // 执行任务
+	app.RouterAdminApi.Post("/citask/run/:id", app.HasPermission("citask:run"), runApproval, runTaskHandler)    // 执行任务
 	app.RouterAdminApi.Get("/citask/logs/:id", app.HasPermission("citask:view"), getTaskLogsHandler)            // 获取任务日志
 	app.RouterAdminApi.Get("/citask/progress/:logId", app.HasPermission("citask:view"), getTaskProgressHandler) // 获取任务进度
 	app.RouterAdminApi.Post("/citask/stop/:logId", app.HasPermission("citask:run"), stopTaskHandler)            // 停止任务
@@ -0,0 +1,51 @@
+package citask
+
+import (
+	"strings"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// taskResource 任务的权限检查资源，按任务 ID 和标签匹配角色的权限范围
+func taskResource(task *models.Task) internal.Resource {
+	return internal.Resource{ID: task.ID, Tags: internal.ParseTags(task.Tags)}
+}
+
+// canTask 当前用户是否可以对任务使用权限
+func canTask(c *fiber.Ctx, code string, task *models.Task) bool {
+	return app.Can(c, code, taskResource(task))
+}
+
+// filterTasks 过滤出当前用户可以查看的任务
+func filterTasks(c *fiber.Ctx, tasks []models.Task) []models.Task {
+	p := app.Principal(c)
+	if p == nil {
+		return []models.Task{}
+	}
+	return internal.Filter(p, "citask:view", tasks, func(task models.Task) internal.Resource {
+		return taskResource(&task)
+	})
+}
+
+// taskForbidden 没有任务的权限
+func taskForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "没有该任务的权限",
+	})
+}
+
+// logTask 获取日志所属的任务
+func logTask(logID uint) (*models.Task, bool) {
+	var taskLog models.TaskLog
+	if err := app.DB.Preload("Task").First(&taskLog, logID).Error; err != nil {
+		return nil, false
+	}
+	return &taskLog.Task, true
+}
+
+// normalizeTags 统一标签格式，去掉空格和重复的标签
+func normalizeTags(tags string) string {
+	return strings.Join(internal.ParseTags(tags), ",")
+}
@@ -0,0 +1,66 @@
+package codereview
+
+import (
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/vcs"
+)
+
+// CreateReq 创建评审的请求
+type CreateReq struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Source      string `json:"source"` // reposync/git
+	Repo        string `json:"repo"`   // 同步方案名称或本地路径
+	FromRev     string `json:"from_rev"`
+	ToRev       string `json:"to_rev"`
+	ReviewerID  uint   `json:"reviewer_id"`
+}
+
+// UpdateReq 更新评审版本范围的请求
+type UpdateReq struct {
+	FromRev string `json:"from_rev"`
+	ToRev   string `json:"to_rev"`
+}
+
+// ReviewerReq 指定评审人的请求
+type ReviewerReq struct {
+	ReviewerID uint `json:"reviewer_id"`
+}
+
+// CommentReq 添加评论的请求，path 为空表示整体评论
+type CommentReq struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+}
+
+// VerdictReq 通过或要求修改的请求
+type VerdictReq struct {
+	Comment string `json:"comment"`
+}
+
+// ReviewDetail 评审详情
+type ReviewDetail struct {
+	models.CodeReview
+	Comments []models.CodeReviewComment `json:"comments"`
+}
+
+// ReviewDiff 评审范围内的提交和差异
+type ReviewDiff struct {
+	Commits []vcs.Commit   `json:"commits"`
+	Files   []vcs.FileDiff `json:"files"`
+}
+
+// RepoOption 可选的仓库
+type RepoOption struct {
+	Source string `json:"source"`
+	Repo   string `json:"repo"`
+	Title  string `json:"title"`
+}
+
+// ReviewerOption 可选的评审人
+type ReviewerOption struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
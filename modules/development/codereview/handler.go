@@ -0,0 +1,415 @@
+package codereview
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/game/reposync"
+	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/andycai/goapi/pkg/vcs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// listReviewsHandler 获取评审列表，reviewer/author 为 me 时只看自己的评审
+func listReviewsHandler(c *fiber.Ctx) error {
+	var total int64
+
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 10)
+	status := c.Query("status")
+	repo := c.Query("repo")
+
+	query := app.DB.Model(&models.CodeReview{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if repo != "" {
+		query = query.Where("repo = ?", repo)
+	}
+	if c.Query("reviewer") == "me" || c.Query("author") == "me" {
+		currentUser := app.CurrentUser(c)
+		if currentUser == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+		}
+		if c.Query("reviewer") == "me" {
+			query = query.Where("reviewer_id = ?", currentUser.ID)
+		}
+		if c.Query("author") == "me" {
+			query = query.Where("author_id = ?", currentUser.ID)
+		}
+	}
+
+	query.Count(&total)
+
+	var reviews []models.CodeReview
+	if err := query.Order("updated_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reviews).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取评审列表失败"})
+	}
+
+	return c.JSON(fiber.Map{
+		"total": total,
+		"data":  reviews,
+	})
+}
+
+// optionsHandler 获取可选的仓库和评审人
+func optionsHandler(c *fiber.Ctx) error {
+	repos := make([]RepoOption, 0)
+	for _, profile := range reposync.ListProfiles() {
+		title := profile.Title
+		if title == "" {
+			title = profile.Name
+		}
+		repos = append(repos, RepoOption{Source: models.ReviewSourceRepoSync, Repo: profile.Name, Title: title})
+	}
+
+	var reviewers []ReviewerOption
+	if err := app.DB.Model(&models.User{}).
+		Select("id, username, nickname").
+		Where("status = ?", 1).
+		Order("username").
+		Find(&reviewers).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取评审人失败"})
+	}
+
+	return c.JSON(fiber.Map{
+		"repos":     repos,
+		"reviewers": reviewers,
+	})
+}
+
+// createReviewHandler 从提交范围创建评审
+func createReviewHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	var req CreateReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Repo = strings.TrimSpace(req.Repo)
+	if req.Title == "" || req.Repo == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "标题和仓库不能为空"})
+	}
+
+	client, err := repoClient(req.Source, req.Repo)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	fromRev, toRev, err := resolveRange(client, req.FromRev, req.ToRev)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reviewer, err := getReviewer(req.ReviewerID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if reviewer.ID == currentUser.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "不能指定自己为评审人"})
+	}
+
+	review := models.CodeReview{
+		Title:        req.Title,
+		Description:  req.Description,
+		Source:       req.Source,
+		Repo:         req.Repo,
+		FromRev:      fromRev,
+		ToRev:        toRev,
+		Status:       models.ReviewStatusOpen,
+		AuthorID:     currentUser.ID,
+		AuthorName:   currentUser.Username,
+		ReviewerID:   reviewer.ID,
+		ReviewerName: reviewer.Username,
+	}
+	if err := app.DB.Create(&review).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "创建评审失败"})
+	}
+
+	publishChange(&review, currentUser, events.ReviewActionCreate, "", review.Description)
+	adminlog.WriteLog(c, "create", "codereview", review.ID, fmt.Sprintf("创建代码评审：%s，仓库：%s，版本：%s..%s", review.Title, review.Repo, review.FromRev, review.ToRev))
+
+	return c.JSON(fiber.Map{"message": "创建成功", "data": review})
+}
+
+// getReviewHandler 获取评审详情和评论
+func getReviewHandler(c *fiber.Ctx) error {
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+
+	var comments []models.CodeReviewComment
+	if err := app.DB.Where("review_id = ?", review.ID).Order("id").Find(&comments).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取评论失败"})
+	}
+
+	return c.JSON(fiber.Map{"data": ReviewDetail{CodeReview: *review, Comments: comments}})
+}
+
+// diffReviewHandler 获取评审范围内的提交和差异
+func diffReviewHandler(c *fiber.Ctx) error {
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+
+	diff, err := reviewDiff(review)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, vcs.ErrNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{"error": fmt.Sprintf("获取差异失败: %v", err)})
+	}
+
+	return c.JSON(fiber.Map{"data": diff})
+}
+
+// updateReviewHandler 更新评审的版本范围，例如作者根据意见提交修改后，评审重新进入待评审状态
+func updateReviewHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+	if review.Status == models.ReviewStatusClosed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评审已关闭"})
+	}
+	if review.AuthorID != currentUser.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "只有发起人可以更新评审"})
+	}
+
+	var req UpdateReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	client, err := repoClient(review.Source, review.Repo)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	fromRev, toRev, err := resolveRange(client, req.FromRev, req.ToRev)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	before := *review
+	if err := changeStatus(review, currentUser, events.ReviewActionUpdate, models.ReviewStatusOpen, "", map[string]any{
+		"from_rev": fromRev,
+		"to_rev":   toRev,
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "更新评审失败"})
+	}
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "codereview", ResourceID: review.ID, Details: fmt.Sprintf("更新代码评审版本：%s，版本：%s..%s", review.Title, fromRev, toRev), Before: before, After: review})
+
+	return c.JSON(fiber.Map{"message": "更新成功", "data": review})
+}
+
+// assignReviewerHandler 指定评审人，评审人变更后评审重新进入待评审状态
+func assignReviewerHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+	if review.Status == models.ReviewStatusClosed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评审已关闭"})
+	}
+	if !canManage(c, review, currentUser) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "只有发起人可以指定评审人"})
+	}
+
+	var req ReviewerReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	reviewer, err := getReviewer(req.ReviewerID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if reviewer.ID != 0 && reviewer.ID == review.AuthorID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评审人不能是发起人"})
+	}
+
+	status := review.Status
+	if reviewer.ID != review.ReviewerID {
+		status = models.ReviewStatusOpen
+	}
+	before := *review
+	if err := changeStatus(review, currentUser, events.ReviewActionAssign, status, "", map[string]any{
+		"reviewer_id":   reviewer.ID,
+		"reviewer_name": reviewer.Username,
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "指定评审人失败"})
+	}
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "codereview", ResourceID: review.ID, Details: fmt.Sprintf("指定代码评审人：%s，评审人：%s", review.Title, reviewer.Username), Before: before, After: review})
+
+	return c.JSON(fiber.Map{"message": "指定成功", "data": review})
+}
+
+// addCommentHandler 添加评论，可以定位到文件和行
+func addCommentHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+	if review.Status == models.ReviewStatusClosed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评审已关闭"})
+	}
+
+	var req CommentReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评论内容不能为空"})
+	}
+	filePath, err := vcs.CleanPath(req.Path)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的文件路径"})
+	}
+	if req.Line < 0 || (filePath == "" && req.Line != 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的行号"})
+	}
+
+	comment := models.CodeReviewComment{
+		ReviewID: review.ID,
+		Kind:     models.ReviewCommentNormal,
+		Path:     filePath,
+		Line:     req.Line,
+		Revision: review.ToRev,
+		Content:  req.Content,
+		UserID:   currentUser.ID,
+		Username: currentUser.Username,
+	}
+	if err := app.DB.Create(&comment).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "添加评论失败"})
+	}
+
+	return c.JSON(fiber.Map{"message": "评论成功", "data": comment})
+}
+
+// resolveCommentHandler 标记评论已解决或取消标记
+func resolveCommentHandler(c *fiber.Ctx) error {
+	var comment models.CodeReviewComment
+	if err := app.DB.Where("id = ? AND review_id = ?", c.Params("cid"), c.Params("id")).First(&comment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评论不存在"})
+	}
+
+	if err := app.DB.Model(&comment).Update("resolved", !comment.Resolved).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "修改评论失败"})
+	}
+
+	return c.JSON(fiber.Map{"message": "修改成功", "data": comment})
+}
+
+// approveHandler 评审人通过评审
+func approveHandler(c *fiber.Ctx) error {
+	return verdict(c, events.ReviewActionApprove, models.ReviewStatusApproved)
+}
+
+// requestChangesHandler 评审人要求修改
+func requestChangesHandler(c *fiber.Ctx) error {
+	return verdict(c, events.ReviewActionRequestChanges, models.ReviewStatusChangesRequested)
+}
+
+func verdict(c *fiber.Ctx, action, status string) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+
+	var req VerdictReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if action == events.ReviewActionRequestChanges && req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "请填写修改意见"})
+	}
+
+	if err := addVerdict(review, currentUser, action, status, req.Comment); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, action, "codereview", review.ID, fmt.Sprintf("审核代码评审：%s，结果：%s", review.Title, status))
+
+	return c.JSON(fiber.Map{"message": "操作成功", "data": review})
+}
+
+// closeReviewHandler 关闭评审
+func closeReviewHandler(c *fiber.Ctx) error {
+	return switchClosed(c, true)
+}
+
+// reopenReviewHandler 重新打开已关闭的评审
+func reopenReviewHandler(c *fiber.Ctx) error {
+	return switchClosed(c, false)
+}
+
+// canManage 发起人或拥有 codereview:manage 权限的用户可以指定评审人、关闭和重新打开评审
+func canManage(c *fiber.Ctx, review *models.CodeReview, user *models.User) bool {
+	return review.AuthorID == user.ID || app.Principal(c).Has("codereview:manage")
+}
+
+func switchClosed(c *fiber.Ctx, closed bool) error {
+	currentUser := app.CurrentUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "登录已过期，请重新登录"})
+	}
+
+	review, err := getReview(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "评审不存在"})
+	}
+	if !canManage(c, review, currentUser) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "只有发起人可以关闭或重新打开评审"})
+	}
+	if closed == (review.Status == models.ReviewStatusClosed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "评审状态未变化"})
+	}
+
+	action, status := events.ReviewActionReopen, models.ReviewStatusOpen
+	if closed {
+		action, status = events.ReviewActionClose, models.ReviewStatusClosed
+	}
+	if err := changeStatus(review, currentUser, action, status, "", nil); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "修改评审状态失败"})
+	}
+
+	adminlog.WriteLog(c, action, "codereview", review.ID, fmt.Sprintf("修改代码评审状态：%s，状态：%s", review.Title, status))
+
+	return c.JSON(fiber.Map{"message": "操作成功", "data": review})
+}
@@ -0,0 +1,167 @@
+package codereview
+
+import (
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/enum"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+// 数据迁移
+func autoMigrate() error {
+	return app.DB.AutoMigrate(&models.CodeReview{}, &models.CodeReviewComment{})
+}
+
+// 初始化数据
+func initData() error {
+	if err := initMenus(); err != nil {
+		return err
+	}
+
+	if err := initPermissions(); err != nil {
+		return err
+	}
+
+	if err := initManagePermissions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("codereview:menu") {
+		log.Println("[代码评审模块]菜单数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建代码评审菜单
+		reviewMenu := models.Menu{
+			MenuID:     2003,
+			ParentID:   enum.MenuIdTools,
+			Name:       "代码评审",
+			Path:       "/admin/codereview",
+			Icon:       "codereview",
+			Sort:       3,
+			Permission: "codereview:view",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&reviewMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "codereview:menu",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("codereview:permission") {
+		log.Println("[代码评审模块]权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建代码评审相关权限
+		permissions := []models.Permission{
+			{
+				Name:        "代码评审查看",
+				Code:        "codereview:view",
+				Description: "查看代码评审及差异",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "发起代码评审",
+				Code:        "codereview:create",
+				Description: "发起、更新和关闭代码评审，指定评审人",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "代码评审评论",
+				Code:        "codereview:comment",
+				Description: "在代码评审中发表评论",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "代码评审审核",
+				Code:        "codereview:review",
+				Description: "作为评审人通过或要求修改",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+		}
+
+		if err := tx.Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "codereview:permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initManagePermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("codereview:permission:manage") {
+		log.Println("[代码评审模块]管理权限已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		permission := models.Permission{
+			Name:        "代码评审管理",
+			Code:        "codereview:manage",
+			Description: "为他人发起的代码评审指定评审人，关闭或重新打开评审",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(&permission).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "codereview:permission:manage",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,56 @@
+package codereview
+
+import (
+	"github.com/andycai/goapi/internal"
+	"github.com/gofiber/fiber/v2"
+)
+
+const ModulePriorityCodeReview = 4004 // 功能-代码评审
+
+var app *internal.App
+
+type codeReviewModule struct {
+	internal.BaseModule
+}
+
+func init() {
+	internal.RegisterModule(&codeReviewModule{}, ModulePriorityCodeReview)
+}
+
+func (m *codeReviewModule) Awake(a *internal.App) error {
+	app = a
+	return autoMigrate()
+}
+
+func (m *codeReviewModule) Start() error {
+	return initData()
+}
+
+func (m *codeReviewModule) AddAuthRouters() error {
+	// admin
+	app.RouterAdmin.Get("/codereview", app.HasPermission("codereview:view"), func(c *fiber.Ctx) error {
+		return c.Render("admin/codereview", fiber.Map{
+			"Title": "代码评审",
+			"Scripts": []string{
+				"/static/js/admin/codereview.js",
+			},
+		}, "admin/layout")
+	})
+
+	// api
+	app.RouterAdminApi.Get("/codereview", app.HasPermission("codereview:view"), listReviewsHandler)                                 // 获取评审列表
+	app.RouterAdminApi.Post("/codereview", app.HasPermission("codereview:create"), createReviewHandler)                             // 创建评审
+	app.RouterAdminApi.Get("/codereview/options", app.HasPermission("codereview:view"), optionsHandler)                             // 可选的仓库和评审人
+	app.RouterAdminApi.Get("/codereview/:id", app.HasPermission("codereview:view"), getReviewHandler)                               // 获取评审详情和评论
+	app.RouterAdminApi.Get("/codereview/:id/diff", app.HasPermission("codereview:view"), diffReviewHandler)                         // 获取评审的提交和差异
+	app.RouterAdminApi.Put("/codereview/:id", app.HasPermission("codereview:create"), updateReviewHandler)                          // 更新版本范围
+	app.RouterAdminApi.Put("/codereview/:id/reviewer", app.HasPermission("codereview:create"), assignReviewerHandler)               // 指定评审人
+	app.RouterAdminApi.Post("/codereview/:id/comments", app.HasPermission("codereview:comment"), addCommentHandler)                 // 添加评论
+	app.RouterAdminApi.Put("/codereview/:id/comments/:cid/resolve", app.HasPermission("codereview:comment"), resolveCommentHandler) // 标记评论已解决
+	app.RouterAdminApi.Post("/codereview/:id/approve", app.HasPermission("codereview:review"), approveHandler)                      // 通过
+	app.RouterAdminApi.Post("/codereview/:id/request-changes", app.HasPermission("codereview:review"), requestChangesHandler)       // 要求修改
+	app.RouterAdminApi.Post("/codereview/:id/close", app.HasPermission("codereview:create"), closeReviewHandler)                    // 关闭
+	app.RouterAdminApi.Post("/codereview/:id/reopen", app.HasPermission("codereview:create"), reopenReviewHandler)                  // 重新打开
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package codereview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/game/reposync"
+	"github.com/andycai/goapi/pkg/event"
+	"github.com/andycai/goapi/pkg/utility/path"
+	"github.com/andycai/goapi/pkg/vcs"
+)
+
+// maxReviewCommits 评审范围内最多列出的提交数
+const maxReviewCommits = 200
+
+// repoClient 获取评审仓库的客户端
+func repoClient(source, repo string) (vcs.VCS, error) {
+	switch source {
+	case models.ReviewSourceRepoSync:
+		return reposync.SourceClient(repo)
+	case models.ReviewSourceGit:
+		if !path.IsValid(repo) {
+			return nil, errors.New("无效的本地路径")
+		}
+		if vcs.Detect(repo) != vcs.KindGit {
+			return nil, fmt.Errorf("不是 git 工作副本: %s", repo)
+		}
+		return vcs.NewGit(repo, vcs.Auth{}), nil
+	}
+	return nil, fmt.Errorf("不支持的仓库来源: %s", source)
+}
+
+// resolveRevision 校验版本号并转换为完整版本号，svn 返回不晚于该版本的最后修改版本
+func resolveRevision(client vcs.VCS, rev string) (string, error) {
+	commits, err := client.History(context.Background(), rev, "", 1)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("%w: %s", vcs.ErrNotFound, rev)
+	}
+	return commits[0].Revision, nil
+}
+
+// resolveRange 校验评审的版本范围，from 为空表示只评审 to 本身
+func resolveRange(client vcs.VCS, from, to string) (string, string, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if to == "" {
+		return "", "", errors.New("请指定结束版本")
+	}
+
+	toRev, err := resolveRevision(client, to)
+	if err != nil {
+		return "", "", fmt.Errorf("结束版本无效: %v", err)
+	}
+	if from == "" {
+		return "", toRev, nil
+	}
+
+	fromRev, err := resolveRevision(client, from)
+	if err != nil {
+		return "", "", fmt.Errorf("起始版本无效: %v", err)
+	}
+	if fromRev == toRev {
+		return "", "", errors.New("起始版本和结束版本相同")
+	}
+	return fromRev, toRev, nil
+}
+
+// reviewDiff 获取评审范围内的提交（从新到旧）和按文件拆分的差异
+func reviewDiff(review *models.CodeReview) (*ReviewDiff, error) {
+	client, err := repoClient(review.Source, review.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	history, err := client.History(ctx, review.ToRev, "", maxReviewCommits)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]vcs.Commit, 0, len(history))
+	for _, commit := range history {
+		if review.FromRev != "" && commit.Revision == review.FromRev {
+			break
+		}
+		commits = append(commits, commit)
+		if review.FromRev == "" {
+			break
+		}
+	}
+
+	files, err := client.DiffFiles(ctx, review.FromRev, review.ToRev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReviewDiff{Commits: commits, Files: files}, nil
+}
+
+// getReview 获取评审
+func getReview(id string) (*models.CodeReview, error) {
+	var review models.CodeReview
+	if err := app.DB.First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// getReviewer 获取评审人，id 为 0 表示不指定
+func getReviewer(id uint) (*models.User, error) {
+	if id == 0 {
+		return &models.User{}, nil
+	}
+	var user models.User
+	if err := app.DB.First(&user, id).Error; err != nil {
+		return nil, errors.New("评审人不存在")
+	}
+	if user.Status != 1 {
+		return nil, errors.New("评审人已被禁用")
+	}
+	return &user, nil
+}
+
+// changeStatus 修改评审状态，可同时修改其他字段，并发布状态变更事件
+func changeStatus(review *models.CodeReview, operator *models.User, action, status, comment string, fields map[string]any) error {
+	oldStatus := review.Status
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["status"] = status
+	if status == models.ReviewStatusClosed {
+		fields["closed_at"] = time.Now()
+	} else if oldStatus == models.ReviewStatusClosed {
+		fields["closed_at"] = nil
+	}
+
+	if err := app.DB.Model(review).Updates(fields).Error; err != nil {
+		return err
+	}
+	if err := app.DB.First(review, review.ID).Error; err != nil {
+		return err
+	}
+
+	publishChange(review, operator, action, oldStatus, comment)
+	return nil
+}
+
+// publishChange 发布评审变更事件，供其他模块订阅
+func publishChange(review *models.CodeReview, operator *models.User, action, oldStatus, comment string) {
+	event.Publish(app.Bus, context.Background(), events.EventCodeReviewChanged{
+		ReviewID:     review.ID,
+		Title:        review.Title,
+		Source:       review.Source,
+		Repo:         review.Repo,
+		FromRev:      review.FromRev,
+		ToRev:        review.ToRev,
+		Action:       action,
+		OldStatus:    oldStatus,
+		Status:       review.Status,
+		AuthorID:     review.AuthorID,
+		ReviewerID:   review.ReviewerID,
+		OperatorID:   operator.ID,
+		OperatorName: operator.Username,
+		Comment:      comment,
+	})
+}
+
+// addVerdict 评审人通过或要求修改，同时记录一条评审意见，action 同时作为评审记录类型
+func addVerdict(review *models.CodeReview, reviewer *models.User, action, status, comment string) error {
+	if review.Status == models.ReviewStatusClosed {
+		return errors.New("评审已关闭")
+	}
+	if review.ReviewerID != reviewer.ID {
+		return errors.New("只有指定的评审人可以审核")
+	}
+
+	if err := app.DB.Create(&models.CodeReviewComment{
+		ReviewID: review.ID,
+		Kind:     action,
+		Revision: review.ToRev,
+		Content:  comment,
+		UserID:   reviewer.ID,
+		Username: reviewer.Username,
+	}).Error; err != nil {
+		return err
+	}
+
+	return changeStatus(review, reviewer, action, status, comment, nil)
+}
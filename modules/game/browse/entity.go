@@ -1,6 +1,10 @@
 package browse
 
-import "time"
+import (
+	"time"
+
+	"github.com/andycai/goapi/models"
+)
 
 // FileEntry 存储文件信息的结构体
 type FileEntry struct {
@@ -11,3 +15,42 @@ type FileEntry struct {
 	IsDir        bool      // 是否是目录
 	FileType     string    // 文件类型
 }
+
+// UploadTargetReq 新增或修改上传目标的请求，修改时 Password 为空表示不修改密码
+type UploadTargetReq struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Kind      string `json:"kind"`
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Bucket    string `json:"bucket"`
+	Region    string `json:"region"`
+	PathStyle bool   `json:"path_style"`
+	HostKey   string `json:"host_key"`
+	Dir       string `json:"dir"`
+	ExtDirs   string `json:"ext_dirs"`
+	Verify    bool   `json:"verify"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// UploadTargetVO 上传目标列表项，不返回密码
+type UploadTargetVO struct {
+	models.UploadTarget
+	HasPassword bool `json:"has_password"`
+	Resumable   bool `json:"resumable"` // 是否支持断点续传
+}
+
+// UploadReq 上传文件的请求
+type UploadReq struct {
+	File     string `json:"file"`      // 相对于输出目录的文件路径
+	TargetID uint   `json:"target_id"` // 上传目标
+	Dir      string `json:"dir"`       // 远程目录，为空时按目标的配置选择
+}
+
+// UploadRecordVO 上传记录，上传中的记录带有当前进度
+type UploadRecordVO struct {
+	models.UploadRecord
+	Transferred int64 `json:"transferred"`
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -102,6 +105,154 @@ func deleteFileHandler(c *fiber.Ctx) error {
 	return handleBrowseDelete(c, absPath)
 }
 
+// listUploadTargetsHandler 获取上传目标列表，不返回密码
+func listUploadTargetsHandler(c *fiber.Ctx) error {
+	var targets []models.UploadTarget
+	if err := app.DB.Order("id").Find(&targets).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取上传目标失败"})
+	}
+
+	list := make([]UploadTargetVO, 0, len(targets))
+	for _, target := range targets {
+		list = append(list, toTargetVO(target))
+	}
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// createUploadTargetHandler 新增上传目标
+func createUploadTargetHandler(c *fiber.Ctx) error {
+	var req UploadTargetReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	var count int64
+	app.DB.Model(&models.UploadTarget{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "上传目标名称已存在"})
+	}
+
+	target := &models.UploadTarget{}
+	if err := saveTarget(target, req, app.CurrentUser(c).Username); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "create", "upload_target", target.ID, fmt.Sprintf("新增上传目标：%s（%s）", target.Name, target.Kind))
+
+	return c.JSON(fiber.Map{"message": "创建成功", "data": toTargetVO(*target)})
+}
+
+// updateUploadTargetHandler 修改上传目标
+func updateUploadTargetHandler(c *fiber.Ctx) error {
+	target, err := getTarget(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req UploadTargetReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+	if req.Name != target.Name {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "不能修改上传目标名称"})
+	}
+
+	before := *target
+	if err := saveTarget(target, req, app.CurrentUser(c).Username); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "upload_target", ResourceID: target.ID, Details: fmt.Sprintf("修改上传目标：%s", target.Name), Before: before, After: target})
+
+	return c.JSON(fiber.Map{"message": "修改成功", "data": toTargetVO(*target)})
+}
+
+// deleteUploadTargetHandler 删除上传目标，上传记录保留
+func deleteUploadTargetHandler(c *fiber.Ctx) error {
+	target, err := getTarget(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := app.DB.Delete(target).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "删除上传目标失败"})
+	}
+
+	adminlog.WriteLog(c, "delete", "upload_target", target.ID, fmt.Sprintf("删除上传目标：%s", target.Name))
+
+	return c.JSON(fiber.Map{"message": "删除成功"})
+}
+
+// testUploadTargetHandler 测试上传目标的连接
+func testUploadTargetHandler(c *fiber.Ctx) error {
+	target, err := getTarget(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := testTarget(target); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "连接失败: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "连接成功"})
+}
+
+// uploadFileHandler 上传输出目录中的文件到指定目标，上传在后台执行
 func uploadFileHandler(c *fiber.Ctx) error {
-	return uploadByFTP(c, app.Config.Server.Output)
+	var req UploadReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	target, err := getTarget(req.TargetID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	record, err := startUpload(target, req.File, strings.TrimSpace(req.Dir), app.CurrentUser(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "upload", "browse", record.ID, fmt.Sprintf("上传文件：%s 到 %s:%s", record.LocalPath, target.Name, record.RemotePath))
+
+	return c.JSON(fiber.Map{"message": "已开始上传", "data": record})
+}
+
+// retryUploadHandler 重新上传失败的记录，支持续传的目标从上次中断的位置继续
+func retryUploadHandler(c *fiber.Ctx) error {
+	var record models.UploadRecord
+	if err := app.DB.First(&record, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "上传记录不存在"})
+	}
+	if record.Status != models.UploadStatusFailed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "只能重试失败的上传"})
+	}
+
+	target, err := getTarget(record.TargetID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	retry, err := startUpload(target, record.LocalPath, path.Dir(record.RemotePath), app.CurrentUser(c))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "upload", "browse", retry.ID, fmt.Sprintf("重试上传：%s 到 %s:%s", retry.LocalPath, target.Name, retry.RemotePath))
+
+	return c.JSON(fiber.Map{"message": "已开始上传", "data": retry})
+}
+
+// listUploadRecordsHandler 获取上传记录
+func listUploadRecordsHandler(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	list, total, err := listUploadRecords(page, pageSize, c.Query("status"), c.QueryInt("target_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取上传记录失败"})
+	}
+
+	return c.JSON(fiber.Map{"data": list, "total": total})
 }
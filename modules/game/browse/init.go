@@ -2,16 +2,22 @@ package browse
 
 import (
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/andycai/goapi/enum"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/upload"
 	"gorm.io/gorm"
 )
 
 // 数据迁移
 func autoMigrate() error {
-	return nil
+	return app.DB.AutoMigrate(
+		&models.UploadTarget{},
+		&models.UploadRecord{},
+	)
 }
 
 // 初始化数据
@@ -24,7 +30,15 @@ func initData() error {
 		return err
 	}
 
-	return nil
+	if err := initUploadMenus(); err != nil {
+		return err
+	}
+
+	if err := initUploadPermissions(); err != nil {
+		return err
+	}
+
+	return initUploadTargets()
 }
 
 func initMenus() error {
@@ -126,3 +140,148 @@ func initPermissions() error {
 		return nil
 	})
 }
+
+func initUploadMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("browse:upload_menu") {
+		log.Println("[文件浏览模块]上传菜单数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建文件上传菜单
+		uploadMenu := models.Menu{
+			MenuID:     3016,
+			ParentID:   enum.MenuIdGame,
+			Name:       "文件上传",
+			Path:       "/admin/upload",
+			Icon:       "upload",
+			Sort:       2,
+			Permission: "browse:ftp",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&uploadMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "browse:upload_menu",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initUploadPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("browse:upload_permission") {
+		log.Println("[文件浏览模块]上传权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建上传目标管理权限，上传文件沿用 browse:ftp
+		permission := models.Permission{
+			Name:        "管理上传目标",
+			Code:        "browse:target",
+			Description: "新增、修改、删除和测试上传目标",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(&permission).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "browse:upload_permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// initUploadTargets 将 [ftp] 配置转换为名为 ftp 的上传目标
+func initUploadTargets() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("browse:upload_target") {
+		log.Println("[文件浏览模块]上传目标数据已初始化，跳过")
+		return nil
+	}
+
+	conf := app.Config.FTP
+	username, password := conf.User, conf.Password
+	if password == "" {
+		// 旧版本的账号保存在用户数据文件中
+		if user, pass, err := ReadFromBinaryFile(app.Config.Server.UserDataPath); err == nil {
+			username, password = user, pass
+		}
+	}
+
+	// 明文密码转存到凭据库，失败时不创建目标，避免密码明文入库
+	password, err := vaultTargetPassword("ftp", password, "browse")
+	if err != nil {
+		log.Printf("[文件浏览模块]FTP 上传目标初始化失败: %v", err)
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		if conf.Host != "" {
+			port, _ := strconv.Atoi(conf.Port)
+			var extDirs []string
+			if conf.APKPath != "" {
+				extDirs = append(extDirs, "apk="+conf.APKPath)
+			}
+			if conf.ZIPPath != "" {
+				extDirs = append(extDirs, "zip="+conf.ZIPPath)
+			}
+
+			target := models.UploadTarget{
+				Name:     "ftp",
+				Title:    "FTP",
+				Kind:     upload.KindFTP,
+				Host:     conf.Host,
+				Port:     port,
+				Username: username,
+				Password: password,
+				Dir:      "/",
+				ExtDirs:  strings.Join(extDirs, "\n"),
+				Verify:   true,
+				Enabled:  true,
+			}
+			if err := tx.Create(&target).Error; err != nil {
+				return err
+			}
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "browse:upload_target",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
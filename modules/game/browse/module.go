@@ -2,6 +2,9 @@ package browse
 
 import (
 	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
+	"github.com/gofiber/fiber/v2"
 )
 
 const ModulePriorityBrowse = 9901 // 游戏-文件浏览
@@ -19,12 +22,29 @@ func init() {
 func (m *browseModule) Awake(a *internal.App) error {
 	app = a
 	// 数据迁移
-	return autoMigrate()
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	retention.RegisterTarget(retention.Target{
+		Name:        "upload",
+		Title:       "文件上传记录",
+		DefaultDays: 180,
+		Tables: []retention.Table{
+			{Model: &models.UploadRecord{}, TimeColumn: "started_at"},
+		},
+	})
+
+	return nil
 }
 
 func (m *browseModule) Start() error {
 	// 初始化数据
-	return initData()
+	if err := initData(); err != nil {
+		return err
+	}
+
+	return recoverUploads()
 }
 
 func (m *browseModule) AddAuthRouters() error {
@@ -35,10 +55,25 @@ func (m *browseModule) AddAuthRouters() error {
 	// 文件删除路由
 	app.RouterAdminApi.Delete("/browse/*", app.HasPermission("browse:delete"), deleteFileHandler)
 
-	// FTP 上传路由
-	app.RouterAdminApi.Post("/ftp/upload", app.HasPermission("browse:ftp"), uploadFileHandler)
+	// 上传目标和上传记录
+	app.RouterAdmin.Get("/upload", app.HasPermission("browse:ftp"), func(c *fiber.Ctx) error {
+		return c.Render("admin/upload", fiber.Map{
+			"Title": "文件上传",
+			"Scripts": []string{
+				"/static/js/admin/upload.js",
+			},
+		}, "admin/layout")
+	})
 
 	// api
+	app.RouterAdminApi.Get("/upload/targets", app.HasPermission("browse:ftp"), listUploadTargetsHandler)
+	app.RouterAdminApi.Post("/upload/targets", app.HasPermission("browse:target"), createUploadTargetHandler)
+	app.RouterAdminApi.Put("/upload/targets/:id", app.HasPermission("browse:target"), updateUploadTargetHandler)
+	app.RouterAdminApi.Delete("/upload/targets/:id", app.HasPermission("browse:target"), deleteUploadTargetHandler)
+	app.RouterAdminApi.Post("/upload/targets/:id/test", app.HasPermission("browse:target"), testUploadTargetHandler)
+	app.RouterAdminApi.Post("/upload", app.HasPermission("browse:ftp"), uploadFileHandler)
+	app.RouterAdminApi.Get("/upload/records", app.HasPermission("browse:ftp"), listUploadRecordsHandler)
+	app.RouterAdminApi.Post("/upload/records/:id/retry", app.HasPermission("browse:ftp"), retryUploadHandler)
 
 	return nil
 }
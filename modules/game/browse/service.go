@@ -8,23 +8,22 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/andycai/goapi/events"
 	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/andycai/goapi/pkg/event"
 	"github.com/gofiber/fiber/v2"
-	"github.com/jlaffaye/ftp"
 	"github.com/saintfish/chardet"
 	"golang.org/x/text/encoding/ianaindex"
 )
 
 // WriteToBinaryFile 写入二进制文件
+//
+// Deprecated: 用户数据文件中的密码只做了 Base64 编码，FTP 密码请使用 [ftp] password 引用凭据库
 func WriteToBinaryFile(filename, username, password string) error {
 	file, err := os.Create(filename)
 	if err != nil {
@@ -359,175 +358,3 @@ func handleBrowseDelete(c *fiber.Ctx, path string) error {
 		"message": "文件删除成功",
 	})
 }
-
-// uploadByFTP 处理 FTP 上传请求
-func uploadByFTP(c *fiber.Ctx, rootPath string) error {
-	filePath := c.Query("file")
-	fileType := c.Query("type")
-
-	if filePath == "" || (fileType != "apk" && fileType != "zip") {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "无效的文件路径或类型",
-		})
-	}
-
-	// 解码文件路径
-	decodedPath, err := url.QueryUnescape(filePath)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "无效的路径编码",
-		})
-	}
-
-	fullPath := filepath.Join(rootPath, decodedPath)
-
-	// 上传到 FTP
-	if err := uploadToFTP(fullPath, fileType); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	// 记录操作日志
-	adminlog.WriteLog(c, "upload", "browse", 0, fmt.Sprintf("上传文件：%s", fullPath))
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "文件上传成功",
-	})
-}
-
-// 上传文件到 FTP
-func uploadToFTP(localPath string, fileType string) error {
-	// 连接 FTP
-	conn, err := ftp.Dial(fmt.Sprintf("%s:%s", app.Config.FTP.Host, app.Config.FTP.Port))
-	if err != nil {
-		writeUploadLog(localPath, fileType, false, fmt.Sprintf("FTP连接失败: %v", err))
-		return fmt.Errorf("FTP连接失败: %v", err)
-	}
-	defer conn.Quit()
-
-	username, password, err := ReadFromBinaryFile(app.Config.Server.UserDataPath)
-	if err != nil {
-		writeUploadLog(localPath, fileType, false, fmt.Sprintf("读取用户数据失败: %v", err))
-		return fmt.Errorf("读取用户数据失败: %v", err)
-	}
-
-	// 登录
-	if err := conn.Login(username, password); err != nil {
-		writeUploadLog(localPath, fileType, false, fmt.Sprintf("FTP登录失败: %v", err))
-		return fmt.Errorf("FTP登录失败: %v", err)
-	}
-
-	// 根据文件类型选择上传路径
-	remotePath := app.Config.FTP.APKPath
-	if fileType == "zip" {
-		remotePath = app.Config.FTP.ZIPPath
-	}
-
-	// 确保远程路径使用正斜杠
-	remotePath = strings.ReplaceAll(remotePath, "\\", "/")
-	if !strings.HasPrefix(remotePath, "/") {
-		remotePath = "/" + remotePath
-	}
-	if !strings.HasSuffix(remotePath, "/") {
-		remotePath = remotePath + "/"
-	}
-
-	// 尝试切换到目标目录
-	if err := conn.ChangeDir(remotePath); err != nil {
-		// 如果目录不存在，尝试创建
-		if err := createRemoteDirectories(conn, remotePath); err != nil {
-			writeUploadLog(localPath, fileType, false, fmt.Sprintf("创建远程目录失败: %v", err))
-			return fmt.Errorf("创建远程目录失败: %v", err)
-		}
-	}
-
-	// 打开本地文件
-	file, err := os.Open(localPath)
-	if err != nil {
-		writeUploadLog(localPath, fileType, false, fmt.Sprintf("打开文件失败: %v", err))
-		return fmt.Errorf("打开文件失败: %v", err)
-	}
-	defer file.Close()
-
-	// 获取文件名并确保它是有效的
-	fileName := filepath.Base(localPath)
-	fileName = strings.TrimSpace(fileName)
-	if fileName == "" || fileName == "." || fileName == ".." {
-		writeUploadLog(localPath, fileType, false, "无效的文件名")
-		return fmt.Errorf("无效的文件名: %s", fileName)
-	}
-
-	// 上传文件
-	err = conn.Stor(fileName, file)
-	if err != nil {
-		writeUploadLog(localPath, fileType, false, fmt.Sprintf("上传文件失败: %v", err))
-		return fmt.Errorf("上传文件失败: %v", err)
-	}
-
-	// 记录成功日志
-	writeUploadLog(localPath, fileType, true, "上传成功")
-	return nil
-}
-
-// createRemoteDirectories 递归创建远程目录
-func createRemoteDirectories(conn *ftp.ServerConn, path string) error {
-	path = strings.Trim(path, "/")
-	dirs := strings.Split(path, "/")
-	currentPath := "/"
-
-	for _, dir := range dirs {
-		if dir == "" {
-			continue
-		}
-		currentPath = filepath.Join(currentPath, dir)
-		currentPath = strings.ReplaceAll(currentPath, "\\", "/")
-
-		err := conn.ChangeDir(currentPath)
-		if err != nil {
-			// 如果目录不存在，创建它
-			if err := conn.MakeDir(currentPath); err != nil {
-				return fmt.Errorf("无法创建目录 %s: %v", currentPath, err)
-			}
-			// 创建后切换到该目录
-			if err := conn.ChangeDir(currentPath); err != nil {
-				return fmt.Errorf("无法切换到目录 %s: %v", currentPath, err)
-			}
-		}
-	}
-	return nil
-}
-
-// 添加日志写入函数
-func writeUploadLog(localPath, fileType string, success bool, message string) error {
-	// 确保日志目录存在
-	if err := os.MkdirAll(app.Config.FTP.LogDir, 0755); err != nil {
-		return fmt.Errorf("创建日志目录失败: %v", err)
-	}
-
-	// 准备日志内容
-	logContent := fmt.Sprintf("[%s] File: %s, Type: %s, Success: %v, Message: %s\n",
-		time.Now().Format("2006-01-02 15:04:05"),
-		localPath,
-		fileType,
-		success,
-		message,
-	)
-
-	// 获取当前日志文件路径
-	logFile := filepath.Join(app.Config.FTP.LogDir, fmt.Sprintf("ftpupload_%s.log", time.Now().Format("20060102150405")))
-
-	// 追加写入日志
-	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("打开日志文件失败: %v", err)
-	}
-	defer f.Close()
-
-	if _, err := f.WriteString(logContent); err != nil {
-		return fmt.Errorf("写入日志失败: %v", err)
-	}
-
-	return nil
-}
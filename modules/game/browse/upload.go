@@ -0,0 +1,344 @@
+package browse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/upload"
+	"github.com/andycai/goapi/pkg/vault"
+)
+
+var (
+	// uploadProgress 上传中的记录已写入的字节数，键为记录 ID
+	uploadProgress sync.Map
+	// uploadRunning 同一目标的同一远程文件同时只能有一个上传，避免写坏续传的临时文件
+	uploadRunning sync.Map
+
+	targetNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+)
+
+// uploadTimeout 单个文件上传的最长时间
+const uploadTimeout = 6 * time.Hour
+
+// resolveLocalPath 校验要上传的文件在输出目录中，返回绝对路径和文件大小
+func resolveLocalPath(rel string) (string, int64, error) {
+	root, err := filepath.Abs(app.Config.Server.Output)
+	if err != nil {
+		return "", 0, errors.New("输出目录配置无效")
+	}
+	abs, err := filepath.Abs(filepath.Join(root, rel))
+	if err != nil || !strings.HasPrefix(abs, root+string(filepath.Separator)) {
+		return "", 0, errors.New("文件不在输出目录中")
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", 0, errors.New("文件不存在")
+	}
+	if info.IsDir() {
+		return "", 0, errors.New("不能上传目录")
+	}
+	return abs, info.Size(), nil
+}
+
+// getTarget 获取上传目标
+func getTarget(id any) (*models.UploadTarget, error) {
+	var target models.UploadTarget
+	if err := app.DB.First(&target, id).Error; err != nil {
+		return nil, errors.New("上传目标不存在")
+	}
+	return &target, nil
+}
+
+// targetConfig 生成上传目标的连接配置，密码从凭据库读取
+func targetConfig(target *models.UploadTarget) (upload.Config, error) {
+	password, err := app.Vault.Resolve(target.Password, "browse:upload:"+target.Name)
+	if err != nil {
+		return upload.Config{}, err
+	}
+	return upload.Config{
+		Kind:      target.Kind,
+		Host:      target.Host,
+		Port:      target.Port,
+		Username:  target.Username,
+		Password:  password,
+		Bucket:    target.Bucket,
+		Region:    target.Region,
+		PathStyle: target.PathStyle,
+		HostKey:   target.HostKey,
+	}, nil
+}
+
+// remoteDir 按扩展名选择远程目录，没有匹配时使用默认目录
+func remoteDir(target *models.UploadTarget, fileName string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	for _, line := range strings.Split(target.ExtDirs, "\n") {
+		key, dir, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), ".") == ext {
+			return strings.TrimSpace(dir)
+		}
+	}
+	return target.Dir
+}
+
+// startUpload 创建上传记录并在后台上传，dir 为空时按目标的配置选择远程目录
+func startUpload(target *models.UploadTarget, rel, dir string, user *models.User) (*models.UploadRecord, error) {
+	if !target.Enabled {
+		return nil, errors.New("上传目标已停用")
+	}
+	localPath, size, err := resolveLocalPath(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		dir = remoteDir(target, localPath)
+	}
+	if dir == "" && target.Kind == upload.KindLocal {
+		return nil, errors.New("上传目标没有配置目录")
+	}
+	remotePath := path.Join(dir, filepath.Base(localPath))
+
+	key := fmt.Sprintf("%d:%s", target.ID, remotePath)
+	if _, loaded := uploadRunning.LoadOrStore(key, true); loaded {
+		return nil, fmt.Errorf("%s 正在上传中", remotePath)
+	}
+
+	record := &models.UploadRecord{
+		TargetID:   target.ID,
+		TargetName: target.Name,
+		Kind:       target.Kind,
+		LocalPath:  filepath.ToSlash(rel),
+		RemotePath: remotePath,
+		Size:       size,
+		Status:     models.UploadStatusRunning,
+		UserID:     user.ID,
+		Username:   user.Username,
+		StartedAt:  time.Now(),
+	}
+	if err := app.DB.Create(record).Error; err != nil {
+		uploadRunning.Delete(key)
+		return nil, err
+	}
+
+	progress := new(atomic.Int64)
+	uploadProgress.Store(record.ID, progress)
+
+	go func() {
+		defer uploadRunning.Delete(key)
+		defer uploadProgress.Delete(record.ID)
+		runUpload(record, *target, localPath, progress)
+	}()
+
+	return record, nil
+}
+
+// runUpload 执行上传并保存结果
+func runUpload(record *models.UploadRecord, target models.UploadTarget, localPath string, progress *atomic.Int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	result, err := func() (*upload.Result, error) {
+		cfg, err := targetConfig(&target)
+		if err != nil {
+			return nil, err
+		}
+		client, err := upload.Open(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+
+		return client.Upload(ctx, localPath, record.RemotePath, upload.Options{
+			Verify:   target.Verify,
+			Progress: progress.Store,
+		})
+	}()
+
+	finishedAt := time.Now()
+	updates := map[string]any{
+		"status":      models.UploadStatusSuccess,
+		"finished_at": finishedAt,
+		"duration":    finishedAt.Sub(record.StartedAt).Milliseconds(),
+	}
+	if err != nil {
+		updates["status"] = models.UploadStatusFailed
+		updates["error"] = err.Error()
+		if errors.Is(err, upload.ErrChecksum) {
+			updates["error"] = "上传后校验失败，远程文件与本地文件不一致"
+		}
+		log.Printf("[文件浏览模块]上传 %s 到 %s 失败: %v", record.LocalPath, target.Name, err)
+	} else {
+		updates["remote_path"] = result.RemotePath
+		updates["size"] = result.Size
+		updates["offset"] = result.Offset
+		updates["checksum"] = result.Checksum
+		updates["verified"] = result.Verified
+	}
+
+	if err := app.DB.Model(record).Updates(updates).Error; err != nil {
+		log.Printf("[文件浏览模块]保存上传记录失败: %v", err)
+	}
+}
+
+// recoverUploads 服务重启后，上次未完成的上传标记为失败，重试时会从临时文件续传
+func recoverUploads() error {
+	return app.DB.Model(&models.UploadRecord{}).
+		Where("status = ?", models.UploadStatusRunning).
+		Updates(map[string]any{
+			"status": models.UploadStatusFailed,
+			"error":  "服务重启，上传中断，可以重试续传",
+		}).Error
+}
+
+// listUploadRecords 分页获取上传记录
+func listUploadRecords(page, pageSize int, status string, targetID int) ([]UploadRecordVO, int64, error) {
+	query := app.DB.Model(&models.UploadRecord{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if targetID > 0 {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var records []models.UploadRecord
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]UploadRecordVO, 0, len(records))
+	for _, record := range records {
+		vo := UploadRecordVO{UploadRecord: record}
+		if value, ok := uploadProgress.Load(record.ID); ok {
+			vo.Transferred = value.(*atomic.Int64).Load()
+		}
+		list = append(list, vo)
+	}
+	return list, total, nil
+}
+
+// toTargetVO 转换为上传目标列表项
+func toTargetVO(target models.UploadTarget) UploadTargetVO {
+	return UploadTargetVO{
+		UploadTarget: target,
+		HasPassword:  target.Password != "",
+		Resumable:    upload.Resumable(target.Kind),
+	}
+}
+
+// validateTarget 校验上传目标
+func validateTarget(req UploadTargetReq) error {
+	if !targetNameRegex.MatchString(req.Name) {
+		return errors.New("名称只能包含字母、数字、下划线和短横线")
+	}
+	switch req.Kind {
+	case upload.KindFTP, upload.KindSFTP:
+		if req.Host == "" {
+			return errors.New("请填写主机地址")
+		}
+	case upload.KindS3:
+		if req.Host == "" || req.Bucket == "" {
+			return errors.New("请填写服务地址和存储桶")
+		}
+	case upload.KindLocal:
+		if req.Dir == "" || !filepath.IsAbs(req.Dir) {
+			return errors.New("本地目录必须是绝对路径")
+		}
+	case upload.KindRsync:
+	default:
+		return fmt.Errorf("不支持的上传目标类型: %s", req.Kind)
+	}
+	if req.Port < 0 || req.Port > 65535 {
+		return errors.New("无效的端口")
+	}
+	return nil
+}
+
+// vaultTargetPassword 明文密码转存到凭据库，返回 secret:// 引用
+func vaultTargetPassword(name, password, operator string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if _, ok := vault.ParseRef(password); ok {
+		return password, nil
+	}
+
+	secretName := "upload-" + name
+	if _, err := app.Vault.Put(secretName, password, fmt.Sprintf("上传目标 %s 的密码", name), operator); err != nil {
+		return "", fmt.Errorf("密码存入凭据库失败: %v", err)
+	}
+	return vault.Ref(secretName), nil
+}
+
+// saveTarget 新增或修改上传目标，修改时未填写密码则保留原密码
+func saveTarget(target *models.UploadTarget, req UploadTargetReq, operator string) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateTarget(req); err != nil {
+		return err
+	}
+
+	password := target.Password
+	if req.Password != "" {
+		var err error
+		if password, err = vaultTargetPassword(req.Name, req.Password, operator); err != nil {
+			return err
+		}
+	}
+
+	*target = models.UploadTarget{
+		ID:        target.ID,
+		Name:      req.Name,
+		Title:     strings.TrimSpace(req.Title),
+		Kind:      req.Kind,
+		Host:      strings.TrimSpace(req.Host),
+		Port:      req.Port,
+		Username:  strings.TrimSpace(req.Username),
+		Password:  password,
+		Bucket:    strings.TrimSpace(req.Bucket),
+		Region:    strings.TrimSpace(req.Region),
+		PathStyle: req.PathStyle,
+		HostKey:   strings.TrimSpace(req.HostKey),
+		Dir:       strings.TrimSpace(req.Dir),
+		ExtDirs:   strings.TrimSpace(req.ExtDirs),
+		Verify:    req.Verify,
+		Enabled:   req.Enabled,
+		CreatedAt: target.CreatedAt,
+	}
+	return app.DB.Save(target).Error
+}
+
+// testTarget 连接上传目标并检查默认目录
+func testTarget(target *models.UploadTarget) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cfg, err := targetConfig(target)
+	if err != nil {
+		return err
+	}
+	client, err := upload.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	dir := target.Dir
+	if dir == "" {
+		dir = "/"
+	}
+	return client.Test(ctx, dir)
+}
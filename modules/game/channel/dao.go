@@ -1,18 +1,21 @@
 package channel
 
-import "github.com/andycai/goapi/models"
+import (
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+)
 
 // Channel DAO operations
-func GetChannels(page, limit int) ([]models.Channel, int64, error) {
+func GetChannels(page, limit int, scope internal.Scope) ([]models.Channel, int64, error) {
 	var channels []models.Channel
 	var total int64
 
-	if err := app.DB.Model(&models.Channel{}).Count(&total).Error; err != nil {
+	if err := scope.Apply(app.DB.Model(&models.Channel{}), "id").Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * limit
-	if err := app.DB.Preload("ServerGroups").Preload("Announcements").
+	if err := scope.Apply(app.DB, "id").Preload("ServerGroups").Preload("Announcements").
 		Order("created_at DESC").Offset(offset).Limit(limit).Find(&channels).Error; err != nil {
 		return nil, 0, err
 	}
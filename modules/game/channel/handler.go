@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
 	"github.com/gofiber/fiber/v2"
 )
@@ -20,7 +21,8 @@ func getChannelsHandler(c *fiber.Ctx) error {
 		page = 1
 	}
 
-	channels, total, err := GetChannels(page, limit)
+	// 只返回权限范围内的渠道
+	channels, total, err := GetChannels(page, limit, app.Scope(c, "channel:view"))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "获取渠道列表失败: " + err.Error(),
@@ -41,6 +43,13 @@ func createChannelHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// 权限范围限定了渠道的角色不能创建新渠道
+	if !app.Can(c, "channel:manage", internal.Resource{}) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "没有创建渠道的权限",
+		})
+	}
+
 	if err := CreateChannelWithRelations(&channel); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "创建渠道失败: " + err.Error(),
@@ -58,6 +67,10 @@ func updateChannelHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if !app.Can(c, "channel:manage", internal.Resource{ID: uint(id)}) {
+		return channelForbidden(c)
+	}
+
 	var data map[string]interface{}
 	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -84,6 +97,10 @@ func deleteChannelHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if !app.Can(c, "channel:manage", internal.Resource{ID: uint(id)}) {
+		return channelForbidden(c)
+	}
+
 	if err := DeleteChannel(uint(id)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "删除渠道失败: " + err.Error(),
@@ -95,6 +112,13 @@ func deleteChannelHandler(c *fiber.Ctx) error {
 	})
 }
 
+// channelForbidden 没有渠道的权限
+func channelForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "没有该渠道的权限",
+	})
+}
+
 // PhysicalServer Handlers
 func getPhysicalServersHandler(c *fiber.Ctx) error {
 	limit, err := strconv.Atoi(c.Query("limit", "10"))
@@ -72,6 +72,7 @@ func updateProjectHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	before := project
 	if err := c.BodyParser(&project); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": fmt.Sprintf("无效的请求数据: %v", err),
@@ -85,7 +86,7 @@ func updateProjectHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "gameconf_project", project.ID, fmt.Sprintf("更新游戏配置项目：%s", project.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gameconf_project", ResourceID: project.ID, Details: fmt.Sprintf("更新游戏配置项目：%s", project.Name), Before: before, After: project})
 
 	return c.JSON(project)
 }
@@ -193,6 +194,7 @@ func updateTableHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	before := table
 	if err := c.BodyParser(&table); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": fmt.Sprintf("无效的请求数据: %v", err),
@@ -206,7 +208,7 @@ func updateTableHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "gameconf_table", table.ID, fmt.Sprintf("更新游戏配置表：%s", table.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gameconf_table", ResourceID: table.ID, Details: fmt.Sprintf("更新游戏配置表：%s", table.Name), Before: before, After: table})
 
 	return c.JSON(table)
 }
@@ -8,5 +8,31 @@ type LogReq struct {
 	Package  string           `json:"package"`
 	RoleName string           `json:"role_name"`
 	Device   string           `json:"device"`
+	Version  string           `json:"app_version"`
 	Logs     []models.GameLog `json:"list"`
 }
+
+// AppReq 上报应用请求结构体
+type AppReq struct {
+	AppID       string `json:"app_id"`
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Description string `json:"description"`
+}
+
+// IssueCloseReq 关闭异常问题请求结构体
+type IssueCloseReq struct {
+	Version string `json:"version"` // 修复版本
+}
+
+// IssueLinkReq 关联缺陷请求结构体
+type IssueLinkReq struct {
+	BugIssueID int64 `json:"bug_issue_id"`
+	ProjectID  int64 `json:"project_id"`
+}
+
+// SavedSearchReq 保存查询请求结构体
+type SavedSearchReq struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
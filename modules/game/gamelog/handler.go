@@ -1,73 +1,148 @@
 package gamelog
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
 	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/andycai/goapi/pkg/symbolicate"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // 创建日志记录
 func createLogHandler(c *fiber.Ctx) error {
+	// 先校验密钥再解压，未授权的请求不消耗解压资源；使用原始请求体，由 decodeLogBody 限制解压大小
+	appID, ok := appForKey(c.Get("X-Api-Key"))
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": ErrInvalidAppKey.Error()})
+	}
+
+	body, err := decodeLogBody(c.Get(fiber.HeaderContentEncoding), c.BodyRaw())
+	if err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid body encoding"})
+	}
+
 	logReq := new(LogReq)
-	if err := c.BodyParser(logReq); err != nil {
+	if err := json.Unmarshal(body, logReq); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
+	if logReq.AppID != appID {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": ErrInvalidAppKey.Error()})
+	}
+
 	// Loop through LogReq.Logs and copy appid, package, role_name, device to each log
+	now := time.Now().UnixMilli()
 	for i := range logReq.Logs {
+		logReq.Logs[i].ID = 0
 		logReq.Logs[i].AppID = logReq.AppID
-		logReq.Logs[i].Package = logReq.Package
+		// 有列宽限制的字段按列宽截断，超长的上报会使整批写入失败
+		logReq.Logs[i].Package = truncate(logReq.Package, 128)
 		logReq.Logs[i].RoleName = logReq.RoleName
-		logReq.Logs[i].Device = logReq.Device
-		logReq.Logs[i].CreateAt = time.Now().UnixMilli()
+		logReq.Logs[i].Device = truncate(logReq.Device, 128)
+		logReq.Logs[i].IssueID = 0
+		if logReq.Logs[i].AppVersion == "" {
+			logReq.Logs[i].AppVersion = logReq.Version
+		}
+		logReq.Logs[i].AppVersion = truncate(logReq.Logs[i].AppVersion, 50)
+		logReq.Logs[i].LogType = truncate(logReq.Logs[i].LogType, 20)
+		logReq.Logs[i].CreateAt = now
 	}
 
-	result := app.DB.CreateInBatches(logReq.Logs, len(logReq.Logs))
-	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create log"})
+	received := len(logReq.Logs)
+	logs, keys := deduper.filter(logReq.Logs)
+	duplicated := received - len(logs)
+
+	allowed := limiter.allow(logReq.AppID+"|"+logReq.Device, len(logs))
+	if allowed == 0 && len(logs) > 0 {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many logs from this device"})
 	}
+	limited := len(logs) - allowed
+	logs = logs[:allowed]
+	deduper.mark(keys[:allowed])
 
-	// 记录操作日志
-	// adminlog.CreateAdminLog(c, "create", "gamelog", 0, fmt.Sprintf("批量创建游戏日志，角色：%s，数量：%d", logReq.RoleName, len(logReq.Logs)))
+	queued, spilled := enqueueLogs(logs)
 
-	return c.Status(201).JSON(logReq.Logs)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"received":   received,
+		"accepted":   queued + spilled,
+		"duplicated": duplicated,
+		"limited":    limited,
+	})
 }
 
 // 获取日志记录
 func listLogsHandler(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
 	pageSize := c.QueryInt("pageSize", 20)
-	search := c.Query("search", "")
+	if pageSize <= 0 || pageSize > 500 {
+		pageSize = 20
+	}
 
-	var logs []models.GameLog
-	var total int64
+	logQuery, err := parseLogQuery(buildLogQueryString(c), time.Now())
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	query := app.DB.Model(&models.GameLog{})
+	query := logQuery.apply(app.DB.Model(&models.GameLog{}))
 
-	if search != "" {
-		query = query.Where("role_name LIKE ? OR log_message LIKE ?", "%"+search+"%", "%"+search+"%")
+	if cursor := c.Query("cursor"); cursor != "" {
+		createAt, id, err := decodeLogCursor(cursor)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid cursor"})
+		}
+		query = query.Where("create_at < ? OR (create_at = ? AND id < ?)", createAt, createAt, id)
 	}
 
-	query.Count(&total)
-
-	offset := (page - 1) * pageSize
-	result := query.Offset(offset).Order("create_at DESC").Limit(pageSize).Find(&logs)
+	var logs []models.GameLog
+	result := query.Order("create_at DESC").Order("id DESC").Limit(pageSize + 1).Find(&logs)
 	if result.Error != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch logs"})
 	}
 
+	nextCursor := ""
+	if len(logs) > pageSize {
+		logs = logs[:pageSize]
+		nextCursor = encodeLogCursor(&logs[len(logs)-1])
+	}
+
 	return c.JSON(fiber.Map{
-		"logs":     logs,
-		"total":    total,
-		"page":     page,
-		"pageSize": pageSize,
+		"logs":        logs,
+		"next_cursor": nextCursor,
+		"pageSize":    pageSize,
 	})
 }
 
+// buildLogQueryString 合并查询语言与旧的筛选参数
+func buildLogQueryString(c *fiber.Ctx) string {
+	parts := []string{c.Query("q")}
+	if search := c.Query("search"); search != "" {
+		parts = append(parts, `"`+strings.ReplaceAll(search, `"`, "")+`"`)
+	}
+	if logType := c.Query("type"); logType != "" {
+		parts = append(parts, "type:"+logType)
+	}
+	if startDate := c.Query("startDate"); startDate != "" {
+		parts = append(parts, "since:"+startDate)
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		// 结束日期包含当天
+		if date, err := time.ParseInLocation("2006-01-02", endDate, time.Local); err == nil {
+			parts = append(parts, "until:"+date.AddDate(0, 0, 1).Format("2006-01-02"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // 删除日志记录
 func deleteLogsBeforeHandler(c *fiber.Ctx) error {
 	dateStr := c.Query("date")
@@ -125,3 +200,441 @@ func deleteLogHandler(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"code": 0, "message": "Log deleted successfully"})
 }
+
+// 获取上报应用列表
+func listAppsHandler(c *fiber.Ctx) error {
+	var apps []models.GameLogApp
+	if err := app.DB.Order("id DESC").Find(&apps).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch apps"})
+	}
+
+	return c.JSON(fiber.Map{"apps": apps})
+}
+
+// 创建上报应用
+func createAppHandler(c *fiber.Ctx) error {
+	var req AppReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if req.AppID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "App ID is required"})
+	}
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate api key"})
+	}
+
+	logApp := models.GameLogApp{
+		AppID:       req.AppID,
+		Name:        req.Name,
+		APIKey:      apiKey,
+		Enabled:     req.Enabled,
+		Description: req.Description,
+	}
+	if err := app.DB.Create(&logApp).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create app"})
+	}
+
+	reloadAppKeys()
+
+	adminlog.WriteLog(c, "create", "gamelog_app", logApp.ID, fmt.Sprintf("创建日志上报应用：%s", logApp.AppID))
+
+	return c.Status(201).JSON(logApp)
+}
+
+// 更新上报应用
+func updateAppHandler(c *fiber.Ctx) error {
+	var logApp models.GameLogApp
+	if err := app.DB.First(&logApp, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "App not found"})
+	}
+
+	var req AppReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	before := logApp
+	logApp.Name = req.Name
+	logApp.Enabled = req.Enabled
+	logApp.Description = req.Description
+	if err := app.DB.Save(&logApp).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update app"})
+	}
+
+	reloadAppKeys()
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gamelog_app", ResourceID: logApp.ID, Details: fmt.Sprintf("更新日志上报应用：%s", logApp.AppID), Before: before, After: logApp})
+
+	return c.JSON(logApp)
+}
+
+// 重置上报应用密钥
+func resetAppKeyHandler(c *fiber.Ctx) error {
+	var logApp models.GameLogApp
+	if err := app.DB.First(&logApp, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "App not found"})
+	}
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate api key"})
+	}
+
+	before := logApp
+	if err := app.DB.Model(&logApp).Update("api_key", apiKey).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reset api key"})
+	}
+
+	reloadAppKeys()
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gamelog_app", ResourceID: logApp.ID, Details: fmt.Sprintf("重置日志上报应用密钥：%s", logApp.AppID), Before: before, After: logApp})
+
+	return c.JSON(logApp)
+}
+
+// 删除上报应用
+func deleteAppHandler(c *fiber.Ctx) error {
+	var logApp models.GameLogApp
+	if err := app.DB.First(&logApp, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "App not found"})
+	}
+
+	if err := app.DB.Delete(&logApp).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete app"})
+	}
+
+	reloadAppKeys()
+
+	adminlog.WriteLog(c, "delete", "gamelog_app", logApp.ID, fmt.Sprintf("删除日志上报应用：%s", logApp.AppID))
+
+	return c.JSON(fiber.Map{"code": 0, "message": "App deleted successfully"})
+}
+
+// 获取异常问题列表
+func listIssuesHandler(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+	status := c.Query("status")
+	appID := c.Query("app_id")
+	search := c.Query("search")
+	sort := c.Query("sort", "last_seen")
+
+	query := app.DB.Model(&models.GameLogIssue{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if appID != "" {
+		query = query.Where("app_id = ?", appID)
+	}
+	if search != "" {
+		query = query.Where("title LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	order := "last_seen DESC"
+	switch sort {
+	case "count":
+		order = "count DESC"
+	case "devices":
+		order = "device_count DESC"
+	case "first_seen":
+		order = "first_seen DESC"
+	}
+
+	var issues []models.GameLogIssue
+	if err := query.Order(order).Offset((page - 1) * pageSize).Limit(pageSize).Find(&issues).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch issues"})
+	}
+
+	return c.JSON(fiber.Map{
+		"issues":   issues,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// 获取异常问题详情
+func getIssueHandler(c *fiber.Ctx) error {
+	var issue models.GameLogIssue
+	if err := app.DB.First(&issue, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Issue not found"})
+	}
+
+	tags := fiber.Map{}
+	for _, kind := range []string{models.GameLogIssueTagDevice, models.GameLogIssueTagRole, models.GameLogIssueTagVersion} {
+		var list []models.GameLogIssueTag
+		app.DB.Where("issue_id = ? AND kind = ?", issue.ID, kind).Order("count DESC").Limit(20).Find(&list)
+		tags[kind] = list
+	}
+
+	var logs []models.GameLog
+	app.DB.Where("issue_id = ?", issue.ID).Order("create_at DESC").Limit(20).Find(&logs)
+
+	return c.JSON(fiber.Map{
+		"issue": issue,
+		"tags":  tags,
+		"logs":  logs,
+	})
+}
+
+// 关闭异常问题
+func closeIssueHandler(c *fiber.Ctx) error {
+	var issue models.GameLogIssue
+	if err := app.DB.First(&issue, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Issue not found"})
+	}
+
+	var req IssueCloseReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	before := issue
+	now := time.Now().UnixMilli()
+	if err := app.DB.Model(&issue).Updates(map[string]interface{}{
+		"status":         models.GameLogIssueClosed,
+		"closed_at":      now,
+		"closed_version": req.Version,
+	}).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to close issue"})
+	}
+	app.DB.First(&issue, issue.ID)
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gamelog_issue", ResourceID: issue.ID, Details: fmt.Sprintf("关闭异常问题：%s，修复版本：%s", issue.Title, req.Version), Before: before, After: issue})
+
+	return c.JSON(issue)
+}
+
+// 重新打开异常问题
+func reopenIssueHandler(c *fiber.Ctx) error {
+	var issue models.GameLogIssue
+	if err := app.DB.First(&issue, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Issue not found"})
+	}
+
+	before := issue
+	if err := app.DB.Model(&issue).Update("status", models.GameLogIssueOpen).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reopen issue"})
+	}
+	app.DB.First(&issue, issue.ID)
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gamelog_issue", ResourceID: issue.ID, Details: fmt.Sprintf("重新打开异常问题：%s", issue.Title), Before: before, After: issue})
+
+	return c.JSON(issue)
+}
+
+// 关联缺陷，未指定缺陷ID时在指定项目下新建缺陷
+func linkIssueHandler(c *fiber.Ctx) error {
+	var issue models.GameLogIssue
+	if err := app.DB.First(&issue, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Issue not found"})
+	}
+
+	var req IssueLinkReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	var bug models.Issue
+	if req.BugIssueID > 0 {
+		if err := app.DB.First(&bug, req.BugIssueID).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Bug issue not found"})
+		}
+	} else {
+		if req.ProjectID == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Project ID is required"})
+		}
+		currentUser := app.CurrentUser(c)
+		bug = models.Issue{
+			ProjectID:   req.ProjectID,
+			Title:       issue.Title,
+			Description: fmt.Sprintf("%s\n\n```\n%s\n```", issue.Title, issue.Stack),
+			Type:        1,
+			Priority:    3,
+			Status:      1,
+			ReporterID:  int64(currentUser.ID),
+		}
+		if err := app.DB.Create(&bug).Error; err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to create bug issue"})
+		}
+	}
+
+	before := issue
+	if err := app.DB.Model(&issue).Update("bug_issue_id", bug.ID).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to link bug issue"})
+	}
+	app.DB.First(&issue, issue.ID)
+
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "gamelog_issue", ResourceID: issue.ID, Details: fmt.Sprintf("异常问题关联缺陷：#%d", bug.ID), Before: before, After: issue})
+
+	return c.JSON(fiber.Map{"issue": issue, "bug": bug})
+}
+
+// 获取当前用户保存的查询
+func listSavedSearchesHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+
+	var searches []models.GameLogSavedSearch
+	if err := app.DB.Where("user_id = ?", currentUser.ID).Order("id DESC").Find(&searches).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch saved searches"})
+	}
+
+	return c.JSON(fiber.Map{"searches": searches})
+}
+
+// 保存查询
+func createSavedSearchHandler(c *fiber.Ctx) error {
+	var req SavedSearchReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if req.Name == "" || req.Query == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name and query are required"})
+	}
+	if _, err := parseLogQuery(req.Query, time.Now()); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	currentUser := app.CurrentUser(c)
+	search := models.GameLogSavedSearch{
+		UserID: currentUser.ID,
+		Name:   req.Name,
+		Query:  req.Query,
+	}
+	if err := app.DB.Create(&search).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save search"})
+	}
+
+	return c.Status(201).JSON(search)
+}
+
+// 删除保存的查询
+func deleteSavedSearchHandler(c *fiber.Ctx) error {
+	currentUser := app.CurrentUser(c)
+
+	result := app.DB.Where("id = ? AND user_id = ?", c.Params("id"), currentUser.ID).Delete(&models.GameLogSavedSearch{})
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete saved search"})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Saved search not found"})
+	}
+
+	return c.JSON(fiber.Map{"code": 0, "message": "Saved search deleted successfully"})
+}
+
+// 获取符号文件列表
+func listSymbolsHandler(c *fiber.Ctx) error {
+	query := app.DB.Model(&models.GameLogSymbol{})
+	if appID := c.Query("app_id"); appID != "" {
+		query = query.Where("app_id = ?", appID)
+	}
+	if version := c.Query("app_version"); version != "" {
+		query = query.Where("app_version = ?", version)
+	}
+
+	var symbols []models.GameLogSymbol
+	if err := query.Order("id DESC").Limit(200).Find(&symbols).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch symbols"})
+	}
+
+	return c.JSON(fiber.Map{"symbols": symbols})
+}
+
+// 上传符号文件
+func uploadSymbolHandler(c *fiber.Ctx) error {
+	symbol := models.GameLogSymbol{
+		AppID:      c.FormValue("app_id"),
+		AppVersion: c.FormValue("app_version"),
+		BuildID:    c.FormValue("build_id"),
+		Platform:   c.FormValue("platform"),
+		Kind:       c.FormValue("kind"),
+		Module:     c.FormValue("module"),
+	}
+	if symbol.AppID == "" || symbol.AppVersion == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "App ID and version are required"})
+	}
+	if !validSymbolPathPart(symbol.AppID) || !validSymbolPathPart(symbol.AppVersion) {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid app ID or version"})
+	}
+
+	switch symbol.Kind {
+	case models.SymbolKindIl2CppMapping, models.SymbolKindELF, models.SymbolKindTextMap:
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid symbol kind"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "File is required"})
+	}
+	if fileHeader.Size > int64(internal.GetGameLogConfig().SymbolMaxSize) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Symbol file is too large"})
+	}
+	symbol.Filename = fileHeader.Filename
+	if symbol.Module == "" {
+		symbol.Module = symbolicate.ModuleName(fileHeader.Filename)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to read file"})
+	}
+	defer file.Close()
+
+	if err := saveSymbolFile(&symbol, file); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	symbol.CreatedBy = app.CurrentUser(c).ID
+	if err := app.DB.Create(&symbol).Error; err != nil {
+		os.Remove(symbol.Path)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save symbol"})
+	}
+
+	invalidateSymbols(symbol.AppID, symbol.AppVersion)
+
+	adminlog.WriteLog(c, "create", "gamelog_symbol", symbol.ID, fmt.Sprintf("上传符号文件：%s，版本：%s", symbol.Filename, symbol.AppVersion))
+
+	return c.Status(201).JSON(symbol)
+}
+
+// 删除符号文件
+func deleteSymbolHandler(c *fiber.Ctx) error {
+	var symbol models.GameLogSymbol
+	if err := app.DB.First(&symbol, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Symbol not found"})
+	}
+
+	if err := app.DB.Delete(&symbol).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete symbol"})
+	}
+	os.Remove(symbol.Path)
+
+	invalidateSymbols(symbol.AppID, symbol.AppVersion)
+
+	adminlog.WriteLog(c, "delete", "gamelog_symbol", symbol.ID, fmt.Sprintf("删除符号文件：%s，版本：%s", symbol.Filename, symbol.AppVersion))
+
+	return c.JSON(fiber.Map{"code": 0, "message": "Symbol deleted successfully"})
+}
+
+// 获取还原后的日志堆栈
+func symbolicateLogHandler(c *fiber.Ctx) error {
+	var entry models.GameLog
+	if err := app.DB.First(&entry, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Log not found"})
+	}
+
+	symbolicated := symbolicateLog(&entry)
+
+	return c.JSON(fiber.Map{
+		"log":          entry,
+		"symbolicated": symbolicated,
+	})
+}
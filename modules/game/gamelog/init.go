@@ -6,12 +6,34 @@ import (
 
 	"github.com/andycai/goapi/enum"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
 	"gorm.io/gorm"
 )
 
 // 数据迁移
 func autoMigrate() error {
-	return app.DB.AutoMigrate(&models.GameLog{})
+	return app.DB.AutoMigrate(
+		&models.GameLog{},
+		&models.GameLogApp{},
+		&models.GameLogIssue{},
+		&models.GameLogIssueTag{},
+		&models.GameLogSavedSearch{},
+		&models.GameLogSymbol{},
+	)
+}
+
+// registerRetention 注册数据保留对象，聚合后的问题属于汇总数据
+func registerRetention() {
+	retention.RegisterTarget(retention.Target{
+		Name:        "gamelog",
+		Title:       "游戏日志",
+		DefaultDays: 30,
+		Tables: []retention.Table{
+			{Model: &models.GameLog{}, TimeColumn: "create_at", Millis: true},
+			{Model: &models.GameLogIssue{}, TimeColumn: "last_seen", Millis: true, Aggregate: true},
+			{Model: &models.GameLogIssueTag{}, TimeColumn: "last_seen", Millis: true, Aggregate: true},
+		},
+	})
 }
 
 // 初始化数据
@@ -24,6 +46,14 @@ func initData() error {
 		return err
 	}
 
+	if err := initAppPermissions(); err != nil {
+		return err
+	}
+
+	if err := initIssueMenus(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -119,3 +149,78 @@ func initPermissions() error {
 		return nil
 	})
 }
+
+func initAppPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("gamelog:permission:app") {
+		log.Println("[游戏日志模块]上报应用权限已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		permission := models.Permission{
+			Name:        "游戏日志上报应用管理",
+			Code:        "gamelog:manage",
+			Description: "管理游戏日志上报应用及密钥",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(&permission).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "gamelog:permission:app",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initIssueMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("gamelog:menu:issue") {
+		log.Println("[游戏日志模块]异常聚合菜单已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		issueMenu := models.Menu{
+			MenuID:     3015,
+			ParentID:   enum.MenuIdGame,
+			Name:       "异常聚合",
+			Path:       "/admin/gamelog/issues",
+			Icon:       "gamelog",
+			Sort:       15,
+			Permission: "gamelog:view",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&issueMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "gamelog:menu:issue",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
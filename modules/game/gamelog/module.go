@@ -20,12 +20,34 @@ func init() {
 func (m *gamelogModule) Awake(a *internal.App) error {
 	app = a
 	// 数据迁移
-	return autoMigrate()
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	// 全文索引
+	initFullText()
+
+	// 数据保留
+	registerRetention()
+
+	return nil
 }
 
 func (m *gamelogModule) Start() error {
 	// 初始化数据
-	return initData()
+	if err := initData(); err != nil {
+		return err
+	}
+
+	// 启动日志收集管道
+	initIngest()
+
+	return nil
+}
+
+func (m *gamelogModule) Dispose() error {
+	stopIngest()
+	return nil
 }
 
 func (m *gamelogModule) AddPublicRouters() error {
@@ -45,10 +67,45 @@ func (m *gamelogModule) AddAuthRouters() error {
 		}, "admin/layout")
 	})
 
+	app.RouterAdmin.Get("/gamelog/issues", app.HasPermission("gamelog:view"), func(c *fiber.Ctx) error {
+		return c.Render("admin/gamelog_issues", fiber.Map{
+			"Title": "异常聚合",
+			"Scripts": []string{
+				"/static/js/admin/gamelog_issues.js",
+			},
+		}, "admin/layout")
+	})
+
 	// api
 	app.RouterAdminApi.Get("/gamelog", app.HasPermission("gamelog:view"), listLogsHandler)
 	app.RouterAdminApi.Delete("/gamelog/before", app.HasPermission("gamelog:delete"), deleteLogsBeforeHandler)
 	app.RouterAdminApi.Delete("/gamelog/:id", app.HasPermission("gamelog:view"), deleteLogHandler)
+	app.RouterAdminApi.Get("/gamelog/:id/symbolicate", app.HasPermission("gamelog:view"), symbolicateLogHandler)
+
+	// 保存的查询
+	app.RouterAdminApi.Get("/gamelog/searches", app.HasPermission("gamelog:view"), listSavedSearchesHandler)
+	app.RouterAdminApi.Post("/gamelog/searches", app.HasPermission("gamelog:view"), createSavedSearchHandler)
+	app.RouterAdminApi.Delete("/gamelog/searches/:id", app.HasPermission("gamelog:view"), deleteSavedSearchHandler)
+
+	// 异常聚合
+	app.RouterAdminApi.Get("/gamelog/issues", app.HasPermission("gamelog:view"), listIssuesHandler)
+	app.RouterAdminApi.Get("/gamelog/issues/:id", app.HasPermission("gamelog:view"), getIssueHandler)
+	app.RouterAdminApi.Post("/gamelog/issues/:id/close", app.HasPermission("gamelog:manage"), closeIssueHandler)
+	app.RouterAdminApi.Post("/gamelog/issues/:id/reopen", app.HasPermission("gamelog:manage"), reopenIssueHandler)
+	app.RouterAdminApi.Post("/gamelog/issues/:id/link", app.HasPermission("gamelog:manage"), linkIssueHandler)
+
+	// 符号文件，上传不受全局请求体上限限制，请求体以流的方式写入临时文件
+	internal.LargeBodyRoute("/api/admin/gamelog/symbols", internal.GetGameLogConfig().SymbolMaxSize)
+	app.RouterAdminApi.Get("/gamelog/symbols", app.HasPermission("gamelog:view"), listSymbolsHandler)
+	app.RouterAdminApi.Post("/gamelog/symbols", app.HasPermission("gamelog:manage"), uploadSymbolHandler)
+	app.RouterAdminApi.Delete("/gamelog/symbols/:id", app.HasPermission("gamelog:manage"), deleteSymbolHandler)
+
+	// 上报应用
+	app.RouterAdminApi.Get("/gamelog/apps", app.HasPermission("gamelog:manage"), listAppsHandler)
+	app.RouterAdminApi.Post("/gamelog/apps", app.HasPermission("gamelog:manage"), createAppHandler)
+	app.RouterAdminApi.Put("/gamelog/apps/:id", app.HasPermission("gamelog:manage"), updateAppHandler)
+	app.RouterAdminApi.Post("/gamelog/apps/:id/reset_key", app.HasPermission("gamelog:manage"), resetAppKeyHandler)
+	app.RouterAdminApi.Delete("/gamelog/apps/:id", app.HasPermission("gamelog:manage"), deleteAppHandler)
 
 	return nil
 }
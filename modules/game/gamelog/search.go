@@ -0,0 +1,342 @@
+package gamelog
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+// 全文检索方式
+const (
+	fullTextNone  = ""
+	fullTextFTS5  = "fts5"
+	fullTextMySQL = "mysql"
+)
+
+var fullTextMode = fullTextNone
+
+// searchFields 查询语言中可用的字段及对应的数据库列
+var searchFields = map[string]string{
+	"type":    "log_type",
+	"app":     "app_id",
+	"app_id":  "app_id",
+	"package": "package",
+	"pkg":     "package",
+	"device":  "device",
+	"role":    "role_name",
+	"version": "app_version",
+	"issue":   "issue_id",
+}
+
+// searchFilter 字段过滤条件
+type searchFilter struct {
+	Column string
+	Value  string
+	Negate bool
+}
+
+// searchText 全文检索词
+type searchText struct {
+	Value  string
+	Negate bool
+}
+
+// logQuery 解析后的日志查询
+type logQuery struct {
+	Filters []searchFilter
+	Texts   []searchText
+	Since   int64 // 毫秒时间戳
+	Until   int64 // 毫秒时间戳
+}
+
+// initFullText 根据数据库类型创建全文索引
+func initFullText() {
+	switch app.DB.Dialector.Name() {
+	case "sqlite":
+		if err := initSQLiteFTS(); err != nil {
+			log.Printf("[游戏日志模块]创建FTS5索引失败，使用LIKE检索: %v", err)
+			return
+		}
+		fullTextMode = fullTextFTS5
+	case "mysql":
+		if !app.DB.Migrator().HasIndex(&models.GameLog{}, "idx_game_logs_message_ft") {
+			if err := app.DB.Exec("ALTER TABLE game_logs ADD FULLTEXT INDEX idx_game_logs_message_ft (log_message)").Error; err != nil {
+				log.Printf("[游戏日志模块]创建FULLTEXT索引失败，使用LIKE检索: %v", err)
+				return
+			}
+		}
+		fullTextMode = fullTextMySQL
+	}
+}
+
+// initSQLiteFTS 创建与 game_logs 同步的 FTS5 外部内容表
+func initSQLiteFTS() error {
+	var exists int64
+	app.DB.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'game_logs_fts'").Scan(&exists)
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS game_logs_fts USING fts5(log_message, content='game_logs', content_rowid='id')`,
+		`CREATE TRIGGER IF NOT EXISTS game_logs_fts_ai AFTER INSERT ON game_logs BEGIN
+			INSERT INTO game_logs_fts(rowid, log_message) VALUES (new.id, new.log_message);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS game_logs_fts_ad AFTER DELETE ON game_logs BEGIN
+			INSERT INTO game_logs_fts(game_logs_fts, rowid, log_message) VALUES ('delete', old.id, old.log_message);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS game_logs_fts_au AFTER UPDATE OF log_message ON game_logs BEGIN
+			INSERT INTO game_logs_fts(game_logs_fts, rowid, log_message) VALUES ('delete', old.id, old.log_message);
+			INSERT INTO game_logs_fts(rowid, log_message) VALUES (new.id, new.log_message);
+		END`,
+	}
+	for _, stmt := range statements {
+		if err := app.DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	// 首次创建时为已有日志建立索引
+	if exists == 0 {
+		return app.DB.Exec("INSERT INTO game_logs_fts(game_logs_fts) VALUES ('rebuild')").Error
+	}
+
+	return nil
+}
+
+// queryToken 查询中的一个词
+type queryToken struct {
+	Text   string
+	Negate bool // 以 - 开头，表示排除
+	Quoted bool // 以双引号开头，整体作为检索词，不解析字段
+}
+
+// tokenizeQuery 按空白切分查询，双引号内的内容作为一个整体
+func tokenizeQuery(input string) ([]queryToken, error) {
+	var (
+		tokens  []queryToken
+		token   queryToken
+		current strings.Builder
+		inQuote bool
+		started bool
+	)
+
+	emit := func() {
+		token.Text = current.String()
+		// 单独的 - 按普通检索词处理
+		if token.Negate && token.Text == "" && !token.Quoted {
+			token = queryToken{Text: "-"}
+		}
+		tokens = append(tokens, token)
+		token = queryToken{}
+		current.Reset()
+		started = false
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			if !inQuote && current.Len() == 0 {
+				token.Quoted = true
+			}
+			inQuote = !inQuote
+			started = true
+		case unicode.IsSpace(r) && !inQuote:
+			if started {
+				emit()
+			}
+		case r == '-' && !inQuote && !started:
+			token.Negate = true
+			started = true
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("引号未闭合")
+	}
+	if started {
+		emit()
+	}
+
+	return tokens, nil
+}
+
+// parseLogQuery 解析形如 type:error device:"iPhone*" since:2h "timeout" 的查询
+func parseLogQuery(input string, now time.Time) (*logQuery, error) {
+	tokens, err := tokenizeQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &logQuery{}
+	for _, token := range tokens {
+		key, value, hasKey := strings.Cut(token.Text, ":")
+		key = strings.ToLower(key)
+		if token.Quoted || !hasKey || (searchFields[key] == "" && key != "since" && key != "until") {
+			// 引号内的内容和不是已知字段的词按全文检索词处理
+			if token.Text != "" {
+				query.Texts = append(query.Texts, searchText{Value: token.Text, Negate: token.Negate})
+			}
+			continue
+		}
+
+		if value == "" {
+			return nil, fmt.Errorf("字段 %s 缺少值", key)
+		}
+
+		switch key {
+		case "since":
+			t, err := parseSearchTime(value, now)
+			if err != nil {
+				return nil, err
+			}
+			query.Since = t
+		case "until":
+			t, err := parseSearchTime(value, now)
+			if err != nil {
+				return nil, err
+			}
+			query.Until = t
+		default:
+			query.Filters = append(query.Filters, searchFilter{Column: searchFields[key], Value: value, Negate: token.Negate})
+		}
+	}
+
+	return query, nil
+}
+
+// parseSearchTime 支持 30m、2h、7d 这样的相对时间以及日期
+func parseSearchTime(value string, now time.Time) (int64, error) {
+	if len(value) > 1 {
+		unit := value[len(value)-1]
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil {
+			switch unit {
+			case 'm':
+				return now.Add(-time.Duration(n) * time.Minute).UnixMilli(), nil
+			case 'h':
+				return now.Add(-time.Duration(n) * time.Hour).UnixMilli(), nil
+			case 'd':
+				return now.AddDate(0, 0, -n).UnixMilli(), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n).UnixMilli(), nil
+			}
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("无效的时间：%s", value)
+}
+
+// apply 将查询条件转换为 SQL
+func (q *logQuery) apply(db *gorm.DB) *gorm.DB {
+	for _, f := range q.Filters {
+		if strings.Contains(f.Value, "*") {
+			op := "LIKE"
+			if f.Negate {
+				op = "NOT LIKE"
+			}
+			pattern := strings.ReplaceAll(escapeLike(f.Value), "*", "%")
+			db = db.Where(fmt.Sprintf("%s %s ? ESCAPE ?", f.Column, op), pattern, likeEscape)
+			continue
+		}
+
+		op := "="
+		if f.Negate {
+			op = "<>"
+		}
+		db = db.Where(fmt.Sprintf("%s %s ?", f.Column, op), f.Value)
+	}
+
+	if q.Since > 0 {
+		db = db.Where("create_at >= ?", q.Since)
+	}
+	if q.Until > 0 {
+		db = db.Where("create_at < ?", q.Until)
+	}
+
+	var include []string
+	for _, t := range q.Texts {
+		if t.Negate {
+			db = db.Where("log_message NOT LIKE ? ESCAPE ?", "%"+escapeLike(t.Value)+"%", likeEscape)
+			continue
+		}
+		include = append(include, t.Value)
+	}
+
+	if len(include) == 0 {
+		return db
+	}
+
+	switch fullTextMode {
+	case fullTextFTS5:
+		phrases := make([]string, len(include))
+		for i, text := range include {
+			phrases[i] = `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		}
+		db = db.Where("id IN (SELECT rowid FROM game_logs_fts WHERE game_logs_fts MATCH ?)", strings.Join(phrases, " AND "))
+	case fullTextMySQL:
+		phrases := make([]string, len(include))
+		for i, text := range include {
+			phrases[i] = `+"` + strings.ReplaceAll(text, `"`, ``) + `"`
+		}
+		db = db.Where("MATCH(log_message) AGAINST (? IN BOOLEAN MODE)", strings.Join(phrases, " "))
+	default:
+		for _, text := range include {
+			db = db.Where("log_message LIKE ? ESCAPE ?", "%"+escapeLike(text)+"%", likeEscape)
+		}
+	}
+
+	return db
+}
+
+// likeEscape LIKE 的转义字符，作为参数传入，MySQL 和 SQLite 的字符串字面量对反斜杠的处理不同
+const likeEscape = `\`
+
+// likeEscaper 转义 LIKE 中的通配符，用户输入的 % 和 _ 按字面量匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike 转义用户输入，用于 LIKE 匹配
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// encodeLogCursor 编码分页游标
+func encodeLogCursor(entry *models.GameLog) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d_%d", entry.CreateAt, entry.ID)))
+}
+
+// decodeLogCursor 解码分页游标
+func decodeLogCursor(cursor string) (int64, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	createAt, id, ok := strings.Cut(string(data), "_")
+	if !ok {
+		return 0, 0, fmt.Errorf("无效的游标")
+	}
+
+	at, err := strconv.ParseInt(createAt, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	logID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return at, uint(logID), nil
+}
@@ -0,0 +1,794 @@
+package gamelog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBodyTooLarge        = errors.New("请求体过大")
+	ErrUnsupportedEncoding = errors.New("不支持的压缩格式")
+	ErrInvalidAppKey       = errors.New("无效的应用密钥")
+)
+
+var (
+	ingestConf  internal.GameLogConfig
+	ingestQueue chan models.GameLog
+	ingestStop  chan struct{}
+	ingestWg    sync.WaitGroup
+	spillMutex  sync.Mutex
+
+	appKeys      = make(map[string]string) // app_id -> api_key
+	appKeysMutex sync.RWMutex
+
+	limiter = &deviceLimiter{buckets: make(map[string]*tokenBucket)}
+	deduper = &logDeduper{seen: make(map[uint64]int64)}
+)
+
+// initIngest 初始化日志收集管道
+func initIngest() {
+	ingestConf = internal.GetGameLogConfig()
+	ingestQueue = make(chan models.GameLog, ingestConf.BufferSize)
+	ingestStop = make(chan struct{})
+
+	if err := os.MkdirAll(ingestConf.SpillDir, 0755); err != nil {
+		log.Printf("[游戏日志模块]创建溢出目录失败: %v", err)
+	}
+	if err := os.MkdirAll(ingestConf.SymbolDir, 0755); err != nil {
+		log.Printf("[游戏日志模块]创建符号目录失败: %v", err)
+	}
+
+	if err := reloadAppKeys(); err != nil {
+		log.Printf("[游戏日志模块]加载应用密钥失败: %v", err)
+	}
+
+	for i := 0; i < ingestConf.Workers; i++ {
+		ingestWg.Add(1)
+		go flushWorker()
+	}
+
+	ingestWg.Add(1)
+	go maintenanceLoop()
+}
+
+// stopIngest 停止日志收集管道，并将缓冲中的日志写入数据库
+func stopIngest() {
+	if ingestStop == nil {
+		return
+	}
+	close(ingestStop)
+	ingestWg.Wait()
+}
+
+// decodeLogBody 根据 Content-Encoding 解压请求体，解压后的大小同样受 MaxBodySize 限制
+func decodeLogBody(encoding string, body []byte) ([]byte, error) {
+	if len(body) > ingestConf.MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	var (
+		r   io.ReadCloser
+		err error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate", "zlib":
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	default:
+		return nil, ErrUnsupportedEncoding
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// 多读一个字节用于判断是否超限，避免压缩炸弹一次性占满内存
+	data, err := io.ReadAll(io.LimitReader(r, int64(ingestConf.MaxBodySize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > ingestConf.MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	return data, nil
+}
+
+// reloadAppKeys 从数据库重新加载应用密钥
+func reloadAppKeys() error {
+	var apps []models.GameLogApp
+	if err := app.DB.Where("enabled = ?", true).Find(&apps).Error; err != nil {
+		return err
+	}
+
+	keys := make(map[string]string, len(apps))
+	for _, a := range apps {
+		keys[a.AppID] = a.APIKey
+	}
+
+	appKeysMutex.Lock()
+	appKeys = keys
+	appKeysMutex.Unlock()
+
+	return nil
+}
+
+// appForKey 根据应用密钥查找应用，密钥全局唯一，在解压请求体之前校验
+func appForKey(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+
+	appKeysMutex.RLock()
+	defer appKeysMutex.RUnlock()
+
+	for appID, expected := range appKeys {
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(apiKey)) == 1 {
+			return appID, true
+		}
+	}
+	return "", false
+}
+
+// generateAPIKey 生成应用密钥
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// enqueueLogs 将日志放入缓冲队列，队列已满时落盘，返回入队和落盘的数量
+func enqueueLogs(logs []models.GameLog) (int, int) {
+	queued := 0
+	var overflow []models.GameLog
+
+	for _, entry := range logs {
+		select {
+		case ingestQueue <- entry:
+			queued++
+		default:
+			overflow = append(overflow, entry)
+		}
+	}
+
+	if len(overflow) > 0 {
+		if err := spillToDisk(overflow); err != nil {
+			log.Printf("[游戏日志模块]日志落盘失败，丢弃%d条: %v", len(overflow), err)
+			return queued, 0
+		}
+	}
+
+	return queued, len(overflow)
+}
+
+// flushWorker 从缓冲队列中取出日志并批量写入数据库
+func flushWorker() {
+	defer ingestWg.Done()
+
+	ticker := time.NewTicker(time.Duration(ingestConf.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	batch := make([]models.GameLog, 0, ingestConf.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		writeBatch(batch)
+		batch = make([]models.GameLog, 0, ingestConf.BatchSize)
+	}
+
+	for {
+		select {
+		case entry := <-ingestQueue:
+			batch = append(batch, entry)
+			if len(batch) >= ingestConf.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ingestStop:
+			// 把队列中剩余的日志写完再退出
+			for {
+				select {
+				case entry := <-ingestQueue:
+					batch = append(batch, entry)
+					if len(batch) >= ingestConf.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeBatch 还原堆栈、聚合异常后批量写入数据库，失败时落盘等待重试
+func writeBatch(batch []models.GameLog) {
+	symbolicateBatch(batch)
+
+	if err := saveBatch(batch); err != nil {
+		log.Printf("[游戏日志模块]批量写入失败，转存磁盘: %v", err)
+		if err := spillToDisk(batch); err != nil {
+			log.Printf("[游戏日志模块]日志落盘失败，丢弃%d条: %v", len(batch), err)
+		}
+	}
+}
+
+// spillToDisk 将日志以 NDJSON 格式写入溢出目录
+func spillToDisk(logs []models.GameLog) error {
+	spillMutex.Lock()
+	defer spillMutex.Unlock()
+
+	filename := filepath.Join(ingestConf.SpillDir, fmt.Sprintf("spill-%d.ndjson", time.Now().UnixNano()))
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for i := range logs {
+		logs[i].ID = 0
+		if err := encoder.Encode(&logs[i]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// saveBatch 在同一个事务里聚合异常并写入日志，写入失败时问题计数一起回滚，
+// 本次聚合回写的 IssueID 也会清除，落盘或重试时重新统计
+func saveBatch(batch []models.GameLog) error {
+	var grouped []int
+	for i := range batch {
+		if batch[i].IssueID == 0 {
+			grouped = append(grouped, i)
+		}
+	}
+
+	err := app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := groupLogs(tx, batch); err != nil {
+			return err
+		}
+		return tx.CreateInBatches(batch, ingestConf.BatchSize).Error
+	})
+	if err != nil {
+		for _, i := range grouped {
+			batch[i].IssueID = 0
+		}
+	}
+	return err
+}
+
+// replaySpilled 在队列空闲时把溢出的日志重新写入数据库
+func replaySpilled() {
+	// 队列仍然繁忙时暂不回放
+	if len(ingestQueue) > cap(ingestQueue)/2 {
+		return
+	}
+
+	spillMutex.Lock()
+	files, err := filepath.Glob(filepath.Join(ingestConf.SpillDir, "spill-*.ndjson"))
+	spillMutex.Unlock()
+	if err != nil || len(files) == 0 {
+		return
+	}
+	sort.Strings(files)
+
+	for _, filename := range files {
+		logs, err := readSpillFile(filename)
+		if err != nil {
+			log.Printf("[游戏日志模块]读取溢出文件失败 %s: %v", filename, err)
+			continue
+		}
+
+		if len(logs) > 0 {
+			if err := saveBatch(logs); err != nil {
+				// 数据库仍不可用，下次再试
+				log.Printf("[游戏日志模块]回放溢出文件失败 %s: %v", filename, err)
+				return
+			}
+		}
+
+		if err := os.Remove(filename); err != nil {
+			log.Printf("[游戏日志模块]删除溢出文件失败 %s: %v", filename, err)
+		}
+	}
+}
+
+func readSpillFile(filename string) ([]models.GameLog, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var logs []models.GameLog
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), ingestConf.MaxBodySize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry models.GameLog
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		logs = append(logs, entry)
+	}
+
+	return logs, scanner.Err()
+}
+
+// maintenanceLoop 定期回放溢出文件并清理限流和去重状态
+func maintenanceLoop() {
+	defer ingestWg.Done()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			replaySpilled()
+			limiter.cleanup(10 * time.Minute)
+			deduper.cleanup()
+		case <-ingestStop:
+			return
+		}
+	}
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// deviceLimiter 按设备限流
+type deviceLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// allow 申请 n 个令牌，返回实际允许的数量
+func (l *deviceLimiter) allow(key string, n int) int {
+	limit := float64(ingestConf.RateLimit)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: limit, lastSeen: now}
+		l.buckets[key] = bucket
+	} else {
+		// 每分钟补充 limit 个令牌
+		elapsed := now.Sub(bucket.lastSeen).Minutes()
+		bucket.tokens = min(limit, bucket.tokens+elapsed*limit)
+		bucket.lastSeen = now
+	}
+
+	allowed := min(n, int(bucket.tokens))
+	bucket.tokens -= float64(allowed)
+
+	return allowed
+}
+
+// cleanup 清理长时间未活动的令牌桶
+func (l *deviceLimiter) cleanup(idle time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// logDeduper 在时间窗口内过滤重复日志
+type logDeduper struct {
+	mu   sync.Mutex
+	seen map[uint64]int64 // 指纹 -> 过期时间
+}
+
+// filter 过滤窗口内已接收和同一批次中重复的日志，返回保留的日志和指纹，指纹在日志被接收后调用 mark 记录
+func (d *logDeduper) filter(logs []models.GameLog) ([]models.GameLog, []uint64) {
+	now := time.Now().Unix()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := logs[:0]
+	keys := make([]uint64, 0, len(logs))
+	batch := make(map[uint64]struct{}, len(logs))
+	for _, entry := range logs {
+		key := fingerprintLog(&entry)
+		if exp, ok := d.seen[key]; ok && exp > now {
+			continue
+		}
+		if _, ok := batch[key]; ok {
+			continue
+		}
+		batch[key] = struct{}{}
+		keys = append(keys, key)
+		result = append(result, entry)
+	}
+
+	return result, keys
+}
+
+// mark 记录已接收日志的指纹，被限流丢弃的日志不记录，设备重试时仍然可以上报
+func (d *logDeduper) mark(keys []uint64) {
+	expireAt := time.Now().Unix() + int64(ingestConf.DedupeWindow)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, key := range keys {
+		d.seen[key] = expireAt
+	}
+}
+
+// cleanup 清理过期的指纹
+func (d *logDeduper) cleanup() {
+	now := time.Now().Unix()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, exp := range d.seen {
+		if exp <= now {
+			delete(d.seen, key)
+		}
+	}
+}
+
+// fingerprintLog 计算日志指纹，同一设备同一时刻的相同日志视为重复
+func fingerprintLog(entry *models.GameLog) uint64 {
+	h := fnv.New64a()
+	for _, s := range []string{entry.AppID, entry.Device, entry.RoleName, entry.LogType, entry.LogMessage, entry.LogStack} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	fmt.Fprintf(h, "%d", entry.LogTime)
+	return h.Sum64()
+}
+
+var (
+	reBracketAddress = regexp.MustCompile(`\[0x[0-9a-fA-F]+\]`)
+	reHexAddress     = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	reNativePC       = regexp.MustCompile(`\bpc [0-9a-fA-F]+`)
+	reModuleGUID     = regexp.MustCompile(`<[0-9a-fA-F]{32}>`)
+	reLineNumber     = regexp.MustCompile(`:\d+(\)|\s|$)`)
+	reNumber         = regexp.MustCompile(`\b\d+\b`)
+)
+
+// 客户端上报字段写入问题时的最大长度，与 GameLogIssueTag.Value 和 GameLogIssue 版本字段的列宽一致
+const (
+	issueTagValueSize = 191
+	issueVersionSize  = 50
+)
+
+// issueDelta 一批日志中同一指纹的统计
+type issueDelta struct {
+	sample   *models.GameLog
+	stack    string
+	indexes  []int
+	first    int64
+	last     int64
+	minVer   string
+	maxVer   string
+	devices  map[string]int64
+	roles    map[string]int64
+	versions map[string]int64
+}
+
+// isGroupableLog 只聚合错误、异常和断言
+func isGroupableLog(logType string) bool {
+	switch strings.ToLower(logType) {
+	case "error", "exception", "assert":
+		return true
+	}
+	return false
+}
+
+// normalizeStack 按配置去掉地址和行号，并截取前几帧
+func normalizeStack(stack string) string {
+	stack = strings.ReplaceAll(stack, `\n`, "\n")
+
+	frames := make([]string, 0, ingestConf.StackDepth)
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if ingestConf.StripAddresses {
+			line = reBracketAddress.ReplaceAllString(line, "")
+			line = reHexAddress.ReplaceAllString(line, "0x?")
+			line = reNativePC.ReplaceAllString(line, "pc ?")
+			line = reModuleGUID.ReplaceAllString(line, "<?>")
+		}
+		if ingestConf.StripLineNumbers {
+			line = reLineNumber.ReplaceAllString(line, "$1")
+		}
+		frames = append(frames, strings.TrimSpace(line))
+		if len(frames) >= ingestConf.StackDepth {
+			break
+		}
+	}
+
+	return strings.Join(frames, "\n")
+}
+
+// normalizeMessage 取日志首行并屏蔽其中变化的数字和地址
+func normalizeMessage(message string) string {
+	message = strings.ReplaceAll(message, `\n`, "\n")
+	if idx := strings.IndexByte(message, '\n'); idx >= 0 {
+		message = message[:idx]
+	}
+	message = reHexAddress.ReplaceAllString(message, "0x?")
+	message = reNumber.ReplaceAllString(message, "N")
+	return strings.TrimSpace(message)
+}
+
+// issueFingerprint 计算异常指纹
+func issueFingerprint(entry *models.GameLog, stack string) string {
+	h := sha256.New()
+	h.Write([]byte(entry.AppID))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToLower(entry.LogType)))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeMessage(entry.LogMessage)))
+	h.Write([]byte{0})
+	h.Write([]byte(stack))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// issueTitle 取日志首行作为问题标题
+func issueTitle(message string) string {
+	message = strings.ReplaceAll(message, `\n`, "\n")
+	if idx := strings.IndexByte(message, '\n'); idx >= 0 {
+		message = message[:idx]
+	}
+	runes := []rune(strings.TrimSpace(message))
+	if len(runes) > 200 {
+		runes = runes[:200]
+	}
+	return string(runes)
+}
+
+// groupLogs 将一批日志按指纹归入问题，并回写 IssueID
+func groupLogs(tx *gorm.DB, batch []models.GameLog) error {
+	deltas := make(map[string]*issueDelta)
+	for i := range batch {
+		entry := &batch[i]
+		// 已聚合过的日志（如落盘后回放）不重复统计
+		if entry.IssueID != 0 || !isGroupableLog(entry.LogType) {
+			continue
+		}
+
+		stack := normalizeStack(entry.LogStack)
+		fp := issueFingerprint(entry, stack)
+		delta, ok := deltas[fp]
+		if !ok {
+			delta = &issueDelta{
+				sample:   entry,
+				stack:    stack,
+				first:    entry.CreateAt,
+				devices:  make(map[string]int64),
+				roles:    make(map[string]int64),
+				versions: make(map[string]int64),
+			}
+			deltas[fp] = delta
+		}
+
+		delta.indexes = append(delta.indexes, i)
+		delta.first = min(delta.first, entry.CreateAt)
+		delta.last = max(delta.last, entry.CreateAt)
+		// 设备、角色和版本来自客户端，长度不受限制，超长时截断以免整批写入失败后反复重试
+		if entry.Device != "" {
+			delta.devices[truncate(entry.Device, issueTagValueSize)]++
+		}
+		if entry.RoleName != "" {
+			delta.roles[truncate(entry.RoleName, issueTagValueSize)]++
+		}
+		if entry.AppVersion != "" {
+			version := truncate(entry.AppVersion, issueVersionSize)
+			delta.versions[version]++
+			if delta.minVer == "" || compareVersions(version, delta.minVer) < 0 {
+				delta.minVer = version
+			}
+			if delta.maxVer == "" || compareVersions(version, delta.maxVer) > 0 {
+				delta.maxVer = version
+			}
+		}
+	}
+
+	for fp, delta := range deltas {
+		issueID, err := applyIssueDelta(tx, fp, delta)
+		if err != nil {
+			return fmt.Errorf("聚合异常失败: %w", err)
+		}
+		for _, i := range delta.indexes {
+			batch[i].IssueID = issueID
+		}
+	}
+	return nil
+}
+
+// applyIssueDelta 更新问题的统计数据，返回问题ID
+func applyIssueDelta(tx *gorm.DB, fingerprint string, delta *issueDelta) (uint, error) {
+	var issue models.GameLogIssue
+	err := tx.Where("fingerprint = ?", fingerprint).First(&issue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		issue = models.GameLogIssue{
+			Fingerprint:  fingerprint,
+			AppID:        delta.sample.AppID,
+			LogType:      delta.sample.LogType,
+			Title:        issueTitle(delta.sample.LogMessage),
+			Stack:        delta.stack,
+			Status:       models.GameLogIssueOpen,
+			FirstVersion: delta.minVer,
+			FirstSeen:    delta.first,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&issue)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 其他协程已经创建了同一指纹的问题
+			if err := tx.Where("fingerprint = ?", fingerprint).First(&issue).Error; err != nil {
+				return 0, err
+			}
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	updates := map[string]interface{}{
+		"count":     gorm.Expr("count + ?", len(delta.indexes)),
+		"last_seen": gorm.Expr("CASE WHEN last_seen > ? THEN last_seen ELSE ? END", delta.last, delta.last),
+	}
+	if delta.maxVer != "" && (issue.LastVersion == "" || compareVersions(delta.maxVer, issue.LastVersion) > 0) {
+		updates["last_version"] = delta.maxVer
+	}
+	if issue.Status == models.GameLogIssueClosed && isRegression(&issue, delta) {
+		updates["status"] = models.GameLogIssueRegressed
+		updates["regressed_at"] = delta.last
+	}
+
+	if err := tx.Model(&models.GameLogIssue{}).Where("id = ?", issue.ID).Updates(updates).Error; err != nil {
+		return 0, err
+	}
+
+	if err := upsertIssueTags(tx, issue.ID, models.GameLogIssueTagDevice, delta.devices, delta.last); err != nil {
+		return 0, err
+	}
+	if err := upsertIssueTags(tx, issue.ID, models.GameLogIssueTagRole, delta.roles, delta.last); err != nil {
+		return 0, err
+	}
+	if err := upsertIssueTags(tx, issue.ID, models.GameLogIssueTagVersion, delta.versions, delta.last); err != nil {
+		return 0, err
+	}
+
+	// 重新统计受影响的设备和角色数量
+	err = tx.Model(&models.GameLogIssue{}).Where("id = ?", issue.ID).Updates(map[string]interface{}{
+		"device_count": tx.Model(&models.GameLogIssueTag{}).Select("COUNT(*)").Where("issue_id = ? AND kind = ?", issue.ID, models.GameLogIssueTagDevice),
+		"role_count":   tx.Model(&models.GameLogIssueTag{}).Select("COUNT(*)").Where("issue_id = ? AND kind = ?", issue.ID, models.GameLogIssueTagRole),
+	}).Error
+
+	return issue.ID, err
+}
+
+// isRegression 已关闭的问题再次出现，且不是来自修复版本之前的旧客户端
+func isRegression(issue *models.GameLogIssue, delta *issueDelta) bool {
+	if delta.last <= issue.ClosedAt {
+		return false
+	}
+	if issue.ClosedVersion == "" {
+		return true
+	}
+	for version := range delta.versions {
+		if compareVersions(version, issue.ClosedVersion) >= 0 {
+			return true
+		}
+	}
+	// 没有版本信息的上报无法判断，按回归处理
+	return len(delta.versions) == 0
+}
+
+// upsertIssueTags 累加问题的设备、角色或版本计数
+func upsertIssueTags(tx *gorm.DB, issueID uint, kind string, values map[string]int64, lastSeen int64) error {
+	for value, count := range values {
+		tag := models.GameLogIssueTag{
+			IssueID:  issueID,
+			Kind:     kind,
+			Value:    value,
+			Count:    count,
+			LastSeen: lastSeen,
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "issue_id"}, {Name: "kind"}, {Name: "value"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count":     gorm.Expr("game_log_issue_tags.count + ?", count),
+				"last_seen": lastSeen,
+			}),
+		}).Create(&tag).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// compareVersions 比较形如 1.2.10 的版本号
+func compareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
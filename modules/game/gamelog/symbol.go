@@ -0,0 +1,215 @@
+package gamelog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/symbolicate"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	symbolCache      = make(map[string]*symbolicate.Symbolicator) // app_id|app_version -> 符号还原器
+	symbolVersions   = make(map[string]uint64)                    // 缓存失效的次数，加载期间符号文件变化时不缓存加载结果
+	symbolCacheMutex sync.Mutex
+	symbolLoads      singleflight.Group // 同一版本同时只加载一次，不同版本并行加载
+
+	// 应用ID和版本号用作符号文件的目录名
+	reSymbolPathPart = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// validSymbolPathPart 检查应用ID或版本号能否安全地用作目录名
+func validSymbolPathPart(s string) bool {
+	return s != "." && s != ".." && reSymbolPathPart.MatchString(s)
+}
+
+func symbolCacheKey(appID, appVersion string) string {
+	return appID + "|" + appVersion
+}
+
+// invalidateSymbols 符号文件变化后清除缓存
+func invalidateSymbols(appID, appVersion string) {
+	key := symbolCacheKey(appID, appVersion)
+
+	symbolCacheMutex.Lock()
+	delete(symbolCache, key)
+	symbolVersions[key]++
+	symbolCacheMutex.Unlock()
+
+	symbolLoads.Forget(key)
+}
+
+// getSymbolicator 获取指定版本的符号还原器，没有符号文件时返回 nil
+// 查询数据库和解析符号文件不持有缓存锁，解析大文件时不影响其他版本的还原
+func getSymbolicator(appID, appVersion string) *symbolicate.Symbolicator {
+	key := symbolCacheKey(appID, appVersion)
+
+	symbolCacheMutex.Lock()
+	s, ok := symbolCache[key]
+	version := symbolVersions[key]
+	symbolCacheMutex.Unlock()
+	if ok {
+		return s
+	}
+
+	v, _, _ := symbolLoads.Do(key, func() (any, error) {
+		s, err := loadSymbolicator(appID, appVersion)
+		if err != nil {
+			return (*symbolicate.Symbolicator)(nil), err
+		}
+
+		// 没有符号文件的版本也缓存下来，避免每次查询数据库
+		symbolCacheMutex.Lock()
+		if symbolVersions[key] == version {
+			symbolCache[key] = s
+		}
+		symbolCacheMutex.Unlock()
+		return s, nil
+	})
+	return v.(*symbolicate.Symbolicator)
+}
+
+// loadSymbolicator 加载指定版本的所有符号文件，没有符号文件时返回 nil
+func loadSymbolicator(appID, appVersion string) (*symbolicate.Symbolicator, error) {
+	var files []models.GameLogSymbol
+	if err := app.DB.Where("app_id = ? AND app_version = ?", appID, appVersion).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	s := symbolicate.New()
+	for _, file := range files {
+		if err := loadSymbolFile(s, &file); err != nil {
+			log.Printf("[游戏日志模块]加载符号文件失败 %s: %v", file.Filename, err)
+		}
+	}
+	return s, nil
+}
+
+// loadSymbolFile 解析符号文件并加入还原器
+func loadSymbolFile(s *symbolicate.Symbolicator, file *models.GameLogSymbol) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch file.Kind {
+	case models.SymbolKindIl2CppMapping:
+		mapping, err := symbolicate.LoadIl2CppMapping(f)
+		if err != nil {
+			return err
+		}
+		s.AddLineMapping(mapping)
+	case models.SymbolKindELF:
+		table, err := symbolicate.LoadELF(f)
+		if err != nil {
+			return err
+		}
+		s.AddModule(file.Module, table)
+	case models.SymbolKindTextMap:
+		table, err := symbolicate.LoadTextMap(f)
+		if err != nil {
+			return err
+		}
+		if table.BuildID == "" {
+			table.BuildID = file.BuildID
+		}
+		s.AddModule(file.Module, table)
+	default:
+		return fmt.Errorf("未知的符号文件类型：%s", file.Kind)
+	}
+
+	return nil
+}
+
+// symbolicateLog 还原日志堆栈，没有对应符号文件时保持不变
+func symbolicateLog(entry *models.GameLog) bool {
+	if entry.LogStack == "" || entry.AppVersion == "" {
+		return false
+	}
+
+	s := getSymbolicator(entry.AppID, entry.AppVersion)
+	if s == nil {
+		return false
+	}
+
+	stack := s.Symbolicate(entry.LogStack)
+	if stack == entry.LogStack {
+		return false
+	}
+
+	entry.LogStack = stack
+	return true
+}
+
+// symbolicateBatch 入库前还原一批日志的堆栈
+func symbolicateBatch(batch []models.GameLog) {
+	if !ingestConf.SymbolicateOnIngest {
+		return
+	}
+	for i := range batch {
+		if isGroupableLog(batch[i].LogType) {
+			symbolicateLog(&batch[i])
+		}
+	}
+}
+
+// saveSymbolFile 保存上传的符号文件并校验能否解析
+func saveSymbolFile(symbol *models.GameLogSymbol, src io.Reader) error {
+	if !validSymbolPathPart(symbol.AppID) || !validSymbolPathPart(symbol.AppVersion) {
+		return errors.New("无效的应用ID或版本号")
+	}
+	root := filepath.Clean(ingestConf.SymbolDir)
+	dir := filepath.Join(root, symbol.AppID, symbol.AppVersion)
+	if !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		return errors.New("无效的应用ID或版本号")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	symbol.Path = filepath.Join(dir, fmt.Sprintf("%s-%d-%s", symbol.Kind, time.Now().UnixNano(), filepath.Base(symbol.Filename)))
+	dst, err := os.Create(symbol.Path)
+	if err != nil {
+		return err
+	}
+
+	size, err := io.Copy(dst, src)
+	dst.Close()
+	if err != nil {
+		os.Remove(symbol.Path)
+		return err
+	}
+	symbol.Size = size
+
+	// 先解析一遍，确保文件格式正确
+	probe := symbolicate.New()
+	if err := loadSymbolFile(probe, symbol); err != nil {
+		os.Remove(symbol.Path)
+		return errors.Join(errors.New("符号文件解析失败"), err)
+	}
+
+	// ELF 文件自带构建ID
+	if symbol.Kind == models.SymbolKindELF && symbol.BuildID == "" {
+		if f, err := os.Open(symbol.Path); err == nil {
+			if table, err := symbolicate.LoadELF(f); err == nil {
+				symbol.BuildID = table.BuildID
+			}
+			f.Close()
+		}
+	}
+
+	return nil
+}
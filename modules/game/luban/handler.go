@@ -132,6 +132,7 @@ func updateProjectHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	before := *project
 	if err := app.DB.Model(project).Updates(updates).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": fmt.Sprintf("更新项目失败: %v", err),
@@ -139,7 +140,8 @@ func updateProjectHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "luban_project", project.ID, fmt.Sprintf("更新项目：%s", project.Name))
+	app.DB.First(project, project.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "luban_project", ResourceID: project.ID, Details: fmt.Sprintf("更新项目：%s", project.Name), Before: before, After: project})
 
 	return c.JSON(project)
 }
@@ -281,6 +283,7 @@ func updateTableHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	before := *table
 	if err := app.DB.Model(table).Updates(updates).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": fmt.Sprintf("更新配置表失败: %v", err),
@@ -288,7 +291,8 @@ func updateTableHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "luban_table", table.ID, fmt.Sprintf("更新配置表：%s", table.Name))
+	app.DB.First(table, table.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "luban_table", ResourceID: table.ID, Details: fmt.Sprintf("更新配置表：%s", table.Name), Before: before, After: table})
 
 	return c.JSON(table)
 }
@@ -1,8 +1,10 @@
 package patch
 
 import (
+	"fmt"
 	"strconv"
 
+	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/andycai/goapi/pkg/utility/path"
 	"github.com/gofiber/fiber/v2"
 )
@@ -117,6 +119,9 @@ func applyPatchHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// 记录操作日志
+	adminlog.WriteLog(c, "apply", "patch", req.RecordID, fmt.Sprintf("应用补丁包：%d", req.RecordID))
+
 	return c.JSON(fiber.Map{
 		"message": "补丁包应用成功",
 	})
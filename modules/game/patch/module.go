@@ -52,7 +52,7 @@ func (m *patchModule) AddAuthRouters() error {
 	app.RouterAdminApi.Get("/patch/config", app.HasPermission("patch:config"), getConfigHandler)
 	app.RouterAdminApi.Post("/patch/generate", app.HasPermission("patch:generate"), generatePatchHandler)
 	app.RouterAdminApi.Get("/patch/records", app.HasPermission("patch:view"), listPatchRecordsHandler)
-	app.RouterAdminApi.Post("/patch/apply", app.HasPermission("patch:apply"), applyPatchHandler)
+	app.RouterAdminApi.Post("/patch/apply", app.HasPermission("patch:apply"), app.RequireApproval("patch:apply", "应用补丁包", "patch:apply"), applyPatchHandler)
 
 	return nil
 }
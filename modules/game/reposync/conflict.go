@@ -0,0 +1,275 @@
+package reposync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm/clause"
+)
+
+// 文件同步操作类型
+const (
+	planAdd       = "add"       // 目标仓库新增文件
+	planModify    = "modify"    // 目标仓库修改文件
+	planDelete    = "delete"    // 目标仓库删除文件或目录
+	planMkdir     = "mkdir"     // 目标仓库创建目录
+	planUnchanged = "unchanged" // 目标仓库内容已一致
+	planSkip      = "skip"      // 无需处理，例如源文件已被后续提交删除
+)
+
+// errSyncConflict 目标仓库存在冲突且未指定强制同步
+var errSyncConflict = errors.New("目标仓库存在冲突，请确认后强制同步")
+
+// fileState 目标仓库文件在同步前的状态
+type fileState struct {
+	exists   bool   // 文件是否存在
+	isDir    bool   // 是否为目录
+	hash     string // 当前内容哈希
+	lastHash string // 最后一次同步写入的哈希，为空表示没有同步过
+}
+
+// planner 生成同步计划，预览多个提交时在内存中记录前面提交计划后的文件状态
+type planner struct {
+	profile *SyncProfile
+	overlay map[string]fileState // 目标仓库URL + 路径 -> 计划执行后的状态，仅预览时使用
+}
+
+// newPlanner 创建同步计划生成器
+func newPlanner(profile *SyncProfile, dryRun bool) *planner {
+	p := &planner{profile: profile}
+	if dryRun {
+		p.overlay = make(map[string]fileState)
+	}
+	return p
+}
+
+// plan 生成一个目标仓库的文件计划
+func (p *planner) plan(target RepoInfo, changes []FileChange) (TargetReport, error) {
+	report := TargetReport{Target: target.URL, Files: []FilePlan{}}
+
+	type mapped struct {
+		change FileChange
+		rel    string
+	}
+	var items []mapped
+	var paths []string
+	for _, change := range changes {
+		rel, ok := p.profile.mapPath(change.Path)
+		if !ok {
+			continue
+		}
+		items = append(items, mapped{change, rel})
+		paths = append(paths, rel)
+	}
+
+	lastHashes, err := loadSyncedHashes(p.profile.Name, target.URL, paths)
+	if err != nil {
+		return report, err
+	}
+
+	for _, item := range items {
+		sourcePath := filepath.Join(p.profile.Source.LocalPath, filepath.FromSlash(item.change.Path))
+		targetPath := filepath.Join(target.LocalPath, filepath.FromSlash(item.rel))
+		if !withinDir(p.profile.Source.LocalPath, sourcePath) || !withinDir(target.LocalPath, targetPath) {
+			return report, fmt.Errorf("无效的文件路径: %s", item.change.Path)
+		}
+
+		state, err := p.state(target, item.rel, targetPath, lastHashes)
+		if err != nil {
+			return report, err
+		}
+
+		plan := FilePlan{Path: item.change.Path, TargetPath: item.rel}
+		var sourceHash string
+		switch item.change.ChangeType {
+		case "A", "M":
+			info, err := os.Stat(sourcePath)
+			switch {
+			case os.IsNotExist(err):
+				// 源仓库已更新到最新版本，文件可能已被后续提交删除
+				plan.Action = planSkip
+			case err != nil:
+				return report, fmt.Errorf("获取源文件信息失败: %v", err)
+			case info.IsDir():
+				plan.Action = planMkdir
+				if state.exists && !state.isDir {
+					plan.Conflict = "目标仓库存在同名文件"
+				}
+			default:
+				if sourceHash, err = hashFile(sourcePath); err != nil {
+					return report, err
+				}
+				plan.Action, plan.Conflict = planWrite(item.change.ChangeType, state, sourceHash)
+			}
+		case "D":
+			plan.Action, plan.Conflict = planRemove(state)
+		default:
+			plan.Action = planSkip
+		}
+
+		if plan.Conflict != "" {
+			report.Conflicts++
+		}
+		report.Files = append(report.Files, plan)
+		p.record(target, item.rel, plan.Action, sourceHash)
+	}
+
+	return report, nil
+}
+
+// planWrite 新增或修改文件时判断目标仓库是否被修改过
+func planWrite(changeType string, state fileState, sourceHash string) (string, string) {
+	switch {
+	case state.isDir:
+		return planModify, "目标仓库存在同名目录"
+	case !state.exists && state.lastHash != "":
+		return planAdd, "目标文件已在目标仓库中被删除"
+	case !state.exists:
+		return planAdd, ""
+	case state.hash == sourceHash:
+		return planUnchanged, ""
+	case state.lastHash != "" && state.hash != state.lastHash:
+		return planModify, "目标文件在上次同步后被修改"
+	case state.lastHash == "" && changeType == "A":
+		// 没有同步记录时无法判断修改来源，只有新增文件覆盖已有文件才视为冲突
+		return planModify, "目标仓库已存在内容不同的同名文件"
+	default:
+		return planModify, ""
+	}
+}
+
+// planRemove 删除文件时判断目标仓库是否被修改过
+func planRemove(state fileState) (string, string) {
+	switch {
+	case !state.exists:
+		return planSkip, ""
+	case state.isDir:
+		return planDelete, ""
+	case state.lastHash != "" && state.hash != state.lastHash:
+		return planDelete, "目标文件在上次同步后被修改"
+	default:
+		return planDelete, ""
+	}
+}
+
+// state 获取目标文件的当前状态，预览时优先使用前面提交计划后的状态
+func (p *planner) state(target RepoInfo, rel, targetPath string, lastHashes map[string]string) (fileState, error) {
+	if p.overlay != nil {
+		if state, ok := p.overlay[target.URL+"|"+rel]; ok {
+			return state, nil
+		}
+	}
+
+	state := fileState{lastHash: lastHashes[rel]}
+	info, err := os.Stat(targetPath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	state.exists = true
+	if info.IsDir() {
+		state.isDir = true
+		return state, nil
+	}
+
+	state.hash, err = hashFile(targetPath)
+	return state, err
+}
+
+// record 预览时记录计划执行后的文件状态
+func (p *planner) record(target RepoInfo, rel, action, sourceHash string) {
+	if p.overlay == nil {
+		return
+	}
+
+	key := target.URL + "|" + rel
+	switch action {
+	case planAdd, planModify, planUnchanged:
+		p.overlay[key] = fileState{exists: true, hash: sourceHash, lastHash: sourceHash}
+	case planMkdir:
+		p.overlay[key] = fileState{exists: true, isDir: true}
+	case planDelete:
+		p.overlay[key] = fileState{}
+	}
+}
+
+// hashFile 计算文件内容的 SHA-256
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadSyncedHashes 获取目标仓库中文件最后一次同步的哈希
+func loadSyncedHashes(profile, target string, paths []string) (map[string]string, error) {
+	hashes := make(map[string]string, len(paths))
+	for start := 0; start < len(paths); start += 500 {
+		end := min(start+500, len(paths))
+
+		var files []models.RepoSyncFile
+		if err := app.DB.Where("profile = ? AND target = ? AND path IN ?", profile, target, paths[start:end]).
+			Find(&files).Error; err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			hashes[file.Path] = file.Hash
+		}
+	}
+	return hashes, nil
+}
+
+// saveSyncedHashes 同步成功后记录写入目标仓库的文件哈希
+func saveSyncedHashes(profile *SyncProfile, target RepoInfo, plans []FilePlan, revision string) error {
+	for _, plan := range plans {
+		switch plan.Action {
+		case planAdd, planModify, planUnchanged:
+			sourcePath := filepath.Join(profile.Source.LocalPath, filepath.FromSlash(plan.Path))
+			hash, err := hashFile(sourcePath)
+			if err != nil {
+				return err
+			}
+			if err := app.DB.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "profile"}, {Name: "target"}, {Name: "path"}},
+				DoUpdates: clause.AssignmentColumns([]string{"hash", "revision", "updated_at"}),
+			}).Create(&models.RepoSyncFile{
+				Profile:  profile.Name,
+				Target:   target.URL,
+				Path:     plan.TargetPath,
+				Hash:     hash,
+				Revision: revision,
+			}).Error; err != nil {
+				return err
+			}
+		case planDelete:
+			// 删除目录时同时删除目录下的文件记录
+			if err := app.DB.Where("profile = ? AND target = ? AND (path = ? OR path LIKE ?)",
+				profile.Name, target.URL, plan.TargetPath, plan.TargetPath+"/%").
+				Delete(&models.RepoSyncFile{}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// clearSyncedHashes 删除方案的文件哈希记录
+func clearSyncedHashes(profile string) error {
+	return app.DB.Where("profile = ?", profile).Delete(&models.RepoSyncFile{}).Error
+}
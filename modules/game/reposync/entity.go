@@ -2,19 +2,50 @@ package reposync
 
 import "time"
 
-// RepoConfig 仓库配置
+// RepoConfig 仓库同步配置
 type RepoConfig struct {
-	RepoType1  string `json:"repo_type1"`  // 第一个仓库类型 (svn/git)
-	RepoURL1   string `json:"repo_url1"`   // 第一个仓库URL
-	LocalPath1 string `json:"local_path1"` // 第一个仓库本地路径
-	Username1  string `json:"username1"`   // 第一个仓库用户名
-	Password1  string `json:"password1"`   // 第一个仓库密码
-	RepoType2  string `json:"repo_type2"`  // 第二个仓库类型 (svn/git)
-	RepoURL2   string `json:"repo_url2"`   // 第二个仓库URL
-	LocalPath2 string `json:"local_path2"` // 第二个仓库本地路径
-	Username2  string `json:"username2"`   // 第二个仓库用户名
-	Password2  string `json:"password2"`   // 第二个仓库密码
-	ConfigPath string `json:"config_path"` // 配置文件路径
+	Profiles   []SyncProfile `json:"profiles"`    // 同步方案列表
+	ConfigPath string        `json:"config_path"` // 配置文件路径
+}
+
+// legacyRepoConfig 旧版本只支持一对仓库的配置格式，加载时转换为默认方案
+type legacyRepoConfig struct {
+	RepoType1  string `json:"repo_type1"`
+	RepoURL1   string `json:"repo_url1"`
+	LocalPath1 string `json:"local_path1"`
+	Username1  string `json:"username1"`
+	Password1  string `json:"password1"`
+	RepoType2  string `json:"repo_type2"`
+	RepoURL2   string `json:"repo_url2"`
+	LocalPath2 string `json:"local_path2"`
+	Username2  string `json:"username2"`
+	Password2  string `json:"password2"`
+}
+
+// SyncProfile 同步方案，将一个源仓库同步到一个或多个目标仓库
+type SyncProfile struct {
+	Name     string        `json:"name"`     // 方案名称，唯一
+	Title    string        `json:"title"`    // 显示名称
+	Source   RepoInfo      `json:"source"`   // 源仓库
+	Targets  []RepoInfo    `json:"targets"`  // 目标仓库
+	Mappings []PathMapping `json:"mappings"` // 路径映射，为空时同步整个仓库
+}
+
+// RepoInfo 仓库信息
+type RepoInfo struct {
+	Type      string `json:"type"`       // 仓库类型 (svn/git)
+	URL       string `json:"url"`        // 仓库URL
+	LocalPath string `json:"local_path"` // 本地路径
+	Username  string `json:"username"`   // 用户名
+	Password  string `json:"password"`   // 密码
+}
+
+// PathMapping 路径映射，将源仓库的子目录同步到目标仓库的子目录
+type PathMapping struct {
+	Source  string   `json:"source"`  // 源仓库子目录，空表示根目录
+	Target  string   `json:"target"`  // 目标仓库子目录，空表示根目录
+	Include []string `json:"include"` // 包含的文件模式，空表示全部
+	Exclude []string `json:"exclude"` // 排除的文件模式
 }
 
 // CommitRecord 提交记录
@@ -33,3 +64,38 @@ type FileChange struct {
 	Path       string `json:"path"`        // 文件路径
 	ChangeType string `json:"change_type"` // 变更类型 (A:新增, M:修改, D:删除)
 }
+
+// SyncOptions 同步选项
+type SyncOptions struct {
+	DryRun bool `json:"dry_run"` // 只生成同步计划，不修改目标仓库
+	Force  bool `json:"force"`   // 存在冲突时强制覆盖目标仓库的修改
+}
+
+// FilePlan 单个文件的同步计划
+type FilePlan struct {
+	Path       string `json:"path"`               // 源仓库中的路径
+	TargetPath string `json:"target_path"`        // 目标仓库中的路径
+	Action     string `json:"action"`             // 操作类型 (add/modify/delete/mkdir/unchanged/skip)
+	Conflict   string `json:"conflict,omitempty"` // 冲突原因，为空表示没有冲突
+}
+
+// TargetReport 单个目标仓库的同步计划
+type TargetReport struct {
+	Target    string     `json:"target"`    // 目标仓库URL
+	Files     []FilePlan `json:"files"`     // 文件计划
+	Conflicts int        `json:"conflicts"` // 冲突文件数
+}
+
+// CommitReport 单个提交的同步计划
+type CommitReport struct {
+	Revision string         `json:"revision"` // 版本号
+	Comment  string         `json:"comment"`  // 提交内容
+	Targets  []TargetReport `json:"targets"`  // 各目标仓库的计划
+}
+
+// SyncReport 同步报告
+type SyncReport struct {
+	DryRun    bool           `json:"dry_run"`   // 是否为预览
+	Commits   []CommitReport `json:"commits"`   // 各提交的计划
+	Conflicts int            `json:"conflicts"` // 冲突文件总数
+}
@@ -1,15 +1,16 @@
 package reposync
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
-	"github.com/andycai/goapi/models"
-	"github.com/andycai/goapi/pkg/utility/path"
+	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/gofiber/fiber/v2"
 )
 
-// saveConfigHandler 保存仓库配置
+// saveConfigHandler 保存全部同步方案
 func saveConfigHandler(c *fiber.Ctx) error {
 	config := new(RepoConfig)
 	if err := c.BodyParser(config); err != nil {
@@ -18,18 +19,13 @@ func saveConfigHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// 验证路径安全性
-	if !path.IsValid(config.LocalPath1) || !path.IsValid(config.LocalPath2) {
+	if err := updateConfig(config); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "无效的本地路径",
+			"error": "保存配置失败: " + err.Error(),
 		})
 	}
 
-	if err := updateConfig(config); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "保存配置失败",
-		})
-	}
+	adminlog.WriteLog(c, "update", "reposync", 0, fmt.Sprintf("保存仓库同步配置，共%d个方案", len(config.Profiles)))
 
 	return c.JSON(fiber.Map{
 		"message": "配置保存成功",
@@ -42,9 +38,72 @@ func getConfigHandler(c *fiber.Ctx) error {
 	return c.JSON(config)
 }
 
-// checkoutHandler 检出仓库
+// listProfilesHandler 获取同步方案列表
+func listProfilesHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"profiles": maskProfiles(listProfiles()),
+	})
+}
+
+// saveProfileHandler 新增或修改同步方案
+func saveProfileHandler(c *fiber.Ctx) error {
+	var profile SyncProfile
+	if err := c.BodyParser(&profile); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "无效的请求数据",
+		})
+	}
+
+	if err := saveProfile(profile); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "保存方案失败: " + err.Error(),
+		})
+	}
+
+	adminlog.WriteLog(c, "update", "reposync", 0, fmt.Sprintf("保存仓库同步方案：%s，目标仓库%d个", profile.Name, len(profile.Targets)))
+
+	return c.JSON(fiber.Map{
+		"message": "方案保存成功",
+	})
+}
+
+// deleteProfileHandler 删除同步方案及其同步记录
+func deleteProfileHandler(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := removeProfile(name); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := ClearSyncData(name); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := clearSyncedHashes(name); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	adminlog.WriteLog(c, "delete", "reposync", 0, fmt.Sprintf("删除仓库同步方案：%s", name))
+
+	return c.JSON(fiber.Map{
+		"message": "方案删除成功",
+	})
+}
+
+// checkoutHandler 检出方案的所有仓库
 func checkoutHandler(c *fiber.Ctx) error {
-	if err := checkoutRepos(); err != nil {
+	profile, err := getProfile(c.Query("profile"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkoutRepos(profile); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "检出仓库失败: " + err.Error(),
 		})
@@ -68,23 +127,21 @@ func listCommitsHandler(c *fiber.Ctx) error {
 		pageSize = 10
 	}
 
+	profile, err := getProfile(c.Query("profile"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// 获取提交记录
-	commits, total, err := getCommits(pageSize, page)
+	commits, total, err := getCommits(profile, pageSize, page)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("获取提交记录失败: %v", err),
 		})
 	}
 
-	// 添加受影响的问题列表
-	for i := range commits {
-		// 从数据库获取问题列表
-		var record models.RepoSyncRecord
-		if app.DB.Where("revision = ?", commits[i].Revision).First(&record).Error == nil {
-			commits[i].AffectedIssues = record.AffectedIssues
-		}
-	}
-
 	// 返回响应
 	return c.JSON(fiber.Map{
 		"commits": commits,
@@ -92,10 +149,12 @@ func listCommitsHandler(c *fiber.Ctx) error {
 	})
 }
 
-// syncCommitsHandler 同步提交记录
+// syncCommitsHandler 同步提交记录，dry_run 时只返回同步计划，存在冲突时需要 force 才会同步
 func syncCommitsHandler(c *fiber.Ctx) error {
 	type SyncRequest struct {
+		Profile   string   `json:"profile"`
 		Revisions []string `json:"revisions"`
+		SyncOptions
 	}
 
 	var req SyncRequest
@@ -111,22 +170,56 @@ func syncCommitsHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := SyncCommits(req.Revisions); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "同步提交失败: " + err.Error(),
+	profile, err := getProfile(req.Profile)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := SyncCommits(profile, req.Revisions, req.SyncOptions)
+	if err != nil {
+		return syncErrorResponse(c, report, err)
+	}
+
+	if req.DryRun {
+		return c.JSON(fiber.Map{
+			"message": "预览成功",
+			"report":  report,
 		})
 	}
 
+	adminlog.WriteLog(c, "sync", "reposync", 0, fmt.Sprintf("同步仓库方案：%s，版本：%s，强制：%v",
+		profile.Name, strings.Join(req.Revisions, ","), req.Force))
+
 	return c.JSON(fiber.Map{
 		"message": "同步成功",
+		"report":  report,
+	})
+}
+
+// syncErrorResponse 同步失败的响应，冲突时返回 409 和冲突报告
+func syncErrorResponse(c *fiber.Ctx, report *SyncReport, err error) error {
+	if errors.Is(err, errSyncConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  err.Error(),
+			"report": report,
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":  "同步提交失败: " + err.Error(),
+		"report": report,
 	})
 }
 
 // syncPublicCommitsHandler 公开的同步API，同步两个版本间的差异
 func syncPublicCommitsHandler(c *fiber.Ctx) error {
 	var req struct {
+		Profile      string `json:"profile"`
 		FromRevision string `json:"fromRevision"`
 		ToRevision   string `json:"toRevision"`
+		SyncOptions
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -141,77 +234,109 @@ func syncPublicCommitsHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// 检查版本号大小关系
-	// 尝试将版本号转换为数字进行比较
+	// 检查版本号大小关系，git 的提交哈希无法比较大小，由仓库判断
 	fromNum, fromErr := strconv.Atoi(req.FromRevision)
 	toNum, toErr := strconv.Atoi(req.ToRevision)
-
-	if fromErr == nil && toErr == nil {
-		// 如果都是数字，直接比较数值
-		if fromNum >= toNum {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "fromRevision 必须小于 toRevision",
-			})
-		}
-	} else {
-		// 如果不是数字，尝试按字符串比较
-		// 对于某些版本号形式，字符串比较可能不准确
-		if req.FromRevision >= req.ToRevision {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "fromRevision 必须小于 toRevision",
-			})
-		}
+	if fromErr == nil && toErr == nil && fromNum >= toNum {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "fromRevision 必须小于 toRevision",
+		})
 	}
 
-	changeCount, err := SyncChangesBetweenRevisions(req.FromRevision, req.ToRevision)
+	profile, err := getProfile(req.Profile)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	changeCount, report, err := SyncChangesBetweenRevisions(profile, req.FromRevision, req.ToRevision, req.SyncOptions)
+	if err != nil {
+		return syncErrorResponse(c, report, err)
+	}
+
 	return c.JSON(fiber.Map{
 		"message": "同步成功",
 		"changes": changeCount,
+		"report":  report,
 	})
 }
 
-// syncPublicAutoHandler 自动同步未同步的提交记录
+// syncPublicAutoHandler 自动同步未同步的提交记录，未指定方案时依次同步所有方案
 func syncPublicAutoHandler(c *fiber.Ctx) error {
-	fromRev, toRev, err := FindUnsyncedRevisionRange()
-	if err != nil {
+	var profiles []SyncProfile
+	if name := c.Query("profile"); name != "" {
+		profile, err := getProfile(name)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		profiles = append(profiles, *profile)
+	} else {
+		profiles = listProfiles()
+	}
+
+	type autoSyncResult struct {
+		Profile   string `json:"profile"`
+		Changes   int    `json:"changes"`
+		Conflicts int    `json:"conflicts,omitempty"`
+		From      string `json:"from,omitempty"`
+		To        string `json:"to,omitempty"`
+		Error     string `json:"error,omitempty"`
+	}
+
+	results := make([]autoSyncResult, 0, len(profiles))
+	total, failed := 0, 0
+	for i := range profiles {
+		result := autoSyncResult{Profile: profiles[i].Name}
+
+		fromRev, toRev, err := FindUnsyncedRevisionRange(&profiles[i])
+		if err == nil && toRev != "" {
+			result.From, result.To = fromRev, toRev
+			// 自动同步不强制覆盖，存在冲突时需要人工处理
+			var report *SyncReport
+			result.Changes, report, err = SyncChangesBetweenRevisions(&profiles[i], fromRev, toRev, SyncOptions{})
+			result.Conflicts = report.Conflicts
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+
+		total += result.Changes
+		results = append(results, result)
+	}
+
+	if failed > 0 {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
+			"error":   fmt.Sprintf("%d个方案同步失败", failed),
+			"changes": total,
+			"results": results,
 		})
 	}
 
 	// 如果没有找到未同步的提交，返回成功但无变更
-	if fromRev == "" || toRev == "" {
+	if total == 0 {
 		return c.JSON(fiber.Map{
 			"message": "没有需要同步的提交",
 			"changes": 0,
-		})
-	}
-
-	changeCount, err := SyncChangesBetweenRevisions(fromRev, toRev)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
+			"results": results,
 		})
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "同步成功",
-		"changes": changeCount,
-		"from":    fromRev,
-		"to":      toRev,
+		"changes": total,
+		"results": results,
 	})
 }
 
 // refreshCommitsHandler 刷新提交记录
 func refreshCommitsHandler(c *fiber.Ctx) error {
 	var req struct {
-		Limit int `json:"limit"`
+		Profile string `json:"profile"`
+		Limit   int    `json:"limit"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -226,7 +351,14 @@ func refreshCommitsHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := RefreshCommits(req.Limit); err != nil {
+	profile, err := getProfile(req.Profile)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := RefreshCommits(profile, req.Limit); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "刷新提交记录失败: " + err.Error(),
 		})
@@ -237,9 +369,16 @@ func refreshCommitsHandler(c *fiber.Ctx) error {
 	})
 }
 
-// clearSyncDataHandler 清空同步数据
+// clearSyncDataHandler 清空方案的同步数据
 func clearSyncDataHandler(c *fiber.Ctx) error {
-	if err := ClearSyncData(); err != nil {
+	profile, err := getProfile(c.Query("profile"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := ClearSyncData(profile.Name); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "清空数据失败: " + err.Error(),
 		})
@@ -15,6 +15,7 @@ import (
 func autoMigrate() error {
 	return app.DB.AutoMigrate(
 		&models.RepoSyncRecord{},
+		&models.RepoSyncFile{},
 	)
 }
 
@@ -57,6 +57,9 @@ func (m *reposyncModule) AddAuthRouters() error {
 	// API路由
 	app.RouterAdminApi.Post("/reposync/config", app.HasPermission("reposync:config"), saveConfigHandler)
 	app.RouterAdminApi.Get("/reposync/config", app.HasPermission("reposync:config"), getConfigHandler)
+	app.RouterAdminApi.Get("/reposync/profiles", app.HasPermission("reposync:view"), listProfilesHandler)
+	app.RouterAdminApi.Post("/reposync/profiles", app.HasPermission("reposync:config"), saveProfileHandler)
+	app.RouterAdminApi.Delete("/reposync/profiles/:name", app.HasPermission("reposync:config"), deleteProfileHandler)
 	app.RouterAdminApi.Post("/reposync/checkout", app.HasPermission("reposync:checkout"), checkoutHandler)
 	app.RouterAdminApi.Get("/reposync/commits", app.HasPermission("reposync:view"), listCommitsHandler)
 	app.RouterAdminApi.Post("/reposync/sync", app.HasPermission("reposync:sync"), syncCommitsHandler)
@@ -0,0 +1,444 @@
+package reposync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	upath "github.com/andycai/goapi/pkg/utility/path"
+	"github.com/andycai/goapi/pkg/vault"
+)
+
+// defaultProfileName 旧版本配置转换后的方案名称，也是历史同步记录的默认方案
+const defaultProfileName = "default"
+
+var (
+	config      *RepoConfig
+	configMutex sync.RWMutex
+
+	// 同一方案的同步操作串行执行，避免同时修改工作副本
+	profileLocks sync.Map
+
+	profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+)
+
+// loadConfig 加载配置，兼容旧版本的单对仓库格式
+func loadConfig() error {
+	data, err := os.ReadFile(config.ConfigPath)
+	if os.IsNotExist(err) {
+		// 配置文件不存在，使用默认配置
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+	if len(config.Profiles) > 0 {
+		// 旧配置中的明文密码转存到凭据库
+		changed, err := vaultPasswords(config.Profiles)
+		if err != nil {
+			log.Printf("[仓库同步模块]密码存入凭据库失败，继续使用明文密码: %v", err)
+			return nil
+		}
+		if changed {
+			return saveConfig(config)
+		}
+		return nil
+	}
+
+	var legacy legacyRepoConfig
+	if err := json.Unmarshal(data, &legacy); err != nil || legacy.RepoURL1 == "" {
+		return nil
+	}
+	config.Profiles = []SyncProfile{{
+		Name:  defaultProfileName,
+		Title: "默认方案",
+		Source: RepoInfo{
+			Type:      legacy.RepoType1,
+			URL:       legacy.RepoURL1,
+			LocalPath: legacy.LocalPath1,
+			Username:  legacy.Username1,
+			Password:  legacy.Password1,
+		},
+		Targets: []RepoInfo{{
+			Type:      legacy.RepoType2,
+			URL:       legacy.RepoURL2,
+			LocalPath: legacy.LocalPath2,
+			Username:  legacy.Username2,
+			Password:  legacy.Password2,
+		}},
+	}}
+
+	if _, err := vaultPasswords(config.Profiles); err != nil {
+		log.Printf("[仓库同步模块]密码存入凭据库失败，继续使用明文密码: %v", err)
+	}
+	return saveConfig(config)
+}
+
+// saveConfig 保存配置
+func saveConfig(conf *RepoConfig) error {
+	if conf == nil {
+		return errors.New("配置为空")
+	}
+
+	// 确保目录存在
+	if err := os.MkdirAll(filepath.Dir(conf.ConfigPath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(conf.ConfigPath, data, 0644)
+}
+
+// updateConfig 校验并替换全部同步方案
+func updateConfig(conf *RepoConfig) error {
+	if err := validateProfiles(conf.Profiles); err != nil {
+		return err
+	}
+	if _, err := vaultPasswords(conf.Profiles); err != nil {
+		return err
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	next := &RepoConfig{Profiles: conf.Profiles, ConfigPath: config.ConfigPath}
+	if err := saveConfig(next); err != nil {
+		return err
+	}
+	config = next
+
+	return nil
+}
+
+// getConfig 获取配置
+func getConfig() *RepoConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	return config
+}
+
+// getProfile 根据名称获取同步方案，只有一个方案时名称可以省略
+func getProfile(name string) (*SyncProfile, error) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if config == nil || len(config.Profiles) == 0 {
+		return nil, errors.New("没有配置同步方案")
+	}
+	if name == "" {
+		if len(config.Profiles) > 1 {
+			return nil, errors.New("请指定同步方案")
+		}
+		profile := config.Profiles[0]
+		return &profile, nil
+	}
+
+	for _, profile := range config.Profiles {
+		if profile.Name == name {
+			return &profile, nil
+		}
+	}
+	return nil, fmt.Errorf("同步方案不存在: %s", name)
+}
+
+// listProfiles 获取所有同步方案
+func listProfiles() []SyncProfile {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if config == nil {
+		return nil
+	}
+	return append([]SyncProfile(nil), config.Profiles...)
+}
+
+// maskProfiles 隐藏仓库密码，用于只有查看权限的接口
+func maskProfiles(profiles []SyncProfile) []SyncProfile {
+	masked := make([]SyncProfile, len(profiles))
+	for i, profile := range profiles {
+		profile.Source.Password = ""
+		profile.Targets = append([]RepoInfo(nil), profile.Targets...)
+		for j := range profile.Targets {
+			profile.Targets[j].Password = ""
+		}
+		masked[i] = profile
+	}
+	return masked
+}
+
+// keepPasswords 未填写密码时沿用同一仓库原有的密码
+func keepPasswords(profile *SyncProfile, old SyncProfile) {
+	passwords := make(map[string]string)
+	for _, repo := range append([]RepoInfo{old.Source}, old.Targets...) {
+		passwords[repo.URL+"|"+repo.Username] = repo.Password
+	}
+
+	fill := func(repo *RepoInfo) {
+		if repo.Password == "" {
+			repo.Password = passwords[repo.URL+"|"+repo.Username]
+		}
+	}
+	fill(&profile.Source)
+	for i := range profile.Targets {
+		fill(&profile.Targets[i])
+	}
+}
+
+// vaultPasswords 将明文密码存入凭据库，配置中只保存 secret:// 引用，changed 表示有密码被替换
+func vaultPasswords(profiles []SyncProfile) (changed bool, err error) {
+	store := func(profile string, repo *RepoInfo, suffix, title string) error {
+		if repo.Password == "" {
+			return nil
+		}
+		if _, ok := vault.ParseRef(repo.Password); ok {
+			return nil
+		}
+
+		name := fmt.Sprintf("reposync-%s-%s", profile, suffix)
+		description := fmt.Sprintf("仓库同步方案 %s 的%s密码（%s）", profile, title, repo.URL)
+		if _, err := app.Vault.Put(name, repo.Password, description, "reposync"); err != nil {
+			return fmt.Errorf("%s %s: %v", profile, title, err)
+		}
+		repo.Password = vault.Ref(name)
+		changed = true
+		return nil
+	}
+
+	for i := range profiles {
+		profile := &profiles[i]
+		if err := store(profile.Name, &profile.Source, "source", "源仓库"); err != nil {
+			return changed, err
+		}
+		for j := range profile.Targets {
+			title := fmt.Sprintf("目标仓库%d", j+1)
+			if err := store(profile.Name, &profile.Targets[j], fmt.Sprintf("target%d", j+1), title); err != nil {
+				return changed, err
+			}
+		}
+	}
+	return changed, nil
+}
+
+// saveProfile 新增或修改同步方案
+func saveProfile(profile SyncProfile) error {
+	configMutex.RLock()
+	profiles := append([]SyncProfile(nil), config.Profiles...)
+	configMutex.RUnlock()
+
+	found := false
+	for i := range profiles {
+		if profiles[i].Name == profile.Name {
+			keepPasswords(&profile, profiles[i])
+			profiles[i] = profile
+			found = true
+			break
+		}
+	}
+	if !found {
+		profiles = append(profiles, profile)
+	}
+
+	return updateConfig(&RepoConfig{Profiles: profiles})
+}
+
+// removeProfile 删除同步方案
+func removeProfile(name string) error {
+	configMutex.RLock()
+	profiles := make([]SyncProfile, 0, len(config.Profiles))
+	for _, profile := range config.Profiles {
+		if profile.Name != name {
+			profiles = append(profiles, profile)
+		}
+	}
+	found := len(profiles) != len(config.Profiles)
+	configMutex.RUnlock()
+
+	if !found {
+		return fmt.Errorf("同步方案不存在: %s", name)
+	}
+
+	return updateConfig(&RepoConfig{Profiles: profiles})
+}
+
+// validateProfiles 校验方案名称、仓库路径和路径映射
+func validateProfiles(profiles []SyncProfile) error {
+	names := make(map[string]bool)
+	paths := make(map[string]string)
+
+	usePath := func(owner, p string) error {
+		if p == "" || !upath.IsValid(p) {
+			return fmt.Errorf("%s: 无效的本地路径", owner)
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return fmt.Errorf("%s: 无效的本地路径", owner)
+		}
+		if other, ok := paths[abs]; ok {
+			return fmt.Errorf("%s: 本地路径与 %s 重复", owner, other)
+		}
+		paths[abs] = owner
+		return nil
+	}
+
+	for _, profile := range profiles {
+		if !profileNameRegex.MatchString(profile.Name) {
+			return fmt.Errorf("无效的方案名称: %s", profile.Name)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("方案名称重复: %s", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if err := validateRepo(profile.Source); err != nil {
+			return fmt.Errorf("%s 源仓库: %v", profile.Name, err)
+		}
+		if err := usePath(profile.Name+" 源仓库", profile.Source.LocalPath); err != nil {
+			return err
+		}
+
+		if len(profile.Targets) == 0 {
+			return fmt.Errorf("%s: 至少需要一个目标仓库", profile.Name)
+		}
+		for i, target := range profile.Targets {
+			owner := fmt.Sprintf("%s 目标仓库%d", profile.Name, i+1)
+			if err := validateRepo(target); err != nil {
+				return fmt.Errorf("%s: %v", owner, err)
+			}
+			if err := usePath(owner, target.LocalPath); err != nil {
+				return err
+			}
+		}
+
+		for _, mapping := range profile.Mappings {
+			if !validSubdir(mapping.Source) || !validSubdir(mapping.Target) {
+				return fmt.Errorf("%s: 无效的路径映射 %s -> %s", profile.Name, mapping.Source, mapping.Target)
+			}
+			for _, pattern := range append(append([]string(nil), mapping.Include...), mapping.Exclude...) {
+				if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+					return fmt.Errorf("%s: 无效的文件模式 %s", profile.Name, pattern)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRepo 校验仓库类型和地址
+func validateRepo(repo RepoInfo) error {
+	switch strings.ToLower(repo.Type) {
+	case "svn", "git":
+	default:
+		return fmt.Errorf("不支持的仓库类型: %s", repo.Type)
+	}
+	if repo.URL == "" {
+		return errors.New("仓库URL不能为空")
+	}
+	return nil
+}
+
+// validSubdir 子目录必须是仓库内的相对路径
+func validSubdir(dir string) bool {
+	if dir == "" {
+		return true
+	}
+	dir = filepath.ToSlash(dir)
+	if strings.HasPrefix(dir, "/") || filepath.IsAbs(dir) {
+		return false
+	}
+	for _, part := range strings.Split(dir, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// lockProfile 获取方案的同步锁
+func lockProfile(name string) func() {
+	value, _ := profileLocks.LoadOrStore(name, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// mapPath 根据路径映射计算源文件在目标仓库中的相对路径，按顺序使用第一个匹配的映射
+func (p *SyncProfile) mapPath(rel string) (string, bool) {
+	rel = strings.TrimPrefix(path.Clean(filepath.ToSlash(rel)), "./")
+	if len(p.Mappings) == 0 {
+		return rel, true
+	}
+
+	for _, mapping := range p.Mappings {
+		source := strings.Trim(path.Clean("/"+filepath.ToSlash(mapping.Source)), "/")
+
+		sub := rel
+		if source != "" {
+			if rel != source && !strings.HasPrefix(rel, source+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(strings.TrimPrefix(rel, source), "/")
+		}
+
+		// 映射的根目录本身始终同步
+		if sub != "" {
+			if len(mapping.Include) > 0 && !matchAny(mapping.Include, sub) {
+				continue
+			}
+			if matchAny(mapping.Exclude, sub) {
+				continue
+			}
+		}
+
+		target := strings.Trim(path.Clean("/"+filepath.ToSlash(mapping.Target)), "/")
+		switch {
+		case target == "":
+			return sub, sub != ""
+		case sub == "":
+			return target, true
+		default:
+			return target + "/" + sub, true
+		}
+	}
+
+	return "", false
+}
+
+// matchAny 判断路径是否匹配任一模式
+// 模式中不含 / 时匹配文件名，以 /** 结尾时匹配整个目录
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return true
+			}
+			continue
+		}
+
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
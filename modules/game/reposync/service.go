@@ -1,12 +1,12 @@
 package reposync
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -14,158 +14,88 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andycai/goapi/events"
 	"github.com/andycai/goapi/models"
-	"github.com/andycai/goapi/modules/interface/git"
-	"github.com/andycai/goapi/modules/interface/svn"
+	"github.com/andycai/goapi/pkg/event"
 	"github.com/andycai/goapi/pkg/utility/path"
+	"github.com/andycai/goapi/pkg/vcs"
 )
 
-var config *RepoConfig
+// 同步记录状态
+const (
+	syncStatusPending = 0 // 未同步
+	syncStatusSynced  = 1 // 同步成功
+	syncStatusFailed  = 2 // 同步失败
+)
 
 // initService 初始化服务
 func initService() {
 	config = &RepoConfig{ConfigPath: "./data/reposync_config.json"}
 
 	// 尝试加载配置
-	loadConfig()
-}
-
-// saveConfig 保存配置
-func saveConfig() error {
-	if config == nil {
-		return errors.New("配置为空")
-	}
-
-	// 确保目录存在
-	dir := filepath.Dir(config.ConfigPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	// 序列化配置
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// 记录操作日志
-	// adminlog.WriteLog(c, "write", "config", permission.ID, fmt.Sprintf("创建权限：%s", permission.Name))
-
-	// 写入文件
-	return os.WriteFile(config.ConfigPath, data, 0644)
-}
-
-// loadConfig 加载配置
-func loadConfig() error {
-	// 检查文件是否存在
-	if _, err := os.Stat(config.ConfigPath); os.IsNotExist(err) {
-		// 配置文件不存在，使用默认配置
-		return nil
-	}
-
-	// 读取文件
-	data, err := os.ReadFile(config.ConfigPath)
-	if err != nil {
-		return err
+	if err := loadConfig(); err != nil {
+		log.Printf("[仓库同步模块]加载配置失败: %v", err)
 	}
-
-	// 反序列化配置
-	return json.Unmarshal(data, config)
 }
 
-// updateConfig 更新配置
-func updateConfig(conf *RepoConfig) error {
-	// 验证路径
-	if !path.IsValid(config.LocalPath1) || !path.IsValid(config.LocalPath2) {
-		return errors.New("无效的本地路径")
-	}
-
-	// 更新配置
-	config.ConfigPath = conf.ConfigPath
-	config = conf
-
-	// 保存配置
-	return saveConfig()
-}
+// checkoutRepos 检出方案中的源仓库和所有目标仓库
+func checkoutRepos(profile *SyncProfile) error {
+	defer lockProfile(profile.Name)()
 
-// getConfig 获取配置
-func getConfig() *RepoConfig {
-	return config
-}
-
-// checkoutRepos 初始化检出仓库
-func checkoutRepos() error {
-	if config == nil {
-		return errors.New("配置为空")
-	}
-
-	// 检出第一个仓库
-	err := checkoutRepo(
-		config.RepoType1,
-		config.RepoURL1,
-		config.LocalPath1,
-		config.Username1,
-		config.Password1,
-	)
-	if err != nil {
-		return fmt.Errorf("检出第一个仓库失败: %v", err)
+	if err := checkoutRepo(profile.Source); err != nil {
+		return fmt.Errorf("检出源仓库失败: %v", err)
 	}
 
-	// 检出第二个仓库
-	err = checkoutRepo(
-		config.RepoType2,
-		config.RepoURL2,
-		config.LocalPath2,
-		config.Username2,
-		config.Password2,
-	)
-	if err != nil {
-		return fmt.Errorf("检出第二个仓库失败: %v", err)
+	for i, target := range profile.Targets {
+		if err := checkoutRepo(target); err != nil {
+			return fmt.Errorf("检出目标仓库%d失败: %v", i+1, err)
+		}
 	}
 
 	return nil
 }
 
 // checkoutRepo 检出单个仓库
-func checkoutRepo(repoType, url, repopath, username, password string) error {
-	if !path.IsValid(repopath) {
+func checkoutRepo(repo RepoInfo) error {
+	if !path.IsValid(repo.LocalPath) {
 		return errors.New("无效的本地路径")
 	}
 
+	// 已经检出的仓库跳过，方案新增目标仓库时只需检出新的仓库
+	for _, dir := range []string{".git", ".svn"} {
+		if info, err := os.Stat(filepath.Join(repo.LocalPath, dir)); err == nil && info.IsDir() {
+			return nil
+		}
+	}
+
 	// 确保目录存在
-	if err := os.MkdirAll(filepath.Dir(repopath), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(repo.LocalPath), 0755); err != nil {
 		return err
 	}
 
-	// 根据仓库类型执行检出操作
-	switch strings.ToLower(repoType) {
-	case "svn":
-		return svn.Checkout(url, repopath, username, password)
-	case "git":
-		return git.Clone(url, repopath, "", username, password)
-	default:
-		return fmt.Errorf("不支持的仓库类型: %s", repoType)
+	client, err := repoClient(repo)
+	if err != nil {
+		return err
 	}
+	return client.Checkout(context.Background(), repo.URL)
 }
 
-// getCommits 获取第一个仓库的提交记录
-func getCommits(limit, page int) ([]CommitRecord, int, error) {
-	if config == nil {
-		return nil, 0, errors.New("配置为空")
-	}
-
+// getCommits 获取方案的提交记录
+func getCommits(profile *SyncProfile, limit, page int) ([]CommitRecord, int, error) {
 	// 直接从数据库获取同步记录
 	var records []models.RepoSyncRecord
 	var total int64
 
+	query := app.DB.Model(&models.RepoSyncRecord{}).Where("profile = ?", profile.Name)
+
 	// 获取总记录数
-	if err := app.DB.Model(&models.RepoSyncRecord{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("获取提交记录总数失败: %v", err)
 	}
 
 	// 分页查询，按照创建时间倒序排列
 	offset := (page - 1) * limit
-	if err := app.DB.Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
 		return nil, 0, fmt.Errorf("查询提交记录失败: %v", err)
 	}
 
@@ -206,7 +136,7 @@ func getCommits(limit, page int) ([]CommitRecord, int, error) {
 			Comment:        record.Comment,
 			Author:         record.Author,
 			Time:           record.SyncTime,
-			Synced:         record.Status == 1, // 1表示同步成功
+			Synced:         record.Status == syncStatusSynced,
 			AffectedIssues: record.AffectedIssues,
 			ChangedFiles:   changedFiles,
 		}
@@ -216,387 +146,131 @@ func getCommits(limit, page int) ([]CommitRecord, int, error) {
 	return commits, int(total), nil
 }
 
-// updateRepo 更新仓库
-func updateRepo(repoType, repopath, username, password string) error {
-	if !path.IsValid(repopath) {
-		return errors.New("无效的本地路径")
-	}
-
-	// 根据仓库类型执行更新操作
-	switch strings.ToLower(repoType) {
-	case "svn":
-		return svn.Update(repopath)
-	case "git":
-		return git.Pull(repopath)
-	default:
-		return fmt.Errorf("不支持的仓库类型: %s", repoType)
-	}
-}
-
-// getRepoCommits 获取仓库提交记录
-func getRepoCommits(repoType, repopath string, limit int) ([]CommitRecord, error) {
-	if !path.IsValid(repopath) {
+// repoClient 创建仓库的版本控制客户端
+func repoClient(repo RepoInfo) (vcs.VCS, error) {
+	if !path.IsValid(repo.LocalPath) {
 		return nil, errors.New("无效的本地路径")
 	}
-
-	// 根据仓库类型获取提交记录
-	switch strings.ToLower(repoType) {
-	case "svn":
-		return getSvnCommits(repopath, limit)
-	case "git":
-		return getGitCommits(repopath, limit)
-	default:
-		return nil, fmt.Errorf("不支持的仓库类型: %s", repoType)
-	}
-}
-
-// getSvnCommits 获取Svn提交记录
-func getSvnCommits(path string, limit int) ([]CommitRecord, error) {
-	// 获取Svn日志
-	logOutput, err := svn.Log(path, limit)
+	password, err := app.Vault.Resolve(repo.Password, "reposync:"+repo.URL)
 	if err != nil {
 		return nil, err
 	}
-
-	// 解析Svn日志
-	var commits []CommitRecord
-	logEntries := strings.Split(logOutput, "------------------------------------------------------------------------")
-
-	for _, entry := range logEntries {
-		if strings.TrimSpace(entry) == "" {
-			continue
-		}
-
-		lines := strings.Split(strings.TrimSpace(entry), "\n")
-		if len(lines) < 2 {
-			continue
-		}
-
-		// 解析第一行: r123 | user | 2023-01-01 12:00:00 +0800
-		headerParts := strings.Split(lines[0], " | ")
-		if len(headerParts) < 3 {
-			continue
-		}
-
-		// 提取版本号
-		revision := strings.TrimPrefix(headerParts[0], "r")
-
-		// 提取作者
-		author := headerParts[1]
-
-		// 解析时间
-		timeStr := headerParts[2]
-		// 移除括号中的额外信息
-		if idx := strings.Index(timeStr, " ("); idx != -1 {
-			timeStr = strings.TrimSpace(timeStr[:idx])
-		}
-		commitTime, err := time.Parse("2006-01-02 15:04:05 -0700", timeStr)
-		if err != nil {
-			// 如果解析失败，使用当前时间
-			commitTime = time.Now()
-		}
-
-		// 提取注释
-		comment := ""
-		if len(lines) > 1 {
-			comment = strings.Join(lines[1:], "\n")
-		}
-
-		// 获取变更文件列表
-		changedFiles, err := getSvnFileChanges(path, revision)
-		if err != nil {
-			changedFiles = []FileChange{} // 如果获取失败，使用空列表
-		}
-
-		// 创建提交记录
-		commits = append(commits, CommitRecord{
-			Revision:     revision,
-			Comment:      comment,
-			Author:       author,
-			Time:         commitTime,
-			Synced:       false,
-			ChangedFiles: changedFiles,
-		})
+	client, err := vcs.New(repo.Type, repo.LocalPath, vcs.Auth{Username: repo.Username, Password: password})
+	if err != nil {
+		return nil, fmt.Errorf("不支持的仓库类型: %s", repo.Type)
 	}
-
-	return commits, nil
+	return client, nil
 }
 
-// getSvnFileChanges 获取Svn文件变更列表
-func getSvnFileChanges(path, revision string) ([]FileChange, error) {
-	// 执行svn log命令获取上一个版本号
-	cmd := exec.Command("svn", "log", "-r", fmt.Sprintf("%s:1", revision), "--limit", "2", path)
-	output, err := cmd.Output()
+// SourceClient 获取同步方案源仓库的客户端，供代码评审等模块读取提交和差异
+func SourceClient(profileName string) (vcs.VCS, error) {
+	profile, err := getProfile(profileName)
 	if err != nil {
-		return nil, fmt.Errorf("获取SVN日志失败: %v", err)
-	}
-
-	// 解析日志获取版本号
-	lines := strings.Split(string(output), "\n")
-	var prevRevision string
-	for _, line := range lines {
-		// 检查是否符合SVN日志格式：r123 | user | 2023-01-01 12:00:00 +0800
-		parts := strings.Split(line, " | ")
-		if len(parts) >= 3 {
-			// 检查版本号格式：r123
-			if strings.HasPrefix(parts[0], "r") && len(parts[0]) > 1 {
-				rev := strings.TrimPrefix(parts[0], "r")
-				// 验证版本号是否为数字
-				if _, err := strconv.Atoi(rev); err == nil {
-					if rev != revision {
-						prevRevision = rev
-						break
-					}
-				}
-			}
-		}
+		return nil, err
 	}
+	return repoClient(profile.Source)
+}
 
-	if prevRevision == "" {
-		// 如果是第一个版本，获取该版本的所有文件作为新增
-		cmd = exec.Command("svn", "list", "-r", revision, path)
-		output, err = cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("获取SVN文件列表失败: %v", err)
-		}
-
-		// 解析输出
-		var changes []FileChange
-		lines = strings.Split(string(output), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-
-			// 所有文件都标记为新增
-			changes = append(changes, FileChange{
-				Path:       line,
-				ChangeType: "A",
-			})
-
-			// 限制最大文件数为50
-			if len(changes) >= 50 {
-				break
-			}
-		}
-
-		return changes, nil
-	}
+// ListProfiles 获取所有同步方案，已隐藏仓库密码
+func ListProfiles() []SyncProfile {
+	return maskProfiles(listProfiles())
+}
 
-	// 执行svn diff命令获取变更文件
-	cmd = exec.Command("svn", "diff", "--summarize", "-r", fmt.Sprintf("%s:%s", prevRevision, revision), path)
-	output, err = cmd.Output()
+// updateRepo 更新仓库
+func updateRepo(repo RepoInfo) error {
+	client, err := repoClient(repo)
 	if err != nil {
-		return nil, fmt.Errorf("获取SVN差异失败: %v", err)
-	}
-
-	// 解析输出
-	var changes []FileChange
-	lines = strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// 格式: A/M/D path/to/file.txt
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) == 2 {
-			changeType := strings.TrimSpace(parts[0])
-			filePath := strings.TrimSpace(parts[1])
-
-			// 计算相对路径
-			relPath, err := filepath.Rel(path, filePath)
-			if err != nil {
-				continue
-			}
-
-			changes = append(changes, FileChange{
-				Path:       relPath,
-				ChangeType: changeType,
-			})
-
-			// 限制最大文件数为50
-			if len(changes) >= 50 {
-				break
-			}
-		}
+		return err
 	}
-
-	return changes, nil
+	return client.Update(context.Background())
 }
 
-// getGitCommits 获取Git提交记录
-func getGitCommits(path string, limit int) ([]CommitRecord, error) {
-	// 获取Git日志
-	logOutput, err := git.Log(path, limit)
+// getRepoCommits 获取仓库提交记录，从新到旧排列
+func getRepoCommits(repo RepoInfo, limit int) ([]CommitRecord, error) {
+	client, err := repoClient(repo)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析Git日志
-	var commits []CommitRecord
-	lines := strings.Split(logOutput, "\n")
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// 格式: abcd123 Commit message
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) < 2 {
-			continue
-		}
-
-		revision := parts[0]
-		comment := parts[1]
-
-		// 获取提交详情
-		details, err := getGitCommitDetails(path, revision)
-		if err != nil {
-			continue
-		}
-
-		// 获取变更文件列表
-		changedFiles, err := getGitFileChanges(path, revision)
-		if err != nil {
-			changedFiles = []FileChange{} // 如果获取失败，使用空列表
-		}
+	logs, err := client.Log(context.Background(), limit)
+	if err != nil {
+		return nil, err
+	}
 
-		// 创建提交记录
+	commits := make([]CommitRecord, 0, len(logs))
+	for _, entry := range logs {
 		commits = append(commits, CommitRecord{
-			Revision:     revision,
-			Comment:      comment,
-			Author:       details.Author,
-			Time:         details.Time,
-			Synced:       false,
-			ChangedFiles: changedFiles,
+			Revision:     entry.Revision,
+			Comment:      entry.Message,
+			Author:       entry.Author,
+			Time:         entry.Time,
+			ChangedFiles: toFileChanges(entry.Changes),
 		})
 	}
 
 	return commits, nil
 }
 
-// GitCommitDetails Git提交详情
-type GitCommitDetails struct {
-	Author string
-	Time   time.Time
-}
-
-// getGitCommitDetails 获取Git提交详情
-func getGitCommitDetails(path, revision string) (*GitCommitDetails, error) {
-	// 执行git show命令获取提交详情
-	cmd := exec.Command("git", "-C", path, "show", "--pretty=format:%an|%at", "-s", revision)
-	output, err := cmd.Output()
+// getFileChanges 获取 fromRev（不含）到 toRev 之间的文件变更，fromRev 为空时获取 toRev 本身的变更
+func getFileChanges(repo RepoInfo, fromRev, toRev string) ([]FileChange, error) {
+	client, err := repoClient(repo)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析输出
-	parts := strings.Split(strings.TrimSpace(string(output)), "|")
-	if len(parts) != 2 {
-		return nil, errors.New("无法解析提交详情")
-	}
-
-	// 解析作者
-	author := parts[0]
-
-	// 解析时间
-	timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+	changes, err := client.ChangedFiles(context.Background(), fromRev, toRev)
 	if err != nil {
 		return nil, err
 	}
-	commitTime := time.Unix(timestamp, 0)
-
-	return &GitCommitDetails{
-		Author: author,
-		Time:   commitTime,
-	}, nil
+	return toFileChanges(changes), nil
 }
 
-// getGitFileChanges 获取Git文件变更列表
-func getGitFileChanges(path, revision string) ([]FileChange, error) {
-	// 执行git show命令获取变更文件
-	cmd := exec.Command("git", "-C", path, "show", "--name-status", "--pretty=format:", revision)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析输出
-	var changes []FileChange
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// 格式: A path/to/file.txt
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) == 2 {
-			changeType := parts[0]
-			filePath := parts[1]
-
-			changes = append(changes, FileChange{
-				Path:       filePath,
-				ChangeType: changeType,
-			})
-		}
+// toFileChanges 转换为文件变更列表
+func toFileChanges(changes []vcs.Change) []FileChange {
+	files := make([]FileChange, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, FileChange{Path: change.Path, ChangeType: change.Action})
 	}
-
-	return changes, nil
+	return files
 }
 
-// SyncCommits 同步选中的提交记录
-func SyncCommits(revisions []string) error {
-	if config == nil {
-		return errors.New("配置为空")
+// sameRevision 判断两个版本号是否相同，兼容旧版本保存的 git 短哈希
+func sameRevision(a, b string) bool {
+	if a == b {
+		return true
 	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return len(a) >= 7 && len(b) == 40 && strings.HasPrefix(b, a)
+}
 
-	// 对版本号进行排序（从小到大）
-	sort.Slice(revisions, func(i, j int) bool {
-		// 尝试将版本号转换为数字进行比较
-		numI, errI := strconv.Atoi(revisions[i])
-		numJ, errJ := strconv.Atoi(revisions[j])
+// SyncCommits 同步选中的提交记录到方案的所有目标仓库
+// 每个提交先为所有目标仓库生成计划，存在冲突时除非强制同步，否则不修改任何目标仓库
+func SyncCommits(profile *SyncProfile, revisions []string, opts SyncOptions) (*SyncReport, error) {
+	defer lockProfile(profile.Name)()
 
-		// 如果两个都是数字，按数字大小排序
-		if errI == nil && errJ == nil {
-			return numI < numJ
-		}
+	report := &SyncReport{DryRun: opts.DryRun, Commits: []CommitReport{}}
 
-		// 如果不是数字，按字符串排序
-		return revisions[i] < revisions[j]
-	})
+	// 对版本号进行排序（从小到大）
+	sortRevisions(revisions)
 
-	// 更新第一个仓库
-	err := updateRepo(
-		config.RepoType1,
-		config.LocalPath1,
-		config.Username1,
-		config.Password1,
-	)
-	if err != nil {
-		return fmt.Errorf("更新第一个仓库失败: %v", err)
+	// 更新源仓库和目标仓库
+	if err := updateRepos(profile); err != nil {
+		return report, err
 	}
 
 	// 获取所有提交记录
-	commits, err := getRepoCommits(
-		config.RepoType1,
-		config.LocalPath1,
-		100, // 获取足够多的记录
-	)
+	commits, err := getRepoCommits(profile.Source, 100) // 获取足够多的记录
 	if err != nil {
-		return fmt.Errorf("获取提交记录失败: %v", err)
+		return report, fmt.Errorf("获取提交记录失败: %v", err)
 	}
 
 	// 筛选需要同步的提交记录
 	var selectedCommits []CommitRecord
 	for _, rev := range revisions {
 		for _, commit := range commits {
-			if commit.Revision == rev {
+			if sameRevision(commit.Revision, rev) {
 				selectedCommits = append(selectedCommits, commit)
 				break
 			}
@@ -604,223 +278,255 @@ func SyncCommits(revisions []string) error {
 	}
 
 	// 同步每个提交记录
+	p := newPlanner(profile, opts.DryRun)
 	for i := range selectedCommits {
-		err := syncCommit(selectedCommits[i])
+		err := syncCommit(profile, p, selectedCommits[i], opts, report)
+		if errors.Is(err, errSyncConflict) {
+			return report, err
+		}
 		if err != nil {
-			return fmt.Errorf("同步提交记录 %s 失败: %v", selectedCommits[i].Revision, err)
+			return report, fmt.Errorf("同步提交记录 %s 失败: %v", selectedCommits[i].Revision, err)
 		}
 	}
 
+	return report, nil
+}
+
+// updateRepos 更新方案的源仓库和所有目标仓库
+func updateRepos(profile *SyncProfile) error {
+	if err := updateRepo(profile.Source); err != nil {
+		return fmt.Errorf("更新源仓库失败: %v", err)
+	}
+	for i, target := range profile.Targets {
+		if err := updateRepo(target); err != nil {
+			return fmt.Errorf("更新目标仓库%d失败: %v", i+1, err)
+		}
+	}
 	return nil
 }
 
-// syncCommit 同步单个提交记录
-func syncCommit(commit CommitRecord) error {
+// syncCommit 同步单个提交记录到所有目标仓库
+func syncCommit(profile *SyncProfile, p *planner, commit CommitRecord, opts SyncOptions, report *SyncReport) error {
 	// 获取变更文件列表
-	changes, err := getFileChanges(
-		config.RepoType1,
-		config.LocalPath1,
-		commit.Revision,
-	)
+	changes, err := getFileChanges(profile.Source, "", commit.Revision)
 	if err != nil {
-		// 记录同步失败状态
-		app.DB.Create(&models.RepoSyncRecord{
-			Revision:       commit.Revision,
-			Comment:        commit.Comment,
-			Author:         commit.Author,
-			AffectedIssues: extractIssueNumbers(commit.Comment),
-			AffectedFiles:  formatAffectedFiles(commit.ChangedFiles),
-			SyncTime:       time.Now(),
-			Status:         2, // 同步失败
-		})
+		if !opts.DryRun {
+			// 记录同步失败状态
+			saveSyncRecord(profile.Name, commit, syncStatusFailed, commit.ChangedFiles)
+		}
 		return fmt.Errorf("获取变更文件列表失败: %v", err)
 	}
 
-	// 更新第一个仓库
-	err = updateRepo(
-		config.RepoType2,
-		config.LocalPath2,
-		config.Username2,
-		config.Password2,
-	)
-	if err != nil {
-		return fmt.Errorf("更新第二个仓库失败: %v", err)
+	message := fmt.Sprintf("Sync from %s: %s", commit.Revision, commit.Comment)
+	targets, err := syncChanges(profile, p, changes, message, commit.Revision, opts)
+
+	commitReport := CommitReport{Revision: commit.Revision, Comment: commit.Comment, Targets: targets}
+	for _, target := range targets {
+		report.Conflicts += target.Conflicts
 	}
+	report.Commits = append(report.Commits, commitReport)
 
-	// 处理每个变更文件
-	for _, change := range changes {
-		// 计算源路径和目标路径
-		sourcePath := filepath.Join(config.LocalPath1, change.Path)
-		targetPath := filepath.Join(config.LocalPath2, change.Path)
-
-		// 根据变更类型执行操作
-		switch change.ChangeType {
-		case "A", "M": // 新增或修改
-			// 检查源文件是否是目录
-			sourceInfo, err := os.Stat(sourcePath)
-			if err != nil {
-				return fmt.Errorf("获取源文件信息失败: %v", err)
-			}
+	switch {
+	case opts.DryRun:
+		return err
+	case errors.Is(err, errSyncConflict):
+		// 冲突时没有修改目标仓库，保持未同步状态
+		return err
+	case err != nil:
+		saveSyncRecord(profile.Name, commit, syncStatusFailed, changes)
+		return err
+	}
 
-			if sourceInfo.IsDir() {
-				// 如果是目录，只需要创建目标目录
-				if err := os.MkdirAll(targetPath, 0755); err != nil {
-					return err
-				}
-			} else {
-				// 如果是文件，确保目标目录存在并复制文件
-				targetDir := filepath.Dir(targetPath)
-				if err := os.MkdirAll(targetDir, 0755); err != nil {
-					return err
-				}
+	// 记录同步成功状态
+	if err := saveSyncRecord(profile.Name, commit, syncStatusSynced, changes); err != nil {
+		return fmt.Errorf("记录同步成功状态失败: %v", err)
+	}
+	commit.Synced = true
+	publishCommits(profile.Name, []CommitRecord{commit})
 
-				// 复制文件
-				if err := copyFile(sourcePath, targetPath); err != nil {
-					return err
-				}
-			}
-		case "D": // 删除
-			// 检查目标路径是否是目录
-			targetInfo, err := os.Stat(targetPath)
-			if err == nil && targetInfo.IsDir() {
-				// 如果是目录，使用RemoveAll删除
+	return nil
+}
+
+// syncChanges 为所有目标仓库生成同步计划，没有冲突或强制同步时应用变更并提交
+func syncChanges(profile *SyncProfile, p *planner, changes []FileChange, message, revision string, opts SyncOptions) ([]TargetReport, error) {
+	reports := make([]TargetReport, 0, len(profile.Targets))
+	conflicts := 0
+	for i, target := range profile.Targets {
+		report, err := p.plan(target, changes)
+		if err != nil {
+			return reports, fmt.Errorf("目标仓库%d: %v", i+1, err)
+		}
+		conflicts += report.Conflicts
+		reports = append(reports, report)
+	}
+
+	if opts.DryRun {
+		return reports, nil
+	}
+	if conflicts > 0 && !opts.Force {
+		return reports, errSyncConflict
+	}
+
+	for i, target := range profile.Targets {
+		if err := applyPlans(profile, target, reports[i].Files, message, revision); err != nil {
+			return reports, fmt.Errorf("目标仓库%d: %v", i+1, err)
+		}
+	}
+
+	return reports, nil
+}
+
+// applyPlans 将同步计划应用到目标仓库的工作副本并提交
+func applyPlans(profile *SyncProfile, target RepoInfo, plans []FilePlan, message, revision string) error {
+	applied := 0
+	for _, plan := range plans {
+		sourcePath := filepath.Join(profile.Source.LocalPath, filepath.FromSlash(plan.Path))
+		targetPath := filepath.Join(target.LocalPath, filepath.FromSlash(plan.TargetPath))
+
+		switch plan.Action {
+		case planAdd, planModify:
+			// 强制同步时目标仓库的同名目录会被替换
+			if info, err := os.Stat(targetPath); err == nil && info.IsDir() {
 				if err := os.RemoveAll(targetPath); err != nil {
 					return err
 				}
-			} else {
-				// 如果是文件或不存在，尝试删除
-				if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
-					return err
-				}
+			}
 
-				// 检查并删除空目录
-				targetDir := filepath.Dir(targetPath)
-				if err := removeEmptyDirs(targetDir, config.LocalPath2); err != nil {
+			// 确保目标目录存在并复制文件
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return err
+			}
+			if err := copyFile(sourcePath, targetPath); err != nil {
+				return err
+			}
+		case planMkdir:
+			if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+				if err := os.Remove(targetPath); err != nil {
 					return err
 				}
 			}
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return err
+			}
+		case planDelete:
+			if err := os.RemoveAll(targetPath); err != nil {
+				return err
+			}
+
+			// 检查并删除空目录
+			if err := removeEmptyDirs(filepath.Dir(targetPath), target.LocalPath); err != nil {
+				return err
+			}
+		default:
+			continue
 		}
+		applied++
 	}
 
-	// 提交到第二个仓库
-	err = commitToRepo(
-		config.RepoType2,
-		config.LocalPath2,
-		fmt.Sprintf("Sync from %s: %s", commit.Revision, commit.Comment),
-	)
-	if err != nil {
-		return err
-	}
+	// 路径映射过滤后没有变更，不需要提交
+	if applied > 0 {
+		if err := commitToRepo(target, message); err != nil {
+			return fmt.Errorf("提交失败: %v", err)
+		}
 
-	// 解析问题编号
-	affectedIssues := extractIssueNumbers(commit.Comment)
+		// 提交后再次更新，保持工作副本为最新版本
+		if err := updateRepo(target); err != nil {
+			return fmt.Errorf("更新仓库失败: %v", err)
+		}
+	}
 
-	// 格式化受影响的文件
-	affectedFiles := formatAffectedFiles(changes)
+	return saveSyncedHashes(profile, target, plans, revision)
+}
 
-	// 记录同步成功状态
-	err = app.DB.Create(&models.RepoSyncRecord{
-		Revision:       commit.Revision,
-		Comment:        commit.Comment,
-		Author:         commit.Author,
-		AffectedIssues: affectedIssues,
-		AffectedFiles:  affectedFiles,
-		SyncTime:       time.Now(),
-		Status:         1, // 同步成功
-	}).Error
+// withinDir 判断路径是否位于目录内
+func withinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
-	if err != nil {
-		return fmt.Errorf("记录同步成功状态失败: %v", err)
+// publishCommits 发布提交记录事件，缺陷跟踪等模块据此关联提交和问题
+func publishCommits(profile string, commits []CommitRecord) {
+	if len(commits) == 0 {
+		return
 	}
 
-	// 更新第一个仓库
-	err = updateRepo(
-		config.RepoType2,
-		config.LocalPath2,
-		config.Username2,
-		config.Password2,
-	)
-	if err != nil {
-		return fmt.Errorf("更新第二个仓库失败2: %v", err)
+	list := make([]events.RepoCommit, 0, len(commits))
+	for _, commit := range commits {
+		list = append(list, events.RepoCommit{
+			Revision: commit.Revision,
+			Author:   commit.Author,
+			Message:  commit.Comment,
+			Time:     commit.Time,
+			Synced:   commit.Synced,
+		})
 	}
-
-	return nil
+	event.Publish(app.Bus, context.Background(), events.EventRepoCommits{
+		Profile: profile,
+		Commits: list,
+	})
 }
 
-// getFileChanges 获取文件变更列表
-func getFileChanges(repoType, path, revision string) ([]FileChange, error) {
-	// 根据仓库类型获取文件变更列表
-	switch strings.ToLower(repoType) {
-	case "svn":
-		return getSvnFileChanges(path, revision)
-	case "git":
-		return getGitFileChanges(path, revision)
-	default:
-		return nil, fmt.Errorf("不支持的仓库类型: %s", repoType)
+// saveSyncRecord 保存方案的同步记录，同一版本只保留一条
+func saveSyncRecord(profile string, commit CommitRecord, status int, changes []FileChange) error {
+	record, found := findSyncRecord(profile, commit.Revision)
+
+	record.Profile = profile
+	record.Revision = commit.Revision
+	record.Comment = commit.Comment
+	record.Author = commit.Author
+	record.AffectedIssues = extractIssueNumbers(commit.Comment)
+	record.AffectedFiles = formatAffectedFiles(changes)
+	record.SyncTime = time.Now()
+	record.Status = status
+
+	if found {
+		return app.DB.Save(&record).Error
 	}
+	return app.DB.Create(&record).Error
 }
 
-// commitToRepo 提交到仓库
-func commitToRepo(repoType, repopath, message string) error {
-	if !path.IsValid(repopath) {
-		return errors.New("无效的本地路径")
+// findSyncRecord 查找方案中指定版本的同步记录，兼容旧版本保存的 git 短哈希
+func findSyncRecord(profile, revision string) (models.RepoSyncRecord, bool) {
+	var record models.RepoSyncRecord
+	query := app.DB.Where("profile = ?", profile)
+	if len(revision) == 40 {
+		query = query.Where("revision IN ?", []string{revision, revision[:7]})
+	} else {
+		query = query.Where("revision = ?", revision)
 	}
+	return record, query.First(&record).Error == nil
+}
 
-	// 根据仓库类型执行提交操作
-	switch strings.ToLower(repoType) {
-	case "svn":
-		// 获取文件状态
-		cmd := exec.Command("svn", "status", repopath)
-		output, err := cmd.Output()
-		if err != nil {
-			return fmt.Errorf("获取文件状态失败: %v", err)
-		}
-
-		// 处理每个文件的状态
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
+// sortRevisions 按版本号从小到大排序
+func sortRevisions(revisions []string) {
+	sort.Slice(revisions, func(i, j int) bool {
+		// 尝试将版本号转换为数字进行比较
+		numI, errI := strconv.Atoi(revisions[i])
+		numJ, errJ := strconv.Atoi(revisions[j])
 
-			// 格式: ?/A/M/D/! path/to/file
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) != 2 {
-				continue
-			}
+		// 如果两个都是数字，按数字大小排序
+		if errI == nil && errJ == nil {
+			return numI < numJ
+		}
 
-			status := parts[0]
-			filePath := strings.TrimSpace(parts[1])
+		// 如果不是数字，按字符串排序
+		return revisions[i] < revisions[j]
+	})
+}
 
-			switch status {
-			case "?":
-				// 未版本控制的文件，需要添加
-				if err := svn.Add(filePath); err != nil {
-					return fmt.Errorf("添加文件失败 %s: %v", filePath, err)
-				}
-			case "!", "D":
-				// 丢失或删除的文件，需要从版本库中删除
-				cmd := exec.Command("svn", "delete", filePath)
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("删除文件失败 %s: %v", filePath, err)
-				}
-			}
-		}
+// commitToRepo 提交工作副本的全部修改到仓库
+func commitToRepo(repo RepoInfo, message string) error {
+	client, err := repoClient(repo)
+	if err != nil {
+		return err
+	}
 
-		// 提交所有变更
-		return svn.Commit(repopath, message)
-	case "git":
-		// 先添加所有变更
-		_, err := git.ExecGitCommand(repopath, "add", "-A")
-		if err != nil {
-			return err
-		}
-		// 提交变更
-		return git.Commit(repopath, message)
-	default:
-		return fmt.Errorf("不支持的仓库类型: %s", repoType)
+	_, err = client.Commit(context.Background(), message)
+	if errors.Is(err, vcs.ErrNoChanges) {
+		// 复制的内容与目标仓库相同
+		return nil
 	}
+	return err
 }
 
 // copyFile 复制文件
@@ -879,167 +585,180 @@ func removeEmptyDirs(dir, rootDir string) error {
 	return removeEmptyDirs(filepath.Dir(dir), rootDir)
 }
 
-// SyncChangesBetweenRevisions 同步两个版本之间的变更
-func SyncChangesBetweenRevisions(fromRev, toRev string) (int, error) {
-	if config == nil {
-		return 0, errors.New("配置为空")
+// SyncChangesBetweenRevisions 同步 fromRev（不含）到 toRev 之间的变更到方案的所有目标仓库
+// fromRev 为空时从最近提交记录中最早的一条开始，同步成功后范围内的提交记录标记为已同步
+func SyncChangesBetweenRevisions(profile *SyncProfile, fromRev, toRev string, opts SyncOptions) (int, *SyncReport, error) {
+	defer lockProfile(profile.Name)()
+
+	source := profile.Source
+	report := &SyncReport{DryRun: opts.DryRun, Commits: []CommitReport{}}
+
+	// 更新源仓库和目标仓库
+	if err := updateRepos(profile); err != nil {
+		return 0, report, err
 	}
 
-	// 更新第一个仓库
-	err := updateRepo(
-		config.RepoType1,
-		config.LocalPath1,
-		config.Username1,
-		config.Password1,
-	)
+	// 找出范围内的提交记录，用于更新同步状态
+	commits, err := getRepoCommits(source, 100)
 	if err != nil {
-		return 0, fmt.Errorf("更新第一个仓库失败: %v", err)
+		return 0, report, fmt.Errorf("获取提交记录失败: %v", err)
 	}
+	commits = revisionRange(commits, fromRev, toRev)
 
 	// 获取两个版本之间的变更
-	var cmd *exec.Cmd
-	if config.RepoType1 == "svn" {
-		cmd = exec.Command("svn", "diff", "--summarize", "-r",
-			fmt.Sprintf("%s:%s", fromRev, toRev), config.LocalPath1)
-	} else if config.RepoType1 == "git" {
-		cmd = exec.Command("git", "-C", config.LocalPath1, "diff",
-			"--name-status", fromRev, toRev)
+	var changes []FileChange
+	if fromRev != "" {
+		changes, err = getFileChanges(source, fromRev, toRev)
+		if err != nil {
+			return 0, report, fmt.Errorf("获取变更失败: %v", err)
+		}
 	} else {
-		return 0, fmt.Errorf("不支持的仓库类型: %s", config.RepoType1)
+		if len(commits) == 0 {
+			return 0, report, fmt.Errorf("版本 %s 不在最近的提交记录中", toRev)
+		}
+		// 没有起始版本时按从旧到新的顺序合并每个提交的变更
+		for i := len(commits) - 1; i >= 0; i-- {
+			changes = mergeChanges(changes, commits[i].ChangedFiles)
+		}
 	}
 
-	output, err := cmd.Output()
+	// 同步到每个目标仓库
+	message := fmt.Sprintf("Sync changes between %s and %s", fromRev, toRev)
+	targets, err := syncChanges(profile, newPlanner(profile, opts.DryRun), changes, message, toRev, opts)
+	report.Commits = append(report.Commits, CommitReport{
+		Revision: fmt.Sprintf("%s:%s", fromRev, toRev),
+		Targets:  targets,
+	})
+	for _, target := range targets {
+		report.Conflicts += target.Conflicts
+	}
+	if errors.Is(err, errSyncConflict) {
+		return 0, report, err
+	}
 	if err != nil {
-		return 0, fmt.Errorf("获取变更失败: %v", err)
+		return 0, report, fmt.Errorf("同步失败: %v", err)
 	}
 
-	// 解析变更
-	var changes []FileChange
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		var changeType, filePath string
-		if config.RepoType1 == "svn" {
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 2 {
-				changeType = strings.TrimSpace(parts[0])
-				filePath = strings.TrimSpace(parts[1])
-				relPath, err := filepath.Rel(config.LocalPath1, filePath)
-				if err == nil {
-					filePath = relPath
-				}
-			}
-		} else { // git
-			parts := strings.SplitN(line, "\t", 2)
-			if len(parts) == 2 {
-				changeType = parts[0]
-				filePath = parts[1]
+	if !opts.DryRun {
+		for i := range commits {
+			if err := saveSyncRecord(profile.Name, commits[i], syncStatusSynced, commits[i].ChangedFiles); err != nil {
+				return len(changes), report, fmt.Errorf("记录同步成功状态失败: %v", err)
 			}
+			commits[i].Synced = true
 		}
+		publishCommits(profile.Name, commits)
+	}
 
-		if changeType != "" && filePath != "" {
-			changes = append(changes, FileChange{
-				Path:       filePath,
-				ChangeType: changeType,
-			})
+	return len(changes), report, nil
+}
+
+// revisionRange 从按新到旧排列的提交记录中取出 fromRev（不含）到 toRev 之间的部分
+func revisionRange(commits []CommitRecord, fromRev, toRev string) []CommitRecord {
+	start := -1
+	for i, commit := range commits {
+		if sameRevision(commit.Revision, toRev) {
+			start = i
+			break
 		}
 	}
+	if start < 0 {
+		return nil
+	}
 
-	// 同步变更
-	// 更新目标仓库
-	if err := updateRepo(
-		config.RepoType2,
-		config.LocalPath2,
-		config.Username2,
-		config.Password2,
-	); err != nil {
-		return 0, fmt.Errorf("更新目标仓库失败: %v", err)
+	for i := start; i < len(commits); i++ {
+		if fromRev != "" && sameRevision(commits[i].Revision, fromRev) {
+			return commits[start:i]
+		}
 	}
+	if fromRev != "" {
+		// 起始版本不在最近的记录中，无法确定范围
+		return nil
+	}
+	return commits[start:]
+}
 
-	// 处理每个变更
-	for _, change := range changes {
-		sourcePath := filepath.Join(config.LocalPath1, change.Path)
-		targetPath := filepath.Join(config.LocalPath2, change.Path)
+// mergeChanges 合并连续两段变更，同一文件以后一段的变更为准
+func mergeChanges(first, second []FileChange) []FileChange {
+	index := make(map[string]int, len(first))
+	merged := append([]FileChange(nil), first...)
+	for i, change := range merged {
+		index[change.Path] = i
+	}
 
-		switch change.ChangeType {
-		case "A", "M": // 新增或修改
-			sourceInfo, err := os.Stat(sourcePath)
-			if err != nil {
-				continue // 跳过不存在的文件
-			}
+	for _, change := range second {
+		i, ok := index[change.Path]
+		if !ok {
+			index[change.Path] = len(merged)
+			merged = append(merged, change)
+			continue
+		}
 
-			if sourceInfo.IsDir() {
-				os.MkdirAll(targetPath, 0755)
-			} else {
-				os.MkdirAll(filepath.Dir(targetPath), 0755)
-				copyFile(sourcePath, targetPath)
-			}
-		case "D": // 删除
-			os.RemoveAll(targetPath)
-			removeEmptyDirs(filepath.Dir(targetPath), config.LocalPath2)
+		prev := merged[i].ChangeType
+		switch {
+		case prev == "A" && change.ChangeType == "M":
+			// 新增后修改仍然是新增
+		case prev == "A" && change.ChangeType == "D":
+			// 新增后删除相当于没有变更
+			merged[i].ChangeType = ""
+		case prev == "D" && change.ChangeType == "A":
+			merged[i].ChangeType = "M"
+		default:
+			merged[i].ChangeType = change.ChangeType
 		}
 	}
 
-	// 提交变更
-	message := fmt.Sprintf("Sync changes between %s and %s", fromRev, toRev)
-	if err := commitToRepo(config.RepoType2, config.LocalPath2, message); err != nil {
-		return 0, fmt.Errorf("提交变更失败: %v", err)
+	result := merged[:0]
+	for _, change := range merged {
+		if change.ChangeType != "" {
+			result = append(result, change)
+		}
 	}
-
-	return len(changes), nil
+	return result
 }
 
-// FindUnsyncedRevisionRange 查找未同步的版本范围
-func FindUnsyncedRevisionRange() (string, string, error) {
-	if config == nil {
-		return "", "", errors.New("配置为空")
-	}
-
-	// 更新第一个仓库
-	err := updateRepo(
-		config.RepoType1,
-		config.LocalPath1,
-		config.Username1,
-		config.Password1,
-	)
-	if err != nil {
-		return "", "", fmt.Errorf("更新第一个仓库失败: %v", err)
+// FindUnsyncedRevisionRange 查找方案中未同步的版本范围
+// 返回最早的未同步提交之前的版本（不含）和最新的未同步提交，最早的未同步提交超出最近记录时起始版本为空
+func FindUnsyncedRevisionRange(profile *SyncProfile) (string, string, error) {
+	// 更新源仓库
+	if err := updateRepo(profile.Source); err != nil {
+		return "", "", fmt.Errorf("更新源仓库失败: %v", err)
 	}
 
-	// 获取最近100条提交记录
-	commits, err := getRepoCommits(
-		config.RepoType1,
-		config.LocalPath1,
-		100,
-	)
+	// 获取最近100条提交记录，从新到旧排列
+	commits, err := getRepoCommits(profile.Source, 100)
 	if err != nil {
 		return "", "", fmt.Errorf("获取提交记录失败: %v", err)
 	}
 
-	if len(commits) == 0 {
-		return "", "", nil
+	var records []models.RepoSyncRecord
+	if err := app.DB.Where("profile = ? AND status = ?", profile.Name, syncStatusSynced).Find(&records).Error; err != nil {
+		return "", "", fmt.Errorf("查询同步记录失败: %v", err)
 	}
-
-	// 找到未同步的最小和最大revision
-	var minRev, maxRev string
-	for _, commit := range commits {
-		// 检查是否已同步
-		var record models.RepoSyncRecord
-		result := app.DB.Where("revision = ?", commit.Revision).First(&record)
-
-		if result.Error != nil { // 未找到记录，说明未同步
-			if minRev == "" {
-				minRev = commit.Revision
+	synced := func(revision string) bool {
+		for _, record := range records {
+			if sameRevision(record.Revision, revision) {
+				return true
 			}
-			maxRev = commit.Revision
 		}
+		return false
 	}
 
-	return minRev, maxRev, nil
+	oldest := -1
+	var toRev string
+	for i, commit := range commits {
+		if synced(commit.Revision) {
+			continue
+		}
+		if toRev == "" {
+			toRev = commit.Revision
+		}
+		oldest = i
+	}
+
+	if oldest < 0 || oldest+1 >= len(commits) {
+		return "", toRev, nil
+	}
+	return commits[oldest+1].Revision, toRev, nil
 }
 
 // formatAffectedFiles 格式化受影响的文件列表
@@ -1082,53 +801,32 @@ func formatAffectedFiles(changes []FileChange) string {
 	return strings.Join(parts, ";")
 }
 
-// RefreshCommits 刷新提交记录
-func RefreshCommits(limit int) error {
-	if config == nil {
-		return errors.New("配置为空")
-	}
+// RefreshCommits 刷新方案的提交记录
+func RefreshCommits(profile *SyncProfile, limit int) error {
+	defer lockProfile(profile.Name)()
 
-	// 更新第一个仓库
-	err := updateRepo(
-		config.RepoType1,
-		config.LocalPath1,
-		config.Username1,
-		config.Password1,
-	)
+	// 更新源仓库
+	err := updateRepo(profile.Source)
 	if err != nil {
-		return fmt.Errorf("更新第一个仓库失败: %v", err)
+		return fmt.Errorf("更新源仓库失败: %v", err)
 	}
 
 	// 获取最近的提交记录
-	commits, err := getRepoCommits(
-		config.RepoType1,
-		config.LocalPath1,
-		limit,
-	)
+	commits, err := getRepoCommits(profile.Source, limit)
 	if err != nil {
 		return fmt.Errorf("获取提交记录失败: %v", err)
 	}
 
-	// 对提交记录按照 revision 从小到大排序
-	sort.Slice(commits, func(i, j int) bool {
-		// 尝试将版本号转换为数字进行比较
-		numI, errI := strconv.Atoi(commits[i].Revision)
-		numJ, errJ := strconv.Atoi(commits[j].Revision)
-
-		// 如果两个都是数字，按数字大小排序
-		if errI == nil && errJ == nil {
-			return numI < numJ
-		}
-
-		// 如果不是数字，按字符串排序
-		return commits[i].Revision < commits[j].Revision
-	})
+	// 日志从新到旧排列，反转后按从旧到新的顺序写入，列表按 id 倒序显示
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
 
 	// 遍历提交记录，将未同步的记录添加到数据库
-	for _, commit := range commits {
+	for i, commit := range commits {
 		// 检查是否已存在
-		var record models.RepoSyncRecord
-		result := app.DB.Where("revision = ?", commit.Revision).First(&record)
+		record, found := findSyncRecord(profile.Name, commit.Revision)
+		commits[i].Synced = found && record.Status == syncStatusSynced
 
 		// 解析提交消息中的问题编号
 		affectedIssues := extractIssueNumbers(commit.Comment)
@@ -1139,24 +837,21 @@ func RefreshCommits(limit int) error {
 			affectedFiles = formatAffectedFiles(commit.ChangedFiles)
 		} else {
 			// 如果提交记录中没有变更文件信息，尝试从仓库获取
-			changes, err := getFileChanges(
-				config.RepoType1,
-				config.LocalPath1,
-				commit.Revision,
-			)
+			changes, err := getFileChanges(profile.Source, "", commit.Revision)
 			if err == nil && len(changes) > 0 {
 				affectedFiles = formatAffectedFiles(changes)
 			}
 		}
 
-		if result.Error != nil { // 未找到记录，说明未同步
+		if !found { // 未找到记录，说明未同步
 			// 创建新记录
 			err = app.DB.Create(&models.RepoSyncRecord{
+				Profile:        profile.Name,
 				Revision:       commit.Revision,
 				Comment:        commit.Comment,
 				Author:         commit.Author,
 				SyncTime:       time.Now(),
-				Status:         0, // 未同步状态
+				Status:         syncStatusPending,
 				AffectedIssues: affectedIssues,
 				AffectedFiles:  affectedFiles,
 			}).Error
@@ -1168,6 +863,11 @@ func RefreshCommits(limit int) error {
 			// 需要更新的字段
 			updates := make(map[string]interface{})
 
+			// 旧版本保存的 git 短哈希更新为完整哈希
+			if record.Revision != commit.Revision {
+				updates["revision"] = commit.Revision
+			}
+
 			// 更新问题列表（如果为空且新解析出的不为空）
 			if record.AffectedIssues == "" && affectedIssues != "" {
 				updates["affected_issues"] = affectedIssues
@@ -1187,6 +887,7 @@ func RefreshCommits(limit int) error {
 			}
 		}
 	}
+	publishCommits(profile.Name, commits)
 
 	return nil
 }
@@ -1232,10 +933,9 @@ func extractIssueNumbers(comment string) string {
 	return strings.Join(result, ", ")
 }
 
-// ClearSyncData 清空同步数据
-func ClearSyncData() error {
-	// 使用 model 方式删除所有同步记录
-	if err := app.DB.Unscoped().Where("1 = 1").Delete(&models.RepoSyncRecord{}).Error; err != nil {
+// ClearSyncData 清空方案的同步数据
+func ClearSyncData(profile string) error {
+	if err := app.DB.Where("profile = ?", profile).Delete(&models.RepoSyncRecord{}).Error; err != nil {
 		return fmt.Errorf("清空同步数据失败: %v", err)
 	}
 
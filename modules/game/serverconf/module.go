@@ -56,7 +56,7 @@ func (m *serverconfModule) AddAuthRouters() error {
 	})
 
 	// api
-	app.RouterAdminApi.Post("/game/serverlist", app.HasPermission("serverconf:update"), updateServerListHandler)
+	app.RouterAdminApi.Post("/game/serverlist", app.HasPermission("serverconf:update"), app.RequireApproval("serverconf:update", "修改服务器列表", "serverconf:update"), updateServerListHandler)
 	app.RouterAdminApi.Post("/game/lastserver", app.HasPermission("serverconf:update"), updateLastServerHandler)
 	app.RouterAdminApi.Post("/game/serverinfo", app.HasPermission("serverconf:update"), updateServerInfoHandler)
 	app.RouterAdminApi.Post("/game/noticelist", app.HasPermission("serverconf:update"), updateNoticeListHandler)
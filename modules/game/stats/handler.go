@@ -1,11 +1,14 @@
 package stats
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/andycai/goapi/models"
@@ -14,9 +17,9 @@ import (
 )
 
 // @Summary 创建统计记录（含图片）
-// @Description 创建新的统计记录，支持图片上传（base64编码）
+// @Description 创建新的统计记录。推荐使用 multipart/form-data：data 字段为 JSON 数据，pic 字段为截图文件；也兼容 JSON 中 base64 编码的 pic
 // @Tags stats
-// @Accept json
+// @Accept json,mpfd
 // @Produce json
 // @Param body body models.StatsRecord true "统计记录数据"
 // @Success 201 {object} models.StatsRecord
@@ -24,9 +27,47 @@ import (
 // @Failure 500 {object} map[string]string
 // @Router /api/stats [post]
 func CreateStats(c *fiber.Ctx) error {
-	var record models.StatsRecord
-	if err := c.BodyParser(&record); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+	var (
+		record models.StatsRecord
+		info   models.StatsInfo
+		pic    io.Reader
+	)
+
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		data := []byte(c.FormValue("data"))
+		if err := json.Unmarshal(data, &record); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+		if err := json.Unmarshal(data, &info); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+		info.Pic = ""
+
+		if file, err := c.FormFile("pic"); err == nil {
+			src, err := file.Open()
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot read image file"})
+			}
+			defer src.Close()
+			pic = src
+		}
+	} else {
+		if err := c.BodyParser(&record); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+		if err := c.BodyParser(&info); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
+		}
+
+		// 兼容旧客户端的 base64 图片
+		if info.Pic != "" {
+			imgData, err := base64.StdEncoding.DecodeString(info.Pic)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid base64 image data"})
+			}
+			pic = bytes.NewReader(imgData)
+			info.Pic = ""
+		}
 	}
 
 	// 设置创建时间
@@ -39,42 +80,23 @@ func CreateStats(c *fiber.Ctx) error {
 		}
 	}
 
-	var info models.StatsInfo
-	if err := c.BodyParser(&info); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot parse JSON"})
-	}
-
 	// 设置创建时间
 	info.CreatedAt = time.Now().UnixMilli()
 
 	tmp, _ := json.Marshal(&info.Process2)
 	info.Process = string(tmp)
 
-	// Handle the pic field
-	if info.Pic != "" {
-		// Decode base64 to image data
-		imgData, err := base64.StdEncoding.DecodeString(info.Pic)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid base64 image data"})
-		}
-
-		// Create directory if it doesn't exist
-		uploadDir := "./uploads/stats"
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create upload directory"})
-		}
-
-		// Generate a unique filename
-		filename := time.Now().Format("20060102150405") + ".jpg"
-		filePath := filepath.Join(uploadDir, filename)
-
-		// Save the image file
-		if err := os.WriteFile(filePath, imgData, 0644); err != nil {
+	// 截图按内容哈希保存到对象存储，相同截图只保存一份
+	if pic != nil {
+		key, err := saveStatsPic(c.Context(), pic)
+		switch {
+		case errors.Is(err, errPicTooLarge), errors.Is(err, errPicFormat):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save image file"})
 		}
-
-		// Update the pic field with the file path
-		info.Pic = filepath.Join("/uploads/stats", filename)
+		info.PicKey = key
+		info.Pic = app.Blob.URL(key)
 	}
 
 	if err := app.DB.Create(&info).Error; err != nil {
@@ -160,42 +182,17 @@ func deleteStatsBeforeHandler(c *fiber.Ctx) error {
 	// Convert date to milliseconds timestamp
 	timestamp := date.UnixNano() / int64(time.Millisecond)
 
-	// Delete from stats_record table
-	if err := app.DB.Where("created_at <= ?", timestamp).Delete(&models.StatsRecord{}).Error; err != nil {
+	// 删除统计数据，不再被引用的截图由定时任务清理
+	count, err := purgeStatsBefore(timestamp)
+	if err != nil {
 		fmt.Println("err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"code": 3, "error": "cannot delete stats records"})
 	}
 
-	// Fetch stats_info records to delete
-	var statsInfo []models.StatsInfo
-	if err := app.DB.Where("created_at <= ?", timestamp).Find(&statsInfo).Error; err != nil {
-		fmt.Println("err", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"code": 4, "error": "cannot fetch stats info records"})
-	}
-
-	// Delete images from file system
-	for _, info := range statsInfo {
-		picPath := filepath.Join("public", info.Pic)
-		fmt.Println("picPath", picPath)
-		if info.Pic != "" {
-			if err := os.Remove(picPath); err != nil {
-				fmt.Println("err", err)
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"code": 5, "error": "cannot delete image file"})
-			}
-		}
-	}
-
-	// Delete from stats_info table
-	result := app.DB.Where("created_at <= ?", timestamp).Delete(&models.StatsInfo{})
-	if result.Error != nil {
-		fmt.Println("err", result.Error)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"code": 6, "error": "cannot delete stats info records"})
-	}
-
 	// 记录操作日志
-	adminlog.WriteLog(c, "delete", "stats", 0, fmt.Sprintf("批量删除%s之前的统计记录，共%d条", dateStr, result.RowsAffected))
+	adminlog.WriteLog(c, "delete", "stats", 0, fmt.Sprintf("批量删除%s之前的统计记录，共%d条", dateStr, count))
 
-	return c.JSON(fiber.Map{"code": 0, "message": "records deleted successfully", "count": result.RowsAffected})
+	return c.JSON(fiber.Map{"code": 0, "message": "records deleted successfully", "count": count})
 }
 
 // @Summary 获取统计详情
@@ -259,22 +256,14 @@ func deleteStatHandler(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"code": 9, "error": "Failed to fetch associated stats info"})
 	}
 
-	// 删除关联的图片文件
-	for _, info := range statsInfoList {
-		if info.Pic != "" {
-			picPath := filepath.Join("public", info.Pic)
-			if err := os.Remove(picPath); err != nil {
-				// 如果删除失败，记录错误但继续执行
-				fmt.Printf("Failed to delete image file %s: %v\n", picPath, err)
-			}
-		}
-	}
-
 	// 删除关联的 StatsInfo 记录
 	if err := app.DB.Where("login_id = ?", statsRecord.LoginID).Delete(&models.StatsInfo{}).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"code": 10, "error": "Failed to delete associated stats info"})
 	}
 
+	// 删除旧版本的截图文件，对象存储中的截图由定时任务清理
+	releaseInfoPics(statsInfoList)
+
 	// 删除 StatsRecord
 	if err := app.DB.Delete(&statsRecord).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"code": 11, "error": "Failed to delete stat record"})
@@ -285,3 +274,331 @@ func deleteStatHandler(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"code": 0, "message": "Stat record, associated info, and image files deleted successfully"})
 }
+
+// perfStat 某一分组的性能统计
+type perfStat struct {
+	Key     string  `json:"key"`
+	Samples int64   `json:"samples"`
+	FPSAvg  float64 `json:"fps_avg"`
+	FPSP1   int     `json:"fps_p1"`
+	FPSP10  int     `json:"fps_p10"`
+	FPSP50  int     `json:"fps_p50"`
+	FPSP90  int     `json:"fps_p90"`
+	MemAvg  float64 `json:"mem_avg"`
+	MemP50  int     `json:"mem_p50"`
+	MemP90  int     `json:"mem_p90"`
+	MemP99  int     `json:"mem_p99"`
+
+	fpsSum  int64
+	memSum  int64
+	fpsHist histogram
+	memHist histogram
+}
+
+// perfCompare 两个版本的对比结果
+type perfCompare struct {
+	Key        string    `json:"key"`
+	Base       *perfStat `json:"base"`
+	Target     *perfStat `json:"target"`
+	FPSP50Diff int       `json:"fps_p50_diff"`
+	FPSP10Diff int       `json:"fps_p10_diff"`
+	MemP90Diff int       `json:"mem_p90_diff"`
+	Regressed  bool      `json:"regressed"`
+}
+
+// queryRollups 按时间范围和过滤条件读取汇总数据，默认最近7天，versions 不为空时只读取这些版本
+func queryRollups(c *fiber.Ctx, versions ...string) ([]models.StatsRollup, error) {
+	end := time.Now()
+	start := end.AddDate(0, 0, -7)
+
+	if s := c.Query("startDate"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid startDate")
+		}
+		start = t
+	}
+	if s := c.Query("endDate"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endDate")
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+
+	query := app.DB.Model(&models.StatsRollup{}).
+		Where("bucket >= ? AND bucket < ?", start.UnixMilli(), end.UnixMilli())
+
+	if appID := c.QueryInt("app_id", 0); appID > 0 {
+		query = query.Where("app_id = ?", appID)
+	}
+	if device := c.Query("device"); device != "" {
+		query = query.Where("device = ?", device)
+	}
+	if gpu := c.Query("gpu"); gpu != "" {
+		query = query.Where("gpu = ?", gpu)
+	}
+	if len(versions) > 0 {
+		query = query.Where("app_version IN ?", versions)
+	}
+
+	var rows []models.StatsRollup
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// rollupGroupKey 返回汇总行在指定维度下的分组值
+func rollupGroupKey(row *models.StatsRollup, groupBy, interval string) string {
+	switch groupBy {
+	case "device":
+		return row.Device
+	case "gpu":
+		return row.GPU
+	case "version":
+		return row.AppVersion
+	case "time":
+		t := time.UnixMilli(row.Bucket)
+		if interval == "day" {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:00")
+	}
+	return "all"
+}
+
+// aggregateRollups 按维度合并汇总行并计算分位数
+func aggregateRollups(rows []models.StatsRollup, groupBy, interval string) map[string]*perfStat {
+	stats := make(map[string]*perfStat)
+	for i := range rows {
+		key := rollupGroupKey(&rows[i], groupBy, interval)
+		stat := stats[key]
+		if stat == nil {
+			stat = &perfStat{Key: key, fpsHist: make(histogram), memHist: make(histogram)}
+			stats[key] = stat
+		}
+		stat.Samples += rows[i].Samples
+		stat.fpsSum += rows[i].FPSSum
+		stat.memSum += rows[i].MemSum
+		stat.fpsHist.merge(decodeHistogram(rows[i].FPSHist))
+		stat.memHist.merge(decodeHistogram(rows[i].MemHist))
+	}
+
+	for _, stat := range stats {
+		if stat.Samples > 0 {
+			stat.FPSAvg = float64(stat.fpsSum) / float64(stat.Samples)
+			stat.MemAvg = float64(stat.memSum) / float64(stat.Samples)
+		}
+		stat.FPSP1 = stat.fpsHist.percentile(1)
+		stat.FPSP10 = stat.fpsHist.percentile(10)
+		stat.FPSP50 = stat.fpsHist.percentile(50)
+		stat.FPSP90 = stat.fpsHist.percentile(90)
+		stat.MemP50 = stat.memHist.percentile(50) * memBinSize
+		stat.MemP90 = stat.memHist.percentile(90) * memBinSize
+		stat.MemP99 = stat.memHist.percentile(99) * memBinSize
+	}
+
+	return stats
+}
+
+// @Summary 性能分位数统计
+// @Description 按设备、显卡、版本或时间统计 FPS 和内存分位数
+// @Tags stats
+// @Produce json
+// @Param group_by query string false "分组维度 (device/gpu/version/time)" default(version)
+// @Param interval query string false "时间粒度 (hour/day)" default(day)
+// @Param version query string false "应用版本"
+// @Param startDate query string false "开始日期 (格式: YYYY-MM-DD)"
+// @Param endDate query string false "结束日期 (格式: YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /api/admin/stats/perf [get]
+func perfPercentilesHandler(c *fiber.Ctx) error {
+	groupBy := c.Query("group_by", "version")
+	interval := c.Query("interval", "day")
+
+	var versions []string
+	if version := c.Query("version"); version != "" {
+		versions = append(versions, version)
+	}
+	rows, err := queryRollups(c, versions...)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	grouped := aggregateRollups(rows, groupBy, interval)
+	list := make([]*perfStat, 0, len(grouped))
+	for _, stat := range grouped {
+		list = append(list, stat)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if groupBy == "time" || groupBy == "version" {
+			return list[i].Key < list[j].Key
+		}
+		return list[i].Samples > list[j].Samples
+	})
+
+	return c.JSON(fiber.Map{
+		"group_by": groupBy,
+		"interval": interval,
+		"stats":    list,
+	})
+}
+
+// @Summary 版本性能对比
+// @Description 对比两个版本的 FPS 和内存分位数，标记性能退化的分组
+// @Tags stats
+// @Produce json
+// @Param base query string true "基准版本"
+// @Param target query string true "对比版本"
+// @Param group_by query string false "分组维度 (device/gpu)"
+// @Param threshold query number false "退化阈值（百分比）" default(5)
+// @Param min_samples query int false "最少采样数" default(30)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /api/admin/stats/perf/compare [get]
+func perfCompareHandler(c *fiber.Ctx) error {
+	base := c.Query("base")
+	target := c.Query("target")
+	if base == "" || target == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "base and target are required"})
+	}
+	groupBy := c.Query("group_by")
+	threshold := c.QueryFloat("threshold", 5)
+	minSamples := int64(c.QueryInt("min_samples", 30))
+
+	rows, err := queryRollups(c, base, target)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var baseRows, targetRows []models.StatsRollup
+	for _, row := range rows {
+		switch row.AppVersion {
+		case base:
+			baseRows = append(baseRows, row)
+		case target:
+			targetRows = append(targetRows, row)
+		}
+	}
+
+	baseStats := aggregateRollups(baseRows, groupBy, "")
+	targetStats := aggregateRollups(targetRows, groupBy, "")
+
+	list := make([]*perfCompare, 0)
+	regressed := 0
+	for key, b := range baseStats {
+		t, ok := targetStats[key]
+		if !ok || b.Samples < minSamples || t.Samples < minSamples {
+			continue
+		}
+		item := &perfCompare{
+			Key:        key,
+			Base:       b,
+			Target:     t,
+			FPSP50Diff: t.FPSP50 - b.FPSP50,
+			FPSP10Diff: t.FPSP10 - b.FPSP10,
+			MemP90Diff: t.MemP90 - b.MemP90,
+		}
+		ratio := threshold / 100
+		item.Regressed = float64(t.FPSP50) < float64(b.FPSP50)*(1-ratio) ||
+			float64(t.FPSP10) < float64(b.FPSP10)*(1-ratio) ||
+			float64(t.MemP90) > float64(b.MemP90)*(1+ratio)
+		if item.Regressed {
+			regressed++
+		}
+		list = append(list, item)
+	}
+
+	// 退化的分组排在前面，其次按 FPS 中位数下降幅度排序
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Regressed != list[j].Regressed {
+			return list[i].Regressed
+		}
+		return list[i].FPSP50Diff < list[j].FPSP50Diff
+	})
+
+	return c.JSON(fiber.Map{
+		"base":      base,
+		"target":    target,
+		"group_by":  groupBy,
+		"regressed": regressed,
+		"items":     list,
+	})
+}
+
+// @Summary 性能最差设备
+// @Description 按 FPS 低分位或内存高分位排序，返回表现最差的设备或显卡
+// @Tags stats
+// @Produce json
+// @Param metric query string false "指标 (fps/memory)" default(fps)
+// @Param group_by query string false "分组维度 (device/gpu)" default(device)
+// @Param version query string false "应用版本"
+// @Param limit query int false "返回数量" default(10)
+// @Param min_samples query int false "最少采样数" default(30)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /api/admin/stats/perf/worst [get]
+func perfWorstHandler(c *fiber.Ctx) error {
+	metric := c.Query("metric", "fps")
+	groupBy := c.Query("group_by", "device")
+	if groupBy != "device" && groupBy != "gpu" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "group_by must be device or gpu"})
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	minSamples := int64(c.QueryInt("min_samples", 30))
+
+	var versions []string
+	if version := c.Query("version"); version != "" {
+		versions = append(versions, version)
+	}
+	rows, err := queryRollups(c, versions...)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	list := make([]*perfStat, 0)
+	for _, stat := range aggregateRollups(rows, groupBy, "") {
+		if stat.Samples >= minSamples {
+			list = append(list, stat)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if metric == "memory" {
+			return list[i].MemP90 > list[j].MemP90
+		}
+		return list[i].FPSP10 < list[j].FPSP10
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	return c.JSON(fiber.Map{
+		"metric":   metric,
+		"group_by": groupBy,
+		"items":    list,
+	})
+}
+
+// @Summary 立即执行性能汇总
+// @Description 手动触发一次性能数据汇总
+// @Tags stats
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/stats/perf/rollup [post]
+func perfRollupHandler(c *fiber.Ctx) error {
+	if err := runRollup(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "rollup failed"})
+	}
+
+	adminlog.WriteLog(c, "rollup", "stats", 0, "手动执行性能数据汇总")
+
+	return c.JSON(fiber.Map{"code": 0, "message": "rollup completed"})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/andycai/goapi/enum"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
 	"gorm.io/gorm"
 )
 
@@ -16,9 +17,44 @@ func autoMigrate() error {
 	return app.DB.AutoMigrate(
 		&models.StatsRecord{},
 		&models.StatsInfo{},
+		&models.StatsRollup{},
+		&models.StatsRollupState{},
 	)
 }
 
+// registerRetention 注册数据保留对象，只清理已经汇总过的采样数据
+func registerRetention() {
+	retention.RegisterTarget(retention.Target{
+		Name:        "stats",
+		Title:       "游戏统计",
+		DefaultDays: 30,
+		Tables: []retention.Table{
+			{
+				Model:      &models.StatsInfo{},
+				TimeColumn: "created_at",
+				Millis:     true,
+				Ready: func(db *gorm.DB) *gorm.DB {
+					return db.Where("id <= (SELECT last_info_id FROM stats_rollup_states WHERE id = 1)")
+				},
+				AfterDelete: func(tx *gorm.DB, rows any) error {
+					releaseInfoPics(*rows.(*[]models.StatsInfo))
+					return nil
+				},
+			},
+			{
+				Model:      &models.StatsRecord{},
+				TimeColumn: "created_at",
+				Millis:     true,
+				Ready: func(db *gorm.DB) *gorm.DB {
+					// 会话下的采样数据全部清理后才删除会话
+					return db.Where("NOT EXISTS (SELECT 1 FROM stats_infos WHERE stats_infos.login_id = stats_records.login_id)")
+				},
+			},
+			{Model: &models.StatsRollup{}, TimeColumn: "bucket", Millis: true, Aggregate: true},
+		},
+	})
+}
+
 // 初始化数据
 func initData() error {
 	if err := initMenus(); err != nil {
@@ -24,12 +24,25 @@ func (m *statsModule) Awake(a *internal.App) error {
 		return err
 	}
 
+	// 数据保留
+	registerRetention()
+
 	return nil
 }
 
 func (m *statsModule) Start() error {
 	// 初始化数据
-	return initData()
+	if err := initData(); err != nil {
+		return err
+	}
+
+	// 启动性能数据汇总和过期数据清理任务
+	return initJobs()
+}
+
+func (m *statsModule) Dispose() error {
+	stopJobs()
+	return nil
 }
 
 func (m *statsModule) AddPublicRouters() error {
@@ -43,6 +56,10 @@ func (m *statsModule) AddAuthRouters() error {
 	app.RouterAdminApi.Get("/stats", app.HasPermission("stats:view"), listStatsHandler)
 	app.RouterAdminApi.Delete("/stats/before", app.HasPermission("stats:delete"), deleteStatsBeforeHandler)
 	app.RouterAdminApi.Get("/stats/details", app.HasPermission("stats:view"), getStatDetailsHandler)
+	app.RouterAdminApi.Get("/stats/perf", app.HasPermission("stats:view"), perfPercentilesHandler)
+	app.RouterAdminApi.Get("/stats/perf/compare", app.HasPermission("stats:view"), perfCompareHandler)
+	app.RouterAdminApi.Get("/stats/perf/worst", app.HasPermission("stats:view"), perfWorstHandler)
+	app.RouterAdminApi.Post("/stats/perf/rollup", app.HasPermission("stats:delete"), perfRollupHandler)
 	app.RouterAdminApi.Delete("/stats/:id", app.HasPermission("stats:delete"), deleteStatHandler)
 
 	// admin
@@ -0,0 +1,285 @@
+package stats
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	rollupSchedule  = "@every 5m" // 汇总任务执行周期
+	sweepSchedule   = "@daily"    // 过期数据清理周期
+	rollupBatchSize = 5000        // 每批读取的采样数
+	memBinSize      = 16          // 内存直方图分桶宽度
+
+	// rollupOrphanAge 设备信息缺失的采样等待设备信息写入的时间，超过后计入未知设备
+	rollupOrphanAge = time.Hour
+)
+
+var (
+	statsCron   *cron.Cron
+	rollupMutex sync.Mutex
+)
+
+// histogram 稀疏直方图，key 为分桶下标，可直接相加合并
+type histogram map[int]int64
+
+func decodeHistogram(data string) histogram {
+	h := make(histogram)
+	if data == "" {
+		return h
+	}
+	var raw map[string]int64
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return h
+	}
+	for k, v := range raw {
+		if bin, err := strconv.Atoi(k); err == nil {
+			h[bin] += v
+		}
+	}
+	return h
+}
+
+func (h histogram) encode() string {
+	data, _ := json.Marshal(h)
+	return string(data)
+}
+
+func (h histogram) merge(other histogram) {
+	for bin, count := range other {
+		h[bin] += count
+	}
+}
+
+// percentile 返回第 p 百分位所在分桶的下标
+func (h histogram) percentile(p float64) int {
+	var total int64
+	bins := make([]int, 0, len(h))
+	for bin, count := range h {
+		total += count
+		bins = append(bins, bin)
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(bins)
+
+	rank := int64(p / 100 * float64(total))
+	if rank >= total {
+		rank = total - 1
+	}
+	var seen int64
+	for _, bin := range bins {
+		seen += h[bin]
+		if seen > rank {
+			return bin
+		}
+	}
+	return bins[len(bins)-1]
+}
+
+// rollupKey 汇总维度
+type rollupKey struct {
+	Bucket     int64
+	AppID      int
+	AppVersion string
+	Device     string
+	GPU        string
+}
+
+// rollupAcc 单个维度的累加结果
+type rollupAcc struct {
+	Samples int64
+	FPSSum  int64
+	MemSum  int64
+	FPSHist histogram
+	MemHist histogram
+}
+
+// initJobs 启动定时汇总和清理任务
+func initJobs() error {
+	statsCron = cron.New()
+	if _, err := statsCron.AddFunc(rollupSchedule, func() {
+		if err := runRollup(); err != nil {
+			log.Printf("[统计模块]性能数据汇总失败: %v", err)
+		}
+	}); err != nil {
+		return err
+	}
+	if _, err := statsCron.AddFunc(sweepSchedule, sweepStats); err != nil {
+		return err
+	}
+	statsCron.Start()
+
+	// 启动时先补齐一次
+	go func() {
+		if err := runRollup(); err != nil {
+			log.Printf("[统计模块]性能数据汇总失败: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// stopJobs 停止定时任务
+func stopJobs() {
+	if statsCron != nil {
+		<-statsCron.Stop().Done()
+	}
+}
+
+// runRollup 将新增的采样数据按小时汇总到 stats_rollups
+func runRollup() error {
+	rollupMutex.Lock()
+	defer rollupMutex.Unlock()
+
+	var state models.StatsRollupState
+	if err := app.DB.FirstOrCreate(&state, models.StatsRollupState{ID: 1}).Error; err != nil {
+		return err
+	}
+
+	records := make(map[int64]*models.StatsRecord)
+	for {
+		var infos []models.StatsInfo
+		if err := app.DB.Select("id", "login_id", "fps", "used_mem", "created_at").
+			Where("id > ?", state.LastInfoID).
+			Order("id").Limit(rollupBatchSize).
+			Find(&infos).Error; err != nil {
+			return err
+		}
+		if len(infos) == 0 {
+			return nil
+		}
+
+		if err := loadRollupRecords(infos, records); err != nil {
+			return err
+		}
+
+		// 设备信息还没有写入的采样留到下次汇总，汇总进度不越过这些采样，数据保留模块也不会删除它们
+		pending := false
+		for i, info := range infos {
+			if records[info.LoginID] == nil && time.Since(time.UnixMilli(info.CreatedAt)) < rollupOrphanAge {
+				infos, pending = infos[:i], true
+				break
+			}
+		}
+		if len(infos) == 0 {
+			return nil
+		}
+
+		accs := make(map[rollupKey]*rollupAcc)
+		for _, info := range infos {
+			record := records[info.LoginID]
+			if record == nil {
+				// 设备信息一直没有写入或已被删除，计入未知设备，不丢弃采样
+				record = &models.StatsRecord{}
+			}
+			key := rollupKey{
+				Bucket:     time.UnixMilli(info.CreatedAt).Truncate(time.Hour).UnixMilli(),
+				AppID:      record.AppID,
+				AppVersion: truncate(record.AppVersion, 50),
+				Device:     truncate(record.Device, 128),
+				GPU:        truncate(record.GPU, 128),
+			}
+			acc := accs[key]
+			if acc == nil {
+				acc = &rollupAcc{FPSHist: make(histogram), MemHist: make(histogram)}
+				accs[key] = acc
+			}
+			acc.Samples++
+			acc.FPSSum += int64(info.FPS)
+			acc.MemSum += int64(info.UsedMem)
+			acc.FPSHist[info.FPS]++
+			acc.MemHist[info.UsedMem/memBinSize]++
+		}
+
+		state.LastInfoID = infos[len(infos)-1].ID
+		if err := app.DB.Transaction(func(tx *gorm.DB) error {
+			for key, acc := range accs {
+				if err := mergeRollup(tx, key, acc); err != nil {
+					return err
+				}
+			}
+			return tx.Save(&state).Error
+		}); err != nil {
+			return err
+		}
+
+		if pending || len(infos) < rollupBatchSize {
+			return nil
+		}
+	}
+}
+
+// loadRollupRecords 加载采样数据对应的设备信息
+func loadRollupRecords(infos []models.StatsInfo, records map[int64]*models.StatsRecord) error {
+	var missing []int64
+	for _, info := range infos {
+		if _, ok := records[info.LoginID]; !ok {
+			missing = append(missing, info.LoginID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var list []models.StatsRecord
+	if err := app.DB.Where("login_id IN ?", missing).Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		records[list[i].LoginID] = &list[i]
+	}
+	return nil
+}
+
+// mergeRollup 将累加结果合并到已有的汇总行
+func mergeRollup(tx *gorm.DB, key rollupKey, acc *rollupAcc) error {
+	var row models.StatsRollup
+	err := tx.Where("bucket = ? AND app_id = ? AND app_version = ? AND device = ? AND gpu = ?",
+		key.Bucket, key.AppID, key.AppVersion, key.Device, key.GPU).
+		First(&row).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		row = models.StatsRollup{
+			Bucket:     key.Bucket,
+			AppID:      key.AppID,
+			AppVersion: key.AppVersion,
+			Device:     key.Device,
+			GPU:        key.GPU,
+		}
+	}
+
+	fpsHist := decodeHistogram(row.FPSHist)
+	fpsHist.merge(acc.FPSHist)
+	memHist := decodeHistogram(row.MemHist)
+	memHist.merge(acc.MemHist)
+
+	row.Samples += acc.Samples
+	row.FPSSum += acc.FPSSum
+	row.MemSum += acc.MemSum
+	row.FPSHist = fpsHist.encode()
+	row.MemHist = memHist.encode()
+
+	return tx.Save(&row).Error
+}
+
+// truncate 截断过长的维度值，避免超出索引列长度
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
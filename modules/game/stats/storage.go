@@ -0,0 +1,154 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/blob"
+)
+
+const (
+	statsPicPrefix  = "stats"           // 截图在对象存储中的前缀
+	statsPicMaxSize = 10 << 20          // 单张截图大小上限
+	orphanPicMinAge = 24 * time.Hour    // 未被引用的截图保留时间，避免误删刚上传或刚被复用的文件
+	legacyPicPrefix = "/uploads/stats/" // 旧版本直接写入磁盘的截图路径
+)
+
+var (
+	errPicTooLarge = errors.New("image too large")
+	errPicFormat   = errors.New("invalid image format")
+)
+
+// saveStatsPic 校验截图并按内容哈希保存，返回对象键
+func saveStatsPic(ctx context.Context, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, statsPicMaxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > statsPicMaxSize {
+		return "", errPicTooLarge
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", errPicFormat
+	}
+
+	ext, contentType := ".jpg", "image/jpeg"
+	if format == "png" {
+		ext, contentType = ".png", "image/png"
+	}
+
+	return blob.PutContent(ctx, app.Blob, statsPicPrefix, bytes.NewReader(data), ext, contentType)
+}
+
+// removeLegacyPic 删除旧版本保存在 uploads/stats 下的截图
+func removeLegacyPic(pic string) {
+	pic = strings.ReplaceAll(pic, "\\", "/")
+	if !strings.HasPrefix(pic, legacyPicPrefix) {
+		return
+	}
+
+	picPath := filepath.Join(".", filepath.FromSlash(pic))
+	if err := os.Remove(picPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("[统计模块]删除截图失败 %s: %v", picPath, err)
+	}
+}
+
+// purgeStatsBefore 删除指定时间（毫秒）之前的统计数据，返回删除的采样数
+// 对象存储中的截图可能正被新上传的相同截图复用，不在这里删除，由 sweepOrphanPics 按修改时间清理
+func purgeStatsBefore(timestamp int64) (int64, error) {
+	var legacy []string
+	if err := app.DB.Model(&models.StatsInfo{}).
+		Where("created_at <= ? AND pic <> '' AND (pic_key = '' OR pic_key IS NULL)", timestamp).
+		Pluck("pic", &legacy).Error; err != nil {
+		return 0, err
+	}
+
+	if err := app.DB.Where("created_at <= ?", timestamp).Delete(&models.StatsRecord{}).Error; err != nil {
+		return 0, err
+	}
+
+	result := app.DB.Where("created_at <= ?", timestamp).Delete(&models.StatsInfo{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	// 数据删除成功后再清理旧版本的截图文件
+	for _, pic := range legacy {
+		removeLegacyPic(pic)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// releaseInfoPics 删除一批统计数据后清理旧版本的截图文件，对象存储中的截图由 sweepOrphanPics 清理
+func releaseInfoPics(infos []models.StatsInfo) {
+	for _, info := range infos {
+		if info.PicKey == "" && info.Pic != "" {
+			removeLegacyPic(info.Pic)
+		}
+	}
+}
+
+// sweepStats 删除没有被引用的截图，过期数据由数据保留策略清理
+func sweepStats() {
+	if err := sweepOrphanPics(); err != nil {
+		log.Printf("[统计模块]清理无引用截图失败: %v", err)
+	}
+}
+
+// sweepOrphanPics 删除对象存储中没有被任何统计数据引用的截图
+func sweepOrphanPics() error {
+	ctx := context.Background()
+	objects, err := app.Blob.List(ctx, statsPicPrefix+"/")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(-orphanPicMinAge)
+	var candidates []string
+	for _, obj := range objects {
+		// 只处理 stats/ab/<hash>.jpg 形式的对象，旧版本的截图由 removeLegacyPic 清理
+		if strings.Count(obj.Key, "/") == 2 && obj.ModTime.Before(deadline) {
+			candidates = append(candidates, obj.Key)
+		}
+	}
+
+	for start := 0; start < len(candidates); start += 500 {
+		end := min(start+500, len(candidates))
+		chunk := candidates[start:end]
+
+		var used []string
+		if err := app.DB.Model(&models.StatsInfo{}).Where("pic_key IN ?", chunk).
+			Distinct().Pluck("pic_key", &used).Error; err != nil {
+			return err
+		}
+		usedSet := make(map[string]bool, len(used))
+		for _, key := range used {
+			usedSet[key] = true
+		}
+
+		for _, key := range chunk {
+			if usedSet[key] {
+				continue
+			}
+			if err := app.Blob.Delete(ctx, key); err != nil {
+				log.Printf("[统计模块]删除截图失败 %s: %v", key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,11 +1,13 @@
 package unibuild
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 
+	"github.com/andycai/goapi/pkg/vcs"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -20,20 +22,17 @@ type UnityBuildConfig struct {
 	LogFilePath  string
 }
 
-// executeSVNOperations 执行SVN操作
+// executeSVNOperations 执行SVN操作，撤销本地修改后更新到最新版本
 func executeSVNOperations(projectPath string) error {
-	// SVN revert
-	revertCmd := exec.Command("svn", "revert", "-R", projectPath)
-	output, err := revertCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("svn revert failed: %v\nOutput: %s", err, string(output))
+	client := vcs.NewSVN(projectPath, vcs.Auth{})
+	ctx := context.Background()
+
+	if err := client.Revert(ctx); err != nil {
+		return fmt.Errorf("svn revert failed: %v", err)
 	}
 
-	// SVN update
-	updateCmd := exec.Command("svn", "update", projectPath)
-	output, err = updateCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("svn update failed: %v\nOutput: %s", err, string(output))
+	if err := client.Update(ctx); err != nil {
+		return fmt.Errorf("svn update failed: %v", err)
 	}
 
 	return nil
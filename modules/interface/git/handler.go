@@ -1,6 +1,9 @@
 package git
 
 import (
+	"errors"
+
+	"github.com/andycai/goapi/pkg/vcs"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -157,7 +160,7 @@ func commitHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	err := Commit(req.Path, req.Message)
+	revision, err := Commit(req.Path, req.Message)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -165,7 +168,8 @@ func commitHandler(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Changes committed successfully",
+		"message":  "Changes committed successfully",
+		"revision": revision,
 	})
 }
 
@@ -303,3 +307,113 @@ func stashHandler(c *fiber.Ctx) error {
 		"message": "Stash operation successful",
 	})
 }
+
+// browseError maps repository browser errors to HTTP responses
+func browseError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	if errors.Is(err, vcs.ErrNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// treeHandler lists a directory of the Git repository at a revision
+func treeHandler(c *fiber.Ctx) error {
+	path := c.Query("path")
+	if path == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Path is required",
+		})
+	}
+
+	entries, err := Tree(path, c.Query("rev"), c.Query("dir"))
+	if err != nil {
+		return browseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"rev":     c.Query("rev"),
+		"dir":     c.Query("dir"),
+		"entries": entries,
+	})
+}
+
+// fileHandler returns a file of the Git repository at a revision
+func fileHandler(c *fiber.Ctx) error {
+	path, file := c.Query("path"), c.Query("file")
+	if path == "" || file == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Path and file are required",
+		})
+	}
+
+	content, err := File(path, c.Query("rev"), file)
+	if err != nil {
+		return browseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"file": content,
+	})
+}
+
+// historyHandler returns the commits touching a file of the Git repository
+func historyHandler(c *fiber.Ctx) error {
+	path := c.Query("path")
+	if path == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Path is required",
+		})
+	}
+
+	commits, err := History(path, c.Query("rev"), c.Query("file"), c.QueryInt("limit", 20))
+	if err != nil {
+		return browseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"commits": commits,
+	})
+}
+
+// blameHandler returns the line-by-line blame of a file of the Git repository
+func blameHandler(c *fiber.Ctx) error {
+	path, file := c.Query("path"), c.Query("file")
+	if path == "" || file == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Path and file are required",
+		})
+	}
+
+	lines, err := Blame(path, c.Query("rev"), file)
+	if err != nil {
+		return browseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"lines": lines,
+	})
+}
+
+// diffHandler returns the per-file diff between two revisions of the Git repository
+func diffHandler(c *fiber.Ctx) error {
+	path, to := c.Query("path"), c.Query("to")
+	if path == "" || to == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Path and to are required",
+		})
+	}
+
+	files, err := Diff(path, c.Query("from"), to, c.Query("file"))
+	if err != nil {
+		return browseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"from":  c.Query("from"),
+		"to":    to,
+		"files": files,
+	})
+}
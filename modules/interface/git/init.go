@@ -22,6 +22,10 @@ func initData() error {
 		return err
 	}
 
+	if err := initBrowsePermissions(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -136,3 +140,38 @@ func initPermissions() error {
 		return nil
 	})
 }
+
+func initBrowsePermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("git:permission:browse") {
+		log.Println("[git模块]浏览权限已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		permission := models.Permission{
+			Name:        "Git浏览",
+			Code:        "git:browse",
+			Description: "浏览Git仓库的文件、历史、追溯和差异",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(&permission).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "git:permission:browse",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
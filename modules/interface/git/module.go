@@ -23,13 +23,7 @@ func (m *gitModule) Awake(a *internal.App) error {
 }
 
 func (m *gitModule) Start() error {
-	if err := initData(); err != nil {
-		return err
-	}
-
-	// Initialize Git service
-	initService()
-	return nil
+	return initData()
 }
 
 func (m *gitModule) AddAuthRouters() error {
@@ -38,6 +32,7 @@ func (m *gitModule) AddAuthRouters() error {
 		return c.Render("admin/git", fiber.Map{
 			"Title": "Git管理",
 			"Scripts": []string{
+				"/static/js/admin/repobrowser.js",
 				"/static/js/admin/git.js",
 			},
 		}, "admin/layout")
@@ -56,5 +51,12 @@ func (m *gitModule) AddAuthRouters() error {
 	app.RouterAdminApi.Post("/git/reset", app.HasPermission("git:reset"), resetHandler)
 	app.RouterAdminApi.Post("/git/stash", app.HasPermission("git:stash"), stashHandler)
 
+	// repository browser
+	app.RouterAdminApi.Get("/git/tree", app.HasPermission("git:browse"), treeHandler)
+	app.RouterAdminApi.Get("/git/file", app.HasPermission("git:browse"), fileHandler)
+	app.RouterAdminApi.Get("/git/history", app.HasPermission("git:browse"), historyHandler)
+	app.RouterAdminApi.Get("/git/blame", app.HasPermission("git:browse"), blameHandler)
+	app.RouterAdminApi.Get("/git/diff", app.HasPermission("git:browse"), diffHandler)
+
 	return nil
 }
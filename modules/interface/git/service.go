@@ -2,18 +2,15 @@ package git
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
-)
-
-var gitCmd string
 
-func initService() {
-	gitCmd = getGitCommand()
-}
+	"github.com/andycai/goapi/pkg/vcs"
+)
 
 // getGitCommand returns the appropriate Git command based on the OS
 func getGitCommand() string {
@@ -53,135 +50,117 @@ func isValidGitPath(path string) bool {
 	return true
 }
 
-// ExecGitCommand executes a Git command and returns its output
-func ExecGitCommand(dir string, args ...string) (string, error) {
-	cmd := exec.Command(gitCmd, args...)
-	if dir != "" {
-		cmd.Dir = dir
-	}
+// execGitCommand executes a Git command, only used for operations go-git does not support (merge, stash)
+func execGitCommand(dir string, args ...string) error {
+	cmd := exec.Command(getGitCommand(), args...)
+	cmd.Dir = dir
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
-	if err != nil {
-		return "", fmt.Errorf("Git command failed: %v\nError: %s", err, stderr.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Git command failed: %v\nError: %s", err, stderr.String())
 	}
 
-	return stdout.String(), nil
+	return nil
 }
 
-// Clone performs Git clone operation
-func Clone(url, path, branch string, username, password string) error {
+// newClient creates a Git client for the repository at path
+func newClient(path string, auth vcs.Auth) (*vcs.Git, error) {
 	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+		return nil, fmt.Errorf("invalid path: %s", path)
 	}
+	return vcs.NewGit(path, auth), nil
+}
 
-	args := []string{"clone"}
-	if branch != "" {
-		args = append(args, "-b", branch)
+// Clone performs Git clone operation
+func Clone(url, path, branch string, username, password string) error {
+	// password may be a secret:// reference into the credential vault
+	password, err := app.Vault.Resolve(password, "git:clone:"+url)
+	if err != nil {
+		return err
 	}
 
-	if username != "" && password != "" {
-		// Insert credentials into URL
-		url = strings.Replace(url, "https://", fmt.Sprintf("https://%s:%s@", username, password), 1)
+	client, err := newClient(path, vcs.Auth{Username: username, Password: password})
+	if err != nil {
+		return err
 	}
 
-	args = append(args, url, path)
-	_, err := ExecGitCommand("", args...)
-	return err
+	return client.Clone(context.Background(), url, branch)
 }
 
 // Pull performs Git pull operation
 func Pull(path string) error {
-	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
 
-	_, err := ExecGitCommand(path, "pull")
-	return err
+	return client.Pull(context.Background())
 }
 
 // Push performs Git push operation
 func Push(path string, branch string) error {
-	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
-	}
-
-	args := []string{"push"}
-	if branch != "" {
-		args = append(args, "origin", branch)
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
 
-	_, err := ExecGitCommand(path, args...)
-	return err
+	return client.Push(context.Background(), branch)
 }
 
 // Status gets Git repository status
-func Status(path string) (string, error) {
-	if !isValidGitPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
+func Status(path string) ([]vcs.FileStatus, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
 	}
 
-	return ExecGitCommand(path, "status")
+	return client.Status(context.Background())
 }
 
 // Log gets Git commit history
-func Log(path string, limit int) (string, error) {
-	if !isValidGitPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
-	}
-
-	args := []string{"log", "--oneline"}
-	if limit > 0 {
-		args = append(args, fmt.Sprintf("-%d", limit))
+func Log(path string, limit int) ([]vcs.Commit, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
 	}
 
-	return ExecGitCommand(path, args...)
+	return client.Log(context.Background(), limit)
 }
 
-// Commit performs Git commit operation
-func Commit(path, message string) error {
-	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+// Commit commits the staged changes and returns the new commit hash
+func Commit(path, message string) (string, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return "", err
 	}
 
-	_, err := ExecGitCommand(path, "commit", "-m", message)
-	return err
+	return client.CommitLocal(context.Background(), message)
 }
 
-// Checkout performs Git checkout operation
+// Checkout switches to branch, creating it first if create is true
 func Checkout(path, branch string, create bool) error {
-	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
-	}
-
-	args := []string{"checkout"}
-	if create {
-		args = append(args, "-b")
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
-	args = append(args, branch)
 
-	_, err := ExecGitCommand(path, args...)
-	return err
+	return client.Switch(context.Background(), branch, create)
 }
 
-// Branch performs Git branch operations
-func Branch(path string, create bool, name string) (string, error) {
-	if !isValidGitPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
+// Branch creates a branch when create is set, otherwise lists local branches
+func Branch(path string, create bool, name string) ([]vcs.Branch, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
 	}
 
-	args := []string{"branch"}
 	if create && name != "" {
-		args = append(args, name)
-		_, err := ExecGitCommand(path, args...)
-		return "", err
+		return nil, client.CreateBranch(context.Background(), name)
 	}
 
-	// List branches if not creating
-	return ExecGitCommand(path, args...)
+	return client.Branches(context.Background())
 }
 
 // Merge performs Git merge operation
@@ -190,23 +169,17 @@ func Merge(path, branch string) error {
 		return fmt.Errorf("invalid path: %s", path)
 	}
 
-	_, err := ExecGitCommand(path, "merge", branch)
-	return err
+	return execGitCommand(path, "merge", branch)
 }
 
 // Reset performs Git reset operation
 func Reset(path string, hard bool) error {
-	if !isValidGitPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
-	}
-
-	args := []string{"reset"}
-	if hard {
-		args = append(args, "--hard")
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
 
-	_, err := ExecGitCommand(path, args...)
-	return err
+	return client.Reset(context.Background(), hard)
 }
 
 // Stash performs Git stash operations
@@ -220,6 +193,62 @@ func Stash(path string, pop bool) error {
 		args = append(args, "pop")
 	}
 
-	_, err := ExecGitCommand(path, args...)
-	return err
+	return execGitCommand(path, args...)
+}
+
+// maxFileSize limits the file content returned by the repository browser
+const maxFileSize = 1 << 20
+
+// Tree lists the entries of dir at rev, rev defaults to the latest revision
+func Tree(path, rev, dir string) ([]vcs.Entry, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Tree(context.Background(), rev, dir)
+}
+
+// File reads a file at rev with its syntax type, binary files have no content
+func File(path, rev, file string) (*vcs.FileContent, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
+
+	return vcs.ReadContent(context.Background(), client, rev, file, maxFileSize)
+}
+
+// History gets the commits touching file (or directory) up to rev
+func History(path, rev, file string, limit int) ([]vcs.Commit, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.History(context.Background(), rev, file, limit)
+}
+
+// Blame gets the last change of every line of file at rev
+func Blame(path, rev, file string) ([]vcs.BlameLine, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Blame(context.Background(), rev, file)
+}
+
+// Diff gets the per-file unified diff between two revisions, from defaults to the parent of to
+func Diff(path, from, to, file string) ([]vcs.FileDiff, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if file != "" {
+		paths = append(paths, file)
+	}
+	return client.DiffFiles(context.Background(), from, to, paths...)
 }
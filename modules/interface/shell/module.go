@@ -27,9 +27,9 @@ func (m *shellModule) Awake(a *internal.App) error {
 	return initData()
 }
 
-func (m *shellModule) AddPublicRouters() error {
-	// public
-	app.RouterPublicApi.Post("/shell", execScriptHandler)
+func (m *shellModule) AddAuthRouters() error {
+	// api，脚本调用方使用 API 令牌认证
+	app.RouterAdminApi.Post("/shell", app.HasPermission("shell:execute"), app.RequireApproval("shell:exec", "执行 Shell 脚本", "shell:execute"), execScriptHandler)
 
 	return nil
 }
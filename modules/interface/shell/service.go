@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -45,6 +46,9 @@ func ExecShell(c *fiber.Ctx, dir string) error {
 		return fmt.Errorf("script file not found: %s", config.Path)
 	}
 
+	// 记录操作日志
+	adminlog.WriteLog(c, "run", "shell", 0, fmt.Sprintf("执行脚本：%s", form.Name))
+
 	// 获取绝对路径
 	absPath, err := filepath.Abs(config.Path)
 	if err != nil {
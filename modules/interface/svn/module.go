@@ -23,13 +23,7 @@ func (m *svnModule) Awake(a *internal.App) error {
 }
 
 func (m *svnModule) Start() error {
-	if err := initData(); err != nil {
-		return err
-	}
-
-	// Initialize SVN service
-	initService()
-	return nil
+	return initData()
 }
 
 func (m *svnModule) AddAuthRouters() error {
@@ -38,6 +32,7 @@ func (m *svnModule) AddAuthRouters() error {
 		return c.Render("admin/svn", fiber.Map{
 			"Title": "SVN管理",
 			"Scripts": []string{
+				"/static/js/admin/repobrowser.js",
 				"/static/js/admin/svn.js",
 			},
 		}, "admin/layout")
@@ -54,5 +49,12 @@ func (m *svnModule) AddAuthRouters() error {
 	app.RouterAdminApi.Post("/svn/add", app.HasPermission("svn:add"), addHandler)
 	app.RouterAdminApi.Delete("/svn/delete", app.HasPermission("svn:delete"), deleteHandler)
 
+	// repository browser
+	app.RouterAdminApi.Get("/svn/tree", app.HasPermission("svn:browse"), treeHandler)
+	app.RouterAdminApi.Get("/svn/file", app.HasPermission("svn:browse"), fileHandler)
+	app.RouterAdminApi.Get("/svn/history", app.HasPermission("svn:browse"), historyHandler)
+	app.RouterAdminApi.Get("/svn/blame", app.HasPermission("svn:browse"), blameHandler)
+	app.RouterAdminApi.Get("/svn/diff", app.HasPermission("svn:browse"), diffHandler)
+
 	return nil
 }
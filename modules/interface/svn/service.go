@@ -1,27 +1,14 @@
 package svn
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
-)
-
-var svnCmd string
 
-func initService() {
-	svnCmd = getSvnCommand()
-}
-
-// getSvnCommand returns the appropriate Svn command based on the OS
-func getSvnCommand() string {
-	if runtime.GOOS == "windows" {
-		return "svn.exe"
-	}
-	return "svn"
-}
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/pkg/vcs"
+)
 
 // isValidSvnPath checks if the path is safe for Svn operations
 func isValidSvnPath(path string) bool {
@@ -53,115 +40,218 @@ func isValidSvnPath(path string) bool {
 	return true
 }
 
-// execSvnCommand executes an Svn command and returns its output
-func execSvnCommand(args ...string) (string, error) {
-	cmd := exec.Command(svnCmd, args...)
+// newClient creates an Svn client for the working copy at path
+func newClient(path string, auth vcs.Auth) (*vcs.SVN, error) {
+	if !isValidSvnPath(path) {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	return vcs.NewSVN(path, auth), nil
+}
+
+// credentialFor finds the configured account whose URL is the longest prefix of url
+func credentialFor(url string) (internal.SVNCredentialConfig, bool) {
+	var found internal.SVNCredentialConfig
+	for _, cred := range internal.GetSVNConfig().Credentials {
+		if cred.URL != "" && strings.HasPrefix(url, cred.URL) && len(cred.URL) > len(found.URL) {
+			found = cred
+		}
+	}
+	return found, found.URL != ""
+}
+
+// repoAuth resolves the account configured for url, the password may be a secret:// reference
+func repoAuth(url string) (vcs.Auth, error) {
+	cred, ok := credentialFor(url)
+	if !ok {
+		return vcs.Auth{}, nil
+	}
+
+	password, err := app.Vault.Resolve(cred.Password, "svn:"+cred.URL)
+	if err != nil {
+		return vcs.Auth{}, err
+	}
+	return vcs.Auth{Username: cred.Username, Password: password}, nil
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// remoteClient creates an Svn client for operations that reach the repository,
+// authenticated with the account configured for the working copy URL
+func remoteClient(path string) (*vcs.SVN, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
+	}
 
-	err := cmd.Run()
+	info, err := client.Info(context.Background())
 	if err != nil {
-		return "", fmt.Errorf("Svn command failed: %v\nError: %s", err, stderr.String())
+		return nil, err
 	}
 
-	return stdout.String(), nil
+	auth, err := repoAuth(info.URL)
+	if err != nil {
+		return nil, err
+	}
+	return vcs.NewSVN(path, auth), nil
 }
 
-// Checkout performs Svn checkout operation
+// Checkout performs Svn checkout operation, without a username the account configured for url is used
 func Checkout(url, path, username, password string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	var auth vcs.Auth
+	if username == "" {
+		configured, err := repoAuth(url)
+		if err != nil {
+			return err
+		}
+		auth = configured
+	} else {
+		// password may be a secret:// reference into the credential vault
+		resolved, err := app.Vault.Resolve(password, "svn:checkout:"+url)
+		if err != nil {
+			return err
+		}
+		auth = vcs.Auth{Username: username, Password: resolved}
 	}
 
-	args := []string{
-		"checkout",
-		url,
-		path,
-		"--username", username,
-		"--password", password,
-		"--non-interactive",
-		"--trust-server-cert",
+	client, err := newClient(path, auth)
+	if err != nil {
+		return err
 	}
 
-	_, err := execSvnCommand(args...)
-	return err
+	return client.Checkout(context.Background(), url)
 }
 
 // Update performs Svn update operation
 func Update(path string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	client, err := remoteClient(path)
+	if err != nil {
+		return err
 	}
 
-	_, err := execSvnCommand("update", path, "--non-interactive")
-	return err
+	return client.Update(context.Background())
 }
 
-// Commit performs Svn commit operation
-func Commit(path, message string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+// Commit commits the versioned changes under path and returns the new revision
+func Commit(path, message string) (string, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return "", err
 	}
 
-	_, err := execSvnCommand("commit", path, "-m", message, "--non-interactive")
-	return err
+	return client.CommitTracked(context.Background(), message)
 }
 
 // Status gets Svn working copy status
-func Status(path string) (string, error) {
-	if !isValidSvnPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
+func Status(path string) ([]vcs.FileStatus, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
 	}
 
-	return execSvnCommand("status", path)
+	return client.Status(context.Background())
 }
 
 // Info gets Svn repository information
-func Info(path string) (string, error) {
-	if !isValidSvnPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
+func Info(path string) (*vcs.Info, error) {
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return nil, err
 	}
 
-	return execSvnCommand("info", path)
+	return client.Info(context.Background())
 }
 
 // Log gets Svn commit history
-func Log(path string, limit int) (string, error) {
-	if !isValidSvnPath(path) {
-		return "", fmt.Errorf("invalid path: %s", path)
+func Log(path string, limit int) ([]vcs.Commit, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return execSvnCommand("log", path, "-l", fmt.Sprintf("%d", limit))
+	return client.Log(context.Background(), limit)
 }
 
 // Revert reverts Svn working copy changes
 func Revert(path string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
 
-	_, err := execSvnCommand("revert", path, "-R")
-	return err
+	return client.Revert(context.Background())
 }
 
 // Add adds files to Svn version control
 func Add(path string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
 	}
 
-	_, err := execSvnCommand("add", path, "--non-interactive")
-	return err
+	return client.Add(context.Background(), ".")
 }
 
 // Delete removes files from Svn version control
 func Delete(path string) error {
-	if !isValidSvnPath(path) {
-		return fmt.Errorf("invalid path: %s", path)
+	client, err := newClient(path, vcs.Auth{})
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(context.Background(), ".")
+}
+
+// maxFileSize limits the file content returned by the repository browser
+const maxFileSize = 1 << 20
+
+// Tree lists the entries of dir at rev, rev defaults to the latest revision
+func Tree(path, rev, dir string) ([]vcs.Entry, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := execSvnCommand("delete", path, "--non-interactive")
-	return err
+	return client.Tree(context.Background(), rev, dir)
+}
+
+// File reads a file at rev with its syntax type, binary files have no content
+func File(path, rev, file string) (*vcs.FileContent, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return vcs.ReadContent(context.Background(), client, rev, file, maxFileSize)
+}
+
+// History gets the commits touching file (or directory) up to rev
+func History(path, rev, file string, limit int) ([]vcs.Commit, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.History(context.Background(), rev, file, limit)
+}
+
+// Blame gets the last change of every line of file at rev
+func Blame(path, rev, file string) ([]vcs.BlameLine, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Blame(context.Background(), rev, file)
+}
+
+// Diff gets the per-file unified diff between two revisions, from defaults to the parent of to
+func Diff(path, from, to, file string) ([]vcs.FileDiff, error) {
+	client, err := remoteClient(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if file != "" {
+		paths = append(paths, file)
+	}
+	return client.DiffFiles(context.Background(), from, to, paths...)
 }
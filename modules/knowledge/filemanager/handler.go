@@ -149,7 +149,15 @@ func downloadHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.Download(path)
+	file, obj, err := openFile(path)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Attachment(info.Name)
+	return c.SendStream(file, int(obj.Size))
 }
 
 // infoHandler returns detailed information about a file or directory
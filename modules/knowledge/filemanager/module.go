@@ -30,8 +30,7 @@ func (m *filemanagerModule) Start() error {
 	}
 
 	// Initialize Filemanager service
-	initService()
-	return nil
+	return initService()
 }
 
 func (m *filemanagerModule) AddAuthRouters() error {
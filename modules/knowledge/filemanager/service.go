@@ -1,18 +1,49 @@
 package filemanager
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/andycai/goapi/pkg/blob"
 )
 
-var rootPath string
+var (
+	rootPath string
+	store    *blob.Local
+)
 
-func initService() {
+func initService() error {
 	rootPath = "./"
+
+	// 文件管理维护的是服务器工作目录中的文件（配置、日志、发布包等），这些文件本来就在本地磁盘上，因此不使用 app.Blob。
+	// 另外 app.Blob 的本地目录通过 /uploads 公开访问，S3 存储也可能允许公开读取，放在其中会绕过 filemanager:download 权限。
+	// 这里只借用本地存储的原子写入和流式读取，目录的列出、创建、移动仍直接操作文件系统
+	var err error
+	store, err = blob.NewLocal(rootPath, "")
+	return err
+}
+
+// fileKey converts a path into a storage key relative to the root directory
+func fileKey(path string) (string, error) {
+	rootAbs, err := filepath.Abs(rootPath)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(rootAbs, absPath)
+	if err != nil {
+		return "", errors.New("invalid path")
+	}
+	return blob.CleanKey(filepath.ToSlash(rel))
 }
 
 // isValidPath checks if the path is safe for file operations
@@ -98,15 +129,13 @@ func uploadFile(path string, file io.Reader, filename string) error {
 		return errors.New("invalid path")
 	}
 
-	targetPath := filepath.Join(path, filename)
-	f, err := os.Create(targetPath)
+	key, err := fileKey(filepath.Join(path, filepath.Base(filename)))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	_, err = io.Copy(f, file)
-	return err
+	// 先写临时文件再替换，上传中断不会留下不完整的文件
+	return store.Put(context.Background(), key, file, -1, "")
 }
 
 // create creates a new directory or file
@@ -168,20 +197,29 @@ func copy(sourcePath, destPath string) error {
 
 // copyFile copies a single file
 func copyFile(sourcePath, destPath string) error {
-	source, err := os.Open(sourcePath)
+	sourceKey, err := fileKey(sourcePath)
 	if err != nil {
 		return err
 	}
-	defer source.Close()
-
-	dest, err := os.Create(destPath)
+	destKey, err := fileKey(destPath)
 	if err != nil {
 		return err
 	}
-	defer dest.Close()
 
-	_, err = io.Copy(dest, source)
-	return err
+	return blob.Copy(context.Background(), store, sourceKey, destKey)
+}
+
+// openFile opens a file for download
+func openFile(path string) (io.ReadCloser, *blob.Object, error) {
+	if !isValidPath(path) {
+		return nil, nil, errors.New("invalid path")
+	}
+
+	key, err := fileKey(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.Get(context.Background(), key)
 }
 
 // copyDir copies a directory recursively
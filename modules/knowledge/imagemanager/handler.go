@@ -135,20 +135,27 @@ func infoHandler(c *fiber.Ctx) error {
 func thumbnailHandler(c *fiber.Ctx) error {
 	path := c.Query("path")
 
-	thumbnailPath, err := srv.GetThumbnail(path)
+	thumbnail, obj, err := srv.GetThumbnail(path)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.SendFile(thumbnailPath)
+	c.Set(fiber.HeaderContentType, obj.ContentType)
+	return c.SendStream(thumbnail, int(obj.Size))
 }
 
 // viewHandler serves the original image
 func viewHandler(c *fiber.Ctx) error {
 	path := c.Query("path")
-	fullPath := srv.rootPath + "/" + path
+	file, obj, err := srv.Open(path)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	return c.SendFile(fullPath)
+	c.Set(fiber.HeaderContentType, obj.ContentType)
+	return c.SendStream(file, int(obj.Size))
 }
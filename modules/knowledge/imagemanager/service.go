@@ -2,16 +2,19 @@ package imagemanager
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"image"
+	_ "image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
-	"os"
-	"path/filepath"
+	"mime"
+	"path"
 	"strings"
 	"time"
 
+	"github.com/andycai/goapi/pkg/blob"
 	"github.com/nfnt/resize"
 )
 
@@ -29,86 +32,66 @@ type ImageInfo struct {
 }
 
 type ImagemanagerService struct {
-	rootPath     string
+	store        blob.Store
+	rootPrefix   string
 	thumbnailDir string
 }
 
 func initService() {
 	srv = &ImagemanagerService{
-		rootPath:     "./uploads/images",
-		thumbnailDir: "./uploads/thumbnails",
+		store:        app.Blob,
+		rootPrefix:   "images",
+		thumbnailDir: "thumbnails",
 	}
-	// Create directories if they don't exist
-	os.MkdirAll(srv.rootPath, 0755)
-	os.MkdirAll(srv.thumbnailDir, 0755)
 }
 
-// isValidPath checks if the path is safe for file operations
-func (s *ImagemanagerService) isValidPath(path string) bool {
-	// Clean and normalize the path
-	cleanPath := filepath.Clean(path)
-
-	// Check for suspicious patterns
-	suspicious := []string{
-		"..",
-		"~",
-		"$",
-		"|",
-		">",
-		"<",
-		"&",
-		"`",
-	}
-
-	for _, pattern := range suspicious {
-		if strings.Contains(cleanPath, pattern) {
-			return false
-		}
+// imageKey converts a path relative to the image root into a storage key
+func (s *ImagemanagerService) imageKey(p string) (string, error) {
+	key, err := blob.CleanKey(path.Join(s.rootPrefix, p))
+	if err != nil || !strings.HasPrefix(key, s.rootPrefix+"/") {
+		return "", errors.New("invalid path")
 	}
+	return key, nil
+}
 
-	// Ensure the path is within the root directory
-	absPath, err := filepath.Abs(cleanPath)
-	if err != nil {
-		return false
+// dirPrefix returns the storage prefix of a directory relative to the image root
+func (s *ImagemanagerService) dirPrefix(p string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+	if strings.Contains(p, "..") {
+		return "", errors.New("invalid path")
 	}
-
-	rootAbs, err := filepath.Abs(s.rootPath)
-	if err != nil {
-		return false
+	if cleaned == "/" {
+		return s.rootPrefix + "/", nil
 	}
+	return s.rootPrefix + cleaned + "/", nil
+}
 
-	return strings.HasPrefix(absPath, rootAbs)
+// isImage reports whether the name has a supported image extension
+func isImage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif"
 }
 
 // List returns a list of images in the specified path
-func (s *ImagemanagerService) List(path string) ([]ImageInfo, error) {
-	fullPath := filepath.Join(s.rootPath, path)
-	if !s.isValidPath(fullPath) {
-		return nil, errors.New("invalid path")
+func (s *ImagemanagerService) List(p string) ([]ImageInfo, error) {
+	prefix, err := s.dirPrefix(p)
+	if err != nil {
+		return nil, err
 	}
 
-	entries, err := os.ReadDir(fullPath)
+	objects, err := s.store.List(context.Background(), prefix)
 	if err != nil {
 		return nil, err
 	}
 
 	var images []ImageInfo
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue // Skip directories
-		}
-
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
-
-		ext := strings.ToLower(filepath.Ext(info.Name()))
-		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" {
-			continue // Skip non-image files
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Key, prefix)
+		if strings.Contains(name, "/") || !isImage(name) {
+			continue // Skip sub directories and non-image files
 		}
 
-		imgInfo, err := s.GetInfo(filepath.Join(path, info.Name()))
+		imgInfo, err := s.GetInfo(strings.TrimPrefix(obj.Key, s.rootPrefix+"/"))
 		if err != nil {
 			continue
 		}
@@ -120,10 +103,10 @@ func (s *ImagemanagerService) List(path string) ([]ImageInfo, error) {
 }
 
 // Upload handles image upload to the specified path
-func (s *ImagemanagerService) Upload(path string, file io.Reader, filename string) error {
-	fullPath := filepath.Join(s.rootPath, path, filename)
-	if !s.isValidPath(fullPath) {
-		return errors.New("invalid path")
+func (s *ImagemanagerService) Upload(p string, file io.Reader, filename string) error {
+	key, err := s.imageKey(path.Join(p, path.Base(filename)))
+	if err != nil {
+		return err
 	}
 
 	// Read the entire file into memory
@@ -138,114 +121,90 @@ func (s *ImagemanagerService) Upload(path string, file io.Reader, filename strin
 		return errors.New("invalid image format: " + format)
 	}
 
-	// Create the target file
-	f, err := os.Create(fullPath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Write the data to the file
-	_, err = io.Copy(f, bytes.NewReader(data))
-	if err != nil {
+	ctx := context.Background()
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mime.TypeByExtension(path.Ext(key))); err != nil {
 		return err
 	}
 
 	// Generate thumbnail
-	return s.generateThumbnail(fullPath)
+	return s.generateThumbnail(ctx, key, data)
 }
 
 // Delete removes an image and its thumbnail
-func (s *ImagemanagerService) Delete(path string) error {
-	fullPath := filepath.Join(s.rootPath, path)
-	if !s.isValidPath(fullPath) {
-		return errors.New("invalid path")
+func (s *ImagemanagerService) Delete(p string) error {
+	key, err := s.imageKey(p)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if _, err := s.store.Stat(ctx, key); err != nil {
+		return err
 	}
 
 	// Delete thumbnail first
-	s.deleteThumbnail(fullPath)
+	s.store.Delete(ctx, s.getThumbnailKey(key))
 
 	// Delete the image file
-	return os.Remove(fullPath)
+	return s.store.Delete(ctx, key)
 }
 
 // Rename renames an image and its thumbnail
 func (s *ImagemanagerService) Rename(oldPath, newPath string) error {
-	oldFullPath := filepath.Join(s.rootPath, oldPath)
-	newFullPath := filepath.Join(s.rootPath, newPath)
-
-	if !s.isValidPath(oldFullPath) || !s.isValidPath(newFullPath) {
-		return errors.New("invalid path")
-	}
-
-	// Rename thumbnail first
-	s.renameThumbnail(oldFullPath, newFullPath)
-
-	// Rename the image file
-	return os.Rename(oldFullPath, newFullPath)
+	return s.Move(oldPath, newPath)
 }
 
 // Move moves an image and its thumbnail to a new location
 func (s *ImagemanagerService) Move(sourcePath, destPath string) error {
-	sourceFullPath := filepath.Join(s.rootPath, sourcePath)
-	destFullPath := filepath.Join(s.rootPath, destPath)
-
-	if !s.isValidPath(sourceFullPath) || !s.isValidPath(destFullPath) {
-		return errors.New("invalid path")
+	sourceKey, err := s.imageKey(sourcePath)
+	if err != nil {
+		return err
 	}
+	destKey, err := s.imageKey(destPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
 
 	// Move thumbnail first
-	s.moveThumbnail(sourceFullPath, destFullPath)
+	blob.Move(ctx, s.store, s.getThumbnailKey(sourceKey), s.getThumbnailKey(destKey))
 
 	// Move the image file
-	return os.Rename(sourceFullPath, destFullPath)
+	return blob.Move(ctx, s.store, sourceKey, destKey)
 }
 
 // Copy copies an image and its thumbnail to a new location
 func (s *ImagemanagerService) Copy(sourcePath, destPath string) error {
-	sourceFullPath := filepath.Join(s.rootPath, sourcePath)
-	destFullPath := filepath.Join(s.rootPath, destPath)
-
-	if !s.isValidPath(sourceFullPath) || !s.isValidPath(destFullPath) {
-		return errors.New("invalid path")
-	}
-
-	// Copy the image file
-	source, err := os.Open(sourceFullPath)
+	sourceKey, err := s.imageKey(sourcePath)
 	if err != nil {
 		return err
 	}
-	defer source.Close()
-
-	dest, err := os.Create(destFullPath)
+	destKey, err := s.imageKey(destPath)
 	if err != nil {
 		return err
 	}
-	defer dest.Close()
 
-	_, err = io.Copy(dest, source)
-	if err != nil {
+	ctx := context.Background()
+	if err := blob.Copy(ctx, s.store, sourceKey, destKey); err != nil {
 		return err
 	}
 
-	// Generate thumbnail for the copied image
-	return s.generateThumbnail(destFullPath)
+	// Copy the thumbnail, regenerate it if missing
+	if err := blob.Copy(ctx, s.store, s.getThumbnailKey(sourceKey), s.getThumbnailKey(destKey)); err != nil {
+		return s.regenerateThumbnail(ctx, destKey)
+	}
+	return nil
 }
 
 // GetInfo returns detailed information about an image
-func (s *ImagemanagerService) GetInfo(path string) (*ImageInfo, error) {
-	fullPath := filepath.Join(s.rootPath, path)
-	if !s.isValidPath(fullPath) {
-		return nil, errors.New("invalid path")
-	}
-
-	info, err := os.Stat(fullPath)
+func (s *ImagemanagerService) GetInfo(p string) (*ImageInfo, error) {
+	key, err := s.imageKey(p)
 	if err != nil {
 		return nil, err
 	}
 
-	// Open the image file
-	file, err := os.Open(fullPath)
+	file, obj, err := s.store.Get(context.Background(), key)
 	if err != nil {
 		return nil, err
 	}
@@ -258,96 +217,90 @@ func (s *ImagemanagerService) GetInfo(path string) (*ImageInfo, error) {
 	}
 
 	return &ImageInfo{
-		Name:      info.Name(),
-		Path:      path,
-		Size:      info.Size(),
+		Name:      path.Base(key),
+		Path:      p,
+		Size:      obj.Size,
 		Width:     config.Width,
 		Height:    config.Height,
 		Format:    format,
-		ModTime:   info.ModTime(),
-		Extension: filepath.Ext(info.Name()),
+		ModTime:   obj.ModTime,
+		Extension: path.Ext(key),
 	}, nil
 }
 
+// Open returns the original image
+func (s *ImagemanagerService) Open(p string) (io.ReadCloser, *blob.Object, error) {
+	key, err := s.imageKey(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.store.Get(context.Background(), key)
+}
+
 // GetThumbnail returns a thumbnail of the image
-func (s *ImagemanagerService) GetThumbnail(path string) (string, error) {
-	fullPath := filepath.Join(s.rootPath, path)
-	if !s.isValidPath(fullPath) {
-		return "", errors.New("invalid path")
+func (s *ImagemanagerService) GetThumbnail(p string) (io.ReadCloser, *blob.Object, error) {
+	key, err := s.imageKey(p)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	thumbnailPath := s.getThumbnailPath(fullPath)
-	if _, err := os.Stat(thumbnailPath); err != nil {
+	ctx := context.Background()
+	thumbnailKey := s.getThumbnailKey(key)
+	if _, err := s.store.Stat(ctx, thumbnailKey); err != nil {
 		// Generate thumbnail if it doesn't exist
-		err = s.generateThumbnail(fullPath)
-		if err != nil {
-			return "", err
+		if err := s.regenerateThumbnail(ctx, key); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	return thumbnailPath, nil
+	return s.store.Get(ctx, thumbnailKey)
 }
 
-// generateThumbnail creates a thumbnail for the given image
-func (s *ImagemanagerService) generateThumbnail(imagePath string) error {
-	// Open the image file
-	file, err := os.Open(imagePath)
+// regenerateThumbnail reads the image and creates its thumbnail
+func (s *ImagemanagerService) regenerateThumbnail(ctx context.Context, key string) error {
+	file, _, err := s.store.Get(ctx, key)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// Decode the image
-	img, format, err := image.Decode(file)
+	data, err := io.ReadAll(file)
+	file.Close()
 	if err != nil {
 		return err
 	}
 
-	// Resize the image to create a thumbnail
-	thumbnail := resize.Thumbnail(200, 200, img, resize.Lanczos3)
+	return s.generateThumbnail(ctx, key, data)
+}
 
-	// Create the thumbnail file
-	thumbnailPath := s.getThumbnailPath(imagePath)
-	os.MkdirAll(filepath.Dir(thumbnailPath), 0755)
-	thumbFile, err := os.Create(thumbnailPath)
+// generateThumbnail creates a thumbnail for the given image
+func (s *ImagemanagerService) generateThumbnail(ctx context.Context, key string, data []byte) error {
+	// Decode the image
+	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
-	defer thumbFile.Close()
+
+	// Resize the image to create a thumbnail
+	thumbnail := resize.Thumbnail(200, 200, img, resize.Lanczos3)
 
 	// Encode the thumbnail
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
 	switch format {
-	case "jpeg":
-		return jpeg.Encode(thumbFile, thumbnail, &jpeg.Options{Quality: 85})
 	case "png":
-		return png.Encode(thumbFile, thumbnail)
+		contentType = "image/png"
+		err = png.Encode(&buf, thumbnail)
 	default:
-		return jpeg.Encode(thumbFile, thumbnail, &jpeg.Options{Quality: 85})
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return err
 	}
-}
-
-// getThumbnailPath returns the path where the thumbnail should be stored
-func (s *ImagemanagerService) getThumbnailPath(imagePath string) string {
-	relPath, _ := filepath.Rel(s.rootPath, imagePath)
-	return filepath.Join(s.thumbnailDir, relPath)
-}
-
-// deleteThumbnail removes the thumbnail for the given image
-func (s *ImagemanagerService) deleteThumbnail(imagePath string) {
-	thumbnailPath := s.getThumbnailPath(imagePath)
-	os.Remove(thumbnailPath)
-}
 
-// renameThumbnail renames the thumbnail for the given image
-func (s *ImagemanagerService) renameThumbnail(oldPath, newPath string) {
-	oldThumbnail := s.getThumbnailPath(oldPath)
-	newThumbnail := s.getThumbnailPath(newPath)
-	os.Rename(oldThumbnail, newThumbnail)
+	thumbnailKey := s.getThumbnailKey(key)
+	return s.store.Put(ctx, thumbnailKey, &buf, int64(buf.Len()), contentType)
 }
 
-// moveThumbnail moves the thumbnail for the given image
-func (s *ImagemanagerService) moveThumbnail(sourcePath, destPath string) {
-	sourceThumbnail := s.getThumbnailPath(sourcePath)
-	destThumbnail := s.getThumbnailPath(destPath)
-	os.Rename(sourceThumbnail, destThumbnail)
+// getThumbnailKey returns the key where the thumbnail should be stored
+func (s *ImagemanagerService) getThumbnailKey(imageKey string) string {
+	return path.Join(s.thumbnailDir, strings.TrimPrefix(imageKey, s.rootPrefix+"/"))
 }
@@ -106,6 +106,7 @@ func updateNoteHandler(c *fiber.Ctx) error {
 
 	user := app.CurrentUser(c)
 
+	before := *note
 	note.Title = req.Title
 	note.Content = req.Content
 	note.CategoryID = req.CategoryID
@@ -118,7 +119,7 @@ func updateNoteHandler(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "更新笔记失败"})
 	}
 
-	adminlog.WriteLog(c, "update", "note", note.ID, fmt.Sprintf("更新笔记：%s", note.Title))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "note", ResourceID: note.ID, Details: fmt.Sprintf("更新笔记：%s", note.Title), Before: before, After: note})
 
 	return c.JSON(note)
 }
@@ -196,6 +197,7 @@ func updateCategoryHandler(c *fiber.Ctx) error {
 
 	user := app.CurrentUser(c)
 
+	before := *category
 	category.Name = req.Name
 	category.Description = req.Description
 	category.ParentID = req.ParentID
@@ -207,7 +209,7 @@ func updateCategoryHandler(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "更新分类失败"})
 	}
 
-	adminlog.WriteLog(c, "update", "note_category", category.ID, fmt.Sprintf("更新笔记分类：%s", category.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "note_category", ResourceID: category.ID, Details: fmt.Sprintf("更新笔记分类：%s", category.Name), Before: before, After: category})
 
 	return c.JSON(category)
 }
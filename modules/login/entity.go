@@ -0,0 +1,27 @@
+package login
+
+import "time"
+
+// ThrottleVO 正在限制登录的 IP 或用户名
+type ThrottleVO struct {
+	Kind         string    `json:"kind"` // ip 或 user
+	Value        string    `json:"value"`
+	Failures     int       `json:"failures"`
+	LastFailure  time.Time `json:"last_failure"`
+	BlockedUntil time.Time `json:"blocked_until"`
+}
+
+// LockedUserVO 被锁定的账号
+type LockedUserVO struct {
+	ID           uint      `json:"id"`
+	Username     string    `json:"username"`
+	Nickname     string    `json:"nickname"`
+	FailedLogins int       `json:"failed_logins"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+// UnblockRequest 解除 IP 或用户名的登录限制
+type UnblockRequest struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
@@ -1,13 +1,16 @@
 package login
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type LoginRequest struct {
@@ -34,23 +37,58 @@ func loginHandler(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
 	}
 
-	var user models.User
-	if err := app.DB.Preload("Role.Permissions").Where("username = ?", req.Username).First(&user).Error; err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "用户名或密码错误"})
+	// 失败次数过多的 IP 和用户名需要等待，不记录登录记录，避免攻击时写满数据库
+	if err := checkThrottle(c, req.Username); err != nil {
+		return throttledResponse(c, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "用户名或密码错误"})
+	existing := findLoginUser(req.Username)
+	if err := checkLocked(existing); err != nil {
+		recordAttempt(c, req.Username, existing.ID, false, "账号已锁定")
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	user, err := authenticate(req.Username, req.Password)
+	if err != nil {
+		loginFailed(c, req.Username, existing, "用户名或密码错误")
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if user.Status != 1 {
+		recordAttempt(c, user.Username, user.ID, false, "账号已禁用")
+		return c.Status(403).JSON(fiber.Map{"error": "账号已禁用"})
+	}
+
+	if user.IsService {
+		recordAttempt(c, user.Username, user.ID, false, "服务账号不能登录")
+		return c.Status(403).JSON(fiber.Map{"error": "服务账号只能使用 API 令牌访问"})
+	}
+
+	// 启用两步验证的用户先保存登录状态，验证码通过后再创建会话
+	if user.TOTPEnabled {
+		if err := internal.StorePendingLogin(c, user.ID, req.Remember); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "会话创建失败"})
+		}
+		return c.JSON(fiber.Map{
+			"code":    0,
+			"message": "请输入两步验证码",
+			"data": fiber.Map{
+				"mfa_required": true,
+			},
+		})
+	}
+
+	return completeLogin(c, user, req.Remember)
+}
+
+// completeLogin 创建会话并返回 token 和用户信息
+func completeLogin(c *fiber.Ctx, user *models.User, remember bool) error {
 	// 根据记住我选项设置不同的过期时间
-	var expireTime time.Time
-	if req.Remember {
-		expireTime = time.Now().Add(time.Hour * 24 * 30) // 30天
-		internal.SetSessionExpiration(c, time.Hour*24*30)
-	} else {
-		expireTime = time.Now().Add(time.Duration(app.Config.Auth.TokenExpire) * time.Second)
+	expiry := time.Duration(app.Config.Auth.TokenExpire) * time.Second
+	if remember {
+		expiry = time.Hour * 24 * 30 // 30天
 	}
+	expireTime := time.Now().Add(expiry)
 
 	// 生成 JWT token
 	claims := jwt.MapClaims{
@@ -69,40 +107,58 @@ func loginHandler(c *fiber.Ctx) error {
 	}
 
 	// 更新最后登录时间
-	app.DB.Model(&user).Update("last_login", time.Now())
+	app.DB.Model(user).Update("last_login", time.Now())
+	loginSucceeded(c, user)
 
 	// 存储会话
-	if err := internal.StoreSession(c, user.ID); err != nil {
+	if err := internal.StoreSession(c, user.ID, expiry); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "会话创建失败"})
 	}
 
-	// 清除密码字段
-	user.Password = ""
-
 	// 构建响应数据
 	responseData := fiber.Map{
 		"code":    0,
 		"message": "登录成功",
 		"data": fiber.Map{
 			"token": tokenString,
-			"user": fiber.Map{
-				"id":              user.ID,
-				"username":        user.Username,
-				"nickname":        user.Nickname,
-				"role_id":         user.RoleID,
-				"role":            user.Role,
-				"status":          user.Status,
-				"last_login":      user.LastLogin,
-				"has_changed_pwd": user.HasChangedPwd,
-				"created_at":      user.CreatedAt,
-				"updated_at":      user.UpdatedAt,
-			},
+			"user":  userInfo(user),
+			// 角色要求两步验证但还没有启用，登录后先去个人信息页面启用
+			"mfa_setup_required": internal.TwoFactorRequired(user) && !user.TOTPEnabled,
 		},
 	}
 
 	return c.JSON(responseData)
 }
 
+// userInfo 返回给前端的用户信息，不包含密码
+func userInfo(user *models.User) fiber.Map {
+	return fiber.Map{
+		"id":              user.ID,
+		"username":        user.Username,
+		"nickname":        user.Nickname,
+		"role_id":         user.RoleID,
+		"role":            user.Role,
+		"roles":           user.Roles,
+		"permissions":     internal.NewPrincipal(user, nil).Codes(), // 所有角色合并后的权限编码
+		"status":          user.Status,
+		"source":          user.Source,
+		"last_login":      user.LastLogin,
+		"has_changed_pwd": user.HasChangedPwd,
+		"totp_enabled":    user.TOTPEnabled,
+		"created_at":      user.CreatedAt,
+		"updated_at":      user.UpdatedAt,
+	}
+}
+
+// meHandler 获取当前登录的用户，单点登录回到登录页面后使用
+func meHandler(c *fiber.Ctx) error {
+	user := app.CurrentUser(c)
+	if user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+	return c.JSON(fiber.Map{"code": 0, "data": userInfo(user)})
+}
+
 // logoutHandler 处理退出登录请求
 func logoutHandler(c *fiber.Ctx) error {
 	isAuthenticated, _ := internal.GetSession(c)
@@ -122,31 +178,57 @@ func changePasswordHandler(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
 	}
 
+	// 修改密码需要验证当前密码，与登录使用相同的失败限制
+	if err := checkThrottle(c, req.Username); err != nil {
+		return throttledResponse(c, err)
+	}
+
 	// 获取用户信息
-	var user models.User
-	if err := app.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
+	user := findLoginUser(req.Username)
+	if user == nil {
+		loginFailed(c, req.Username, nil, "修改密码：用户不存在")
+		return c.Status(400).JSON(fiber.Map{"error": "当前密码错误"})
+	}
+	if err := checkLocked(user); err != nil {
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// 外部账号的密码由目录或身份提供方管理
+	if !isLocalUser(user) {
+		return c.Status(400).JSON(fiber.Map{"error": "该账号的密码由统一身份认证管理，不能在这里修改"})
 	}
 
 	// 验证旧密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+		loginFailed(c, req.Username, user, "修改密码：当前密码错误")
 		return c.Status(400).JSON(fiber.Map{"error": "当前密码错误"})
 	}
 
+	// 检查密码策略
+	if err := validatePassword(user, req.NewPassword); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// 生成新密码的哈希值
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "密码加密失败"})
 	}
 
-	// 更新密码和密码修改状态
+	// 更新密码和密码修改状态，旧密码保存到历史记录
 	updates := map[string]interface{}{
 		"password":        string(hashedPassword),
 		"has_changed_pwd": true,
 		"updated_at":      time.Now(),
 	}
 
-	if err := app.DB.Model(&user).Updates(updates).Error; err != nil {
+	err = app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := savePasswordHistory(tx, user); err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(updates).Error
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "更新密码失败"})
 	}
 
@@ -184,3 +266,53 @@ func CurrentUser(c *fiber.Ctx) *models.User {
 
 	return &vo
 }
+
+// listLoginAttemptsHandler 获取登录记录
+func listLoginAttemptsHandler(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	attempts, total, err := listLoginAttempts(page, pageSize, c.Query("username"), c.Query("ip"), c.Query("result"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "获取登录记录失败"})
+	}
+	return c.JSON(fiber.Map{"data": attempts, "total": total})
+}
+
+// listLocksHandler 获取锁定中的账号和限制登录的 IP、用户名
+func listLocksHandler(c *fiber.Ctx) error {
+	users, err := listLockedUsers()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "获取锁定账号失败"})
+	}
+	return c.JSON(fiber.Map{"users": users, "throttled": throttle.blocked()})
+}
+
+// unlockUserHandler 解锁账号
+func unlockUserHandler(c *fiber.Ctx) error {
+	user, err := unlockUser(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "unlock", "user", user.ID, fmt.Sprintf("解锁账号：%s", user.Username))
+
+	return c.JSON(fiber.Map{"message": "账号已解锁"})
+}
+
+// unblockHandler 解除 IP 或用户名的登录限制
+func unblockHandler(c *fiber.Ctx) error {
+	var req UnblockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+	if req.Kind != throttleKindIP && req.Kind != throttleKindUser {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的限制类型"})
+	}
+
+	throttle.reset(throttleKey(req.Kind, req.Value))
+
+	adminlog.WriteLog(c, "unlock", "login", 0, fmt.Sprintf("解除登录限制：%s %s", req.Kind, req.Value))
+
+	return c.JSON(fiber.Map{"message": "已解除限制"})
+}
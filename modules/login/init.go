@@ -0,0 +1,117 @@
+package login
+
+import (
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/enum"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+func autoMigrate() error {
+	return app.DB.AutoMigrate(
+		&models.LoginAttempt{},
+		&models.PasswordHistory{},
+		&models.UserSession{},
+	)
+}
+
+// 初始化数据
+func initData() error {
+	if err := initMenus(); err != nil {
+		return err
+	}
+
+	if err := initPermissions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("login:menu") {
+		log.Println("[登录模块]菜单数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建登录安全菜单
+		loginMenu := models.Menu{
+			MenuID:     1012,
+			ParentID:   enum.MenuIdSystem,
+			Name:       "登录安全",
+			Path:       "/admin/login-security",
+			Icon:       "lock",
+			Sort:       6,
+			Permission: "login:view",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&loginMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "login:menu",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("login:permission") {
+		log.Println("[登录模块]权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建登录安全相关权限
+		permissions := []models.Permission{
+			{
+				Name:        "查看登录记录",
+				Code:        "login:view",
+				Description: "查看登录记录和锁定的账号",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "解锁账号",
+				Code:        "login:unlock",
+				Description: "解锁账号，解除 IP 和用户名的登录限制",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+		}
+
+		if err := tx.Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "login:permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
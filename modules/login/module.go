@@ -1,7 +1,11 @@
 package login
 
 import (
+	"time"
+
 	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -21,17 +25,51 @@ func init() {
 
 func (m *loginModule) Awake(a *internal.App) error {
 	app = a
+	// 数据迁移
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	retention.RegisterTarget(retention.Target{
+		Name:        "login_attempt",
+		Title:       "登录记录",
+		DefaultDays: 180,
+		Tables: []retention.Table{
+			{Model: &models.LoginAttempt{}, TimeColumn: "created_at"},
+		},
+	})
+
 	return nil
 }
 
+func (m *loginModule) Start() error {
+	// 定期清除长时间没有失败的登录限制
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			throttle.cleanup()
+		}
+	}()
+
+	// 初始化数据
+	return initData()
+}
+
 func (m *loginModule) AddPublicRouters() error {
 	// 登录页面路由（不需要认证）
 	app.RouterPublic.Get("/login", func(c *fiber.Ctx) error {
-		return c.Render("login", fiber.Map{}, "login")
+		return c.Render("login", fiber.Map{
+			"OIDC":           app.Config.SSO.OIDC.Enabled,
+			"OIDCName":       app.Config.SSO.OIDC.Name,
+			"PasswordPolicy": passwordPolicyText(),
+		}, "login")
 	})
 
 	// 登录 API 路由（不需要认证）
 	app.RouterPublic.Post("/login", loginHandler)
+	// 两步验证（不需要认证）
+	app.RouterPublic.Post("/login/2fa", loginTwoFactorHandler)
 	// 退出登录 API 路由（不需要认证）
 	app.RouterPublic.Get("/logout", logoutHandler)
 	app.RouterPublic.Post("/logout", logoutHandler)
@@ -39,6 +77,10 @@ func (m *loginModule) AddPublicRouters() error {
 	// 修改密码路由（不需要认证）
 	app.RouterPublic.Post("/change-password", changePasswordHandler)
 
+	// OIDC 单点登录（不需要认证）
+	app.RouterPublic.Get("/login/oidc", oidcLoginHandler)
+	app.RouterPublic.Get("/login/oidc/callback", oidcCallbackHandler)
+
 	return nil
 }
 
@@ -50,7 +92,22 @@ func (m *loginModule) AddAuthRouters() error {
 		}, "admin/layout")
 	})
 
+	app.RouterAdmin.Get("/login-security", app.HasPermission("login:view"), func(c *fiber.Ctx) error {
+		return c.Render("admin/login_security", fiber.Map{
+			"Title": "登录安全",
+			"Scripts": []string{
+				"/static/js/admin/login_security.js",
+			},
+		}, "admin/layout")
+	})
+
 	// api
+	app.RouterAdminApi.Get("/me", meHandler)
+
+	app.RouterAdminApi.Get("/login/attempts", app.HasPermission("login:view"), listLoginAttemptsHandler)
+	app.RouterAdminApi.Get("/login/locks", app.HasPermission("login:view"), listLocksHandler)
+	app.RouterAdminApi.Post("/login/locks/:id/unlock", app.HasPermission("login:unlock"), unlockUserHandler)
+	app.RouterAdminApi.Post("/login/unblock", app.HasPermission("login:unlock"), unblockHandler)
 
 	return nil
 }
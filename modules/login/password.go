@@ -0,0 +1,98 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andycai/goapi/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// passwordPolicyText 密码要求，显示在修改密码的页面
+func passwordPolicyText() string {
+	conf := app.Config.Auth
+	parts := []string{fmt.Sprintf("至少 %d 位", conf.PasswordMinLength)}
+	if conf.PasswordClasses > 1 {
+		parts = append(parts, fmt.Sprintf("包含大写字母、小写字母、数字、符号中的至少 %d 类", conf.PasswordClasses))
+	}
+	if conf.PasswordHistory > 0 {
+		parts = append(parts, fmt.Sprintf("不能与最近 %d 次使用过的密码相同", conf.PasswordHistory))
+	}
+	return "密码" + strings.Join(parts, "，")
+}
+
+// validatePassword 检查新密码的长度、复杂度和是否与最近使用过的密码相同
+func validatePassword(user *models.User, password string) error {
+	conf := app.Config.Auth
+	if utf8.RuneCountInString(password) < conf.PasswordMinLength {
+		return fmt.Errorf("密码长度不能少于 %d 位", conf.PasswordMinLength)
+	}
+	if len(password) > 72 {
+		// bcrypt 只使用前 72 个字节
+		return errors.New("密码过长")
+	}
+	if strings.EqualFold(password, user.Username) {
+		return errors.New("密码不能与用户名相同")
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{upper, lower, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < conf.PasswordClasses {
+		return fmt.Errorf("密码需要包含大写字母、小写字母、数字、符号中的至少 %d 类", conf.PasswordClasses)
+	}
+
+	if conf.PasswordHistory > 0 {
+		// 当前密码也算一次
+		hashes := []string{user.Password}
+		var history []models.PasswordHistory
+		app.DB.Where("user_id = ?", user.ID).Order("id DESC").Limit(conf.PasswordHistory - 1).Find(&history)
+		for _, item := range history {
+			hashes = append(hashes, item.Password)
+		}
+		for _, hash := range hashes {
+			if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+				return fmt.Errorf("不能使用最近 %d 次使用过的密码", conf.PasswordHistory)
+			}
+		}
+	}
+	return nil
+}
+
+// savePasswordHistory 保存旧密码，只保留策略需要的条数
+func savePasswordHistory(tx *gorm.DB, user *models.User) error {
+	keep := app.Config.Auth.PasswordHistory - 1
+	if keep <= 0 {
+		return nil
+	}
+	if err := tx.Create(&models.PasswordHistory{UserID: user.ID, Password: user.Password}).Error; err != nil {
+		return err
+	}
+
+	var ids []uint
+	tx.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Order("id DESC").Offset(keep).Pluck("id", &ids)
+	if len(ids) > 0 {
+		return tx.Delete(&models.PasswordHistory{}, ids).Error
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// errThrottled 登录失败次数过多，需要等待后重试
+type errThrottled struct {
+	wait time.Duration
+}
+
+func (e *errThrottled) Error() string {
+	return fmt.Sprintf("登录失败次数过多，请 %d 秒后重试", int(math.Ceil(e.wait.Seconds())))
+}
+
+// checkThrottle 检查 IP 和用户名是否需要等待
+func checkThrottle(c *fiber.Ctx, username string) *errThrottled {
+	wait := throttle.wait(throttleKey(throttleKindIP, c.IP()), throttleKey(throttleKindUser, username))
+	if wait > 0 {
+		return &errThrottled{wait: wait}
+	}
+	return nil
+}
+
+// throttledResponse 返回 429 和需要等待的秒数
+func throttledResponse(c *fiber.Ctx, err *errThrottled) error {
+	seconds := int(math.Ceil(err.wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, fmt.Sprint(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error(), "retry_after": seconds})
+}
+
+// findLoginUser 按用户名查找账号，不存在时返回 nil
+func findLoginUser(username string) *models.User {
+	var user models.User
+	if err := app.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil
+	}
+	return &user
+}
+
+// checkLocked 账号是否处于锁定状态
+func checkLocked(user *models.User) error {
+	if user == nil || user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		return nil
+	}
+	minutes := int(math.Ceil(time.Until(*user.LockedUntil).Minutes()))
+	return fmt.Errorf("账号已锁定，请 %d 分钟后重试或联系管理员解锁", minutes)
+}
+
+// recordAttempt 保存登录记录
+func recordAttempt(c *fiber.Ctx, username string, userID uint, success bool, reason string) {
+	attempt := models.LoginAttempt{
+		Username:  truncate(username, 50),
+		UserID:    userID,
+		IP:        c.IP(),
+		UserAgent: truncate(c.Get(fiber.HeaderUserAgent), 255),
+		Success:   success,
+		Reason:    reason,
+	}
+	if err := app.DB.Create(&attempt).Error; err != nil {
+		log.Printf("[登录模块]保存登录记录失败: %v", err)
+	}
+}
+
+// loginFailed 记录登录失败，增加 IP 和用户名的失败次数，账号连续失败次数过多时锁定
+func loginFailed(c *fiber.Ctx, username string, user *models.User, reason string) {
+	conf := app.Config.Auth
+	lock := time.Duration(conf.LockMinutes) * time.Minute
+
+	throttle.fail(throttleKey(throttleKindIP, c.IP()), ipFreeFailures, conf.IPMaxFailures, lock)
+	throttle.fail(throttleKey(throttleKindUser, username), userFreeFailures, 0, 0)
+
+	var userID uint
+	if user != nil {
+		userID = user.ID
+		failures := user.FailedLogins + 1
+		updates := map[string]any{"failed_logins": gorm.Expr("failed_logins + 1")}
+		// 上次锁定已过期，重新计数
+		if user.LockedUntil != nil && !user.LockedUntil.After(time.Now()) {
+			failures = 1
+			updates = map[string]any{"failed_logins": 1, "locked_until": nil}
+		}
+		if failures >= conf.MaxFailures {
+			updates["locked_until"] = time.Now().Add(lock)
+			log.Printf("[登录模块]账号 %s 连续登录失败 %d 次，锁定 %d 分钟", user.Username, failures, conf.LockMinutes)
+		}
+		app.DB.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(updates)
+	}
+
+	recordAttempt(c, username, userID, false, reason)
+}
+
+// TwoFactorBlocked 登录后校验两步验证码（启用、关闭两步验证和重新生成恢复码）前检查账号是否锁定或需要等待，
+// 与登录第二步使用同样的限制，被限制时写入响应并返回 true
+func TwoFactorBlocked(c *fiber.Ctx, user *models.User) (bool, error) {
+	if err := checkLocked(user); err != nil {
+		return true, c.Status(fiber.StatusLocked).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := checkThrottle(c, user.Username); err != nil {
+		return true, throttledResponse(c, err)
+	}
+	return false, nil
+}
+
+// TwoFactorFailed 登录后输入的两步验证码错误，计入账号的失败次数，次数过多时锁定账号
+func TwoFactorFailed(c *fiber.Ctx, user *models.User) {
+	loginFailed(c, user.Username, user, "两步验证码错误")
+}
+
+// loginSucceeded 记录登录成功，清除用户名的失败次数
+func loginSucceeded(c *fiber.Ctx, user *models.User) {
+	throttle.reset(throttleKey(throttleKindUser, user.Username))
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		app.DB.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]any{
+			"failed_logins": 0,
+			"locked_until":  nil,
+		})
+	}
+	recordAttempt(c, user.Username, user.ID, true, "")
+}
+
+// listLockedUsers 获取锁定中的账号
+func listLockedUsers() ([]LockedUserVO, error) {
+	var users []models.User
+	if err := app.DB.Where("locked_until > ?", time.Now()).Order("locked_until DESC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]LockedUserVO, 0, len(users))
+	for _, user := range users {
+		list = append(list, LockedUserVO{
+			ID:           user.ID,
+			Username:     user.Username,
+			Nickname:     user.Nickname,
+			FailedLogins: user.FailedLogins,
+			LockedUntil:  *user.LockedUntil,
+		})
+	}
+	return list, nil
+}
+
+// unlockUser 解锁账号，同时清除用户名的登录限制
+func unlockUser(id any) (*models.User, error) {
+	var user models.User
+	if err := app.DB.First(&user, id).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	err := app.DB.Model(&user).UpdateColumns(map[string]any{
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	throttle.reset(throttleKey(throttleKindUser, user.Username))
+	return &user, nil
+}
+
+// listLoginAttempts 分页获取登录记录
+func listLoginAttempts(page, pageSize int, username, ip, result string) ([]models.LoginAttempt, int64, error) {
+	query := app.DB.Model(&models.LoginAttempt{})
+	if username != "" {
+		query = query.Where("username LIKE ?", "%"+username+"%")
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	switch result {
+	case "success":
+		query = query.Where("success = ?", true)
+	case "failed":
+		query = query.Where("success = ?", false)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var attempts []models.LoginAttempt
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&attempts).Error
+	return attempts, total, err
+}
+
+// truncate 截断过长的字符串，按字符截断避免产生无效的 UTF-8
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
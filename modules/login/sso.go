@@ -0,0 +1,341 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/sso"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateExpire = 10 * time.Minute
+)
+
+var (
+	errInvalidLogin = errors.New("用户名或密码错误")
+
+	// oidcClient 第一次使用时读取 discovery 文档，失败时下次登录重试
+	oidcClient *sso.OIDC
+	oidcMu     sync.Mutex
+)
+
+// authenticate 验证用户名和密码，启用 LDAP 时先通过目录验证，目录中没有的用户使用本地账号
+func authenticate(username, password string) (*models.User, error) {
+	conf := app.Config.SSO.LDAP
+	if conf.Enabled {
+		identity, err := ldapAuthenticate(conf, username, password)
+		switch {
+		case err == nil:
+			return provisionUser(identity, conf.Roles, conf.DefaultRole)
+		case errors.Is(err, sso.ErrUserNotFound), errors.Is(err, sso.ErrInvalidCredentials):
+			// 使用本地账号验证
+		default:
+			// 目录服务不可用时本地账号仍然可以登录
+			log.Printf("[登录模块]LDAP 验证失败: %v", err)
+		}
+	}
+
+	var found models.User
+	if err := app.DB.Select("id").Where("username = ?", username).First(&found).Error; err != nil {
+		return nil, errInvalidLogin
+	}
+	user := app.LoadUser(found.ID)
+	if user == nil {
+		return nil, errInvalidLogin
+	}
+	if !isLocalUser(user) {
+		// 外部账号的本地密码是随机生成的，不能用于登录
+		return nil, errInvalidLogin
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, errInvalidLogin
+	}
+	return user, nil
+}
+
+// isLocalUser 是否为本地账号，旧数据的来源为空
+func isLocalUser(user *models.User) bool {
+	return user.Source == "" || user.Source == "local"
+}
+
+func ldapAuthenticate(conf internal.LDAPConfig, username, password string) (*sso.Identity, error) {
+	bindPassword, err := app.Vault.Resolve(conf.BindPassword, "sso:ldap")
+	if err != nil {
+		return nil, err
+	}
+
+	return sso.NewLDAP(sso.LDAPConfig{
+		URL:                conf.URL,
+		StartTLS:           conf.StartTLS,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+		BindDN:             conf.BindDN,
+		BindPassword:       bindPassword,
+		BaseDN:             conf.BaseDN,
+		UserFilter:         conf.UserFilter,
+		UsernameAttr:       conf.UsernameAttr,
+		NameAttr:           conf.NameAttr,
+		EmailAttr:          conf.EmailAttr,
+		GroupAttr:          conf.GroupAttr,
+	}).Authenticate(username, password)
+}
+
+// provisionUser 外部身份第一次登录时创建用户，之后每次登录按用户组更新角色，账号按来源和外部标识关联
+func provisionUser(identity *sso.Identity, roleConfs []internal.SSORoleConfig, defaultRole string) (*models.User, error) {
+	rules := make([]sso.RoleRule, 0, len(roleConfs))
+	for _, conf := range roleConfs {
+		rules = append(rules, sso.RoleRule{Group: conf.Group, Role: conf.Role})
+	}
+	roleName := sso.MapRole(identity.Groups, rules, defaultRole)
+	if roleName == "" {
+		return nil, errors.New("你所在的用户组没有对应的角色，请联系管理员")
+	}
+
+	var role models.Role
+	if err := app.DB.Where("name = ?", roleName).First(&role).Error; err != nil {
+		log.Printf("[登录模块]%s 用户 %s 映射的角色 %s 不存在", identity.Provider, identity.Username, roleName)
+		return nil, fmt.Errorf("角色 %s 不存在，请联系管理员", roleName)
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("身份提供方没有返回用户标识")
+	}
+
+	// 按来源和外部标识查找用户，用户名只用于显示，不用于关联账号
+	user, err := findExternalUser(identity)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		password, err := randomPassword()
+		if err != nil {
+			return nil, err
+		}
+		username, err := availableUsername(identity.Username, identity.Provider)
+		if err != nil {
+			return nil, err
+		}
+		user = &models.User{
+			Username:      username,
+			Password:      password,
+			Nickname:      identity.Nickname,
+			RoleID:        role.ID,
+			Status:        1,
+			Source:        identity.Provider,
+			Subject:       identity.Subject,
+			HasChangedPwd: true,
+		}
+		if err := app.DB.Create(user).Error; err != nil {
+			return nil, err
+		}
+		log.Printf("[登录模块]%s 用户 %s 首次登录，已创建账号 %s，角色 %s", identity.Provider, identity.Subject, user.Username, role.Name)
+	case err != nil:
+		return nil, err
+	case user.DeletedAt.Valid:
+		return nil, errors.New("账号已被删除，请联系管理员")
+	case user.Status != 1:
+		return nil, errors.New("账号已禁用")
+	default:
+		updates := map[string]any{"role_id": role.ID}
+		if identity.Nickname != "" {
+			updates["nickname"] = identity.Nickname
+		}
+		if err := app.DB.Model(user).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	loaded := app.LoadUser(user.ID)
+	if loaded == nil {
+		return nil, errors.New("加载用户失败")
+	}
+	return loaded, nil
+}
+
+// findExternalUser 按来源和外部标识查找用户，包括已删除的用户
+func findExternalUser(identity *sso.Identity) (*models.User, error) {
+	var user models.User
+	err := app.DB.Unscoped().Where("source = ? AND subject = ?", identity.Provider, identity.Subject).First(&user).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) || identity.Provider != sso.ProviderLDAP {
+		return &user, err
+	}
+
+	// 旧版本创建的 LDAP 账号没有保存 DN，用户名经过目录验证，第一次登录时补充标识
+	err = app.DB.Unscoped().Where("source = ? AND subject = '' AND username = ?", identity.Provider, identity.Username).First(&user).Error
+	if err != nil {
+		return &user, err
+	}
+	if err := app.DB.Unscoped().Model(&user).Update("subject", identity.Subject).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// availableUsername 新建外部账号时使用的用户名，已被其他账号使用时加上来源和序号
+func availableUsername(username, provider string) (string, error) {
+	if username == "" {
+		username = provider
+	}
+	candidate := username
+	for i := 1; i <= 100; i++ {
+		var count int64
+		if err := app.DB.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%s%d", username, provider, i)
+	}
+	return "", fmt.Errorf("用户名 %s 已被其他账号使用，请联系管理员", username)
+}
+
+// randomPassword 外部账号的本地密码，随机生成且不告诉任何人
+func randomPassword() (string, error) {
+	raw, err := sso.RandomString()
+	if err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// getOIDC 获取 OIDC 登录
+func getOIDC(ctx context.Context) (*sso.OIDC, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	if oidcClient != nil {
+		return oidcClient, nil
+	}
+
+	conf := app.Config.SSO.OIDC
+	clientSecret, err := app.Vault.Resolve(conf.ClientSecret, "sso:oidc")
+	if err != nil {
+		return nil, err
+	}
+	client, err := sso.NewOIDC(ctx, sso.OIDCConfig{
+		Issuer:        conf.Issuer,
+		ClientID:      conf.ClientID,
+		ClientSecret:  clientSecret,
+		RedirectURL:   conf.RedirectURL,
+		Scopes:        conf.Scopes,
+		UsernameClaim: conf.UsernameClaim,
+		NameClaim:     conf.NameClaim,
+		GroupsClaim:   conf.GroupsClaim,
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcClient = client
+	return client, nil
+}
+
+// oidcLoginHandler 跳转到身份提供方登录
+func oidcLoginHandler(c *fiber.Ctx) error {
+	if !app.Config.SSO.OIDC.Enabled {
+		return c.Redirect("/login")
+	}
+
+	client, err := getOIDC(c.Context())
+	if err != nil {
+		log.Printf("[登录模块]OIDC 初始化失败: %v", err)
+		return oidcFailed(c, "单点登录暂不可用")
+	}
+
+	state, err := sso.RandomString()
+	if err != nil {
+		return oidcFailed(c, "单点登录暂不可用")
+	}
+	nonce, err := sso.RandomString()
+	if err != nil {
+		return oidcFailed(c, "单点登录暂不可用")
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state + "." + nonce,
+		Path:     "/login/oidc",
+		Expires:  time.Now().Add(oidcStateExpire),
+		HTTPOnly: true,
+		Secure:   internal.IsSecureMode(),
+		SameSite: "Lax",
+	})
+	return c.Redirect(client.AuthCodeURL(state, nonce))
+}
+
+// oidcCallbackHandler 身份提供方登录后的回调
+func oidcCallbackHandler(c *fiber.Ctx) error {
+	if !app.Config.SSO.OIDC.Enabled {
+		return c.Redirect("/login")
+	}
+
+	// 无论成功与否 state 只能使用一次
+	cookie := c.Cookies(oidcStateCookie)
+	c.Cookie(&fiber.Cookie{Name: oidcStateCookie, Path: "/login/oidc", Expires: time.Unix(0, 0), HTTPOnly: true})
+
+	if errCode := c.Query("error"); errCode != "" {
+		log.Printf("[登录模块]OIDC 登录失败: %s %s", errCode, c.Query("error_description"))
+		return oidcFailed(c, "单点登录失败："+errCode)
+	}
+
+	state, nonce, ok := strings.Cut(cookie, ".")
+	if !ok || state == "" || state != c.Query("state") {
+		return oidcFailed(c, "登录请求已过期，请重新登录")
+	}
+
+	client, err := getOIDC(c.Context())
+	if err != nil {
+		log.Printf("[登录模块]OIDC 初始化失败: %v", err)
+		return oidcFailed(c, "单点登录暂不可用")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+	identity, err := client.Exchange(ctx, c.Query("code"), nonce)
+	if err != nil {
+		log.Printf("[登录模块]OIDC 登录失败: %v", err)
+		return oidcFailed(c, "单点登录失败")
+	}
+
+	conf := app.Config.SSO.OIDC
+	user, err := provisionUser(identity, conf.Roles, conf.DefaultRole)
+	if err != nil {
+		return oidcFailed(c, err.Error())
+	}
+
+	if err := checkLocked(user); err != nil {
+		recordAttempt(c, user.Username, user.ID, false, "账号已锁定")
+		return oidcFailed(c, err.Error())
+	}
+
+	// 启用两步验证的用户回到登录页面输入验证码
+	if user.TOTPEnabled {
+		if err := internal.StorePendingLogin(c, user.ID, false); err != nil {
+			return oidcFailed(c, "会话创建失败")
+		}
+		return c.Redirect("/login?mfa=1")
+	}
+
+	app.DB.Model(user).Update("last_login", time.Now())
+	if err := internal.StoreSession(c, user.ID, 0); err != nil {
+		return oidcFailed(c, "会话创建失败")
+	}
+	loginSucceeded(c, user)
+
+	// 登录页面读取当前用户并保存后进入后台
+	return c.Redirect("/login?sso=1")
+}
+
+func oidcFailed(c *fiber.Ctx, message string) error {
+	return c.Redirect("/login?sso_error=" + url.QueryEscape(message))
+}
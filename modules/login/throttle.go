@@ -0,0 +1,135 @@
+package login
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	throttleKindIP   = "ip"
+	throttleKindUser = "user"
+
+	// userFreeFailures、ipFreeFailures 不需要等待的失败次数，之后每次失败等待时间翻倍
+	userFreeFailures = 3
+	ipFreeFailures   = 5
+	// maxBackoff 指数退避的最长等待时间
+	maxBackoff = 5 * time.Minute
+	// throttleIdle 超过该时间没有失败，清除失败次数
+	throttleIdle = time.Hour
+)
+
+// throttleEntry 同一 IP 或用户名的登录失败情况
+type throttleEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// loginThrottle 按 IP 和用户名限制登录失败后的重试频率
+type loginThrottle struct {
+	mu      sync.Mutex
+	entries map[string]*throttleEntry
+}
+
+var throttle = &loginThrottle{entries: make(map[string]*throttleEntry)}
+
+func throttleKey(kind, value string) string {
+	if kind == throttleKindUser {
+		value = strings.ToLower(value)
+	}
+	return kind + ":" + value
+}
+
+// wait 返回需要等待的时间，多个键时取最长的
+func (t *loginThrottle) wait(keys ...string) time.Duration {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range keys {
+		if entry, ok := t.entries[key]; ok && entry.blockedUntil.After(now) {
+			wait = max(wait, entry.blockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// fail 记录一次失败，超过 free 次后按指数退避，达到 limit 次时锁定 lock 时间，limit 为 0 表示不锁定
+func (t *loginThrottle) fail(key string, free, limit int, lock time.Duration) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok || now.Sub(entry.lastFailure) > throttleIdle {
+		entry = &throttleEntry{}
+		t.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+
+	if limit > 0 && entry.failures >= limit {
+		entry.blockedUntil = now.Add(lock)
+		return
+	}
+	if entry.failures > free {
+		backoff := maxBackoff
+		if shift := entry.failures - free - 1; shift < 16 {
+			backoff = min(maxBackoff, time.Second<<shift)
+		}
+		entry.blockedUntil = now.Add(backoff)
+	}
+}
+
+// reset 清除失败记录
+func (t *loginThrottle) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// cleanup 清除长时间没有失败的记录
+func (t *loginThrottle) cleanup() {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, entry := range t.entries {
+		if now.Sub(entry.lastFailure) > throttleIdle && now.After(entry.blockedUntil) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// blocked 正在限制登录的 IP 和用户名
+func (t *loginThrottle) blocked() []ThrottleVO {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]ThrottleVO, 0)
+	for key, entry := range t.entries {
+		if !entry.blockedUntil.After(now) {
+			continue
+		}
+		kind, value, _ := strings.Cut(key, ":")
+		list = append(list, ThrottleVO{
+			Kind:         kind,
+			Value:        value,
+			Failures:     entry.failures,
+			LastFailure:  entry.lastFailure,
+			BlockedUntil: entry.blockedUntil,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].BlockedUntil.After(list[j].BlockedUntil)
+	})
+	return list
+}
@@ -0,0 +1,49 @@
+package login
+
+import (
+	"log"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorLoginRequest struct {
+	Code string `json:"code"` // 身份验证器的验证码或恢复码
+}
+
+// loginTwoFactorHandler 登录第二步，校验两步验证码后创建会话
+func loginTwoFactorHandler(c *fiber.Ctx) error {
+	var req TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	pending, err := internal.GetPendingLogin(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "expired": true})
+	}
+
+	user := app.LoadUser(pending.UserID)
+	if user == nil || user.Status != 1 {
+		internal.ClearPendingLogin(c)
+		return c.Status(401).JSON(fiber.Map{"error": "账号不存在或已禁用", "expired": true})
+	}
+
+	if err := checkLocked(user); err != nil {
+		internal.ClearPendingLogin(c)
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{"error": err.Error(), "expired": true})
+	}
+
+	usedRecovery, err := app.VerifyTwoFactor(user, req.Code)
+	if err != nil {
+		// 验证码错误计入账号的失败次数，防止反复输入密码后穷举验证码
+		loginFailed(c, user.Username, user, "两步验证码错误")
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	}
+	if usedRecovery {
+		log.Printf("[登录模块]用户 %s 使用恢复码登录，剩余 %d 个", user.Username, app.RemainingRecoveryCodes(user.ID))
+	}
+
+	internal.ClearPendingLogin(c)
+	return completeLogin(c, user, pending.Remember)
+}
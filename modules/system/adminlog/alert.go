@@ -0,0 +1,314 @@
+package adminlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/models"
+)
+
+const (
+	defaultWorkStart   = "09:00"
+	defaultWorkEnd     = "18:00"
+	defaultAlertWindow = 300 // 设置了阈值但没有设置时间窗口时使用 5 分钟
+)
+
+var alerts = &alertEngine{
+	hits:  make(map[uint][]time.Time),
+	fired: make(map[uint]time.Time),
+}
+
+// alertEngine 按告警规则检查操作日志事件，规则修改后重新加载
+type alertEngine struct {
+	mu     sync.Mutex
+	rules  []models.AdminLogAlertRule
+	loaded bool
+	hits   map[uint][]time.Time // 规则在时间窗口内匹配的时间
+	fired  map[uint]time.Time   // 规则最后一次告警的时间
+}
+
+// pendingAlert 需要发送的告警
+type pendingAlert struct {
+	rule  models.AdminLogAlertRule
+	count int
+}
+
+// invalidate 告警规则修改后调用，下一条日志重新加载规则
+func (e *alertEngine) invalidate() {
+	e.mu.Lock()
+	e.loaded = false
+	e.mu.Unlock()
+}
+
+// load 加载启用的规则，调用方持有锁
+func (e *alertEngine) load() error {
+	if e.loaded {
+		return nil
+	}
+
+	var rules []models.AdminLogAlertRule
+	if err := app.DB.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return err
+	}
+
+	active := make(map[uint]bool, len(rules))
+	for _, rule := range rules {
+		active[rule.ID] = true
+		if _, ok := e.fired[rule.ID]; !ok && rule.LastFiredAt != nil {
+			// 重启后冷却时间从上次告警开始计算
+			e.fired[rule.ID] = *rule.LastFiredAt
+		}
+	}
+	for id := range e.hits {
+		if !active[id] {
+			delete(e.hits, id)
+		}
+	}
+
+	e.rules = rules
+	e.loaded = true
+	return nil
+}
+
+// evaluate 返回日志触发的告警，at 为日志的时间
+func (e *alertEngine) evaluate(entry events.EventAddOperationLog, at time.Time) ([]pendingAlert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+
+	var result []pendingAlert
+	for _, rule := range e.rules {
+		if !matchRule(&rule, entry, at) {
+			continue
+		}
+
+		count := 1
+		if rule.Threshold > 1 {
+			window := time.Duration(rule.Window) * time.Second
+			if rule.Window <= 0 {
+				window = defaultAlertWindow * time.Second
+			}
+			hits := append(e.hits[rule.ID], at)
+			for len(hits) > 0 && at.Sub(hits[0]) > window {
+				hits = hits[1:]
+			}
+			e.hits[rule.ID] = hits
+			if len(hits) < rule.Threshold {
+				continue
+			}
+			count = len(hits)
+		}
+
+		if last, ok := e.fired[rule.ID]; ok && at.Sub(last) < time.Duration(rule.Cooldown)*time.Second {
+			continue
+		}
+
+		// 告警后重新计数，避免之后的每条日志都触发告警
+		delete(e.hits, rule.ID)
+		e.fired[rule.ID] = at
+		result = append(result, pendingAlert{rule: rule, count: count})
+	}
+	return result, nil
+}
+
+// matchRule 日志是否满足规则的所有条件
+func matchRule(rule *models.AdminLogAlertRule, entry events.EventAddOperationLog, at time.Time) bool {
+	if !matchList(rule.Actions, entry.Action) || !matchList(rule.Resources, entry.Resource) || !matchList(rule.Usernames, entry.Username) {
+		return false
+	}
+	if rule.Keyword != "" && !strings.Contains(entry.Details, rule.Keyword) {
+		return false
+	}
+	if rule.OffHours && inWorkHours(rule, at) {
+		return false
+	}
+	return true
+}
+
+// matchList 逗号分隔的列表是否包含 value，列表为空时匹配所有值
+func matchList(list, value string) bool {
+	if strings.TrimSpace(list) == "" {
+		return true
+	}
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// inWorkHours 是否在工作时间内，周末全天为工作时间之外，结束时间早于开始时间表示跨越零点
+func inWorkHours(rule *models.AdminLogAlertRule, at time.Time) bool {
+	if at.Weekday() == time.Saturday || at.Weekday() == time.Sunday {
+		return false
+	}
+
+	start := parseClock(rule.WorkStart, defaultWorkStart)
+	end := parseClock(rule.WorkEnd, defaultWorkEnd)
+	minute := at.Hour()*60 + at.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock 解析 15:04 格式的时间，返回当天的分钟数
+func parseClock(value, fallback string) int {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		t, _ = time.Parse("15:04", fallback)
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// validClock 时间格式是否为 15:04，为空时使用默认值
+func validClock(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
+
+// evaluateAlerts 订阅操作日志事件，按告警规则发送通知
+func evaluateAlerts(ctx context.Context, entry events.EventAddOperationLog) error {
+	at := entry.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	pending, err := alerts.evaluate(entry, at)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		fireAlert(p.rule, p.count, entry, at)
+	}
+	return nil
+}
+
+// fireAlert 发送告警并保存记录
+func fireAlert(rule models.AdminLogAlertRule, count int, entry events.EventAddOperationLog, at time.Time) {
+	payload := newAlertPayload(rule, count, entry, at)
+
+	alert := models.AdminLogAlert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Count:     count,
+		Message:   payload.Text,
+		CreatedAt: at,
+	}
+	if err := deliverAlert(rule, payload); err != nil {
+		alert.Error = truncate(err.Error(), 500)
+		log.Printf("[后台日志模块]告警规则 %s 发送通知失败: %v", rule.Name, err)
+	} else {
+		alert.Delivered = true
+	}
+
+	if err := app.DB.Create(&alert).Error; err != nil {
+		log.Printf("[后台日志模块]保存告警记录失败: %v", err)
+	}
+	app.DB.Model(&models.AdminLogAlertRule{}).Where("id = ?", rule.ID).UpdateColumn("last_fired_at", at)
+}
+
+// newAlertPayload 生成告警通知内容
+func newAlertPayload(rule models.AdminLogAlertRule, count int, entry events.EventAddOperationLog, at time.Time) AlertPayload {
+	username := entry.Username
+	if username == "" {
+		username = "匿名"
+	}
+
+	text := fmt.Sprintf("[操作日志告警] %s：%s 在 %s 执行 %s %s", rule.Name, username, at.Format("2006-01-02 15:04:05"), entry.Action, entry.Resource)
+	if entry.Details != "" {
+		text += "，" + entry.Details
+	}
+	if entry.IP != "" {
+		text += "（IP " + entry.IP + "）"
+	}
+	if count > 1 {
+		window := rule.Window
+		if window <= 0 {
+			window = defaultAlertWindow
+		}
+		text += fmt.Sprintf("，%d 秒内共 %d 次", window, count)
+	}
+
+	return AlertPayload{
+		Text:   text,
+		RuleID: rule.ID,
+		Rule:   rule.Name,
+		Count:  count,
+		Log: AlertLog{
+			ID:         entry.LogID,
+			UserID:     entry.UserID,
+			Username:   entry.Username,
+			Action:     entry.Action,
+			Resource:   entry.Resource,
+			ResourceID: entry.ResourceID,
+			Details:    entry.Details,
+			IP:         entry.IP,
+			CreatedAt:  at,
+		},
+	}
+}
+
+// deliverAlert 将告警以 JSON 发送到规则或配置中的 Webhook
+func deliverAlert(rule models.AdminLogAlertRule, payload AlertPayload) error {
+	url := rule.WebhookURL
+	if url == "" {
+		url = app.Config.AdminLog.AlertWebhook
+	}
+	if url == "" {
+		return errors.New("未配置 Webhook 地址")
+	}
+
+	url, err := app.Vault.Resolve(url, fmt.Sprintf("adminlog:alert:%d", rule.ID))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(app.Config.AdminLog.AlertTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("Webhook 返回 %d：%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// truncate 截断过长的字符串，按字符截断避免产生无效的 UTF-8
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,352 @@
+package adminlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+// verifyBatchSize 校验时每次读取的日志条数
+const verifyBatchSize = 500
+
+const (
+	// chainKeyPurpose 从主密钥派生哈希链密钥的用途
+	chainKeyPurpose = "adminlog:chain"
+	// headID 最新日志记录的固定 ID
+	headID = 1
+)
+
+var (
+	// chainMu 保证日志按顺序写入，每条日志都能读到上一条日志的哈希
+	chainMu sync.Mutex
+	// chainKey 哈希链的 HMAC 密钥，没有密钥无法在修改日志后重新计算哈希
+	chainKey []byte
+)
+
+// hashPayload 参与哈希计算的字段，时间精确到毫秒，与数据库的时间精度无关
+type hashPayload struct {
+	PrevHash   string `json:"prev_hash"`
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	Action     string `json:"action"`
+	Resource   string `json:"resource"`
+	ResourceID uint   `json:"resource_id"`
+	Details    string `json:"details"`
+	Changes    string `json:"changes"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// loadChainKey 从凭据库的主密钥派生哈希链密钥，主密钥不可用时只能发现意外的修改
+func loadChainKey() {
+	key, err := app.Vault.DeriveKey(chainKeyPurpose)
+	if err != nil {
+		log.Printf("[后台日志模块]主密钥不可用，操作日志哈希链不使用密钥: %v", err)
+		return
+	}
+	chainKey = key
+}
+
+// computeHash 计算日志的 HMAC
+func computeHash(entry *models.AdminLog) string {
+	data, _ := json.Marshal(hashPayload{
+		PrevHash:   entry.PrevHash,
+		UserID:     entry.UserID,
+		Username:   entry.Username,
+		Action:     entry.Action,
+		Resource:   entry.Resource,
+		ResourceID: entry.ResourceID,
+		Details:    entry.Details,
+		Changes:    entry.Changes,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		CreatedAt:  entry.CreatedAt.UnixMilli(),
+	})
+	return macHex(data)
+}
+
+// signAnchor 签名删除记录或最新日志记录的 ID 和哈希，kind 区分两种记录，签名不能互相挪用
+func signAnchor(kind string, id uint, hash string) string {
+	return macHex([]byte(fmt.Sprintf("%s:%d:%s", kind, id, hash)))
+}
+
+func macHex(data []byte) string {
+	mac := hmac.New(sha256.New, chainKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validCheckpoint 删除记录的签名是否正确
+func validCheckpoint(checkpoint *models.AdminLogCheckpoint) bool {
+	return hmac.Equal([]byte(checkpoint.Sig), []byte(signAnchor("checkpoint", checkpoint.LastID, checkpoint.LastHash)))
+}
+
+// saveHead 保存最新日志的 ID 和哈希，tx 可以是事务
+func saveHead(tx *gorm.DB, id uint, hash string) error {
+	return tx.Save(&models.AdminLogHead{
+		ID:        headID,
+		LastID:    id,
+		LastHash:  hash,
+		Sig:       signAnchor("head", id, hash),
+		UpdatedAt: time.Now(),
+	}).Error
+}
+
+// appendLog 写入日志，接在最后一条日志之后
+func appendLog(entry *models.AdminLog) error {
+	chainMu.Lock()
+	defer chainMu.Unlock()
+
+	var last models.AdminLog
+	if err := app.DB.Select("id", "hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	entry.PrevHash = last.Hash
+
+	// 最后的日志已归档删除时接在删除记录之后，恢复归档后仍然可以校验
+	var checkpoint models.AdminLogCheckpoint
+	if err := app.DB.Order("last_id DESC").Limit(1).Find(&checkpoint).Error; err != nil {
+		return err
+	}
+	if checkpoint.LastID > last.ID {
+		entry.PrevHash = checkpoint.LastHash
+	}
+	entry.Hash = computeHash(entry)
+
+	// 日志和最新日志记录在同一事务中写入，删除最新的日志后校验可以发现
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return saveHead(tx, entry.ID, entry.Hash)
+	})
+}
+
+// rekeyChain 使用 HMAC 重新计算启用密钥之前写入的日志哈希，并为删除记录和最新日志补充签名
+func rekeyChain() error {
+	if app.IsInitializedModule("adminlog:chain:hmac") {
+		return nil
+	}
+
+	chainMu.Lock()
+	defer chainMu.Unlock()
+
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		var (
+			rows             []models.AdminLog
+			last             models.AdminLog
+			prevOld, prevNew string
+			rekeyed          int
+		)
+		err := tx.Where("hash <> ''").FindInBatches(&rows, verifyBatchSize, func(batch *gorm.DB, n int) error {
+			for i := range rows {
+				row := &rows[i]
+				old := row.Hash
+				// 接在删除记录之后的日志保留删除记录中的哈希
+				if row.PrevHash == prevOld {
+					row.PrevHash = prevNew
+				}
+				row.Hash = computeHash(row)
+				if err := tx.Model(row).UpdateColumns(map[string]any{
+					"prev_hash": row.PrevHash,
+					"hash":      row.Hash,
+				}).Error; err != nil {
+					return err
+				}
+				prevOld, prevNew = old, row.Hash
+				last = *row
+			}
+			rekeyed += len(rows)
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var checkpoints []models.AdminLogCheckpoint
+		if err := tx.Find(&checkpoints).Error; err != nil {
+			return err
+		}
+		for i := range checkpoints {
+			sig := signAnchor("checkpoint", checkpoints[i].LastID, checkpoints[i].LastHash)
+			if err := tx.Model(&checkpoints[i]).Update("sig", sig).Error; err != nil {
+				return err
+			}
+		}
+
+		if last.ID != 0 {
+			if err := saveHead(tx, last.ID, last.Hash); err != nil {
+				return err
+			}
+			log.Printf("[后台日志模块]已使用密钥重新计算 %d 条日志的哈希", rekeyed)
+		}
+
+		// 标记模块已初始化
+		return tx.Create(&models.ModuleInit{
+			Module:      "adminlog:chain:hmac",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error
+	})
+}
+
+// sealLegacyLogs 为启用哈希链之前写入的日志计算哈希，哈希链已经开始后不再补充，没有哈希的日志在校验时报告
+func sealLegacyLogs() error {
+	chainMu.Lock()
+	defer chainMu.Unlock()
+
+	var heads int64
+	if err := app.DB.Model(&models.AdminLogHead{}).Count(&heads).Error; err != nil || heads > 0 {
+		return err
+	}
+
+	var first models.AdminLog
+	if err := app.DB.Where("hash = '' OR hash IS NULL").Order("id").Limit(1).Find(&first).Error; err != nil || first.ID == 0 {
+		return err
+	}
+
+	var prev models.AdminLog
+	if err := app.DB.Select("hash").Where("id < ?", first.ID).Order("id DESC").Limit(1).Find(&prev).Error; err != nil {
+		return err
+	}
+
+	var (
+		rows   []models.AdminLog
+		last   models.AdminLog
+		sealed int
+	)
+	prevHash := prev.Hash
+	err := app.DB.Where("id >= ?", first.ID).FindInBatches(&rows, verifyBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range rows {
+			rows[i].PrevHash = prevHash
+			rows[i].Hash = computeHash(&rows[i])
+			prevHash = rows[i].Hash
+			if err := app.DB.Model(&rows[i]).UpdateColumns(map[string]any{
+				"prev_hash": rows[i].PrevHash,
+				"hash":      rows[i].Hash,
+			}).Error; err != nil {
+				return err
+			}
+			last = rows[i]
+		}
+		sealed += len(rows)
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := saveHead(app.DB, last.ID, last.Hash); err != nil {
+		return err
+	}
+
+	log.Printf("[后台日志模块]已为 %d 条历史日志计算哈希", sealed)
+	return nil
+}
+
+// verifyChain 按顺序校验所有日志的哈希，返回第一条校验失败的日志，最后确认最新的日志没有被删除
+func verifyChain() (VerifyResult, error) {
+	// 归档后删除的日志，其后的第一条日志可以接在删除记录之后，签名不正确的删除记录不能使用
+	var checkpoints []models.AdminLogCheckpoint
+	if err := app.DB.Find(&checkpoints).Error; err != nil {
+		return VerifyResult{}, err
+	}
+	archived := make(map[string]bool, len(checkpoints))
+	var archivedID uint
+	for i := range checkpoints {
+		if validCheckpoint(&checkpoints[i]) {
+			archived[checkpoints[i].LastHash] = true
+			archivedID = max(archivedID, checkpoints[i].LastID)
+		}
+	}
+
+	var head models.AdminLogHead
+	if err := app.DB.Limit(1).Find(&head, headID).Error; err != nil {
+		return VerifyResult{}, err
+	}
+
+	result := VerifyResult{Valid: true}
+	var (
+		prevHash string
+		lastID   uint
+	)
+	for {
+		var rows []models.AdminLog
+		if err := app.DB.Where("id > ?", lastID).Order("id").Limit(verifyBatchSize).Find(&rows).Error; err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			if row.Hash != computeHash(row) {
+				return result.broken(row.ID, "日志内容被修改"), nil
+			}
+			if row.PrevHash != prevHash && !archived[row.PrevHash] {
+				return result.broken(row.ID, "该日志之前的日志被删除或修改"), nil
+			}
+			prevHash = row.Hash
+			result.Checked++
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	return verifyHead(result, &head, lastID, prevHash, archivedID), nil
+}
+
+// verifyHead 校验最后一条日志与单独保存的最新日志记录一致
+func verifyHead(result VerifyResult, head *models.AdminLogHead, lastID uint, lastHash string, archivedID uint) VerifyResult {
+	if head.ID == 0 {
+		if lastID != 0 {
+			return result.broken(lastID, "最新日志记录丢失")
+		}
+		return result
+	}
+	if !hmac.Equal([]byte(head.Sig), []byte(signAnchor("head", head.LastID, head.LastHash))) {
+		return result.broken(head.LastID, "最新日志记录被修改")
+	}
+
+	switch {
+	case lastID == head.LastID && lastHash == head.LastHash:
+		return result
+	case lastID == 0 && head.LastID <= archivedID:
+		// 所有日志都已归档删除
+		return result
+	case lastID < head.LastID:
+		return result.broken(head.LastID, fmt.Sprintf("最新的日志被删除，最后一条日志应为 #%d", head.LastID))
+	default:
+		return result.broken(lastID, "最新日志与记录不一致")
+	}
+}
+
+// RecordPurge 记录归档后删除的日志，数据保留模块在删除每批日志的事务中调用，rows 为 *[]models.AdminLog
+// 删除记录与删除在同一事务中提交，保存失败时回滚删除，避免校验时出现无法解释的缺口
+func RecordPurge(tx *gorm.DB, rows any) error {
+	list, ok := rows.(*[]models.AdminLog)
+	if !ok || len(*list) == 0 {
+		return nil
+	}
+
+	last := (*list)[len(*list)-1]
+	checkpoint := models.AdminLogCheckpoint{
+		LastID:   last.ID,
+		LastHash: last.Hash,
+		Deleted:  int64(len(*list)),
+		Sig:      signAnchor("checkpoint", last.ID, last.Hash),
+	}
+	if err := tx.Create(&checkpoint).Error; err != nil {
+		return fmt.Errorf("保存删除记录失败: %w", err)
+	}
+	return nil
+}
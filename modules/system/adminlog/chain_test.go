@@ -0,0 +1,330 @@
+package adminlog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newChainTestApp 使用内存数据库和固定的哈希链密钥，测试结束后恢复包级变量
+func newChainTestApp(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&models.AdminLog{}, &models.AdminLogCheckpoint{}, &models.AdminLogHead{}, &models.ModuleInit{}); err != nil {
+		t.Fatal(err)
+	}
+
+	oldApp, oldKey := app, chainKey
+	app = &internal.App{DB: db}
+	chainKey = bytes.Repeat([]byte{1}, 32)
+	t.Cleanup(func() {
+		app, chainKey = oldApp, oldKey
+		sqlDB.Close()
+	})
+	return db
+}
+
+// newTestLog 第 i 条测试日志
+func newTestLog(i int) *models.AdminLog {
+	return &models.AdminLog{
+		UserID:     1,
+		Username:   "admin",
+		Action:     "update",
+		Resource:   "user",
+		ResourceID: uint(i),
+		Details:    fmt.Sprintf("更新用户 %d", i),
+		IP:         "127.0.0.1",
+		CreatedAt:  time.Now(),
+	}
+}
+
+// appendTestLogs 按顺序写入 n 条日志
+func appendTestLogs(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := appendLog(newTestLog(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// purgeTestLogs 与数据保留模块一样删除 ID 不大于 lastID 的日志，并在同一事务中写入删除记录，返回删除的日志
+func purgeTestLogs(t *testing.T, db *gorm.DB, lastID uint) []models.AdminLog {
+	t.Helper()
+	var rows []models.AdminLog
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id <= ?", lastID).Order("id").Find(&rows).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id <= ?", lastID).Delete(&models.AdminLog{}).Error; err != nil {
+			return err
+		}
+		return RecordPurge(tx, &rows)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func mustVerify(t *testing.T) VerifyResult {
+	t.Helper()
+	result, err := verifyChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestVerifyChain(t *testing.T) {
+	newChainTestApp(t)
+
+	if result := mustVerify(t); !result.Valid || result.Checked != 0 {
+		t.Errorf("empty chain: %+v", result)
+	}
+
+	appendTestLogs(t, 5)
+	if result := mustVerify(t); !result.Valid || result.Checked != 5 {
+		t.Errorf("verify = %+v, want 5 valid logs", result)
+	}
+}
+
+func TestVerifyChainTampered(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(db *gorm.DB) error
+		broken uint
+	}{
+		{
+			name: "edited row",
+			tamper: func(db *gorm.DB) error {
+				return db.Model(&models.AdminLog{ID: 3}).UpdateColumn("details", "没有操作").Error
+			},
+			broken: 3,
+		},
+		{
+			name: "edited row rehashed without the key",
+			tamper: func(db *gorm.DB) error {
+				var row models.AdminLog
+				db.First(&row, 3)
+				row.Details = "没有操作"
+				chainKey = nil
+				hash := computeHash(&row)
+				chainKey = bytes.Repeat([]byte{1}, 32)
+				return db.Model(&row).UpdateColumns(map[string]any{"details": row.Details, "hash": hash}).Error
+			},
+			broken: 3,
+		},
+		{
+			name: "deleted middle row",
+			tamper: func(db *gorm.DB) error {
+				return db.Delete(&models.AdminLog{}, 3).Error
+			},
+			broken: 4,
+		},
+		{
+			// 删除最后一条日志后其余日志的哈希链仍然完整，只能由最新日志记录发现
+			name: "deleted last row",
+			tamper: func(db *gorm.DB) error {
+				return db.Delete(&models.AdminLog{}, 5).Error
+			},
+			broken: 5,
+		},
+		{
+			name: "deleted first rows without checkpoint",
+			tamper: func(db *gorm.DB) error {
+				return db.Where("id <= ?", 2).Delete(&models.AdminLog{}).Error
+			},
+			broken: 3,
+		},
+		{
+			name: "forged checkpoint",
+			tamper: func(db *gorm.DB) error {
+				var row models.AdminLog
+				db.First(&row, 2)
+				if err := db.Where("id <= ?", 2).Delete(&models.AdminLog{}).Error; err != nil {
+					return err
+				}
+				return db.Create(&models.AdminLogCheckpoint{LastID: row.ID, LastHash: row.Hash, Deleted: 2, Sig: "forged"}).Error
+			},
+			broken: 3,
+		},
+		{
+			name: "edited head",
+			tamper: func(db *gorm.DB) error {
+				return db.Model(&models.AdminLogHead{ID: headID}).UpdateColumn("last_id", 4).Error
+			},
+			broken: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newChainTestApp(t)
+			appendTestLogs(t, 5)
+
+			if err := tt.tamper(db); err != nil {
+				t.Fatal(err)
+			}
+			result := mustVerify(t)
+			if result.Valid || result.BrokenID != tt.broken {
+				t.Errorf("verify = %+v, want broken at #%d", result, tt.broken)
+			}
+		})
+	}
+}
+
+func TestVerifyChainPurge(t *testing.T) {
+	db := newChainTestApp(t)
+	appendTestLogs(t, 5)
+
+	purgeTestLogs(t, db, 3)
+	if result := mustVerify(t); !result.Valid || result.Checked != 2 {
+		t.Fatalf("after purge: %+v, want 2 valid logs", result)
+	}
+
+	var checkpoint models.AdminLogCheckpoint
+	db.First(&checkpoint)
+	if checkpoint.LastID != 3 || checkpoint.Deleted != 3 {
+		t.Errorf("checkpoint = %+v", checkpoint)
+	}
+
+	// 删除剩余的全部日志后，新的日志接在删除记录之后
+	purgeTestLogs(t, db, 5)
+	if result := mustVerify(t); !result.Valid || result.Checked != 0 {
+		t.Fatalf("after purging all logs: %+v", result)
+	}
+	appendTestLogs(t, 2)
+	if result := mustVerify(t); !result.Valid || result.Checked != 2 {
+		t.Errorf("after appending: %+v, want 2 valid logs", result)
+	}
+}
+
+func TestVerifyChainPurgeRollback(t *testing.T) {
+	db := newChainTestApp(t)
+	appendTestLogs(t, 5)
+
+	// 删除记录写入失败时删除回滚，不会留下无法解释的缺口
+	if err := db.Migrator().DropTable(&models.AdminLogCheckpoint{}); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []models.AdminLog
+		tx.Where("id <= ?", 3).Find(&rows)
+		if err := tx.Where("id <= ?", 3).Delete(&models.AdminLog{}).Error; err != nil {
+			return err
+		}
+		return RecordPurge(tx, &rows)
+	})
+	if err == nil {
+		t.Fatal("RecordPurge succeeded without the checkpoint table")
+	}
+
+	var count int64
+	db.Model(&models.AdminLog{}).Count(&count)
+	if count != 5 {
+		t.Errorf("%d logs left after the rollback, want 5", count)
+	}
+}
+
+func TestVerifyChainRestoreArchive(t *testing.T) {
+	db := newChainTestApp(t)
+	appendTestLogs(t, 5)
+
+	archived := purgeTestLogs(t, db, 3)
+	appendTestLogs(t, 1)
+
+	// 恢复归档时按原 ID 写回，恢复的日志与其后的日志重新连成完整的链
+	if err := db.Create(&archived).Error; err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); !result.Valid || result.Checked != 6 {
+		t.Errorf("after restore: %+v, want 6 valid logs", result)
+	}
+
+	// 恢复保留到期后再次删除
+	if err := db.Where("id <= ?", 3).Delete(&models.AdminLog{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); !result.Valid || result.Checked != 3 {
+		t.Errorf("after releasing the restore: %+v, want 3 valid logs", result)
+	}
+}
+
+func TestRekeyChain(t *testing.T) {
+	db := newChainTestApp(t)
+
+	// 主密钥可用之前写入的日志和删除记录
+	chainKey = nil
+	appendTestLogs(t, 5)
+	purgeTestLogs(t, db, 2)
+
+	chainKey = bytes.Repeat([]byte{1}, 32)
+	if result := mustVerify(t); result.Valid {
+		t.Fatal("logs hashed without the key verified with the key")
+	}
+
+	if err := rekeyChain(); err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); !result.Valid || result.Checked != 3 {
+		t.Fatalf("after rekey: %+v, want 3 valid logs", result)
+	}
+
+	// 只执行一次，之后的修改不会被重新计算掩盖
+	if err := db.Model(&models.AdminLog{ID: 4}).UpdateColumn("details", "没有操作").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := rekeyChain(); err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); result.Valid || result.BrokenID != 4 {
+		t.Errorf("second rekey: %+v, want broken at #4", result)
+	}
+}
+
+func TestSealLegacyLogs(t *testing.T) {
+	db := newChainTestApp(t)
+
+	// 启用哈希链之前写入的日志没有哈希
+	for i := 0; i < 3; i++ {
+		if err := db.Create(newTestLog(i)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sealLegacyLogs(); err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); !result.Valid || result.Checked != 3 {
+		t.Fatalf("after seal: %+v, want 3 valid logs", result)
+	}
+
+	appendTestLogs(t, 2)
+	if result := mustVerify(t); !result.Valid || result.Checked != 5 {
+		t.Fatalf("after appending: %+v, want 5 valid logs", result)
+	}
+
+	// 哈希链开始后绕过 appendLog 写入的日志不再补充哈希
+	if err := db.Create(newTestLog(9)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := sealLegacyLogs(); err != nil {
+		t.Fatal(err)
+	}
+	if result := mustVerify(t); result.Valid || result.BrokenID != 6 {
+		t.Errorf("unsealed log: %+v, want broken at #6", result)
+	}
+}
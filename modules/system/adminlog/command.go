@@ -10,10 +10,21 @@ import (
 
 func subscribeEvents(bus *event.EventBus) {
 	event.Subscribe(bus, event.EventHandler[events.EventAddOperationLog](commandAddLog))
+	event.Subscribe(bus, event.EventHandler[events.EventAddOperationLog](evaluateAlerts))
 }
 
 // commandAddLog 写入操作日志命令
 func commandAddLog(ctx context.Context, event events.EventAddOperationLog) error {
+	// WriteLog 和 Record 写入的日志已经保存，事件只用于通知
+	if event.LogID != 0 {
+		return nil
+	}
+
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = app.DB.NowFunc()
+	}
+
 	log := models.AdminLog{
 		UserID:     event.UserID,
 		Username:   event.Username,
@@ -23,12 +34,8 @@ func commandAddLog(ctx context.Context, event events.EventAddOperationLog) error
 		Details:    event.Details,
 		IP:         event.IP,
 		UserAgent:  event.UserAgent,
-		CreatedAt:  app.DB.NowFunc(),
-	}
-
-	if err := app.DB.Create(&log).Error; err != nil {
-		return err
+		CreatedAt:  createdAt,
 	}
 
-	return nil
+	return appendLog(&log)
 }
@@ -0,0 +1,107 @@
+package adminlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// schemaCache 模型表结构的缓存
+var schemaCache sync.Map
+
+// diffIgnored 不记录修改的字段
+var diffIgnored = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// diffMasked 只记录发生了修改、不记录值的字段，如上报密钥
+var diffMasked = map[string]bool{
+	"api_key": true,
+}
+
+// maskedValue 脱敏字段记录的值
+var maskedValue = json.RawMessage(`"******"`)
+
+// Diff 比较模型修改前后的字段，字段名和值与模型的 JSON 格式一致，不输出 JSON 的字段（如密码）不会记录，脱敏字段只记录发生了修改。
+// 只比较数据表的字段，不比较关联，before 为 nil 时记录所有字段的新值，after 为 nil 时记录所有字段的旧值
+func Diff(before, after any) map[string]Change {
+	oldFields := jsonFields(before)
+	newFields := jsonFields(after)
+
+	changes := make(map[string]Change)
+	for name, value := range oldFields {
+		if newValue, ok := newFields[name]; !ok || !bytes.Equal(value, newValue) {
+			changes[name] = Change{Before: value, After: newFields[name]}
+		}
+	}
+	for name, value := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+	for name, change := range changes {
+		if diffMasked[name] {
+			changes[name] = maskChange(change)
+		}
+	}
+	return changes
+}
+
+// maskChange 隐藏脱敏字段的值，保留修改前后是否有值
+func maskChange(change Change) Change {
+	if change.Before != nil {
+		change.Before = maskedValue
+	}
+	if change.After != nil {
+		change.After = maskedValue
+	}
+	return change
+}
+
+// jsonFields 模型数据表字段的 JSON 值，关联和忽略的字段不返回
+func jsonFields(model any) map[string]json.RawMessage {
+	if model == nil {
+		return nil
+	}
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	columns := columnNames(model)
+	for name := range fields {
+		if diffIgnored[name] || (columns != nil && !columns[name]) {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// columnNames 模型中对应数据表字段的 JSON 名称，不是 GORM 模型时返回 nil
+func columnNames(model any) map[string]bool {
+	sch, err := schema.Parse(model, &schemaCache, app.DB.NamingStrategy)
+	if err != nil {
+		return nil
+	}
+
+	names := make(map[string]bool, len(sch.Fields))
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -1,6 +1,10 @@
 package adminlog
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 // AdminLogEntry 存储操作日志信息的结构体
 type AdminLogEntry struct {
@@ -14,3 +18,98 @@ type AdminLogEntry struct {
 	UserAgent  string    // 用户代理
 	CreatedAt  time.Time // 创建时间
 }
+
+// Entry 结构化的操作日志
+type Entry struct {
+	Action     string // 操作类型
+	Resource   string // 资源类型
+	ResourceID uint   // 资源ID
+	Details    string // 操作详情
+	Before     any    // 修改前的模型，创建时为 nil
+	After      any    // 修改后的模型，删除时为 nil
+}
+
+// Change 字段修改前后的值
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// VerifyResult 哈希链校验结果
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`             // 校验通过的日志条数
+	BrokenID uint   `json:"broken_id,omitempty"` // 第一条校验失败的日志
+	Reason   string `json:"reason,omitempty"`
+}
+
+func (r VerifyResult) broken(id uint, reason string) VerifyResult {
+	r.Valid = false
+	r.BrokenID = id
+	r.Reason = reason
+	return r
+}
+
+// LogFilter 操作日志的查询条件，列表和导出共用
+type LogFilter struct {
+	UserID     uint   `query:"userId"`
+	Username   string `query:"username"` // 模糊匹配
+	Action     string `query:"action"`
+	Resource   string `query:"resource"`
+	ResourceID uint   `query:"resourceId"`
+	IP         string `query:"ip"`      // 前缀匹配，如 192.168.1.
+	Keyword    string `query:"keyword"` // 操作详情包含的关键字
+	StartDate  string `query:"startDate"`
+	EndDate    string `query:"endDate"` // 只有日期时包含当天
+}
+
+// clone 复制过滤条件中的字符串，使其不再引用请求的缓冲区
+func (f LogFilter) clone() LogFilter {
+	f.Username = strings.Clone(f.Username)
+	f.Action = strings.Clone(f.Action)
+	f.Resource = strings.Clone(f.Resource)
+	f.IP = strings.Clone(f.IP)
+	f.Keyword = strings.Clone(f.Keyword)
+	f.StartDate = strings.Clone(f.StartDate)
+	f.EndDate = strings.Clone(f.EndDate)
+	return f
+}
+
+// AlertPayload 发送到 Webhook 的告警内容
+type AlertPayload struct {
+	Text   string   `json:"text"` // 通知文本，可以直接用于常见聊天工具的 Webhook
+	RuleID uint     `json:"rule_id"`
+	Rule   string   `json:"rule"`
+	Count  int      `json:"count"` // 时间窗口内匹配的次数
+	Log    AlertLog `json:"log"`   // 触发告警的日志
+}
+
+// AlertLog 触发告警的日志
+type AlertLog struct {
+	ID         uint      `json:"id,omitempty"`
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID uint      `json:"resource_id"`
+	Details    string    `json:"details"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AlertRuleForm 告警规则表单
+type AlertRuleForm struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Actions    string `json:"actions"`
+	Resources  string `json:"resources"`
+	Usernames  string `json:"usernames"`
+	Keyword    string `json:"keyword"`
+	OffHours   bool   `json:"off_hours"`
+	WorkStart  string `json:"work_start"`
+	WorkEnd    string `json:"work_end"`
+	Threshold  int    `json:"threshold"`
+	Window     int    `json:"window"`
+	Cooldown   int    `json:"cooldown"`
+	WebhookURL string `json:"webhook_url"`
+}
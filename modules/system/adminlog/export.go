@@ -0,0 +1,101 @@
+package adminlog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/models"
+)
+
+// exportBatchSize 导出时每次读取的日志条数，每页读完后释放数据库连接再写出
+const exportBatchSize = 500
+
+var csvHeader = []string{"id", "created_at", "user_id", "username", "action", "resource", "resource_id", "details", "ip", "user_agent", "changes", "hash"}
+
+// writeExport 按 id 分页读取不晚于 maxID 的日志并逐页写入输出，
+// 不在下载期间持有查询游标，避免 SQLite 在慢速下载时一直锁住数据库
+func writeExport(w *bufio.Writer, filter LogFilter, maxID uint, format string) error {
+	var cw *csv.Writer
+	if format == "csv" {
+		// Excel 需要 BOM 才能正确识别 UTF-8
+		w.WriteString("\xEF\xBB\xBF")
+		cw = csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	var lastID uint
+	for {
+		query, err := filterLogs(filter)
+		if err != nil {
+			return err
+		}
+		var rows []models.AdminLog
+		if err := query.Where("id > ? AND id <= ?", lastID, maxID).Order("id").Limit(exportBatchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+
+		for i := range rows {
+			if cw != nil {
+				if err := cw.Write(csvRecord(&rows[i])); err != nil {
+					return err
+				}
+				continue
+			}
+			data, err := json.Marshal(rows[i])
+			if err != nil {
+				return err
+			}
+			w.Write(data)
+			w.WriteByte('\n')
+		}
+
+		if cw != nil {
+			cw.Flush()
+		}
+		if err := w.Flush(); err != nil {
+			// 客户端已断开
+			return err
+		}
+	}
+
+	if cw != nil {
+		cw.Flush()
+	}
+	return w.Flush()
+}
+
+// csvRecord 日志转换为 CSV 的一行
+func csvRecord(entry *models.AdminLog) []string {
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt.Format(time.RFC3339),
+		strconv.FormatUint(uint64(entry.UserID), 10),
+		csvSafe(entry.Username),
+		entry.Action,
+		entry.Resource,
+		strconv.FormatUint(uint64(entry.ResourceID), 10),
+		csvSafe(entry.Details),
+		entry.IP,
+		csvSafe(entry.UserAgent),
+		entry.Changes,
+		entry.Hash,
+	}
+}
+
+// csvSafe 以公式字符开头的内容加上单引号，避免在表格软件中被当作公式执行
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
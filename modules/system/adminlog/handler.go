@@ -1,6 +1,12 @@
 package adminlog
 
 import (
+	"bufio"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/events"
 	"github.com/andycai/goapi/models"
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,29 +18,20 @@ func listLogsHandler(c *fiber.Ctx) error {
 	// 获取查询参数
 	page := c.QueryInt("page", 1)
 	pageSize := c.QueryInt("pageSize", 10)
-	username := c.Query("username")
-	action := c.Query("action")
-	resource := c.Query("resource")
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-
-	// 构建查询
-	query := app.DB.Model(&models.AdminLog{})
 
-	if username != "" {
-		query = query.Where("username LIKE ?", "%"+username+"%")
-	}
-	if action != "" {
-		query = query.Where("action = ?", action)
-	}
-	if resource != "" {
-		query = query.Where("resource = ?", resource)
-	}
-	if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
+	var filter LogFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "无效的查询条件",
+		})
 	}
-	if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
+
+	// 构建查询
+	query, err := filterLogs(filter)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	// 获取总数
@@ -57,7 +54,253 @@ func listLogsHandler(c *fiber.Ctx) error {
 	})
 }
 
-// deleteLogsHandler 删除指定日期之前的操作日志
+// exportLogsHandler 按查询条件导出操作日志，format 为 csv 或 ndjson，按时间顺序流式输出
+func exportLogsHandler(c *fiber.Ctx) error {
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "导出格式只支持 csv 和 ndjson",
+		})
+	}
+
+	var filter LogFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "无效的查询条件",
+		})
+	}
+
+	// 先校验过滤条件，导出时每页重新构造查询
+	if _, err := filterLogs(filter); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// 导出范围固定为请求时已有的日志，不包含导出过程中新写入的日志
+	var maxID uint
+	if err := app.DB.Model(&models.AdminLog{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "导出操作日志失败",
+		})
+	}
+	// 查询参数引用的请求内容在处理函数返回后会被复用，流式输出前先复制
+	filter = filter.clone()
+
+	WriteLog(c, "export", "adminlog", 0, fmt.Sprintf("导出操作日志（%s）：%s", format, c.Context().QueryArgs().String()))
+
+	c.Attachment(fmt.Sprintf("adminlog-%s.%s", time.Now().Format("20060102-150405"), format))
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeExport(w, filter, maxID, format); err != nil {
+			log.Printf("[后台日志模块]导出操作日志失败: %v", err)
+		}
+	})
+	return nil
+}
+
+// listAlertRulesHandler 获取告警规则列表
+func listAlertRulesHandler(c *fiber.Ctx) error {
+	var rules []models.AdminLogAlertRule
+	if err := app.DB.Order("id").Find(&rules).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "获取告警规则失败",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            rules,
+		"default_webhook": app.Config.AdminLog.AlertWebhook != "",
+	})
+}
+
+// createAlertRuleHandler 创建告警规则
+func createAlertRuleHandler(c *fiber.Ctx) error {
+	var form AlertRuleForm
+	if err := c.BodyParser(&form); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "无效的请求数据",
+		})
+	}
+
+	var rule models.AdminLogAlertRule
+	if err := applyAlertRuleForm(&rule, form); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := app.DB.Create(&rule).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "创建告警规则失败",
+		})
+	}
+	alerts.invalidate()
+
+	Record(c, Entry{
+		Action:     "create",
+		Resource:   "adminlog_alert",
+		ResourceID: rule.ID,
+		Details:    fmt.Sprintf("创建告警规则：%s", rule.Name),
+		After:      &rule,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "创建成功",
+		"data":    rule,
+	})
+}
+
+// updateAlertRuleHandler 修改告警规则
+func updateAlertRuleHandler(c *fiber.Ctx) error {
+	var rule models.AdminLogAlertRule
+	if err := app.DB.First(&rule, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "告警规则不存在",
+		})
+	}
+
+	var form AlertRuleForm
+	if err := c.BodyParser(&form); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "无效的请求数据",
+		})
+	}
+
+	before := rule
+	if err := applyAlertRuleForm(&rule, form); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := app.DB.Save(&rule).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "修改告警规则失败",
+		})
+	}
+	alerts.invalidate()
+
+	Record(c, Entry{
+		Action:     "update",
+		Resource:   "adminlog_alert",
+		ResourceID: rule.ID,
+		Details:    fmt.Sprintf("修改告警规则：%s", rule.Name),
+		Before:     &before,
+		After:      &rule,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "修改成功",
+	})
+}
+
+// deleteAlertRuleHandler 删除告警规则，告警记录保留
+func deleteAlertRuleHandler(c *fiber.Ctx) error {
+	var rule models.AdminLogAlertRule
+	if err := app.DB.First(&rule, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "告警规则不存在",
+		})
+	}
+
+	if err := app.DB.Delete(&rule).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "删除告警规则失败",
+		})
+	}
+	alerts.invalidate()
+
+	Record(c, Entry{
+		Action:     "delete",
+		Resource:   "adminlog_alert",
+		ResourceID: rule.ID,
+		Details:    fmt.Sprintf("删除告警规则：%s", rule.Name),
+		Before:     &rule,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "删除成功",
+	})
+}
+
+// testAlertRuleHandler 发送一条测试告警，检查 Webhook 是否可用
+func testAlertRuleHandler(c *fiber.Ctx) error {
+	var rule models.AdminLogAlertRule
+	if err := app.DB.First(&rule, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "告警规则不存在",
+		})
+	}
+
+	user := app.CurrentUser(c)
+	payload := newAlertPayload(rule, 1, events.EventAddOperationLog{
+		UserID:   user.ID,
+		Username: user.Username,
+		IP:       c.IP(),
+		Action:   "test",
+		Resource: "adminlog_alert",
+		Details:  "测试告警通知",
+	}, time.Now())
+	if err := deliverAlert(rule, payload); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "发送失败：" + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "测试告警已发送",
+	})
+}
+
+// listAlertsHandler 获取触发的告警记录
+func listAlertsHandler(c *fiber.Ctx) error {
+	var total int64
+
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	query := app.DB.Model(&models.AdminLogAlert{})
+	if ruleID := c.QueryInt("ruleId"); ruleID > 0 {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+
+	query.Count(&total)
+
+	var list []models.AdminLogAlert
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&list).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "获取告警记录失败",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"total": total,
+		"data":  list,
+	})
+}
+
+// verifyLogsHandler 校验操作日志的哈希链，检查日志是否被修改或删除
+func verifyLogsHandler(c *fiber.Ctx) error {
+	result, err := verifyChain()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "校验操作日志失败",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": result,
+	})
+}
+
+// deleteLogsHandler 归档并删除指定日期之前的操作日志，日志只能在写入归档文件后删除
 func deleteLogsHandler(c *fiber.Ctx) error {
 	beforeDate := c.Query("beforeDate")
 	if beforeDate == "" {
@@ -66,13 +309,39 @@ func deleteLogsHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := app.DB.Where("created_at < ?", beforeDate).Delete(&models.AdminLog{}).Error; err != nil {
+	before, err := parseDate(beforeDate)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "无效的日期",
+		})
+	}
+
+	if archiver == nil {
 		return c.Status(500).JSON(fiber.Map{
-			"error": "删除操作日志失败",
+			"error": "没有可用的归档方式，不能删除操作日志",
 		})
 	}
 
+	deleted, archiveID, err := archiver(before)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "归档操作日志失败：" + err.Error(),
+		})
+	}
+
+	WriteLog(c, "delete", "adminlog", archiveID, fmt.Sprintf("归档并删除 %s 之前的操作日志，共 %d 条", before.Format("2006-01-02 15:04:05"), deleted))
+
 	return c.JSON(fiber.Map{
-		"message": "删除成功",
+		"message":    fmt.Sprintf("已归档并删除 %d 条日志", deleted),
+		"deleted":    deleted,
+		"archive_id": archiveID,
 	})
 }
+
+// parseDate 解析日期，支持 2006-01-02 和 RFC3339 格式
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -15,6 +15,10 @@ import (
 func autoMigrate() error {
 	return app.DB.AutoMigrate(
 		&models.AdminLog{},
+		&models.AdminLogCheckpoint{},
+		&models.AdminLogHead{},
+		&models.AdminLogAlertRule{},
+		&models.AdminLogAlert{},
 	)
 }
 
@@ -28,6 +32,14 @@ func initData() error {
 		return err
 	}
 
+	if err := initAlertMenus(); err != nil {
+		return err
+	}
+
+	if err := initAlertPermissions(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -123,3 +135,78 @@ func initPermissions() error {
 		return nil
 	})
 }
+
+func initAlertMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("adminlog:menu:alert") {
+		log.Println("[后台日志模块]告警规则菜单已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		alertMenu := models.Menu{
+			MenuID:     1013,
+			ParentID:   enum.MenuIdSystem,
+			Name:       "日志告警",
+			Path:       "/admin/adminlog/alerts",
+			Icon:       "adminlog",
+			Sort:       6,
+			Permission: "adminlog:alert",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&alertMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "adminlog:menu:alert",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initAlertPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("adminlog:permission:alert") {
+		log.Println("[后台日志模块]告警规则权限已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		permission := models.Permission{
+			Name:        "管理日志告警",
+			Code:        "adminlog:alert",
+			Description: "管理操作日志告警规则，查看告警记录",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(&permission).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "adminlog:permission:alert",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -31,6 +31,17 @@ func (m *adminlogModule) Start() error {
 		return err
 	}
 
+	// 哈希链使用主密钥派生的密钥，启用密钥之前的日志重新计算哈希
+	loadChainKey()
+	if err := rekeyChain(); err != nil {
+		return err
+	}
+
+	// 启用哈希链之前写入的日志补充哈希
+	if err := sealLegacyLogs(); err != nil {
+		return err
+	}
+
 	subscribeEvents(app.Bus)
 
 	return nil
@@ -47,9 +58,27 @@ func (m *adminlogModule) AddAuthRouters() error {
 		}, "admin/layout")
 	})
 
+	app.RouterAdmin.Get("/adminlog/alerts", app.HasPermission("adminlog:alert"), func(c *fiber.Ctx) error {
+		return c.Render("admin/adminlog_alerts", fiber.Map{
+			"Title": "日志告警",
+			"Scripts": []string{
+				"/static/js/admin/adminlog_alerts.js",
+			},
+		}, "admin/layout")
+	})
+
 	// api
 	app.RouterAdminApi.Get("/adminlog", app.HasPermission("adminlog:view"), listLogsHandler)
+	app.RouterAdminApi.Get("/adminlog/export", app.HasPermission("adminlog:view"), exportLogsHandler)
+	app.RouterAdminApi.Get("/adminlog/verify", app.HasPermission("adminlog:view"), verifyLogsHandler)
 	app.RouterAdminApi.Delete("/adminlog", app.HasPermission("adminlog:delete"), deleteLogsHandler)
 
+	app.RouterAdminApi.Get("/adminlog/alerts", app.HasPermission("adminlog:alert"), listAlertRulesHandler)
+	app.RouterAdminApi.Post("/adminlog/alerts", app.HasPermission("adminlog:alert"), createAlertRuleHandler)
+	app.RouterAdminApi.Get("/adminlog/alerts/history", app.HasPermission("adminlog:alert"), listAlertsHandler)
+	app.RouterAdminApi.Put("/adminlog/alerts/:id", app.HasPermission("adminlog:alert"), updateAlertRuleHandler)
+	app.RouterAdminApi.Delete("/adminlog/alerts/:id", app.HasPermission("adminlog:alert"), deleteAlertRuleHandler)
+	app.RouterAdminApi.Post("/adminlog/alerts/:id/test", app.HasPermission("adminlog:alert"), testAlertRuleHandler)
+
 	return nil
 }
@@ -1,35 +1,174 @@
 package adminlog
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/event"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// archiver 归档并删除指定时间之前的操作日志，返回删除的条数和归档文件 ID
+var archiver func(before time.Time) (int64, uint, error)
+
+// SetArchiver 注册归档操作日志的方法，由数据保留模块在 Awake 中调用（数据保留模块依赖本模块记录日志）
+func SetArchiver(fn func(before time.Time) (int64, uint, error)) {
+	archiver = fn
+}
+
 // WriteLog 创建操作日志
 func WriteLog(c *fiber.Ctx, action string, resource string, resourceID uint, details string) error {
+	return Record(c, Entry{
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Details:    details,
+	})
+}
+
+// Record 创建结构化的操作日志，Before 或 After 不为空时记录修改的字段
+func Record(c *fiber.Ctx, entry Entry) error {
 	currentUser := app.CurrentUser(c)
 
-	if currentUser.ID == 0 {
+	if currentUser == nil || currentUser.ID == 0 {
 		return fmt.Errorf("登录已过期，请重新登录")
 	}
 
 	log := models.AdminLog{
 		UserID:     currentUser.ID,
 		Username:   currentUser.Username,
-		Action:     action,
-		Resource:   resource,
-		ResourceID: resourceID,
-		Details:    details,
+		Action:     entry.Action,
+		Resource:   entry.Resource,
+		ResourceID: entry.ResourceID,
+		Details:    entry.Details,
 		IP:         c.IP(),
 		UserAgent:  c.Get("User-Agent"),
 		CreatedAt:  app.DB.NowFunc(),
 	}
 
-	if err := app.DB.Create(&log).Error; err != nil {
+	// 审批通过后执行的操作注明审批单
+	if req := internal.CurrentApproval(c); req != nil {
+		log.Details += fmt.Sprintf("（审批单 #%d，审批人 %s）", req.ID, req.Reviewer)
+	}
+
+	if entry.Before != nil || entry.After != nil {
+		if changes := Diff(entry.Before, entry.After); len(changes) > 0 {
+			data, err := json.Marshal(changes)
+			if err != nil {
+				return err
+			}
+			log.Changes = string(data)
+		}
+	}
+
+	if err := appendLog(&log); err != nil {
 		return err
 	}
 
+	publishLog(&log)
+	return nil
+}
+
+// publishLog 发布已写入的日志，告警规则等订阅者通过事件总线处理
+func publishLog(log *models.AdminLog) {
+	event.Publish(app.Bus, context.Background(), events.EventAddOperationLog{
+		LogID:      log.ID,
+		UserID:     log.UserID,
+		Username:   log.Username,
+		IP:         log.IP,
+		UserAgent:  log.UserAgent,
+		Action:     log.Action,
+		Resource:   log.Resource,
+		ResourceID: log.ResourceID,
+		Details:    log.Details,
+		CreatedAt:  log.CreatedAt,
+	})
+}
+
+// filterLogs 按查询条件过滤操作日志
+func filterLogs(filter LogFilter) (*gorm.DB, error) {
+	query := app.DB.Model(&models.AdminLog{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Username != "" {
+		query = query.Where("username LIKE ?", "%"+filter.Username+"%")
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceID != 0 {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip LIKE ?", filter.IP+"%")
+	}
+	if filter.Keyword != "" {
+		query = query.Where("details LIKE ?", "%"+filter.Keyword+"%")
+	}
+	if filter.StartDate != "" {
+		start, err := parseDate(filter.StartDate)
+		if err != nil {
+			return nil, errors.New("无效的开始日期")
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if filter.EndDate != "" {
+		end, err := parseDate(filter.EndDate)
+		if err != nil {
+			return nil, errors.New("无效的结束日期")
+		}
+		if len(filter.EndDate) == len("2006-01-02") {
+			// 只选择日期时包含当天的日志
+			end = end.AddDate(0, 0, 1)
+		}
+		query = query.Where("created_at < ?", end)
+	}
+
+	return query, nil
+}
+
+// applyAlertRuleForm 校验表单并写入告警规则
+func applyAlertRuleForm(rule *models.AdminLogAlertRule, form AlertRuleForm) error {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return errors.New("请输入规则名称")
+	}
+	if !validClock(form.WorkStart) || !validClock(form.WorkEnd) {
+		return errors.New("工作时间格式应为 09:00")
+	}
+	if form.Threshold < 0 || form.Window < 0 || form.Cooldown < 0 {
+		return errors.New("阈值、时间窗口和冷却时间不能为负数")
+	}
+	if form.WebhookURL != "" && !strings.HasPrefix(form.WebhookURL, "http://") &&
+		!strings.HasPrefix(form.WebhookURL, "https://") && !strings.HasPrefix(form.WebhookURL, "secret://") {
+		return errors.New("Webhook 地址应以 http://、https:// 或 secret:// 开头")
+	}
+
+	rule.Name = form.Name
+	rule.Enabled = form.Enabled
+	rule.Actions = strings.TrimSpace(form.Actions)
+	rule.Resources = strings.TrimSpace(form.Resources)
+	rule.Usernames = strings.TrimSpace(form.Usernames)
+	rule.Keyword = strings.TrimSpace(form.Keyword)
+	rule.OffHours = form.OffHours
+	rule.WorkStart = form.WorkStart
+	rule.WorkEnd = form.WorkEnd
+	rule.Threshold = form.Threshold
+	rule.Window = form.Window
+	rule.Cooldown = form.Cooldown
+	rule.WebhookURL = strings.TrimSpace(form.WebhookURL)
 	return nil
 }
@@ -0,0 +1,21 @@
+package approval
+
+import "github.com/andycai/goapi/internal"
+
+// ActionVO 可以要求审批的操作
+type ActionVO struct {
+	internal.ApprovalAction
+	Enabled bool  `json:"enabled"` // 是否需要审批
+	Pending int64 `json:"pending"` // 待审批的数量
+}
+
+// PolicyReq 设置操作是否需要审批
+type PolicyReq struct {
+	Action  string `json:"action"`
+	Enabled bool   `json:"enabled"`
+}
+
+// ReviewReq 审批意见
+type ReviewReq struct {
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,167 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/gofiber/fiber/v2"
+)
+
+// listApprovalsHandler 获取审批单列表
+func listApprovalsHandler(c *fiber.Ctx) error {
+	return listApprovals(c, 0)
+}
+
+// listMyApprovalsHandler 获取当前用户提交的审批单，不需要审批权限
+func listMyApprovalsHandler(c *fiber.Ctx) error {
+	return listApprovals(c, app.CurrentUser(c).ID)
+}
+
+func listApprovals(c *fiber.Ctx, requesterID uint) error {
+	var total int64
+
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	if err := expirePending(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取审批单失败"})
+	}
+
+	query := filterApprovals(app.DB.Model(&models.ApprovalRequest{}), c.Query("status"), c.Query("action"), requesterID)
+	query.Count(&total)
+
+	var list []models.ApprovalRequest
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&list).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取审批单失败"})
+	}
+
+	return c.JSON(fiber.Map{"total": total, "data": list})
+}
+
+// getApprovalHandler 获取审批单详情，申请人可以查看自己的审批单
+func getApprovalHandler(c *fiber.Ctx) error {
+	req, err := getApproval(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "审批单不存在"})
+	}
+
+	p := app.Principal(c)
+	if req.RequesterID != p.User.ID && !p.Has("approval:view") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "没有权限"})
+	}
+
+	return c.JSON(fiber.Map{"data": req})
+}
+
+// listActionsHandler 获取可以要求审批的操作
+func listActionsHandler(c *fiber.Ctx) error {
+	list, err := listActions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取审批配置失败"})
+	}
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// updatePolicyHandler 设置操作是否需要审批
+func updatePolicyHandler(c *fiber.Ctx) error {
+	var req PolicyReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	action, ok := internal.FindApprovalAction(req.Action)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "操作不存在"})
+	}
+
+	user := app.CurrentUser(c)
+	before, after, err := setPolicy(action.Code, req.Enabled, user.Username)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "保存审批配置失败"})
+	}
+
+	details := fmt.Sprintf("关闭审批：%s", action.Name)
+	if req.Enabled {
+		details = fmt.Sprintf("开启审批：%s", action.Name)
+	}
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "approval_policy", Details: details, Before: before, After: after})
+
+	return c.JSON(fiber.Map{"message": "保存成功"})
+}
+
+// approveHandler 批准审批单，并以申请人的身份执行
+func approveHandler(c *fiber.Ctx) error {
+	req, err := getApproval(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "审批单不存在"})
+	}
+
+	var form ReviewReq
+	if err := c.BodyParser(&form); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	if err := review(req, app.Principal(c), models.ApprovalApproved, strings.TrimSpace(form.Comment)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	adminlog.WriteLog(c, "approve", "approval", req.ID, fmt.Sprintf("批准审批单 #%d：%s，申请人 %s", req.ID, req.ActionName, req.Requester))
+
+	if err := execute(req); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "保存执行结果失败"})
+	}
+
+	message := "已批准，执行成功"
+	if req.Status == models.ApprovalFailed {
+		message = "已批准，但执行失败"
+	}
+	adminlog.WriteLog(c, "execute", "approval", req.ID, fmt.Sprintf("执行审批单 #%d：%s，结果 %d", req.ID, req.ActionName, req.ResultCode))
+
+	return c.JSON(fiber.Map{"message": message, "data": req})
+}
+
+// rejectHandler 驳回审批单
+func rejectHandler(c *fiber.Ctx) error {
+	req, err := getApproval(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "审批单不存在"})
+	}
+
+	var form ReviewReq
+	if err := c.BodyParser(&form); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	if err := review(req, app.Principal(c), models.ApprovalRejected, strings.TrimSpace(form.Comment)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	details := fmt.Sprintf("驳回审批单 #%d：%s，申请人 %s", req.ID, req.ActionName, req.Requester)
+	if req.Comment != "" {
+		details += "，意见：" + req.Comment
+	}
+	adminlog.WriteLog(c, "reject", "approval", req.ID, details)
+
+	return c.JSON(fiber.Map{"message": "已驳回"})
+}
+
+// cancelHandler 申请人撤回审批单
+func cancelHandler(c *fiber.Ctx) error {
+	req, err := getApproval(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "审批单不存在"})
+	}
+
+	if err := cancel(req, app.CurrentUser(c).ID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "cancel", "approval", req.ID, fmt.Sprintf("撤回审批单 #%d：%s", req.ID, req.ActionName))
+
+	return c.JSON(fiber.Map{"message": "已撤回"})
+}
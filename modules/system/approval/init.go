@@ -0,0 +1,123 @@
+package approval
+
+import (
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/enum"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+func autoMigrate() error {
+	return app.DB.AutoMigrate(
+		&models.ApprovalPolicy{},
+		&models.ApprovalRequest{},
+	)
+}
+
+// 初始化数据
+func initData() error {
+	if err := initMenus(); err != nil {
+		return err
+	}
+
+	if err := initPermissions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("approval:menu") {
+		log.Println("[审批模块]菜单数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建操作审批菜单
+		approvalMenu := models.Menu{
+			MenuID:     1014,
+			ParentID:   enum.MenuIdSystem,
+			Name:       "操作审批",
+			Path:       "/admin/approvals",
+			Icon:       "approval",
+			Sort:       8,
+			Permission: "approval:view",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&approvalMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "approval:menu",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("approval:permission") {
+		log.Println("[审批模块]权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建审批相关权限
+		permissions := []models.Permission{
+			{
+				Name:        "查看审批",
+				Code:        "approval:view",
+				Description: "查看所有审批单和需要审批的操作",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "审批操作",
+				Code:        "approval:review",
+				Description: "批准或驳回其他人提交的审批单，还需要拥有该操作本身的权限",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "管理审批配置",
+				Code:        "approval:manage",
+				Description: "设置哪些操作需要审批",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+		}
+
+		if err := tx.Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "approval:permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
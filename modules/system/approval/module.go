@@ -0,0 +1,68 @@
+package approval
+
+import (
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
+	"github.com/gofiber/fiber/v2"
+)
+
+const ModulePriorityApproval = 1008 // 系统-操作审批
+
+var app *internal.App
+
+type approvalModule struct {
+	internal.BaseModule
+}
+
+func init() {
+	internal.RegisterModule(&approvalModule{}, ModulePriorityApproval)
+}
+
+func (m *approvalModule) Awake(a *internal.App) error {
+	app = a
+	// 数据迁移
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	retention.RegisterTarget(retention.Target{
+		Name:        "approval",
+		Title:       "审批单",
+		DefaultDays: 365,
+		Tables: []retention.Table{
+			{Model: &models.ApprovalRequest{}, TimeColumn: "created_at"},
+		},
+	})
+
+	return nil
+}
+
+func (m *approvalModule) Start() error {
+	// 初始化数据
+	return initData()
+}
+
+func (m *approvalModule) AddAuthRouters() error {
+	// admin
+	app.RouterAdmin.Get("/approvals", app.HasPermission("approval:view"), func(c *fiber.Ctx) error {
+		return c.Render("admin/approvals", fiber.Map{
+			"Title": "操作审批",
+			"Scripts": []string{
+				"/static/js/admin/approvals.js",
+			},
+		}, "admin/layout")
+	})
+
+	// api
+	app.RouterAdminApi.Get("/approvals", app.HasPermission("approval:view"), listApprovalsHandler)
+	app.RouterAdminApi.Get("/approvals/actions", app.HasPermission("approval:view"), listActionsHandler)
+	app.RouterAdminApi.Put("/approvals/actions", app.HasPermission("approval:manage"), updatePolicyHandler)
+	app.RouterAdminApi.Get("/approvals/mine", listMyApprovalsHandler)
+	app.RouterAdminApi.Get("/approvals/:id", getApprovalHandler)
+	app.RouterAdminApi.Post("/approvals/:id/approve", app.HasPermission("approval:review"), approveHandler)
+	app.RouterAdminApi.Post("/approvals/:id/reject", app.HasPermission("approval:review"), rejectHandler)
+	app.RouterAdminApi.Post("/approvals/:id/cancel", cancelHandler)
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+// resultLimit 保存的执行结果的最大长度
+const resultLimit = 4000
+
+// expirePending 将超过有效期的待审批单标记为过期
+func expirePending() error {
+	return app.DB.Model(&models.ApprovalRequest{}).
+		Where("status = ? AND expires_at <= ?", models.ApprovalPending, time.Now()).
+		Update("status", models.ApprovalExpired).Error
+}
+
+// getApproval 获取审批单
+func getApproval(id string) (*models.ApprovalRequest, error) {
+	var req models.ApprovalRequest
+	if err := app.DB.First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// listActions 所有可以要求审批的操作和是否启用
+func listActions() ([]ActionVO, error) {
+	var policies []models.ApprovalPolicy
+	if err := app.DB.Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		enabled[policy.Action] = policy.Enabled
+	}
+
+	type pendingCount struct {
+		Action string
+		Count  int64
+	}
+	var counts []pendingCount
+	if err := app.DB.Model(&models.ApprovalRequest{}).
+		Select("action, COUNT(*) AS count").
+		Where("status = ?", models.ApprovalPending).
+		Group("action").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	pending := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		pending[c.Action] = c.Count
+	}
+
+	actions := internal.ApprovalActions()
+	list := make([]ActionVO, 0, len(actions))
+	for _, action := range actions {
+		list = append(list, ActionVO{
+			ApprovalAction: action,
+			Enabled:        enabled[action.Code],
+			Pending:        pending[action.Code],
+		})
+	}
+	return list, nil
+}
+
+// setPolicy 设置操作是否需要审批，关闭后已提交的审批单仍然可以审批，返回修改前后的设置，没有设置时视为不需要审批
+func setPolicy(action string, enabled bool, username string) (models.ApprovalPolicy, models.ApprovalPolicy, error) {
+	before := models.ApprovalPolicy{Action: action}
+	if err := app.DB.Where("action = ?", action).Limit(1).Find(&before).Error; err != nil {
+		return before, before, err
+	}
+
+	after := models.ApprovalPolicy{
+		Action:    action,
+		Enabled:   enabled,
+		UpdatedBy: username,
+	}
+	err := app.DB.Save(&after).Error
+	return before, after, err
+}
+
+// checkReviewer 审批人不能是申请人，并且必须拥有操作本身的权限
+func checkReviewer(req *models.ApprovalRequest, reviewer *internal.Principal) error {
+	if req.Status == models.ApprovalPending && !req.ExpiresAt.After(time.Now()) {
+		app.DB.Model(req).Update("status", models.ApprovalExpired)
+		return errors.New("审批单已过期，请申请人重新提交")
+	}
+	if req.Status != models.ApprovalPending {
+		return errors.New("审批单已处理")
+	}
+	if reviewer.User.ID == req.RequesterID {
+		return errors.New("不能审批自己提交的申请")
+	}
+
+	action, ok := internal.FindApprovalAction(req.Action)
+	if !ok {
+		return errors.New("操作已不存在")
+	}
+	if !reviewer.Has(action.Permission) {
+		return fmt.Errorf("审批人需要拥有 %s 权限", action.Permission)
+	}
+	return nil
+}
+
+// review 批准或驳回审批单，只有待审批的审批单可以处理，并发审批时只有一个生效
+func review(req *models.ApprovalRequest, reviewer *internal.Principal, status, comment string) error {
+	if err := checkReviewer(req, reviewer); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := app.DB.Model(&models.ApprovalRequest{}).
+		Where("id = ? AND status = ?", req.ID, models.ApprovalPending).
+		Updates(map[string]any{
+			"status":      status,
+			"reviewer_id": reviewer.User.ID,
+			"reviewer":    reviewer.User.Username,
+			"comment":     comment,
+			"reviewed_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("审批单已处理")
+	}
+
+	req.Status = status
+	req.ReviewerID = reviewer.User.ID
+	req.Reviewer = reviewer.User.Username
+	req.Comment = comment
+	req.ReviewedAt = &now
+	return nil
+}
+
+// execute 以申请人的身份执行已批准的审批单，保存执行结果
+func execute(req *models.ApprovalRequest) error {
+	code, body, err := app.ExecuteApproval(req)
+
+	req.ResultCode = code
+	req.Result = body
+	req.Status = models.ApprovalExecuted
+	if err != nil {
+		req.Status = models.ApprovalFailed
+		req.Result = err.Error()
+	} else if code < 200 || code >= 300 {
+		req.Status = models.ApprovalFailed
+	}
+	if runes := []rune(req.Result); len(runes) > resultLimit {
+		req.Result = string(runes[:resultLimit])
+	}
+
+	return app.DB.Model(req).Select("status", "result_code", "result").Updates(req).Error
+}
+
+// cancel 申请人撤回待审批的审批单
+func cancel(req *models.ApprovalRequest, userID uint) error {
+	if req.RequesterID != userID {
+		return errors.New("只能撤回自己提交的申请")
+	}
+
+	result := app.DB.Model(&models.ApprovalRequest{}).
+		Where("id = ? AND status = ?", req.ID, models.ApprovalPending).
+		Update("status", models.ApprovalCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("审批单已处理")
+	}
+	req.Status = models.ApprovalCancelled
+	return nil
+}
+
+// filterApprovals 按状态、操作和申请人过滤审批单
+func filterApprovals(db *gorm.DB, status, action string, requesterID uint) *gorm.DB {
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if action != "" {
+		db = db.Where("action = ?", action)
+	}
+	if requesterID != 0 {
+		db = db.Where("requester_id = ?", requesterID)
+	}
+	return db
+}
@@ -61,6 +61,13 @@ func updateMenuHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	before, err := dao.GetMenuByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "菜单不存在",
+		})
+	}
+
 	menu := new(models.Menu)
 	if err := c.BodyParser(menu); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -69,6 +76,7 @@ func updateMenuHandler(c *fiber.Ctx) error {
 	}
 
 	menu.ID = uint(id)
+	menu.CreatedAt = before.CreatedAt
 	if err := dao.UpdateMenu(menu); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "更新菜单失败",
@@ -76,7 +84,7 @@ func updateMenuHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "menu", menu.ID, fmt.Sprintf("更新菜单：%s", menu.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "menu", ResourceID: menu.ID, Details: fmt.Sprintf("更新菜单：%s", menu.Name), Before: before, After: menu})
 
 	return c.JSON(menu)
 }
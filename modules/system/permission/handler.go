@@ -1,11 +1,14 @@
 package permission
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/andycai/goapi/events"
 	"github.com/andycai/goapi/models"
 	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/andycai/goapi/pkg/event"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -99,7 +102,7 @@ func createPermissionHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "create", "permission", permission.ID, fmt.Sprintf("创建权限：%s", permission.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "create", Resource: "permission", ResourceID: permission.ID, Details: fmt.Sprintf("创建权限：%s", permission.Name), After: permission})
 
 	return c.JSON(permission)
 }
@@ -116,6 +119,7 @@ func updatePermissionHandler(c *fiber.Ctx) error {
 	if err := app.DB.First(&permission, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "权限不存在"})
 	}
+	before := permission
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -141,8 +145,11 @@ func updatePermissionHandler(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "更新权限失败"})
 	}
 
+	// 权限编码可能已修改，清除所有角色的权限缓存
+	event.Publish(app.Bus, context.Background(), events.EventRoleChanged{})
+
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "permission", permission.ID, fmt.Sprintf("更新权限：%s", permission.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "permission", ResourceID: permission.ID, Details: fmt.Sprintf("更新权限：%s", permission.Name), Before: before, After: permission})
 
 	return c.JSON(permission)
 }
@@ -171,7 +178,7 @@ func deletePermissionHandler(c *fiber.Ctx) error {
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "delete", "permission", permission.ID, fmt.Sprintf("删除权限：%s", permission.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "delete", Resource: "permission", ResourceID: permission.ID, Details: fmt.Sprintf("删除权限：%s", permission.Name), Before: permission})
 
 	return c.JSON(fiber.Map{"message": "删除成功"})
 }
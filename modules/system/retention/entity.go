@@ -0,0 +1,23 @@
+package retention
+
+// PolicyReq 修改保留策略的请求
+type PolicyReq struct {
+	KeepDays int    `json:"keep_days"`
+	Mode     string `json:"mode"`
+	Archive  bool   `json:"archive"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// PolicyVO 保留策略及其包含的数据表
+type PolicyVO struct {
+	ID        uint     `json:"id"`
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	Tables    []string `json:"tables"`
+	KeepDays  int      `json:"keep_days"`
+	Mode      string   `json:"mode"`
+	Archive   bool     `json:"archive"`
+	Enabled   bool     `json:"enabled"`
+	LastRunAt int64    `json:"last_run_at"`
+	LastError string   `json:"last_error"`
+}
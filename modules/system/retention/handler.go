@@ -0,0 +1,210 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/gofiber/fiber/v2"
+)
+
+// listPoliciesHandler 获取数据保留策略列表
+func listPoliciesHandler(c *fiber.Ctx) error {
+	var policies []models.RetentionPolicy
+	if err := app.DB.Order("id").Find(&policies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取保留策略失败"})
+	}
+
+	list := make([]PolicyVO, 0, len(policies))
+	for _, policy := range policies {
+		vo := PolicyVO{
+			ID:        policy.ID,
+			Name:      policy.Name,
+			KeepDays:  policy.KeepDays,
+			Mode:      policy.Mode,
+			Archive:   policy.Archive,
+			Enabled:   policy.Enabled,
+			LastRunAt: policy.LastRunAt,
+			LastError: policy.LastError,
+		}
+		if target, ok := getTarget(policy.Name); ok {
+			vo.Title = target.Title
+			for _, table := range target.Tables {
+				if sch, err := parseSchema(table.Model); err == nil {
+					vo.Tables = append(vo.Tables, sch.Table)
+				}
+			}
+		}
+		list = append(list, vo)
+	}
+
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// updatePolicyHandler 修改数据保留策略
+func updatePolicyHandler(c *fiber.Ctx) error {
+	var policy models.RetentionPolicy
+	if err := app.DB.Where("name = ?", c.Params("name")).First(&policy).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "保留策略不存在"})
+	}
+
+	var req PolicyReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	if req.Mode != models.RetentionModeDays && req.Mode != models.RetentionModeAggregates {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的保留方式"})
+	}
+	// 只有 aggregates 模式允许明细不保留
+	if req.KeepDays < 0 || (req.KeepDays == 0 && req.Mode == models.RetentionModeDays) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "保留天数必须大于0"})
+	}
+
+	if target, ok := getTarget(policy.Name); ok && target.ArchiveRequired && !req.Archive {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": target.Title + "必须归档后才能删除，不能关闭归档"})
+	}
+
+	before := policy
+	if err := app.DB.Model(&policy).Updates(map[string]any{
+		"keep_days": req.KeepDays,
+		"mode":      req.Mode,
+		"archive":   req.Archive,
+		"enabled":   req.Enabled,
+	}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "修改保留策略失败"})
+	}
+	app.DB.First(&policy, policy.ID)
+
+	adminlog.Record(c, adminlog.Entry{
+		Action:     "update",
+		Resource:   "retention",
+		ResourceID: policy.ID,
+		Details: fmt.Sprintf("修改数据保留策略：%s，保留%d天，方式%s，归档%v，启用%v",
+			policy.Name, req.KeepDays, req.Mode, req.Archive, req.Enabled),
+		Before: before,
+		After:  policy,
+	})
+
+	return c.JSON(fiber.Map{"message": "修改成功", "data": policy})
+}
+
+// runPolicyHandler 立即执行数据保留策略
+func runPolicyHandler(c *fiber.Ctx) error {
+	var policy models.RetentionPolicy
+	if err := app.DB.Where("name = ?", c.Params("name")).First(&policy).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "保留策略不存在"})
+	}
+
+	results, err := runPolicy(&policy)
+
+	var deleted int64
+	for _, r := range results {
+		deleted += r.Deleted
+	}
+	adminlog.WriteLog(c, "run", "retention", policy.ID, fmt.Sprintf("执行数据保留策略：%s，共清理%d条", policy.Name, deleted))
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "data": results})
+	}
+
+	return c.JSON(fiber.Map{"message": "执行成功", "data": results})
+}
+
+// listArchivesHandler 获取归档列表
+func listArchivesHandler(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	query := app.DB.Model(&models.RetentionArchive{})
+	if policy := c.Query("policy"); policy != "" {
+		query = query.Where("policy = ?", policy)
+	}
+	if table := c.Query("table"); table != "" {
+		query = query.Where("table_name = ?", table)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var archives []models.RetentionArchive
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&archives).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取归档列表失败"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     archives,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// downloadArchiveHandler 下载归档文件
+func downloadArchiveHandler(c *fiber.Ctx) error {
+	var archive models.RetentionArchive
+	if err := app.DB.First(&archive, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "归档不存在"})
+	}
+
+	return c.Download(archive.Path, filepath.Base(archive.Path))
+}
+
+// restoreArchiveHandler 将归档数据恢复到原表，恢复后的数据在保留期内不会被再次清理
+func restoreArchiveHandler(c *fiber.Ctx) error {
+	var archive models.RetentionArchive
+	if err := app.DB.First(&archive, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "归档不存在"})
+	}
+
+	holdDays := c.QueryInt("hold_days", 7)
+	if holdDays <= 0 {
+		holdDays = 7
+	}
+
+	restored, err := restoreArchive(&archive, holdDays)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("恢复归档失败：%v", err), "restored": restored})
+	}
+
+	adminlog.WriteLog(c, "restore", "retention", archive.ID, fmt.Sprintf("恢复归档：%s，共%d条，保留%d天", archive.Table, restored, holdDays))
+
+	return c.JSON(fiber.Map{"message": "恢复成功", "restored": restored, "data": archive})
+}
+
+// releaseArchiveHandler 取消恢复数据的保留期，下次执行策略时重新清理
+func releaseArchiveHandler(c *fiber.Ctx) error {
+	var archive models.RetentionArchive
+	if err := app.DB.First(&archive, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "归档不存在"})
+	}
+
+	if err := app.DB.Model(&archive).Update("hold_until", 0).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "操作失败"})
+	}
+
+	adminlog.WriteLog(c, "release", "retention", archive.ID, fmt.Sprintf("取消归档恢复保留：%s", archive.Table))
+
+	return c.JSON(fiber.Map{"message": "操作成功"})
+}
+
+// deleteArchiveHandler 删除归档文件
+func deleteArchiveHandler(c *fiber.Ctx) error {
+	var archive models.RetentionArchive
+	if err := app.DB.First(&archive, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "归档不存在"})
+	}
+
+	if err := os.Remove(archive.Path); err != nil && !os.IsNotExist(err) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "删除归档文件失败"})
+	}
+	if err := app.DB.Delete(&archive).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "删除归档失败"})
+	}
+
+	adminlog.WriteLog(c, "delete", "retention", archive.ID, fmt.Sprintf("删除归档：%s，共%d条", archive.Table, archive.Rows))
+
+	return c.JSON(fiber.Map{"message": "删除成功"})
+}
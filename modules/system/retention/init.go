@@ -0,0 +1,66 @@
+package retention
+
+import (
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+func autoMigrate() error {
+	return app.DB.AutoMigrate(
+		&models.RetentionPolicy{},
+		&models.RetentionArchive{},
+	)
+}
+
+// 初始化数据
+func initData() error {
+	return initPermissions()
+}
+
+func initPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("retention:permission") {
+		log.Println("[数据保留模块]权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建数据保留相关权限
+		permissions := []models.Permission{
+			{
+				Name:        "查看数据保留",
+				Code:        "retention:view",
+				Description: "查看数据保留策略和归档",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "管理数据保留",
+				Code:        "retention:manage",
+				Description: "修改保留策略、执行清理和恢复归档",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+		}
+
+		if err := tx.Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "retention:permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package retention
+
+import (
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
+)
+
+const ModulePriorityRetention = 1006 // 系统-数据保留
+
+var app *internal.App
+
+type retentionModule struct {
+	internal.BaseModule
+}
+
+func init() {
+	internal.RegisterModule(&retentionModule{}, ModulePriorityRetention)
+}
+
+func (m *retentionModule) Awake(a *internal.App) error {
+	app = a
+	// 数据迁移
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	// 操作日志模块依赖本模块记录日志，由本模块注册其保留对象，并提供手动归档删除的方法
+	RegisterTarget(Target{
+		Name:        "adminlog",
+		Title:       "操作日志",
+		DefaultDays: 180,
+		Tables: []Table{
+			{Model: &models.AdminLog{}, TimeColumn: "created_at", AfterDelete: adminlog.RecordPurge},
+		},
+		ArchiveRequired: true,
+	})
+	adminlog.SetArchiver(archiveAdminLogs)
+
+	return nil
+}
+
+func (m *retentionModule) Start() error {
+	// 初始化数据
+	if err := initData(); err != nil {
+		return err
+	}
+
+	// 各模块在 Awake 中注册保留对象，此时已全部注册完成
+	if err := ensurePolicies(); err != nil {
+		return err
+	}
+
+	return initJobs()
+}
+
+func (m *retentionModule) Dispose() error {
+	stopJobs()
+	return nil
+}
+
+func (m *retentionModule) AddAuthRouters() error {
+	app.RouterAdminApi.Get("/retention/policies", app.HasPermission("retention:view"), listPoliciesHandler)
+	app.RouterAdminApi.Put("/retention/policies/:name", app.HasPermission("retention:manage"), updatePolicyHandler)
+	app.RouterAdminApi.Post("/retention/policies/:name/run", app.HasPermission("retention:manage"), runPolicyHandler)
+	app.RouterAdminApi.Get("/retention/archives", app.HasPermission("retention:view"), listArchivesHandler)
+	app.RouterAdminApi.Get("/retention/archives/:id/download", app.HasPermission("retention:view"), downloadArchiveHandler)
+	app.RouterAdminApi.Post("/retention/archives/:id/restore", app.HasPermission("retention:manage"), restoreArchiveHandler)
+	app.RouterAdminApi.Post("/retention/archives/:id/release", app.HasPermission("retention:manage"), releaseArchiveHandler)
+	app.RouterAdminApi.Delete("/retention/archives/:id", app.HasPermission("retention:manage"), deleteArchiveHandler)
+
+	return nil
+}
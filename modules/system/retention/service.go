@@ -0,0 +1,435 @@
+package retention
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/utility/compress"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	retentionCron *cron.Cron
+	runMutex      sync.Mutex
+	schemaCache   sync.Map
+)
+
+// runResult 单个数据表的清理结果
+type runResult struct {
+	Table     string `json:"table"`
+	Deleted   int64  `json:"deleted"`
+	ArchiveID uint   `json:"archive_id,omitempty"`
+}
+
+// initJobs 启动每天执行的数据保留任务
+func initJobs() error {
+	retentionCron = cron.New()
+	if _, err := retentionCron.AddFunc(internal.GetRetentionConfig().Schedule, func() {
+		runAll()
+	}); err != nil {
+		return err
+	}
+	retentionCron.Start()
+	return nil
+}
+
+// stopJobs 停止定时任务
+func stopJobs() {
+	if retentionCron != nil {
+		<-retentionCron.Stop().Done()
+	}
+}
+
+// ensurePolicies 为已注册的对象创建默认策略（默认不启用）
+func ensurePolicies() error {
+	for _, target := range getTargets() {
+		policy := models.RetentionPolicy{
+			Name:     target.Name,
+			KeepDays: target.DefaultDays,
+			Mode:     models.RetentionModeDays,
+			Archive:  true,
+		}
+		if err := app.DB.Where("name = ?", target.Name).FirstOrCreate(&policy).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAll 执行所有启用的策略
+func runAll() {
+	var policies []models.RetentionPolicy
+	if err := app.DB.Where("enabled = ?", true).Find(&policies).Error; err != nil {
+		log.Printf("[数据保留模块]读取保留策略失败: %v", err)
+		return
+	}
+
+	for i := range policies {
+		results, err := runPolicy(&policies[i])
+		if err != nil {
+			log.Printf("[数据保留模块]执行策略 %s 失败: %v", policies[i].Name, err)
+			continue
+		}
+		for _, r := range results {
+			if r.Deleted > 0 {
+				log.Printf("[数据保留模块]策略 %s 清理 %s 共%d条", policies[i].Name, r.Table, r.Deleted)
+			}
+		}
+	}
+}
+
+// runPolicy 按策略归档并清理过期数据
+func runPolicy(policy *models.RetentionPolicy) ([]runResult, error) {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	target, ok := getTarget(policy.Name)
+	if !ok {
+		return nil, fmt.Errorf("未注册的数据类型：%s", policy.Name)
+	}
+
+	if target.ArchiveRequired && !policy.Archive {
+		return nil, fmt.Errorf("%s必须归档后才能删除，请开启归档", target.Title)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -policy.KeepDays)
+
+	var (
+		results []runResult
+		runErr  error
+	)
+	for _, table := range target.Tables {
+		// aggregates 模式只清理明细，汇总数据永久保留
+		if table.Aggregate && policy.Mode == models.RetentionModeAggregates {
+			continue
+		}
+
+		result, err := purgeTable(policy, table, cutoff)
+		if err != nil {
+			runErr = errors.Join(runErr, fmt.Errorf("%s: %w", result.Table, err))
+		}
+		results = append(results, result)
+	}
+
+	updates := map[string]any{"last_run_at": time.Now().UnixMilli(), "last_error": ""}
+	if runErr != nil {
+		updates["last_error"] = truncate(runErr.Error(), 500)
+	}
+	app.DB.Model(policy).Updates(updates)
+
+	return results, runErr
+}
+
+// archiveAdminLogs 归档并删除指定时间之前的操作日志，用于操作日志页面手动清理
+func archiveAdminLogs(before time.Time) (int64, uint, error) {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	target, ok := getTarget("adminlog")
+	if !ok {
+		return 0, 0, errors.New("未注册操作日志的保留对象")
+	}
+
+	policy := &models.RetentionPolicy{Name: target.Name, Archive: true}
+	result, err := purgeTable(policy, target.Tables[0], before)
+	return result.Deleted, result.ArchiveID, err
+}
+
+// parseSchema 解析模型的表结构
+func parseSchema(model any) (*schema.Schema, error) {
+	return schema.Parse(model, &schemaCache, app.DB.NamingStrategy)
+}
+
+// timeValue 将毫秒时间戳转换为时间列对应的值
+func timeValue(table Table, ms int64) any {
+	if table.Millis {
+		return ms
+	}
+	return time.UnixMilli(ms)
+}
+
+// expiredQuery 返回过期数据的查询条件，跳过处于恢复保留期内的归档范围
+func expiredQuery(policy *models.RetentionPolicy, table Table, tableName string, cutoff time.Time) *gorm.DB {
+	query := app.DB.Model(table.Model).Where(table.TimeColumn+" < ?", timeValue(table, cutoff.UnixMilli()))
+	if table.Ready != nil {
+		query = table.Ready(query)
+	}
+
+	var holds []models.RetentionArchive
+	app.DB.Where("policy = ? AND table_name = ? AND hold_until > ?", policy.Name, tableName, time.Now().UnixMilli()).Find(&holds)
+	for _, hold := range holds {
+		query = query.Where("NOT ("+table.TimeColumn+" BETWEEN ? AND ?)", timeValue(table, hold.MinTime), timeValue(table, hold.MaxTime))
+	}
+
+	return query
+}
+
+// purgeTable 分批导出并删除一个数据表的过期数据
+func purgeTable(policy *models.RetentionPolicy, table Table, cutoff time.Time) (runResult, error) {
+	sch, err := parseSchema(table.Model)
+	if err != nil {
+		return runResult{}, err
+	}
+	result := runResult{Table: sch.Table}
+
+	pk := sch.PrioritizedPrimaryField
+	timeField := sch.LookUpField(table.TimeColumn)
+	if pk == nil || timeField == nil {
+		return result, fmt.Errorf("表 %s 缺少主键或时间列", sch.Table)
+	}
+
+	conf := internal.GetRetentionConfig()
+	sliceType := reflect.SliceOf(reflect.TypeOf(table.Model).Elem())
+
+	var (
+		writer  *compress.GzipFileWriter
+		archive *models.RetentionArchive
+	)
+	// 出错时也要关闭归档文件并记录已写入的部分
+	defer func() {
+		if writer != nil {
+			writer.Close()
+			finishArchive(archive)
+		}
+	}()
+
+	for {
+		rows := reflect.New(sliceType)
+		if err := expiredQuery(policy, table, sch.Table, cutoff).
+			Order(pk.DBName).Limit(conf.ChunkSize).
+			Find(rows.Interface()).Error; err != nil {
+			return result, err
+		}
+
+		list := rows.Elem()
+		if list.Len() == 0 {
+			return result, nil
+		}
+
+		ids := make([]any, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			item := list.Index(i)
+			id, _ := pk.ValueOf(context.Background(), item)
+			ids = append(ids, id)
+		}
+
+		// 先写归档并刷盘，再删除数据
+		if policy.Archive {
+			if writer == nil {
+				archive, writer, err = createArchive(policy, sch.Table, conf.ArchiveDir)
+				if err != nil {
+					return result, err
+				}
+				result.ArchiveID = archive.ID
+			}
+			if err := writeArchiveRows(writer, archive, list, timeField, table.Millis); err != nil {
+				return result, err
+			}
+		}
+
+		var deleted int64
+		err := app.DB.Transaction(func(tx *gorm.DB) error {
+			res := tx.Where(pk.DBName+" IN ?", ids).Delete(table.Model)
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted = res.RowsAffected
+			if table.AfterDelete != nil {
+				return table.AfterDelete(tx, rows.Interface())
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Deleted += deleted
+
+		if deleted == 0 || list.Len() < conf.ChunkSize {
+			return result, nil
+		}
+
+		// 让出数据库，避免长时间阻塞其他写入（SQLite 只有一个写锁）
+		time.Sleep(time.Duration(conf.ChunkPause) * time.Millisecond)
+	}
+}
+
+// createArchive 创建归档文件和记录
+func createArchive(policy *models.RetentionPolicy, tableName, dir string) (*models.RetentionArchive, *compress.GzipFileWriter, error) {
+	now := time.Now()
+	path := filepath.Join(dir, policy.Name, fmt.Sprintf("%s-%s-%d.ndjson.gz", tableName, now.Format("20060102"), now.UnixNano()))
+
+	writer, err := compress.CreateGzipFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	archive := &models.RetentionArchive{
+		Policy: policy.Name,
+		Table:  tableName,
+		Path:   path,
+	}
+	if err := app.DB.Create(archive).Error; err != nil {
+		writer.Close()
+		os.Remove(path)
+		return nil, nil, err
+	}
+
+	return archive, writer, nil
+}
+
+// writeArchiveRows 以 NDJSON 格式写入一批数据
+func writeArchiveRows(writer *compress.GzipFileWriter, archive *models.RetentionArchive, list reflect.Value, timeField *schema.Field, millis bool) error {
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		data, err := json.Marshal(item.Interface())
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(append(data, '\n')); err != nil {
+			return err
+		}
+
+		value, _ := timeField.ValueOf(context.Background(), item)
+		var ms int64
+		switch v := value.(type) {
+		case int64:
+			ms = v
+		case time.Time:
+			ms = v.UnixMilli()
+		}
+		if !millis && ms == 0 {
+			continue
+		}
+		if archive.MinTime == 0 || ms < archive.MinTime {
+			archive.MinTime = ms
+		}
+		if ms > archive.MaxTime {
+			archive.MaxTime = ms
+		}
+	}
+	archive.Rows += int64(list.Len())
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return app.DB.Model(archive).Updates(map[string]any{
+		"rows":     archive.Rows,
+		"min_time": archive.MinTime,
+		"max_time": archive.MaxTime,
+	}).Error
+}
+
+// finishArchive 归档完成后记录文件大小
+func finishArchive(archive *models.RetentionArchive) {
+	if info, err := os.Stat(archive.Path); err == nil {
+		archive.Size = info.Size()
+		app.DB.Model(archive).Update("size", archive.Size)
+	}
+}
+
+// findTable 根据表名查找归档对应的数据表
+func findTable(policy, tableName string) (Table, error) {
+	target, ok := getTarget(policy)
+	if !ok {
+		return Table{}, fmt.Errorf("未注册的数据类型：%s", policy)
+	}
+	for _, table := range target.Tables {
+		sch, err := parseSchema(table.Model)
+		if err == nil && sch.Table == tableName {
+			return table, nil
+		}
+	}
+	return Table{}, fmt.Errorf("未找到数据表：%s", tableName)
+}
+
+// restoreArchive 将归档数据写回原表，已存在的数据跳过，返回恢复的行数
+func restoreArchive(archive *models.RetentionArchive, holdDays int) (int64, error) {
+	table, err := findTable(archive.Policy, archive.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	reader, err := compress.OpenGzipFile(archive.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	conf := internal.GetRetentionConfig()
+	modelType := reflect.TypeOf(table.Model).Elem()
+	batch := reflect.MakeSlice(reflect.SliceOf(modelType), 0, conf.ChunkSize)
+
+	var restored int64
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		ptr := reflect.New(batch.Type())
+		ptr.Elem().Set(batch)
+		result := app.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(ptr.Interface())
+		if result.Error != nil {
+			return result.Error
+		}
+		restored += result.RowsAffected
+		batch = batch.Slice(0, 0)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		item := reflect.New(modelType)
+		if err := json.Unmarshal(scanner.Bytes(), item.Interface()); err != nil {
+			return restored, err
+		}
+		batch = reflect.Append(batch, item.Elem())
+		if batch.Len() >= conf.ChunkSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	// 中断的归档没有结束标记，已刷盘的部分仍然可以恢复
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return restored, err
+	}
+	if err := flush(); err != nil {
+		return restored, err
+	}
+
+	now := time.Now()
+	archive.RestoredAt = now.UnixMilli()
+	archive.HoldUntil = now.AddDate(0, 0, holdDays).UnixMilli()
+	if err := app.DB.Model(archive).Updates(map[string]any{
+		"restored_at": archive.RestoredAt,
+		"hold_until":  archive.HoldUntil,
+	}).Error; err != nil {
+		return restored, err
+	}
+
+	return restored, nil
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Table 参与数据保留的数据表
+type Table struct {
+	Model      any    // 模型指针，如 &models.GameLog{}
+	TimeColumn string // 判断过期的时间列
+	Millis     bool   // 时间列为毫秒时间戳
+	Aggregate  bool   // 汇总表，aggregates 模式下永久保留
+
+	// Ready 限定可以清理的数据，例如只清理已经汇总过的明细
+	Ready func(db *gorm.DB) *gorm.DB
+	// AfterDelete 在删除每批数据的事务中调用，rows 为模型切片的指针，返回错误时回滚本批删除
+	AfterDelete func(tx *gorm.DB, rows any) error
+}
+
+// Target 一类数据的保留对象，例如游戏日志包括明细和聚合后的问题
+type Target struct {
+	Name        string // 策略名称
+	Title       string // 显示名称
+	DefaultDays int    // 默认保留天数
+	Tables      []Table
+
+	// ArchiveRequired 必须先归档才能删除，不能关闭策略的归档
+	ArchiveRequired bool
+}
+
+var (
+	targets      []Target
+	targetsMutex sync.RWMutex
+)
+
+// RegisterTarget 注册数据保留对象，各模块在 Awake 中调用
+func RegisterTarget(target Target) {
+	targetsMutex.Lock()
+	defer targetsMutex.Unlock()
+
+	for i := range targets {
+		if targets[i].Name == target.Name {
+			targets[i] = target
+			return
+		}
+	}
+	targets = append(targets, target)
+}
+
+// getTargets 返回所有已注册的对象
+func getTargets() []Target {
+	targetsMutex.RLock()
+	defer targetsMutex.RUnlock()
+
+	return append([]Target(nil), targets...)
+}
+
+// getTarget 根据名称查找对象
+func getTarget(name string) (Target, bool) {
+	targetsMutex.RLock()
+	defer targetsMutex.RUnlock()
+
+	for _, target := range targets {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return Target{}, false
+}
@@ -10,21 +10,32 @@ import (
 )
 
 type CreateRoleRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Permissions []uint `json:"permissions"` // 权限ID列表
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Require2FA  bool           `json:"require_2fa"` // 是否要求启用两步验证
+	Permissions []uint         `json:"permissions"` // 权限ID列表
+	Scopes      []ScopeRequest `json:"scopes"`      // 权限的资源范围
 }
 
 type UpdateRoleRequest struct {
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	Permissions []uint `json:"permissions,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Require2FA  *bool           `json:"require_2fa,omitempty"`
+	Permissions []uint          `json:"permissions,omitempty"`
+	Scopes      *[]ScopeRequest `json:"scopes,omitempty"` // 不传时不修改
+}
+
+// ScopeRequest 权限的资源范围，资源 ID 和标签填写一个
+type ScopeRequest struct {
+	Permission string `json:"permission"`
+	ResourceID uint   `json:"resource_id"`
+	Tag        string `json:"tag"`
 }
 
 // listRolesHandler 获取角色列表
 func listRolesHandler(c *fiber.Ctx) error {
 	var roles []models.Role
-	if err := app.DB.Preload("Permissions").Find(&roles).Error; err != nil {
+	if err := app.DB.Preload("Permissions").Preload("Scopes").Find(&roles).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "获取角色列表失败"})
 	}
 	return c.JSON(roles)
@@ -36,6 +47,9 @@ func createRoleHandler(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
 	}
+	if err := validateScopes(req.Scopes); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// 检查角色名是否已存在
 	var count int64
@@ -50,6 +64,7 @@ func createRoleHandler(c *fiber.Ctx) error {
 	role := models.Role{
 		Name:        req.Name,
 		Description: req.Description,
+		Require2FA:  req.Require2FA,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -73,10 +88,15 @@ func createRoleHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	if err := saveScopes(tx, role.ID, req.Scopes); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{"error": "设置权限范围失败"})
+	}
+
 	tx.Commit()
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "create", "role", role.ID, fmt.Sprintf("创建角色：%s", role.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "create", Resource: "role", ResourceID: role.ID, Details: fmt.Sprintf("创建角色：%s", role.Name), After: role})
 
 	return c.JSON(role)
 }
@@ -88,11 +108,17 @@ func updateRoleHandler(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
 	}
+	if req.Scopes != nil {
+		if err := validateScopes(*req.Scopes); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
 
 	var role models.Role
 	if err := app.DB.First(&role, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "角色不存在"})
 	}
+	before := role
 
 	// 开始事务
 	tx := app.DB.Begin()
@@ -107,6 +133,9 @@ func updateRoleHandler(c *fiber.Ctx) error {
 	if req.Description != "" {
 		updates["description"] = req.Description
 	}
+	if req.Require2FA != nil {
+		updates["require_2fa"] = *req.Require2FA
+	}
 
 	if err := tx.Model(&role).Updates(updates).Error; err != nil {
 		tx.Rollback()
@@ -127,10 +156,18 @@ func updateRoleHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	if req.Scopes != nil {
+		if err := saveScopes(tx, role.ID, *req.Scopes); err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{"error": "更新权限范围失败"})
+		}
+	}
+
 	tx.Commit()
+	publishRoleChanged(role.ID)
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "role", role.ID, fmt.Sprintf("更新角色：%s", role.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "role", ResourceID: role.ID, Details: fmt.Sprintf("更新角色：%s", role.Name), Before: before, After: role})
 
 	return c.JSON(role)
 }
@@ -139,11 +176,15 @@ func updateRoleHandler(c *fiber.Ctx) error {
 func deleteRoleHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	// 检查是否有用户使用此角色
-	var count int64
+	// 检查是否有用户使用此角色，包括作为其他角色
+	var count, extra int64
 	if err := app.DB.Model(&models.User{}).Where("role_id = ?", id).Count(&count).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "检查角色使用状态失败"})
 	}
+	if err := app.DB.Model(&models.UserRole{}).Where("role_id = ?", id).Count(&extra).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "检查角色使用状态失败"})
+	}
+	count += extra
 
 	if count > 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "该角色正在使用中，无法删除"})
@@ -163,6 +204,12 @@ func deleteRoleHandler(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "清除权限关联失败"})
 	}
 
+	// 清除权限范围
+	if err := tx.Where("role_id = ?", role.ID).Delete(&models.RoleScope{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{"error": "清除权限范围失败"})
+	}
+
 	// 删除角色
 	if err := tx.Delete(&role).Error; err != nil {
 		tx.Rollback()
@@ -170,9 +217,10 @@ func deleteRoleHandler(c *fiber.Ctx) error {
 	}
 
 	tx.Commit()
+	publishRoleChanged(role.ID)
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "delete", "role", role.ID, fmt.Sprintf("删除角色：%s", role.Name))
+	adminlog.Record(c, adminlog.Entry{Action: "delete", Resource: "role", ResourceID: role.ID, Details: fmt.Sprintf("删除角色：%s", role.Name), Before: role})
 
 	return c.JSON(fiber.Map{"message": "删除成功"})
 }
@@ -0,0 +1,60 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andycai/goapi/events"
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/event"
+	"gorm.io/gorm"
+)
+
+// validateScopes 检查权限范围，每条范围需要填写权限编码，资源 ID 和标签填写一个
+func validateScopes(scopes []ScopeRequest) error {
+	for i, scope := range scopes {
+		if strings.TrimSpace(scope.Permission) == "" {
+			return fmt.Errorf("第 %d 条权限范围没有选择权限", i+1)
+		}
+		tag := internal.NormalizeTag(scope.Tag)
+		if scope.ResourceID == 0 && tag == "" {
+			return fmt.Errorf("第 %d 条权限范围需要填写资源 ID 或标签", i+1)
+		}
+		if scope.ResourceID != 0 && tag != "" {
+			return fmt.Errorf("第 %d 条权限范围只能填写资源 ID 或标签中的一个", i+1)
+		}
+		if len(tag) > 50 {
+			return errors.New("标签过长")
+		}
+	}
+	return nil
+}
+
+// saveScopes 替换角色的权限范围
+func saveScopes(tx *gorm.DB, roleID uint, scopes []ScopeRequest) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RoleScope{}).Error; err != nil {
+		return err
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	records := make([]models.RoleScope, 0, len(scopes))
+	for _, scope := range scopes {
+		records = append(records, models.RoleScope{
+			RoleID:     roleID,
+			Permission: strings.TrimSpace(scope.Permission),
+			ResourceID: scope.ResourceID,
+			Tag:        internal.NormalizeTag(scope.Tag),
+		})
+	}
+	return tx.Create(&records).Error
+}
+
+// publishRoleChanged 通知角色的权限已变更，清除角色权限缓存
+func publishRoleChanged(roleID uint) {
+	event.Publish(app.Bus, context.Background(), events.EventRoleChanged{RoleID: roleID})
+}
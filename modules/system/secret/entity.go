@@ -0,0 +1,16 @@
+package secret
+
+import "github.com/andycai/goapi/models"
+
+// SecretReq 新增或修改凭据的请求，修改时 Value 为空表示不修改值
+type SecretReq struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// SecretVO 凭据列表项，不包含凭据的值
+type SecretVO struct {
+	models.Secret
+	Ref string `json:"ref"` // 其他模块配置中使用的引用，如 secret://svn-main
+}
@@ -0,0 +1,125 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/adminlog"
+	"github.com/gofiber/fiber/v2"
+)
+
+// listSecretsHandler 获取凭据列表，不返回凭据的值
+func listSecretsHandler(c *fiber.Ctx) error {
+	var secrets []models.Secret
+	if err := app.DB.Order("name").Find(&secrets).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取凭据列表失败"})
+	}
+
+	list := make([]SecretVO, 0, len(secrets))
+	for _, secret := range secrets {
+		list = append(list, toVO(secret))
+	}
+
+	// 主密钥不可用时页面提示原因
+	vaultError := ""
+	if err := app.Vault.Err(); err != nil {
+		vaultError = err.Error()
+	}
+
+	return c.JSON(fiber.Map{"data": list, "vault_error": vaultError})
+}
+
+// listAccessHandler 获取凭据访问记录
+func listAccessHandler(c *fiber.Ctx) error {
+	var total int64
+
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+	name := c.Query("name")
+	consumer := c.Query("consumer")
+
+	query := app.DB.Model(&models.SecretAccessLog{})
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if consumer != "" {
+		query = query.Where("consumer LIKE ?", "%"+consumer+"%")
+	}
+	if c.Query("failed") == "1" {
+		query = query.Where("success = ?", false)
+	}
+
+	query.Count(&total)
+
+	var logs []models.SecretAccessLog
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "获取访问记录失败"})
+	}
+
+	return c.JSON(fiber.Map{"total": total, "data": logs})
+}
+
+// createSecretHandler 新增凭据
+func createSecretHandler(c *fiber.Ctx) error {
+	var req SecretReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	user := app.CurrentUser(c)
+	secret, err := createSecret(req, user.Username)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "create", "secret", secret.ID, fmt.Sprintf("新增凭据：%s", secret.Name))
+
+	return c.JSON(fiber.Map{"message": "创建成功", "data": toVO(*secret)})
+}
+
+// updateSecretHandler 修改凭据
+func updateSecretHandler(c *fiber.Ctx) error {
+	secret, err := getSecret(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "凭据不存在"})
+	}
+
+	var req SecretReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	user := app.CurrentUser(c)
+	before := *secret
+	if err := updateSecret(secret, req, user.Username); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	app.DB.First(secret, secret.ID)
+
+	details := fmt.Sprintf("修改凭据说明：%s", secret.Name)
+	if req.Value != "" {
+		details = fmt.Sprintf("修改凭据的值：%s", secret.Name)
+	}
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "secret", ResourceID: secret.ID, Details: details, Before: before, After: secret})
+
+	return c.JSON(fiber.Map{"message": "修改成功"})
+}
+
+// deleteSecretHandler 删除凭据，访问记录保留
+func deleteSecretHandler(c *fiber.Ctx) error {
+	secret, err := getSecret(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "凭据不存在"})
+	}
+
+	if err := app.DB.Delete(secret).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "删除凭据失败"})
+	}
+
+	adminlog.WriteLog(c, "delete", "secret", secret.ID, fmt.Sprintf("删除凭据：%s", secret.Name))
+
+	return c.JSON(fiber.Map{"message": "删除成功"})
+}
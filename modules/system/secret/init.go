@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"log"
+	"time"
+
+	"github.com/andycai/goapi/enum"
+	"github.com/andycai/goapi/models"
+	"gorm.io/gorm"
+)
+
+func autoMigrate() error {
+	return app.DB.AutoMigrate(
+		&models.Secret{},
+		&models.SecretAccessLog{},
+	)
+}
+
+// 初始化数据
+func initData() error {
+	if err := initMenus(); err != nil {
+		return err
+	}
+
+	if err := initPermissions(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initMenus() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("secret:menu") {
+		log.Println("[凭据库模块]菜单数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建凭据库菜单
+		secretMenu := models.Menu{
+			MenuID:     1011,
+			ParentID:   enum.MenuIdSystem,
+			Name:       "凭据库",
+			Path:       "/admin/secret",
+			Icon:       "secret",
+			Sort:       7,
+			Permission: "secret:view",
+			IsShow:     true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		if err := tx.Create(&secretMenu).Error; err != nil {
+			return err
+		}
+
+		// 标记菜单已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "secret:menu",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func initPermissions() error {
+	// 检查是否已初始化
+	if app.IsInitializedModule("secret:permission") {
+		log.Println("[凭据库模块]权限数据已初始化，跳过")
+		return nil
+	}
+
+	// 开始事务
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		// 创建凭据库相关权限
+		permissions := []models.Permission{
+			{
+				Name:        "查看凭据",
+				Code:        "secret:view",
+				Description: "查看凭据列表和访问记录，不包括凭据的值",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+			{
+				Name:        "管理凭据",
+				Code:        "secret:manage",
+				Description: "新增、修改和删除凭据",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			},
+		}
+
+		if err := tx.Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		// 标记模块已初始化
+		if err := tx.Create(&models.ModuleInit{
+			Module:      "secret:permission",
+			Initialized: 1,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/system/retention"
+	"github.com/gofiber/fiber/v2"
+)
+
+const ModulePrioritySecret = 1007 // 系统-凭据库
+
+var app *internal.App
+
+type secretModule struct {
+	internal.BaseModule
+}
+
+func init() {
+	internal.RegisterModule(&secretModule{}, ModulePrioritySecret)
+}
+
+func (m *secretModule) Awake(a *internal.App) error {
+	app = a
+	// 数据迁移
+	if err := autoMigrate(); err != nil {
+		return err
+	}
+
+	// 凭据只保留读取记录，凭据本身不过期
+	retention.RegisterTarget(retention.Target{
+		Name:        "secret_access",
+		Title:       "凭据访问记录",
+		DefaultDays: 180,
+		Tables: []retention.Table{
+			{Model: &models.SecretAccessLog{}, TimeColumn: "created_at"},
+		},
+	})
+
+	return nil
+}
+
+func (m *secretModule) Start() error {
+	// 初始化数据
+	return initData()
+}
+
+func (m *secretModule) AddAuthRouters() error {
+	// admin
+	app.RouterAdmin.Get("/secret", app.HasPermission("secret:view"), func(c *fiber.Ctx) error {
+		return c.Render("admin/secret", fiber.Map{
+			"Title": "凭据库",
+			"Scripts": []string{
+				"/static/js/admin/secret.js",
+			},
+		}, "admin/layout")
+	})
+
+	// api
+	app.RouterAdminApi.Get("/secrets", app.HasPermission("secret:view"), listSecretsHandler)
+	app.RouterAdminApi.Get("/secrets/access", app.HasPermission("secret:view"), listAccessHandler)
+	app.RouterAdminApi.Post("/secrets", app.HasPermission("secret:manage"), createSecretHandler)
+	app.RouterAdminApi.Put("/secrets/:id", app.HasPermission("secret:manage"), updateSecretHandler)
+	app.RouterAdminApi.Delete("/secrets/:id", app.HasPermission("secret:manage"), deleteSecretHandler)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/vault"
+)
+
+// getSecret 获取凭据
+func getSecret(id string) (*models.Secret, error) {
+	var secret models.Secret
+	if err := app.DB.First(&secret, id).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// createSecret 新增凭据
+func createSecret(req SecretReq, operator string) (*models.Secret, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := vault.ValidName(req.Name); err != nil {
+		return nil, errors.New("凭据名称只能包含字母、数字、点、下划线和短横线，且以字母或数字开头")
+	}
+	if req.Value == "" {
+		return nil, errors.New("凭据的值不能为空")
+	}
+	if app.Vault.Exists(req.Name) {
+		return nil, errors.New("凭据已存在")
+	}
+
+	return app.Vault.Put(req.Name, req.Value, strings.TrimSpace(req.Description), operator)
+}
+
+// updateSecret 修改凭据说明，Value 不为空时替换凭据的值
+func updateSecret(secret *models.Secret, req SecretReq, operator string) error {
+	if req.Value != "" {
+		if _, err := app.Vault.Put(secret.Name, req.Value, "", operator); err != nil {
+			return err
+		}
+	}
+
+	return app.DB.Model(secret).Updates(map[string]any{
+		"description": strings.TrimSpace(req.Description),
+		"updated_by":  operator,
+	}).Error
+}
+
+// toVO 转换为列表项
+func toVO(secret models.Secret) SecretVO {
+	return SecretVO{Secret: secret, Ref: vault.Ref(secret.Name)}
+}
@@ -14,7 +14,7 @@ func getUserList(page, limit int) ([]models.User, int64, error) {
 		db = db.Offset(offset).Limit(limit)
 	}
 
-	if err := db.Preload("Role").Order("id desc").Find(&users).Error; err != nil {
+	if err := db.Preload("Role").Preload("Roles").Order("id desc").Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
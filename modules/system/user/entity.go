@@ -1,15 +1,58 @@
 package user
 
+import "github.com/andycai/goapi/models"
+
 type CreateUserRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Nickname string `json:"nickname"`
-	RoleID   uint   `json:"role_id"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Nickname  string `json:"nickname"`
+	RoleID    uint   `json:"role_id"`
+	RoleIDs   []uint `json:"role_ids"`   // 其他角色
+	IsService bool   `json:"is_service"` // 服务账号不设置密码，只能使用 API 令牌
 }
 
 type UpdateUserRequest struct {
-	Password string `json:"password,omitempty"`
-	Nickname string `json:"nickname,omitempty"`
-	RoleID   uint   `json:"role_id,omitempty"`
-	Status   *int   `json:"status,omitempty"`
+	Password string  `json:"password,omitempty"`
+	Nickname string  `json:"nickname,omitempty"`
+	RoleID   uint    `json:"role_id,omitempty"`
+	RoleIDs  *[]uint `json:"role_ids,omitempty"` // 其他角色，不传时不修改
+	Status   *int    `json:"status,omitempty"`
+}
+
+// CreateTokenRequest 创建 API 令牌的请求
+type CreateTokenRequest struct {
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`       // 权限编码，只能从用户角色的权限中选择
+	ExpiresDays int      `json:"expires_days"` // 有效天数，0 表示永不过期
+}
+
+// TokenScope 可以授予令牌的权限
+type TokenScope struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// TwoFactorCodeRequest 需要验证码确认的两步验证操作
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"` // 身份验证器的验证码，关闭两步验证时也可以使用恢复码
+}
+
+// TwoFactorStatus 两步验证的状态
+type TwoFactorStatus struct {
+	Enabled       bool  `json:"enabled"`
+	Required      bool  `json:"required"`       // 角色要求启用两步验证
+	RecoveryCodes int64 `json:"recovery_codes"` // 未使用的恢复码数量
+}
+
+// TwoFactorSetup 开始设置两步验证时返回的密钥和二维码
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qrcode"` // PNG 图片的 data URL
+}
+
+// SessionVO 登录会话
+type SessionVO struct {
+	models.UserSession
+	Current bool `json:"current"` // 是否是当前请求使用的会话
 }
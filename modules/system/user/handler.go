@@ -1,15 +1,19 @@
 package user
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/modules/login"
 	"github.com/andycai/goapi/modules/system/adminlog"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // listUsersHandler 获取用户列表
@@ -63,6 +67,15 @@ func createUserHandler(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "用户名已存在"})
 	}
 
+	// 服务账号使用随机密码，不能登录后台
+	if req.IsService {
+		password, err := randomPassword()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "密码加密失败"})
+		}
+		req.Password = password
+	}
+
 	// 加密密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -74,17 +87,28 @@ func createUserHandler(c *fiber.Ctx) error {
 		Password:  string(hashedPassword),
 		Nickname:  req.Nickname,
 		RoleID:    req.RoleID,
+		IsService: req.IsService,
 		Status:    1,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	if err := app.DB.Create(&user).Error; err != nil {
+	err = app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return setUserRoles(tx, &user, req.RoleIDs)
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "创建用户失败"})
 	}
 
 	// 记录操作日志
-	adminlog.WriteLog(c, "create", "user", user.ID, fmt.Sprintf("创建用户：%s", user.Username))
+	details := fmt.Sprintf("创建用户：%s", user.Username)
+	if user.IsService {
+		details = fmt.Sprintf("创建服务账号：%s", user.Username)
+	}
+	adminlog.Record(c, adminlog.Entry{Action: "create", Resource: "user", ResourceID: user.ID, Details: details, After: user})
 
 	return c.JSON(user)
 }
@@ -122,13 +146,34 @@ func updateUserHandler(c *fiber.Ctx) error {
 	if err := app.DB.First(&user, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
 	}
+	before := user
 
-	if err := app.DB.Model(&user).Updates(updates).Error; err != nil {
+	err := app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.RoleIDs != nil {
+			if req.RoleID != 0 {
+				user.RoleID = req.RoleID
+			}
+			return setUserRoles(tx, &user, *req.RoleIDs)
+		}
+		return nil
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "更新用户失败"})
 	}
 
+	// 禁用的用户立即下线
+	if req.Status != nil && *req.Status != 1 {
+		if _, err := internal.RevokeUserSessions(user.ID, ""); err != nil {
+			log.Printf("[用户模块]下线用户 %s 的会话失败: %v", user.Username, err)
+		}
+	}
+
 	// 记录操作日志
-	adminlog.WriteLog(c, "update", "user", user.ID, fmt.Sprintf("更新用户：%s", user.Username))
+	app.DB.First(&user, user.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "update", Resource: "user", ResourceID: user.ID, Details: fmt.Sprintf("更新用户：%s", user.Username), Before: before, After: user})
 
 	return c.JSON(user)
 }
@@ -150,10 +195,380 @@ func deleteUserHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if _, err := internal.RevokeUserSessions(user.ID, ""); err != nil {
+		log.Printf("[用户模块]下线用户 %s 的会话失败: %v", user.Username, err)
+	}
+
 	// 记录操作日志
-	adminlog.WriteLog(c, "delete", "user", user.ID, fmt.Sprintf("删除用户：%s", user.Username))
+	adminlog.Record(c, adminlog.Entry{Action: "delete", Resource: "user", ResourceID: user.ID, Details: fmt.Sprintf("删除用户：%s", user.Username), Before: user})
 
 	return c.JSON(fiber.Map{
 		"message": "删除成功",
 	})
 }
+
+// listMyTokensHandler 获取当前用户的 API 令牌
+func listMyTokensHandler(c *fiber.Ctx) error {
+	if internal.CurrentToken(c) != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌管理令牌"})
+	}
+	return listTokensResponse(c, app.CurrentUser(c))
+}
+
+// createMyTokenHandler 为当前用户创建 API 令牌
+func createMyTokenHandler(c *fiber.Ctx) error {
+	if internal.CurrentToken(c) != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌管理令牌"})
+	}
+	user := app.CurrentUser(c)
+	// 令牌不需要两步验证，避免绕过角色的两步验证要求
+	if user != nil && internal.TwoFactorRequired(user) && !user.TOTPEnabled {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "角色要求启用两步验证，请先启用后再创建令牌"})
+	}
+	return createTokenResponse(c, user)
+}
+
+// revokeMyTokenHandler 吊销当前用户的 API 令牌
+func revokeMyTokenHandler(c *fiber.Ctx) error {
+	if internal.CurrentToken(c) != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌管理令牌"})
+	}
+	return revokeTokenResponse(c, app.CurrentUser(c))
+}
+
+// listUserTokensHandler 获取用户的 API 令牌
+func listUserTokensHandler(c *fiber.Ctx) error {
+	user, err := getUserWithPermissions(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return listTokensResponse(c, user)
+}
+
+// createUserTokenHandler 为服务账号创建 API 令牌，普通用户只能自己创建令牌
+func createUserTokenHandler(c *fiber.Ctx) error {
+	if internal.CurrentToken(c) != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌管理令牌"})
+	}
+	user, err := getUserWithPermissions(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !user.IsService {
+		return c.Status(400).JSON(fiber.Map{"error": "只能为服务账号创建令牌，普通用户请在个人信息页面创建"})
+	}
+	return createTokenResponse(c, user)
+}
+
+// revokeUserTokenHandler 吊销用户的 API 令牌
+func revokeUserTokenHandler(c *fiber.Ctx) error {
+	user, err := getUserWithPermissions(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return revokeTokenResponse(c, user)
+}
+
+func listTokensResponse(c *fiber.Ctx, user *models.User) error {
+	if user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+
+	tokens, err := listTokens(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "获取令牌失败"})
+	}
+	return c.JSON(fiber.Map{"data": tokens, "scopes": tokenScopes(user)})
+}
+
+func createTokenResponse(c *fiber.Ctx, user *models.User) error {
+	if user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+
+	var req CreateTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	token, raw, err := createToken(user, req, app.CurrentUser(c).Username)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "create", "token", token.ID, fmt.Sprintf("创建 API 令牌：%s（%s，用户 %s）", token.Name, token.Prefix, user.Username))
+
+	// 令牌明文只返回这一次
+	return c.JSON(fiber.Map{"message": "创建成功", "data": token, "token": raw})
+}
+
+func revokeTokenResponse(c *fiber.Ctx, user *models.User) error {
+	if user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+
+	token, err := revokeToken(user.ID, c.Params("tid"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "revoke", "token", token.ID, fmt.Sprintf("吊销 API 令牌：%s（%s，用户 %s）", token.Name, token.Prefix, user.Username))
+
+	return c.JSON(fiber.Map{"message": "令牌已吊销"})
+}
+
+// currentSessionUser 当前通过会话登录的用户，两步验证只能在登录后的页面中设置
+func currentSessionUser(c *fiber.Ctx) (*models.User, error) {
+	if internal.CurrentToken(c) != nil {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌设置两步验证"})
+	}
+	user := app.CurrentUser(c)
+	if user == nil {
+		return nil, c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+	return user, nil
+}
+
+// getTwoFactorHandler 获取当前用户的两步验证状态
+func getTwoFactorHandler(c *fiber.Ctx) error {
+	user, err := currentSessionUser(c)
+	if user == nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"data": twoFactorStatus(user)})
+}
+
+// setupTwoFactorHandler 生成两步验证的密钥和二维码
+func setupTwoFactorHandler(c *fiber.Ctx) error {
+	user, err := currentSessionUser(c)
+	if user == nil {
+		return err
+	}
+
+	setup, err := setupTwoFactor(user)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": setup})
+}
+
+// enableTwoFactorHandler 校验验证码并启用两步验证
+func enableTwoFactorHandler(c *fiber.Ctx) error {
+	user, err := currentSessionUser(c)
+	if user == nil {
+		return err
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	// 与登录第二步共用失败次数和锁定，防止在登录后穷举验证码
+	if blocked, err := login.TwoFactorBlocked(c, user); blocked {
+		return err
+	}
+
+	before := *user
+	codes, err := enableTwoFactor(user, req.Code)
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidTwoFactorCode) {
+			login.TwoFactorFailed(c, user)
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	app.DB.First(user, user.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "enable_2fa", Resource: "user", ResourceID: user.ID, Details: fmt.Sprintf("启用两步验证：%s", user.Username), Before: before, After: user})
+
+	// 恢复码明文只返回这一次
+	return c.JSON(fiber.Map{"message": "两步验证已启用", "recovery_codes": codes})
+}
+
+// disableTwoFactorHandler 校验验证码并关闭两步验证
+func disableTwoFactorHandler(c *fiber.Ctx) error {
+	user, err := currentSessionUser(c)
+	if user == nil {
+		return err
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	if blocked, err := login.TwoFactorBlocked(c, user); blocked {
+		return err
+	}
+
+	before := *user
+	if err := disableTwoFactor(user, req.Code); err != nil {
+		if errors.Is(err, internal.ErrInvalidTwoFactorCode) {
+			login.TwoFactorFailed(c, user)
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	app.DB.First(user, user.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "disable_2fa", Resource: "user", ResourceID: user.ID, Details: fmt.Sprintf("关闭两步验证：%s", user.Username), Before: before, After: user})
+
+	return c.JSON(fiber.Map{"message": "两步验证已关闭"})
+}
+
+// regenerateRecoveryCodesHandler 重新生成恢复码
+func regenerateRecoveryCodesHandler(c *fiber.Ctx) error {
+	user, err := currentSessionUser(c)
+	if user == nil {
+		return err
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "无效的请求数据"})
+	}
+
+	if blocked, err := login.TwoFactorBlocked(c, user); blocked {
+		return err
+	}
+
+	codes, err := regenerateRecoveryCodes(user, req.Code)
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidTwoFactorCode) {
+			login.TwoFactorFailed(c, user)
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "update", "user", user.ID, fmt.Sprintf("重新生成两步验证恢复码：%s", user.Username))
+
+	return c.JSON(fiber.Map{"message": "恢复码已重新生成", "recovery_codes": codes})
+}
+
+// resetUserTwoFactorHandler 管理员重置用户的两步验证，用于用户丢失设备和恢复码的情况
+func resetUserTwoFactorHandler(c *fiber.Ctx) error {
+	var user models.User
+	if err := app.DB.First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
+	}
+	if !user.TOTPEnabled && user.TOTPSecret == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "该用户未启用两步验证"})
+	}
+
+	before := user
+	if err := resetTwoFactor(&user); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "重置两步验证失败"})
+	}
+
+	app.DB.First(&user, user.ID)
+	adminlog.Record(c, adminlog.Entry{Action: "reset_2fa", Resource: "user", ResourceID: user.ID, Details: fmt.Sprintf("重置两步验证：%s", user.Username), Before: before, After: user})
+
+	return c.JSON(fiber.Map{"message": "两步验证已重置，用户下次登录只需要密码"})
+}
+
+// currentSessionOwner 当前通过会话登录的用户，API 令牌不能管理会话
+func currentSessionOwner(c *fiber.Ctx) (*models.User, error) {
+	if internal.CurrentToken(c) != nil {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "不能使用 API 令牌管理会话"})
+	}
+	user := app.CurrentUser(c)
+	if user == nil {
+		return nil, c.Status(401).JSON(fiber.Map{"error": "请先登录"})
+	}
+	return user, nil
+}
+
+// listMySessionsHandler 获取当前用户的登录会话
+func listMySessionsHandler(c *fiber.Ctx) error {
+	user, err := currentSessionOwner(c)
+	if user == nil {
+		return err
+	}
+
+	list, err := listSessions(user.ID, internal.CurrentSessionID(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "获取会话失败"})
+	}
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// revokeMySessionHandler 强制当前用户的会话下线，可以是当前会话
+func revokeMySessionHandler(c *fiber.Ctx) error {
+	user, err := currentSessionOwner(c)
+	if user == nil {
+		return err
+	}
+
+	session, err := internal.RevokeSession(user.ID, c.Params("sid"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "revoke", "session", session.ID, fmt.Sprintf("下线会话：用户 %s，IP %s", user.Username, session.IP))
+
+	return c.JSON(fiber.Map{"message": "会话已下线"})
+}
+
+// revokeMyOtherSessionsHandler 强制当前用户的其他会话下线
+func revokeMyOtherSessionsHandler(c *fiber.Ctx) error {
+	user, err := currentSessionOwner(c)
+	if user == nil {
+		return err
+	}
+
+	count, err := internal.RevokeUserSessions(user.ID, internal.CurrentSessionID(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "下线会话失败"})
+	}
+
+	adminlog.WriteLog(c, "revoke", "session", user.ID, fmt.Sprintf("下线其他会话：用户 %s，共 %d 个", user.Username, count))
+
+	return c.JSON(fiber.Map{"message": fmt.Sprintf("已下线 %d 个会话", count)})
+}
+
+// listUserSessionsHandler 获取用户的登录会话
+func listUserSessionsHandler(c *fiber.Ctx) error {
+	var user models.User
+	if err := app.DB.First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
+	}
+
+	list, err := listSessions(user.ID, internal.CurrentSessionID(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "获取会话失败"})
+	}
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// revokeUserSessionHandler 强制用户的会话下线
+func revokeUserSessionHandler(c *fiber.Ctx) error {
+	var user models.User
+	if err := app.DB.First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
+	}
+
+	session, err := internal.RevokeSession(user.ID, c.Params("sid"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminlog.WriteLog(c, "revoke", "session", session.ID, fmt.Sprintf("下线会话：用户 %s，IP %s", user.Username, session.IP))
+
+	return c.JSON(fiber.Map{"message": "会话已下线"})
+}
+
+// revokeAllUserSessionsHandler 强制用户在所有设备上下线
+func revokeAllUserSessionsHandler(c *fiber.Ctx) error {
+	var user models.User
+	if err := app.DB.First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "用户不存在"})
+	}
+
+	count, err := internal.RevokeUserSessions(user.ID, "")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "下线会话失败"})
+	}
+
+	adminlog.WriteLog(c, "revoke", "session", user.ID, fmt.Sprintf("强制下线：用户 %s，共 %d 个会话", user.Username, count))
+
+	return c.JSON(fiber.Map{"message": fmt.Sprintf("已下线 %d 个会话", count)})
+}
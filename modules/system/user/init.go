@@ -19,7 +19,7 @@ func NewUserDao() *UserDao {
 
 // 数据迁移
 func autoMigrate() error {
-	return app.DB.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.RolePermission{}, &models.ModuleInit{})
+	return app.DB.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.RolePermission{}, &models.UserRole{}, &models.RoleScope{}, &models.ModuleInit{}, &models.APIToken{}, &models.RecoveryCode{})
 }
 
 // 初始化数据
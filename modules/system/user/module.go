@@ -39,12 +39,49 @@ func (m *userModule) AddAuthRouters() error {
 		return c.Render("admin/users", fiber.Map{
 			"Title": "用户管理",
 			"Scripts": []string{
+				"/static/js/admin/tokens.js",
+				"/static/js/admin/sessions.js",
 				"/static/js/admin/users.js",
 			},
 		}, "admin/layout")
 	})
 
+	// 个人信息，所有登录用户都可以访问
+	app.RouterAdmin.Get("/profile", func(c *fiber.Ctx) error {
+		return c.Render("admin/profile", fiber.Map{
+			"Title": "个人信息",
+			"Scripts": []string{
+				"/static/js/admin/tokens.js",
+				"/static/js/admin/sessions.js",
+				"/static/js/admin/profile.js",
+			},
+		}, "admin/layout")
+	})
+
 	// api
+	app.RouterAdminApi.Get("/tokens", listMyTokensHandler)
+	app.RouterAdminApi.Post("/tokens", createMyTokenHandler)
+	app.RouterAdminApi.Delete("/tokens/:tid", revokeMyTokenHandler)
+
+	app.RouterAdminApi.Get("/sessions", listMySessionsHandler)
+	app.RouterAdminApi.Post("/sessions/revoke-others", revokeMyOtherSessionsHandler)
+	app.RouterAdminApi.Delete("/sessions/:sid", revokeMySessionHandler)
+
+	app.RouterAdminApi.Get("/2fa", getTwoFactorHandler)
+	app.RouterAdminApi.Post("/2fa/setup", setupTwoFactorHandler)
+	app.RouterAdminApi.Post("/2fa/enable", enableTwoFactorHandler)
+	app.RouterAdminApi.Post("/2fa/disable", disableTwoFactorHandler)
+	app.RouterAdminApi.Post("/2fa/recovery-codes", regenerateRecoveryCodesHandler)
+
+	app.RouterAdminApi.Delete("/users/:id/2fa", app.HasPermission("user:update"), resetUserTwoFactorHandler)
+	app.RouterAdminApi.Get("/users/:id/tokens", app.HasPermission("user:view"), listUserTokensHandler)
+	app.RouterAdminApi.Post("/users/:id/tokens", app.HasPermission("user:update"), createUserTokenHandler)
+	app.RouterAdminApi.Delete("/users/:id/tokens/:tid", app.HasPermission("user:update"), revokeUserTokenHandler)
+
+	app.RouterAdminApi.Get("/users/:id/sessions", app.HasPermission("user:view"), listUserSessionsHandler)
+	app.RouterAdminApi.Delete("/users/:id/sessions", app.HasPermission("user:update"), revokeAllUserSessionsHandler)
+	app.RouterAdminApi.Delete("/users/:id/sessions/:sid", app.HasPermission("user:update"), revokeUserSessionHandler)
+
 	app.RouterAdminApi.Get("/users", app.HasPermission("user:view"), listUsersHandler)
 	app.RouterAdminApi.Post("/users", app.HasPermission("user:create"), createUserHandler)
 	app.RouterAdminApi.Put("/users/:id", app.HasPermission("user:update"), updateUserHandler)
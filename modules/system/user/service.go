@@ -1,9 +1,13 @@
 package user
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"github.com/andycai/goapi/internal"
 	"github.com/andycai/goapi/models"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // GetByID 获取用户
@@ -26,3 +30,23 @@ func Current(c *fiber.Ctx) *models.User {
 
 	return GetByID(userID)
 }
+
+// randomPassword 生成随机密码，用于不能登录的服务账号
+func randomPassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// setUserRoles 设置用户的其他角色，忽略不存在的角色和主角色
+func setUserRoles(tx *gorm.DB, user *models.User, roleIDs []uint) error {
+	var roles []models.Role
+	if len(roleIDs) > 0 {
+		if err := tx.Where("id IN ? AND id <> ?", roleIDs, user.RoleID).Find(&roles).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(user).Association("Roles").Replace(roles)
+}
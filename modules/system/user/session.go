@@ -0,0 +1,20 @@
+package user
+
+import "github.com/andycai/goapi/internal"
+
+// listSessions 获取用户的登录会话，标记当前请求使用的会话
+func listSessions(userID uint, current string) ([]SessionVO, error) {
+	sessions, err := internal.ListUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]SessionVO, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, SessionVO{
+			UserSession: session,
+			Current:     current != "" && session.SessionID == current,
+		})
+	}
+	return list, nil
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+)
+
+// maxTokenDays 令牌最长有效天数
+const maxTokenDays = 3650
+
+// getUserWithPermissions 获取用户和角色的权限
+func getUserWithPermissions(id string) (*models.User, error) {
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	user := app.LoadUser(uint(userID))
+	if user == nil {
+		return nil, errors.New("用户不存在")
+	}
+	return user, nil
+}
+
+// tokenScopes 用户可以授予令牌的权限，即用户所有角色的权限
+func tokenScopes(user *models.User) []TokenScope {
+	seen := make(map[string]bool)
+	scopes := make([]TokenScope, 0)
+	for _, role := range internal.UserRoles(user) {
+		for _, perm := range role.Permissions {
+			if seen[perm.Code] {
+				continue
+			}
+			seen[perm.Code] = true
+			scopes = append(scopes, TokenScope{Code: perm.Code, Name: perm.Name})
+		}
+	}
+	return scopes
+}
+
+// listTokens 获取用户的令牌，已吊销的令牌排在后面
+func listTokens(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := app.DB.Where("user_id = ?", userID).
+		Order("revoked_at IS NOT NULL, id DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// createToken 为用户创建令牌，返回令牌明文
+func createToken(user *models.User, req CreateTokenRequest, operator string) (*models.APIToken, string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return nil, "", errors.New("请填写令牌名称")
+	}
+	if req.ExpiresDays < 0 || req.ExpiresDays > maxTokenDays {
+		return nil, "", errors.New("无效的有效期")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, "", errors.New("请至少选择一个权限")
+	}
+
+	allowed := make(map[string]bool)
+	for _, scope := range tokenScopes(user) {
+		allowed[scope.Code] = true
+	}
+	seen := make(map[string]bool)
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, code := range req.Scopes {
+		if !allowed[code] {
+			return nil, "", errors.New("用户没有权限：" + code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			scopes = append(scopes, code)
+		}
+	}
+
+	raw, prefix, hash, err := internal.GenerateAPIToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := &models.APIToken{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		TokenHash: hash,
+		Scopes:    strings.Join(scopes, ","),
+		CreatedBy: operator,
+	}
+	if req.ExpiresDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := app.DB.Create(token).Error; err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+// revokeToken 吊销用户的令牌
+func revokeToken(userID uint, tokenID any) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := app.DB.Where("user_id = ?", userID).First(&token, tokenID).Error; err != nil {
+		return nil, errors.New("令牌不存在")
+	}
+	if token.RevokedAt != nil {
+		return nil, errors.New("令牌已吊销")
+	}
+
+	now := time.Now()
+	if err := app.DB.Model(&token).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+	return &token, nil
+}
@@ -0,0 +1,119 @@
+package user
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/andycai/goapi/internal"
+	"github.com/andycai/goapi/models"
+	"github.com/andycai/goapi/pkg/totp"
+	qrcode "github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// totpIssuer 身份验证器应用中显示的名称
+const totpIssuer = "UniTool"
+
+// twoFactorStatus 获取用户的两步验证状态
+func twoFactorStatus(user *models.User) TwoFactorStatus {
+	status := TwoFactorStatus{
+		Enabled:  user.TOTPEnabled,
+		Required: internal.TwoFactorRequired(user),
+	}
+	if user.TOTPEnabled {
+		status.RecoveryCodes = app.RemainingRecoveryCodes(user.ID)
+	}
+	return status
+}
+
+// setupTwoFactor 生成新的密钥，验证码校验通过后才会启用
+func setupTwoFactor(user *models.User) (*TwoFactorSetup, error) {
+	if user.TOTPEnabled {
+		return nil, errors.New("已启用两步验证，请先关闭后再重新设置")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := app.EncryptTOTPSecret(user.ID, secret)
+	if err != nil {
+		return nil, errors.New("两步验证密钥加密失败，请检查凭据库主密钥")
+	}
+
+	uri := totp.ProvisioningURI(totpIssuer, user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{"totp_secret": encrypted, "totp_counter": 0}
+	if err := app.DB.Model(user).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// enableTwoFactor 校验验证码后启用两步验证，返回恢复码
+func enableTwoFactor(user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, errors.New("已启用两步验证")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("请先扫描二维码")
+	}
+	if err := app.VerifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := app.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if codes, err = internal.ResetRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Model(user).Update("totp_enabled", true).Error
+	})
+	return codes, err
+}
+
+// disableTwoFactor 校验验证码或恢复码后关闭两步验证
+func disableTwoFactor(user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return errors.New("未启用两步验证")
+	}
+	if internal.TwoFactorRequired(user) {
+		return errors.New("角色要求启用两步验证，不能关闭")
+	}
+	if _, err := app.VerifyTwoFactor(user, code); err != nil {
+		return err
+	}
+	return resetTwoFactor(user)
+}
+
+// regenerateRecoveryCodes 校验验证码后重新生成恢复码，原有的恢复码失效
+func regenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if !user.TOTPEnabled {
+		return nil, errors.New("未启用两步验证")
+	}
+	if err := app.VerifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+	return internal.ResetRecoveryCodes(app.DB, user.ID)
+}
+
+// resetTwoFactor 清除用户的两步验证密钥和恢复码
+func resetTwoFactor(user *models.User) error {
+	return app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{"totp_secret": "", "totp_enabled": false, "totp_counter": 0}
+		return tx.Model(user).Updates(updates).Error
+	})
+}
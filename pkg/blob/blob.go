@@ -0,0 +1,152 @@
+// Package blob 提供统一的对象存储接口，支持本地磁盘和 S3 兼容存储
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ContentRefreshAge PutContent 遇到相同内容的对象时，超过该时间没有写入则重新写入以刷新修改时间，
+// 按修改时间清理无引用对象的调用方只要保留时间大于该值，就不会删除刚被 PutContent 返回的对象
+const ContentRefreshAge = time.Hour
+
+var (
+	ErrNotFound   = errors.New("blob: object not found")
+	ErrInvalidKey = errors.New("blob: invalid key")
+)
+
+// Object 对象元信息
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// Store 对象存储接口
+type Store interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Stat 获取对象元信息
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// List 列出指定前缀下的所有对象（递归）
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// URL 返回对象的访问地址
+	URL(key string) string
+}
+
+// Config 存储配置
+type Config struct {
+	Driver    string // local 或 s3
+	Dir       string // 本地存储根目录
+	PublicURL string // 对象访问地址前缀
+	Endpoint  string // S3 服务地址
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // 使用 endpoint/bucket/key 形式的地址（MinIO 等）
+}
+
+// Open 根据配置创建存储
+func Open(cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.Dir, cfg.PublicURL)
+	case "s3":
+		return NewS3(cfg)
+	}
+	return nil, fmt.Errorf("blob: unknown driver %q", cfg.Driver)
+}
+
+// CleanKey 规范化对象键，拒绝越界和绝对路径
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned == "." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// ContentKey 返回按内容哈希命名的对象键，如 stats/ab/abcdef....jpg
+func ContentKey(prefix, sum, ext string) string {
+	return path.Join(prefix, sum[:2], sum+ext)
+}
+
+// PutContent 按内容的 SHA-256 命名保存对象，相同内容只保存一份，返回对象键
+// 返回的对象修改时间不早于 ContentRefreshAge 之前
+func PutContent(ctx context.Context, s Store, prefix string, r io.Reader, ext, contentType string) (string, error) {
+	// 先落到临时文件，边写边计算哈希，避免大文件占用内存
+	tmp, err := os.CreateTemp("", "blob-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return "", err
+	}
+
+	key := ContentKey(prefix, hex.EncodeToString(h.Sum(nil)), ext)
+	if obj, err := s.Stat(ctx, key); err == nil {
+		if time.Since(obj.ModTime) < ContentRefreshAge {
+			return key, nil
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, key, tmp, size, contentType); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Copy 复制对象
+func Copy(ctx context.Context, s Store, src, dst string) error {
+	r, obj, err := s.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return s.Put(ctx, dst, r, obj.Size, obj.ContentType)
+}
+
+// Move 移动对象
+func Move(ctx context.Context, s Store, src, dst string) error {
+	if err := Copy(ctx, s, src, dst); err != nil {
+		return err
+	}
+	return s.Delete(ctx, src)
+}
@@ -0,0 +1,207 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	store, err := NewLocal(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// newTestS3 创建连接到 s3Server 的 S3 存储，s3Server 的数据保存在本地存储中
+func newTestS3(t *testing.T) *S3 {
+	t.Helper()
+	server := httptest.NewServer(newS3Server(newTestLocal(t), "test"))
+	t.Cleanup(server.Close)
+
+	store, err := NewS3(Config{
+		Endpoint:  server.URL,
+		Bucket:    "test",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func testStores(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"local": newTestLocal(t),
+		"s3":    newTestS3(t),
+	}
+}
+
+func readAll(t *testing.T, s Store, key string) (string, *Object) {
+	t.Helper()
+	rc, obj, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), obj
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Put(ctx, "stats/a.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Put(ctx, "stats/sub/b.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Put(ctx, "other/c.txt", strings.NewReader("c"), 1, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			data, obj := readAll(t, s, "stats/a.jpg")
+			if data != "jpeg" || obj.Size != 4 || obj.ContentType != "image/jpeg" {
+				t.Errorf("Get = %q, %+v", data, obj)
+			}
+
+			stat, err := s.Stat(ctx, "stats/sub/b.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stat.Size != 5 || time.Since(stat.ModTime) > time.Minute {
+				t.Errorf("Stat = %+v", stat)
+			}
+
+			list, err := s.List(ctx, "stats/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, obj := range list {
+				keys = append(keys, obj.Key)
+			}
+			sort.Strings(keys)
+			if strings.Join(keys, ",") != "stats/a.jpg,stats/sub/b.txt" {
+				t.Errorf("List = %v", keys)
+			}
+
+			if err := s.Delete(ctx, "stats/a.jpg"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Stat(ctx, "stats/a.jpg"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Stat after Delete: %v, want ErrNotFound", err)
+			}
+			if _, _, err := s.Get(ctx, "stats/a.jpg"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+			}
+			// 删除不存在的对象不返回错误
+			if err := s.Delete(ctx, "stats/a.jpg"); err != nil {
+				t.Errorf("Delete missing object: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", `..\secret`} {
+				if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestS3Multipart(t *testing.T) {
+	ctx := context.Background()
+	s := newTestS3(t)
+
+	// 未知大小和超过阈值的数据都使用分片上传
+	data := bytes.Repeat([]byte("0123456789abcdef"), (s3PartSize+1024)/16)
+	if err := s.Put(ctx, "archives/unknown.bin", bytes.NewReader(data), -1, "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	got, obj := readAll(t, s, "archives/unknown.bin")
+	if got != string(data) || obj.Size != int64(len(data)) {
+		t.Errorf("multipart object has %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestPutContent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocal(t)
+
+	key, err := PutContent(ctx, s, "stats", strings.NewReader("screenshot"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "stats/") || !strings.HasSuffix(key, ".jpg") {
+		t.Errorf("key = %q", key)
+	}
+
+	again, err := PutContent(ctx, s, "stats", strings.NewReader("screenshot"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != key {
+		t.Errorf("same content got key %q, want %q", again, key)
+	}
+
+	// 修改时间早于 ContentRefreshAge 的对象重新写入，避免刚引用就被清理
+	old := time.Now().Add(-2 * ContentRefreshAge)
+	path := filepath.Join(s.Root(), filepath.FromSlash(key))
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PutContent(ctx, s, "stats", strings.NewReader("screenshot"), ".jpg", "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(obj.ModTime) > ContentRefreshAge {
+		t.Errorf("old object was not refreshed, mod time %v", obj.ModTime)
+	}
+}
+
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Put(ctx, "tmp/a.txt", strings.NewReader("moved"), 5, "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+			if err := Move(ctx, s, "tmp/a.txt", "files/a.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := readAll(t, s, "files/a.txt"); data != "moved" {
+				t.Errorf("moved object = %q", data)
+			}
+			if _, err := s.Stat(ctx, "tmp/a.txt"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("source still exists: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3PartSize         = 8 << 20  // 分片上传每片大小
+	s3MultipartMinSize = 16 << 20 // 超过该大小使用分片上传
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	s3Algorithm        = "AWS4-HMAC-SHA256"
+)
+
+// S3 S3 兼容存储（AWS S3、MinIO、COS 等）
+type S3 struct {
+	cfg      Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 创建 S3 兼容存储
+func NewS3(cfg Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("blob: s3 endpoint and bucket are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" {
+		endpoint, err = url.Parse("https://" + cfg.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// objectURL 返回对象的请求地址
+func (s *S3) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path = "/" + s.cfg.Bucket
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = ""
+	}
+	if key != "" {
+		u.Path += "/" + key
+	} else if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawPath = encodePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do 发送签名后的请求
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	u := s.objectURL(key, query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && body != nil {
+		req.ContentLength = size
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	s.sign(req, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign 使用 AWS Signature V4 签名请求
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	if size < 0 || size > s3MultipartMinSize {
+		return s.putMultipart(ctx, key, r, contentType)
+	}
+
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, nil, r, size, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// putMultipart 分片上传大文件或未知大小的数据
+func (s *S3) putMultipart(ctx context.Context, key string, r io.Reader, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, header)
+	if err != nil {
+		return err
+	}
+	var initiate struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = decodeResponse(resp, &initiate)
+	if err != nil {
+		return err
+	}
+
+	complete, err := s.uploadParts(ctx, key, initiate.UploadID, r)
+	if err != nil {
+		// 上传失败时取消分片任务，释放服务端已保存的分片
+		if resp, abortErr := s.do(context.Background(), http.MethodDelete, key, url.Values{"uploadId": {initiate.UploadID}}, nil, 0, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err = s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {initiate.UploadID}}, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// completeMultipartUpload 完成分片上传的请求体
+type completeMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, r io.Reader) (*completeMultipartUpload, error) {
+	complete := &completeMultipartUpload{}
+	buf := make([]byte, s3PartSize)
+
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF && number > 1 {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		query := url.Values{
+			"partNumber": {strconv.Itoa(number)},
+			"uploadId":   {uploadID},
+		}
+		resp, doErr := s.do(ctx, http.MethodPut, key, query, bytes.NewReader(buf[:n]), int64(n), nil)
+		if doErr != nil {
+			return nil, doErr
+		}
+		checkErr := checkResponse(resp)
+		resp.Body.Close()
+		if checkErr != nil {
+			return nil, checkErr
+		}
+		complete.Parts = append(complete.Parts, completePart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		if err != nil {
+			// 最后一片
+			break
+		}
+	}
+
+	return complete, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+
+	return resp.Body, objectFromHeader(key, resp), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return objectFromHeader(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// listBucketResult ListObjectsV2 的响应
+type listBucketResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Contents              []listBucketObject
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+type listBucketObject struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var (
+		objects []Object
+		token   string
+	)
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Contents {
+			objects = append(objects, Object{
+				Key:     item.Key,
+				Size:    item.Size,
+				ETag:    strings.Trim(item.ETag, `"`),
+				ModTime: item.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) URL(key string) string {
+	if s.cfg.PublicURL == "" {
+		return "/blob/" + key
+	}
+	return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + key
+}
+
+// s3Error S3 错误响应
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	var e s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("blob: s3 %s: %s", e.Code, e.Message)
+	}
+	return fmt.Errorf("blob: s3 status %d", resp.StatusCode)
+}
+
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+func objectFromHeader(key string, resp *http.Response) *Object {
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return &Object{
+		Key:         key,
+		Size:        size,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+		ModTime:     modTime,
+	}
+}
+
+// canonicalQuery 按签名要求排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodePath 编码对象路径，保留分隔符
+func encodePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return uriEncode(p, false)
+}
+
+// uriEncode 按 RFC 3986 编码，仅保留非保留字符
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,267 @@
+package blob
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// s3Server 以 S3 协议暴露任意 Store 的简易服务，测试 S3 存储时替代真实的对象存储。
+// 只支持 path-style 地址，不校验签名。
+type s3Server struct {
+	store  Store
+	bucket string
+
+	mu      sync.Mutex
+	uploads map[string]string // uploadId -> 分片临时目录
+}
+
+// newS3Server 创建 S3 兼容服务
+func newS3Server(store Store, bucket string) *s3Server {
+	return &s3Server{
+		store:   store,
+		bucket:  bucket,
+		uploads: make(map[string]string),
+	}
+}
+
+func (s *s3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, r, query.Get("prefix"))
+	case key == "":
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported bucket operation")
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.createUpload(w)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.uploadPart(w, r, query.Get("uploadId"), query.Get("partNumber"))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, r, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		s.abortUpload(w, query.Get("uploadId"))
+	case r.Method == http.MethodPut:
+		if err := s.store.Put(r.Context(), key, r.Body, r.ContentLength, r.Header.Get("Content-Type")); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet:
+		rc, obj, err := s.store.Get(r.Context(), key)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		defer rc.Close()
+		writeObjectHeader(w, obj)
+		io.Copy(w, rc)
+	case r.Method == http.MethodHead:
+		obj, err := s.store.Stat(r.Context(), key)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeObjectHeader(w, obj)
+	case r.Method == http.MethodDelete:
+		if err := s.store.Delete(r.Context(), key); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported object operation")
+	}
+}
+
+func (s *s3Server) list(w http.ResponseWriter, r *http.Request, prefix string) {
+	objects, err := s.store.List(r.Context(), prefix)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	result := listBucketResult{}
+	for _, obj := range objects {
+		result.Contents = append(result.Contents, listBucketObject{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			ETag:         obj.ETag,
+			LastModified: obj.ModTime.UTC(),
+		})
+	}
+	writeXML(w, result)
+}
+
+func (s *s3Server) createUpload(w http.ResponseWriter) {
+	dir, err := os.MkdirTemp("", "s3-upload-*")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	uploadID := hex.EncodeToString(id)
+
+	s.mu.Lock()
+	s.uploads[uploadID] = dir
+	s.mu.Unlock()
+
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		UploadID string   `xml:"UploadId"`
+	}{Bucket: s.bucket, UploadID: uploadID})
+}
+
+func (s *s3Server) uploadDir(uploadID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir, ok := s.uploads[uploadID]
+	return dir, ok
+}
+
+func (s *s3Server) uploadPart(w http.ResponseWriter, r *http.Request, uploadID, partNumber string) {
+	dir, ok := s.uploadDir(uploadID)
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	number, err := strconv.Atoi(partNumber)
+	if err != nil || number < 1 || number > 10000 {
+		writeS3Error(w, http.StatusBadRequest, "InvalidArgument", "invalid part number")
+		return
+	}
+
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%05d", number)))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	_, err = io.Copy(f, r.Body)
+	f.Close()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, uploadID, number))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *s3Server) completeUpload(w http.ResponseWriter, r *http.Request, key, uploadID string) {
+	dir, ok := s.uploadDir(uploadID)
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	var req completeMultipartUpload
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeS3Error(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	sort.Slice(req.Parts, func(i, j int) bool { return req.Parts[i].PartNumber < req.Parts[j].PartNumber })
+
+	// 按顺序拼接分片
+	readers := make([]io.Reader, 0, len(req.Parts))
+	var size int64
+	for _, part := range req.Parts {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%05d", part.PartNumber)))
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidPart", "part not found")
+			return
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil {
+			size += info.Size()
+		}
+		readers = append(readers, f)
+	}
+
+	if err := s.store.Put(r.Context(), key, io.MultiReader(readers...), size, ""); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.removeUpload(uploadID)
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string   `xml:"Bucket"`
+		Key     string   `xml:"Key"`
+	}{Bucket: s.bucket, Key: key})
+}
+
+func (s *s3Server) abortUpload(w http.ResponseWriter, uploadID string) {
+	s.removeUpload(uploadID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *s3Server) removeUpload(uploadID string) {
+	s.mu.Lock()
+	dir, ok := s.uploads[uploadID]
+	delete(s.uploads, uploadID)
+	s.mu.Unlock()
+
+	if ok {
+		os.RemoveAll(dir)
+	}
+}
+
+func writeObjectHeader(w http.ResponseWriter, obj *Object) {
+	if obj.ContentType != "" {
+		w.Header().Set("Content-Type", obj.ContentType)
+	}
+	if obj.ETag != "" {
+		w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	w.Header().Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeS3Error(w, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+	case errors.Is(err, ErrInvalidKey):
+		writeS3Error(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+	default:
+		writeS3Error(w, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		RequestID string   `xml:"RequestId"`
+	}{Code: code, Message: message, RequestID: strconv.FormatInt(time.Now().UnixNano(), 36)})
+}
@@ -0,0 +1,137 @@
+package sso
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig LDAP 配置
+type LDAPConfig struct {
+	URL                string // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // 查询用户的服务账号，为空时匿名查询
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // 查询用户的过滤器，%s 为用户名，如 (uid=%s)
+	UsernameAttr       string // 用户名属性，为空时使用登录时输入的用户名
+	NameAttr           string // 显示名称属性，如 cn、displayName
+	EmailAttr          string
+	GroupAttr          string // 用户组属性，如 memberOf
+	Timeout            time.Duration
+}
+
+// LDAPConn LDAP 连接，测试时可以替换为内存实现
+type LDAPConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAP 通过 LDAP 绑定验证用户名和密码
+type LDAP struct {
+	cfg  LDAPConfig
+	Dial func(cfg LDAPConfig) (LDAPConn, error)
+}
+
+// NewLDAP 创建 LDAP 登录
+func NewLDAP(cfg LDAPConfig) *LDAP {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &LDAP{cfg: cfg, Dial: dialLDAP}
+}
+
+func dialLDAP(cfg LDAPConfig) (LDAPConn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(cfg.Timeout)
+
+	if cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// Authenticate 查询用户并使用用户的 DN 和密码绑定
+func (l *LDAP) Authenticate(username, password string) (*Identity, error) {
+	// 空密码会被当作匿名绑定并成功，必须拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := l.Dial(l.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("sso: connect ldap: %w", err)
+	}
+	defer conn.Close()
+
+	if l.cfg.BindDN != "" {
+		if err := conn.Bind(l.cfg.BindDN, l.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("sso: ldap service bind: %w", err)
+		}
+	}
+
+	attrs := []string{"dn"}
+	for _, attr := range []string{l.cfg.UsernameAttr, l.cfg.NameAttr, l.cfg.EmailAttr, l.cfg.GroupAttr} {
+		if attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		l.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(l.cfg.Timeout.Seconds()), false,
+		fmt.Sprintf(l.cfg.UserFilter, ldap.EscapeFilter(username)),
+		attrs, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("sso: ldap search: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	if len(result.Entries) > 1 {
+		return nil, errors.New("sso: ldap filter matched more than one user")
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("sso: ldap bind: %w", err)
+	}
+
+	identity := &Identity{
+		Provider: ProviderLDAP,
+		Subject:  entry.DN,
+		Username: username,
+	}
+	if l.cfg.UsernameAttr != "" {
+		if value := entry.GetAttributeValue(l.cfg.UsernameAttr); value != "" {
+			identity.Username = value
+		}
+	}
+	if l.cfg.NameAttr != "" {
+		identity.Nickname = entry.GetAttributeValue(l.cfg.NameAttr)
+	}
+	if l.cfg.EmailAttr != "" {
+		identity.Email = entry.GetAttributeValue(l.cfg.EmailAttr)
+	}
+	if l.cfg.GroupAttr != "" {
+		identity.Groups = entry.GetAttributeValues(l.cfg.GroupAttr)
+	}
+	return identity, nil
+}
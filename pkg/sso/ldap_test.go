@@ -0,0 +1,218 @@
+package sso
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapUser 内存目录中的用户
+type ldapUser struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// ldapStub 内存中的 LDAP 目录，按 (uid=xxx) 过滤器查找用户
+type ldapStub struct {
+	users   map[string]ldapUser // uid -> 用户
+	service ldapUser            // 服务账号
+	binds   []string            // 依次绑定的 DN
+	filters []string            // 收到的查询过滤器
+	closed  int
+}
+
+func newLDAPStub() *ldapStub {
+	return &ldapStub{
+		service: ldapUser{dn: "cn=readonly,dc=example,dc=com", password: "readonly"},
+		users: map[string]ldapUser{
+			"alice": {
+				dn:       "uid=alice,ou=people,dc=example,dc=com",
+				password: "wonderland",
+				attrs: map[string][]string{
+					"uid":      {"alice"},
+					"cn":       {"Alice Liddell"},
+					"mail":     {"alice@example.com"},
+					"memberOf": {"cn=admins,ou=groups,dc=example,dc=com", "cn=dev,ou=groups,dc=example,dc=com"},
+				},
+			},
+			"bob*": {
+				dn:       "uid=bob,ou=people,dc=example,dc=com",
+				password: "builder",
+				attrs:    map[string][]string{"uid": {"bob"}},
+			},
+		},
+	}
+}
+
+func (s *ldapStub) dial(LDAPConfig) (LDAPConn, error) {
+	return s, nil
+}
+
+func (s *ldapStub) Bind(username, password string) error {
+	s.binds = append(s.binds, username)
+	if username == s.service.dn && password == s.service.password {
+		return nil
+	}
+	for _, user := range s.users {
+		if user.dn == username && user.password == password {
+			return nil
+		}
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (s *ldapStub) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	s.filters = append(s.filters, req.Filter)
+	result := &ldap.SearchResult{}
+	for uid, user := range s.users {
+		if req.Filter != fmt.Sprintf("(uid=%s)", ldap.EscapeFilter(uid)) {
+			continue
+		}
+		attrs := make(map[string][]string)
+		for _, name := range req.Attributes {
+			if values, ok := user.attrs[name]; ok {
+				attrs[name] = values
+			}
+		}
+		result.Entries = append(result.Entries, ldap.NewEntry(user.dn, attrs))
+	}
+	return result, nil
+}
+
+func (s *ldapStub) Close() error {
+	s.closed++
+	return nil
+}
+
+func newTestLDAP(stub *ldapStub, cfg LDAPConfig) *LDAP {
+	if cfg.BaseDN == "" {
+		cfg.BaseDN = "ou=people,dc=example,dc=com"
+	}
+	l := NewLDAP(cfg)
+	l.Dial = stub.dial
+	return l
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	stub := newLDAPStub()
+	l := newTestLDAP(stub, LDAPConfig{
+		BindDN:       stub.service.dn,
+		BindPassword: stub.service.password,
+		UsernameAttr: "uid",
+		NameAttr:     "cn",
+		EmailAttr:    "mail",
+		GroupAttr:    "memberOf",
+	})
+
+	identity, err := l.Authenticate("alice", "wonderland")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.Provider != ProviderLDAP {
+		t.Errorf("Provider = %q", identity.Provider)
+	}
+	if identity.Subject != "uid=alice,ou=people,dc=example,dc=com" {
+		t.Errorf("Subject = %q, want the user DN", identity.Subject)
+	}
+	if identity.Username != "alice" || identity.Nickname != "Alice Liddell" || identity.Email != "alice@example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if len(identity.Groups) != 2 {
+		t.Errorf("Groups = %v", identity.Groups)
+	}
+
+	// 先用服务账号查询，再用用户自己的 DN 绑定
+	wantBinds := []string{stub.service.dn, "uid=alice,ou=people,dc=example,dc=com"}
+	if fmt.Sprint(stub.binds) != fmt.Sprint(wantBinds) {
+		t.Errorf("binds = %v, want %v", stub.binds, wantBinds)
+	}
+	if stub.closed != 1 {
+		t.Errorf("connection closed %d times, want 1", stub.closed)
+	}
+}
+
+func TestLDAPAuthenticateFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     error
+	}{
+		{"wrong password", "alice", "queen", ErrInvalidCredentials},
+		{"unknown user", "carol", "secret", ErrUserNotFound},
+		{"empty password", "alice", "", ErrInvalidCredentials},
+		{"empty username", "", "wonderland", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newLDAPStub()
+			l := newTestLDAP(stub, LDAPConfig{})
+
+			_, err := l.Authenticate(tt.username, tt.password)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			// 空密码会被服务端当作匿名绑定，不能发到服务端
+			if tt.password == "" && len(stub.binds) > 0 {
+				t.Errorf("empty password reached the server: %v", stub.binds)
+			}
+		})
+	}
+}
+
+func TestLDAPEscapesFilter(t *testing.T) {
+	stub := newLDAPStub()
+	l := newTestLDAP(stub, LDAPConfig{UsernameAttr: "uid"})
+
+	// 用户名中的通配符按字面量查询，只能匹配 uid 恰好为 bob* 的条目
+	identity, err := l.Authenticate("bob*", "builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.filters[0] != `(uid=bob\2a)` {
+		t.Errorf("filter = %q", stub.filters[0])
+	}
+	// 用户名以目录中的属性为准
+	if identity.Username != "bob" {
+		t.Errorf("Username = %q, want bob", identity.Username)
+	}
+}
+
+func TestLDAPServiceBindFailure(t *testing.T) {
+	stub := newLDAPStub()
+	l := newTestLDAP(stub, LDAPConfig{BindDN: stub.service.dn, BindPassword: "wrong"})
+
+	_, err := l.Authenticate("alice", "wonderland")
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want a service bind error", err)
+	}
+	if len(stub.filters) != 0 {
+		t.Error("searched after the service bind failed")
+	}
+}
+
+func TestMapRole(t *testing.T) {
+	rules := []RoleRule{
+		{Group: "cn=admins,ou=groups,dc=example,dc=com", Role: "admin"},
+		{Group: "cn=dev,ou=groups,dc=example,dc=com", Role: "developer"},
+	}
+
+	tests := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"cn=dev,ou=groups,dc=example,dc=com", "CN=Admins,OU=Groups,DC=example,DC=com"}, "admin"},
+		{[]string{" cn=dev,ou=groups,dc=example,dc=com "}, "developer"},
+		{[]string{"cn=guests,ou=groups,dc=example,dc=com"}, "guest"},
+		{nil, "guest"},
+	}
+	for _, tt := range tests {
+		if got := MapRole(tt.groups, rules, "guest"); got != tt.want {
+			t.Errorf("MapRole(%v) = %q, want %q", tt.groups, got, tt.want)
+		}
+	}
+}
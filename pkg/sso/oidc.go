@@ -0,0 +1,145 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig OpenID Connect 配置
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string   // 回调地址，如 https://tools.example.com/login/oidc/callback
+	Scopes        []string // 为空时使用 openid profile email
+	UsernameClaim string   // 为空时依次使用 preferred_username、email、sub
+	NameClaim     string   // 为空时使用 name
+	GroupsClaim   string   // 为空时使用 groups
+}
+
+// OIDC 授权码模式登录
+type OIDC struct {
+	cfg      OIDCConfig
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDC 读取身份提供方的 discovery 文档并创建登录
+func NewOIDC(ctx context.Context, cfg OIDCConfig) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("sso: oidc discovery: %w", err)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	if !contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	return &OIDC{
+		cfg: cfg,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// AuthCodeURL 跳转到身份提供方的登录地址
+func (o *OIDC) AuthCodeURL(state, nonce string) string {
+	return o.oauth.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+// Exchange 使用授权码换取并校验 ID Token，返回其中的身份
+func (o *OIDC) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	token, err := o.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("sso: oidc exchange: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("sso: oidc response has no id_token")
+	}
+
+	idToken, err := o.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("sso: oidc verify: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("sso: oidc nonce mismatch")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("sso: oidc claims: %w", err)
+	}
+
+	identity := &Identity{
+		Provider: ProviderOIDC,
+		Subject:  idToken.Subject,
+		Nickname: claimString(claims, o.cfg.NameClaim, "name"),
+		Email:    claimString(claims, "email"),
+		Groups:   claimStrings(claims, o.cfg.GroupsClaim, "groups"),
+	}
+	if o.cfg.UsernameClaim != "" {
+		identity.Username = claimString(claims, o.cfg.UsernameClaim)
+	} else {
+		identity.Username = claimString(claims, "preferred_username", "email", "sub")
+	}
+	if identity.Username == "" {
+		return nil, errors.New("sso: oidc id_token has no username claim")
+	}
+	return identity, nil
+}
+
+// claimString 返回第一个非空的字符串声明
+func claimString(claims map[string]any, names ...string) string {
+	for _, name := range names {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// claimStrings 读取字符串数组声明，也支持逗号分隔的字符串
+func claimStrings(claims map[string]any, names ...string) []string {
+	for _, name := range names {
+		switch value := claims[name].(type) {
+		case []any:
+			var list []string
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					list = append(list, s)
+				}
+			}
+			return list
+		case string:
+			if value != "" {
+				return strings.Split(value, ",")
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
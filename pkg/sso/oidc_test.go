@@ -0,0 +1,318 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	testClientID     = "unitool"
+	testClientSecret = "client-secret"
+	testCode         = "auth-code"
+)
+
+// oidcIssuer 内存中的身份提供方，提供 discovery、JWKS 和授权码换取 ID Token
+type oidcIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey // 签名密钥
+	public *rsa.PublicKey  // JWKS 中公布的公钥
+	claims map[string]any  // 下一次签发的 ID Token 声明，iss、aud、exp、iat 自动补齐
+}
+
+func newOIDCIssuer(t *testing.T) *oidcIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := &oidcIssuer{key: key, public: &key.PublicKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (i *oidcIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                i.URL,
+		"authorization_endpoint":                i.URL + "/authorize",
+		"token_endpoint":                        i.URL + "/token",
+		"jwks_uri":                              i.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *oidcIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       i.public,
+		KeyID:     "test",
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+}
+
+func (i *oidcIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("code") != testCode {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := i.sign()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (i *oidcIssuer) sign() (string, error) {
+	now := time.Now()
+	claims := map[string]any{
+		"iss": i.URL,
+		"aud": testClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range i.claims {
+		claims[k] = v
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: i.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"),
+	)
+	if err != nil {
+		return "", err
+	}
+	object, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return object.CompactSerialize()
+}
+
+func newTestOIDC(t *testing.T, issuer *oidcIssuer, cfg OIDCConfig) *OIDC {
+	t.Helper()
+	cfg.Issuer = issuer.URL
+	cfg.ClientID = testClientID
+	if cfg.ClientSecret == "" {
+		cfg.ClientSecret = testClientSecret
+	}
+	cfg.RedirectURL = "http://localhost:3000/login/oidc/callback"
+
+	o, err := NewOIDC(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	issuer := newOIDCIssuer(t)
+	o := newTestOIDC(t, issuer, OIDCConfig{Scopes: []string{"profile", "groups"}})
+
+	u, err := url.Parse(o.AuthCodeURL("state-1", "nonce-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" {
+		t.Errorf("AuthCodeURL = %s", u)
+	}
+	// openid 总是放在第一个
+	if scope := query.Get("scope"); scope != "openid profile groups" {
+		t.Errorf("scope = %q", scope)
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	issuer := newOIDCIssuer(t)
+	issuer.claims = map[string]any{
+		"sub":                "248289761001",
+		"nonce":              "nonce-1",
+		"preferred_username": "alice",
+		"name":               "Alice Liddell",
+		"email":              "alice@example.com",
+		"groups":             []string{"unitool-admins", "dev"},
+	}
+	o := newTestOIDC(t, issuer, OIDCConfig{})
+
+	identity, err := o.Exchange(context.Background(), testCode, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.Provider != ProviderOIDC || identity.Subject != "248289761001" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if identity.Username != "alice" || identity.Nickname != "Alice Liddell" || identity.Email != "alice@example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if strings.Join(identity.Groups, ",") != "unitool-admins,dev" {
+		t.Errorf("Groups = %v", identity.Groups)
+	}
+}
+
+func TestOIDCExchangeClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    OIDCConfig
+		claims map[string]any
+		user   string
+		groups string
+	}{
+		{
+			name:   "fallback to email",
+			claims: map[string]any{"sub": "1", "email": "bob@example.com"},
+			user:   "bob@example.com",
+		},
+		{
+			name:   "fallback to sub",
+			claims: map[string]any{"sub": "1"},
+			user:   "1",
+		},
+		{
+			name:   "configured claims",
+			cfg:    OIDCConfig{UsernameClaim: "upn", GroupsClaim: "roles"},
+			claims: map[string]any{"sub": "1", "upn": "carol", "preferred_username": "ignored", "roles": "a,b"},
+			user:   "carol",
+			groups: "a,b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newOIDCIssuer(t)
+			issuer.claims = tt.claims
+			issuer.claims["nonce"] = "n"
+			o := newTestOIDC(t, issuer, tt.cfg)
+
+			identity, err := o.Exchange(context.Background(), testCode, "n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Username != tt.user {
+				t.Errorf("Username = %q, want %q", identity.Username, tt.user)
+			}
+			if got := strings.Join(identity.Groups, ","); got != tt.groups {
+				t.Errorf("Groups = %q, want %q", got, tt.groups)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    OIDCConfig
+		code   string
+		nonce  string
+		claims map[string]any
+	}{
+		{
+			name:   "nonce mismatch",
+			code:   testCode,
+			nonce:  "other",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice"},
+		},
+		{
+			name:   "wrong audience",
+			code:   testCode,
+			nonce:  "n",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice", "aud": "another-client"},
+		},
+		{
+			name:   "expired",
+			code:   testCode,
+			nonce:  "n",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice", "exp": time.Now().Add(-time.Hour).Unix()},
+		},
+		{
+			name:   "missing username claim",
+			cfg:    OIDCConfig{UsernameClaim: "upn"},
+			code:   testCode,
+			nonce:  "n",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice"},
+		},
+		{
+			name:   "invalid code",
+			code:   "stolen",
+			nonce:  "n",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice"},
+		},
+		{
+			name:   "invalid client secret",
+			cfg:    OIDCConfig{ClientSecret: "wrong"},
+			code:   testCode,
+			nonce:  "n",
+			claims: map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newOIDCIssuer(t)
+			issuer.claims = tt.claims
+			o := newTestOIDC(t, issuer, tt.cfg)
+
+			if identity, err := o.Exchange(context.Background(), tt.code, tt.nonce); err == nil {
+				t.Fatalf("Exchange accepted the token: %+v", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCRejectsForeignKey(t *testing.T) {
+	issuer := newOIDCIssuer(t)
+	issuer.claims = map[string]any{"sub": "1", "nonce": "n", "preferred_username": "alice"}
+	o := newTestOIDC(t, issuer, OIDCConfig{})
+
+	// 换成不在 JWKS 中的密钥签名
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer.key = key
+
+	if _, err := o.Exchange(context.Background(), testCode, "n"); err == nil {
+		t.Fatal("Exchange accepted a token signed by an unknown key")
+	}
+}
@@ -0,0 +1,59 @@
+// Package sso 提供 LDAP 和 OpenID Connect 登录，返回统一的外部身份
+// 账号开通和角色映射由调用方完成
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// 身份提供方
+const (
+	ProviderLDAP = "ldap"
+	ProviderOIDC = "oidc"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("sso: invalid credentials")
+	ErrUserNotFound       = errors.New("sso: user not found")
+)
+
+// Identity 外部身份
+type Identity struct {
+	Provider string
+	Subject  string // ldap 为用户 DN，oidc 为 sub
+	Username string
+	Nickname string
+	Email    string
+	Groups   []string
+}
+
+// RoleRule 用户组到角色的映射
+type RoleRule struct {
+	Group string
+	Role  string
+}
+
+// MapRole 按规则顺序匹配用户组，返回第一个匹配的角色，都不匹配时返回默认角色
+// 用户组不区分大小写，LDAP 的 DN 本身也不区分大小写
+func MapRole(groups []string, rules []RoleRule, defaultRole string) string {
+	for _, rule := range rules {
+		for _, group := range groups {
+			if strings.EqualFold(strings.TrimSpace(group), strings.TrimSpace(rule.Group)) {
+				return rule.Role
+			}
+		}
+	}
+	return defaultRole
+}
+
+// RandomString 生成随机字符串，用于 state、nonce 等
+func RandomString() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
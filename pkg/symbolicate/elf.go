@@ -0,0 +1,86 @@
+package symbolicate
+
+import (
+	"debug/elf"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// LoadELF 从 ELF 文件（如 Android 的 .so.sym）读取函数符号
+func LoadELF(r io.ReaderAt) (*AddressTable, error) {
+	file, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var symbols []Symbol
+	collect := func(list []elf.Symbol) {
+		for _, s := range list {
+			if elf.ST_TYPE(s.Info) != elf.STT_FUNC || s.Value == 0 || s.Name == "" {
+				continue
+			}
+			symbols = append(symbols, Symbol{Name: s.Name, Addr: s.Value, Size: s.Size})
+		}
+	}
+
+	if list, err := file.Symbols(); err == nil {
+		collect(list)
+	}
+	if list, err := file.DynamicSymbols(); err == nil {
+		collect(list)
+	}
+
+	if len(symbols) == 0 {
+		return nil, errors.New("ELF文件中没有函数符号")
+	}
+
+	return NewAddressTable(elfBuildID(file), dedupeSymbols(symbols)), nil
+}
+
+// elfBuildID 读取 .note.gnu.build-id 中的构建ID
+func elfBuildID(file *elf.File) string {
+	section := file.Section(".note.gnu.build-id")
+	if section == nil {
+		return ""
+	}
+
+	data, err := section.Data()
+	if err != nil || len(data) < 16 {
+		return ""
+	}
+
+	order := file.ByteOrder
+	nameSize := order.Uint32(data[0:4])
+	descSize := order.Uint32(data[4:8])
+	noteType := order.Uint32(data[8:12])
+	if noteType != 3 { // NT_GNU_BUILD_ID
+		return ""
+	}
+
+	descStart := 12 + align4(nameSize)
+	if uint32(len(data)) < descStart+descSize {
+		return ""
+	}
+
+	return hex.EncodeToString(data[descStart : descStart+descSize])
+}
+
+func align4(n uint32) uint32 {
+	return (n + 3) &^ 3
+}
+
+// dedupeSymbols 去掉 .symtab 与 .dynsym 中重复的符号
+func dedupeSymbols(symbols []Symbol) []Symbol {
+	seen := make(map[uint64]bool, len(symbols))
+	result := symbols[:0]
+	for _, s := range symbols {
+		if seen[s.Addr] {
+			continue
+		}
+		seen[s.Addr] = true
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package symbolicate
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SourceLine C# 源码位置
+type SourceLine struct {
+	File string
+	Line int
+}
+
+// LineMapping Il2CPP 生成的 C++ 行号到 C# 源码的映射
+type LineMapping struct {
+	files map[string]map[int]SourceLine // C++ 文件名 -> C++ 行号 -> C# 位置
+}
+
+// LoadIl2CppMapping 读取 Il2CPP 输出的 LineNumberMappings.json
+//
+// 文件结构为 { "<C++文件>": { "<C++行号>": <C#位置> } }，C#位置既可以是
+// "Assets/Foo.cs:12" 形式的字符串，也可以是包含文件和行号字段的对象。
+func LoadIl2CppMapping(r io.Reader) (*LineMapping, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	mapping := &LineMapping{files: make(map[string]map[int]SourceLine, len(raw))}
+	for cppFile, lines := range raw {
+		entries := make(map[int]SourceLine, len(lines))
+		for cppLine, value := range lines {
+			n, err := strconv.Atoi(cppLine)
+			if err != nil {
+				continue
+			}
+			if source, ok := parseSourceLine(value); ok {
+				entries[n] = source
+			}
+		}
+		mapping.files[path.Base(strings.ReplaceAll(cppFile, "\\", "/"))] = entries
+	}
+
+	return mapping, nil
+}
+
+func parseSourceLine(value json.RawMessage) (SourceLine, bool) {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		file, line, ok := strings.Cut(text, ":")
+		if !ok {
+			return SourceLine{File: text}, text != ""
+		}
+		n, _ := strconv.Atoi(line)
+		return SourceLine{File: file, Line: n}, true
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return SourceLine{}, false
+	}
+
+	var source SourceLine
+	for key, v := range obj {
+		switch strings.ToLower(key) {
+		case "path", "file", "csfilename", "csfile", "c#":
+			source.File, _ = v.(string)
+		case "line", "cslinenumber", "csline":
+			if f, ok := v.(float64); ok {
+				source.Line = int(f)
+			}
+		}
+	}
+
+	return source, source.File != ""
+}
+
+// Lookup 查找 C++ 文件行号对应的 C# 源码位置，找不到时使用之前最近的映射行
+func (m *LineMapping) Lookup(cppFile string, cppLine int) (SourceLine, bool) {
+	lines, ok := m.files[path.Base(strings.ReplaceAll(cppFile, "\\", "/"))]
+	if !ok {
+		return SourceLine{}, false
+	}
+
+	if source, ok := lines[cppLine]; ok {
+		return source, true
+	}
+
+	// 生成的代码中一个 C# 语句通常对应多行 C++，向前找最近的映射
+	for n := cppLine - 1; n > 0 && n > cppLine-50; n-- {
+		if source, ok := lines[n]; ok {
+			return source, true
+		}
+	}
+
+	return SourceLine{}, false
+}
@@ -0,0 +1,124 @@
+package symbolicate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// #00 pc 000000000041c5b4  /data/app/.../lib/arm64/libil2cpp.so (BuildId: 1a2b3c)
+	reAndroidFrame = regexp.MustCompile(`^(\s*#\d+\s+pc\s+)([0-9a-fA-F]+)(\s+)(\S+)(.*)$`)
+	// 3   UnityFramework   0x0000000104a3c1d0 0x1047e8000 + 2441680
+	reIOSFrame = regexp.MustCompile(`^(\s*\d+\s+)(\S+)(\s+)0x([0-9a-fA-F]+)\s+(0x[0-9a-fA-F]+|\S+)\s+\+\s+(\d+)(.*)$`)
+	// Bulk_Assembly-CSharp_0.cpp:12345
+	reCppLine = regexp.MustCompile(`([\w\-./\\]+\.cpp):(\d+)`)
+	reBuildID = regexp.MustCompile(`BuildId:\s*([0-9a-fA-F]+)`)
+)
+
+// Symbolicator 将原始地址堆栈还原为符号
+type Symbolicator struct {
+	modules map[string][]*AddressTable // 模块名 -> 符号表（同一模块可能有多个构建）
+	lines   []*LineMapping
+}
+
+// New 创建符号还原器
+func New() *Symbolicator {
+	return &Symbolicator{modules: make(map[string][]*AddressTable)}
+}
+
+// AddModule 添加模块的符号表，模块名如 libil2cpp.so、UnityFramework
+func (s *Symbolicator) AddModule(module string, table *AddressTable) {
+	key := ModuleName(module)
+	s.modules[key] = append(s.modules[key], table)
+}
+
+// AddLineMapping 添加 Il2CPP 行号映射
+func (s *Symbolicator) AddLineMapping(mapping *LineMapping) {
+	s.lines = append(s.lines, mapping)
+}
+
+// ModuleName 由路径或符号文件名得到模块名，libil2cpp.sym.so、libil2cpp.so.sym 都对应 libil2cpp.so
+func ModuleName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Replace(name, ".sym.so", ".so", 1)
+	name = strings.TrimSuffix(name, ".sym")
+	name = strings.TrimSuffix(name, ".debug")
+	return strings.ToLower(name)
+}
+
+// Symbolicate 还原整个堆栈，无法识别的行保持不变
+func (s *Symbolicator) Symbolicate(stack string) string {
+	// 客户端上报的堆栈中换行可能被转义
+	escaped := !strings.Contains(stack, "\n") && strings.Contains(stack, `\n`)
+	if escaped {
+		stack = strings.ReplaceAll(stack, `\n`, "\n")
+	}
+
+	lines := strings.Split(stack, "\n")
+	for i, line := range lines {
+		lines[i] = s.SymbolicateLine(line)
+	}
+
+	result := strings.Join(lines, "\n")
+	if escaped {
+		result = strings.ReplaceAll(result, "\n", `\n`)
+	}
+	return result
+}
+
+// SymbolicateLine 还原单行堆栈
+func (s *Symbolicator) SymbolicateLine(line string) string {
+	if m := reAndroidFrame.FindStringSubmatch(line); m != nil {
+		addr, err := strconv.ParseUint(m[2], 16, 64)
+		if err == nil {
+			buildID := ""
+			if b := reBuildID.FindStringSubmatch(m[5]); b != nil {
+				buildID = b[1]
+			}
+			if sym, ok := s.lookup(m[4], buildID, addr); ok {
+				return fmt.Sprintf("%s%s%s%s (%s+%d)%s", m[1], m[2], m[3], m[4], sym.Name, addr-sym.Addr, m[5])
+			}
+		}
+	}
+
+	if m := reIOSFrame.FindStringSubmatch(line); m != nil {
+		offset, err := strconv.ParseUint(m[6], 10, 64)
+		if err == nil {
+			if sym, ok := s.lookup(m[2], "", offset); ok {
+				return fmt.Sprintf("%s%s%s0x%s %s + %d%s", m[1], m[2], m[3], m[4], sym.Name, offset-sym.Addr, m[7])
+			}
+		}
+	}
+
+	if len(s.lines) > 0 && reCppLine.MatchString(line) {
+		line = reCppLine.ReplaceAllStringFunc(line, func(match string) string {
+			sub := reCppLine.FindStringSubmatch(match)
+			n, _ := strconv.Atoi(sub[2])
+			for _, mapping := range s.lines {
+				if source, ok := mapping.Lookup(sub[1], n); ok {
+					return fmt.Sprintf("%s:%d", source.File, source.Line)
+				}
+			}
+			return match
+		})
+	}
+
+	return line
+}
+
+// lookup 在模块的符号表中查找地址，指定了构建ID时只使用匹配的符号表
+func (s *Symbolicator) lookup(module, buildID string, addr uint64) (Symbol, bool) {
+	buildID = normalizeBuildID(buildID)
+	for _, table := range s.modules[ModuleName(module)] {
+		if buildID != "" && table.BuildID != "" && !strings.EqualFold(table.BuildID, buildID) {
+			continue
+		}
+		if sym, ok := table.Lookup(addr); ok {
+			return sym, true
+		}
+	}
+	return Symbol{}, false
+}
@@ -0,0 +1,211 @@
+package symbolicate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata 中的符号文件：
+//
+//	libgame.so              gcc 编译的 Android 共享库，game_update@0x1020+5、game_render@0x1025+17
+//	UnityFramework.txt      iOS 文本符号表，同时包含大小格式和 nm 格式
+//	LineNumberMappings.json Il2CPP 行号映射，包含字符串和对象两种写法
+
+const fixtureBuildID = "1a2b3c4d5e6f708192a3b4c5d6e7f80912345678"
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func loadFixtures(t *testing.T) *Symbolicator {
+	t.Helper()
+	s := New()
+
+	elfTable, err := LoadELF(openFixture(t, "libgame.so"))
+	if err != nil {
+		t.Fatalf("LoadELF: %v", err)
+	}
+	s.AddModule("libgame.sym.so", elfTable)
+
+	textTable, err := LoadTextMap(openFixture(t, "UnityFramework.txt"))
+	if err != nil {
+		t.Fatalf("LoadTextMap: %v", err)
+	}
+	s.AddModule("UnityFramework", textTable)
+
+	mapping, err := LoadIl2CppMapping(openFixture(t, "LineNumberMappings.json"))
+	if err != nil {
+		t.Fatalf("LoadIl2CppMapping: %v", err)
+	}
+	s.AddLineMapping(mapping)
+
+	return s
+}
+
+func TestLoadELF(t *testing.T) {
+	table, err := LoadELF(openFixture(t, "libgame.so"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.BuildID != fixtureBuildID {
+		t.Errorf("BuildID = %q, want %q", table.BuildID, fixtureBuildID)
+	}
+	// .symtab 与 .dynsym 中的同一个符号只保留一份
+	if table.Len() != 2 {
+		t.Errorf("Len = %d, want 2", table.Len())
+	}
+
+	tests := []struct {
+		addr uint64
+		name string
+		ok   bool
+	}{
+		{0x1020, "game_update", true},
+		{0x1024, "game_update", true},
+		{0x1025, "game_render", true},
+		{0x1035, "game_render", true},
+		{0x1036, "", false}, // 超出 game_render 的大小
+		{0x1000, "", false},
+	}
+	for _, tt := range tests {
+		sym, ok := table.Lookup(tt.addr)
+		if ok != tt.ok || sym.Name != tt.name {
+			t.Errorf("Lookup(%#x) = %q, %v, want %q, %v", tt.addr, sym.Name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestLoadELFInvalid(t *testing.T) {
+	if _, err := LoadELF(openFixture(t, "UnityFramework.txt")); err == nil {
+		t.Error("LoadELF accepted a text file")
+	}
+}
+
+func TestLoadTextMap(t *testing.T) {
+	table, err := LoadTextMap(openFixture(t, "UnityFramework.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0b5c1e2a3f4d5e6f708192a3b4c5d6e7"; table.BuildID != want {
+		t.Errorf("BuildID = %q, want %q", table.BuildID, want)
+	}
+	// nm 格式中的数据段符号被忽略
+	if table.Len() != 3 {
+		t.Errorf("Len = %d, want 3", table.Len())
+	}
+
+	tests := []struct {
+		addr uint64
+		name string
+		ok   bool
+	}{
+		{0x1000, "PlayerLoop", true},
+		{0x107f, "PlayerLoop", true},
+		{0x1080, "ScriptingInvoke", true},
+		{0x10c0, "", false},
+		{0x2345, "il2cpp_raise_exception", true}, // nm 格式没有大小，延伸到下一个符号
+	}
+	for _, tt := range tests {
+		sym, ok := table.Lookup(tt.addr)
+		if ok != tt.ok || sym.Name != tt.name {
+			t.Errorf("Lookup(%#x) = %q, %v, want %q, %v", tt.addr, sym.Name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestLoadIl2CppMapping(t *testing.T) {
+	mapping, err := LoadIl2CppMapping(openFixture(t, "LineNumberMappings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		line int
+		want SourceLine
+		ok   bool
+	}{
+		{"Bulk_Assembly-CSharp_0.cpp", 120, SourceLine{"Assets/Scripts/Player.cs", 42}, true},
+		{"Bulk_Assembly-CSharp_0.cpp", 125, SourceLine{"Assets/Scripts/Player.cs", 42}, true},
+		{`C:\build\il2cppOutput\Bulk_Assembly-CSharp_0.cpp`, 130, SourceLine{"Assets/Scripts/Enemy.cs", 17}, true},
+		{"Bulk_Assembly-CSharp_0.cpp", 100, SourceLine{}, false},
+		{"Bulk_Other_0.cpp", 120, SourceLine{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := mapping.Lookup(tt.file, tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%s, %d) = %+v, %v, want %+v, %v", tt.file, tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSymbolicate(t *testing.T) {
+	s := loadFixtures(t)
+
+	tests := []struct {
+		name  string
+		stack string
+		want  string
+	}{
+		{
+			name:  "android",
+			stack: "#00 pc 0000000000001022  /data/app/com.example/lib/arm64/libgame.so (BuildId: " + fixtureBuildID + ")",
+			want:  "#00 pc 0000000000001022  /data/app/com.example/lib/arm64/libgame.so (game_update+2) (BuildId: " + fixtureBuildID + ")",
+		},
+		{
+			name:  "android build id mismatch",
+			stack: "#00 pc 0000000000001022  libgame.so (BuildId: ffff)",
+			want:  "#00 pc 0000000000001022  libgame.so (BuildId: ffff)",
+		},
+		{
+			name:  "ios",
+			stack: "3   UnityFramework   0x0000000104a3d090 0x104a3c000 + 4240",
+			want:  "3   UnityFramework   0x0000000104a3d090 ScriptingInvoke + 16",
+		},
+		{
+			name:  "il2cpp",
+			stack: "at Player.Update () [0x00000] in Bulk_Assembly-CSharp_0.cpp:121",
+			want:  "at Player.Update () [0x00000] in Assets/Scripts/Player.cs:42",
+		},
+		{
+			name:  "escaped newlines",
+			stack: `#00 pc 0000000000001030  libgame.so\n#01 pc 0000000000009999  libgame.so`,
+			want:  `#00 pc 0000000000001030  libgame.so (game_render+11)\n#01 pc 0000000000009999  libgame.so`,
+		},
+		{
+			name:  "unknown module",
+			stack: "#00 pc 0000000000001022  libunity.so",
+			want:  "#00 pc 0000000000001022  libunity.so",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Symbolicate(tt.stack); got != tt.want {
+				t.Errorf("Symbolicate:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	tests := map[string]string{
+		"libil2cpp.sym.so":                      "libil2cpp.so",
+		"libil2cpp.so.sym":                      "libil2cpp.so",
+		"libil2cpp.so.debug":                    "libil2cpp.so",
+		"/data/app/lib/arm64/libil2cpp.so":      "libil2cpp.so",
+		`C:\symbols\UnityFramework`:             "unityframework",
+		"UnityFramework.dSYM/../UnityFramework": "unityframework",
+	}
+	for name, want := range tests {
+		if got := ModuleName(name); got != want {
+			t.Errorf("ModuleName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package symbolicate
+
+import (
+	"sort"
+)
+
+// Symbol 符号信息
+type Symbol struct {
+	Name string
+	Addr uint64
+	Size uint64 // 为0时表示大小未知，一直延伸到下一个符号
+}
+
+// AddressTable 按地址排序的符号表
+type AddressTable struct {
+	BuildID string
+	symbols []Symbol
+}
+
+// NewAddressTable 创建符号表，symbols 不要求有序
+func NewAddressTable(buildID string, symbols []Symbol) *AddressTable {
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Addr < symbols[j].Addr
+	})
+	return &AddressTable{BuildID: buildID, symbols: symbols}
+}
+
+// Len 符号数量
+func (t *AddressTable) Len() int {
+	return len(t.symbols)
+}
+
+// Lookup 查找包含指定地址的符号
+func (t *AddressTable) Lookup(addr uint64) (Symbol, bool) {
+	// 找到第一个起始地址大于 addr 的符号，前一个即为候选
+	i := sort.Search(len(t.symbols), func(i int) bool {
+		return t.symbols[i].Addr > addr
+	})
+	if i == 0 {
+		return Symbol{}, false
+	}
+
+	sym := t.symbols[i-1]
+	if sym.Size > 0 && addr >= sym.Addr+sym.Size {
+		return Symbol{}, false
+	}
+
+	return sym, true
+}
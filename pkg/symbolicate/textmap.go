@@ -0,0 +1,89 @@
+package symbolicate
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadTextMap 读取文本格式的符号表，用于从 iOS dSYM 精简得到的映射文件
+//
+// 每行一个符号，支持两种格式：
+//
+//	<起始地址> <大小> <符号名>
+//	<起始地址> <类型> <符号名>   （nm 输出格式）
+//
+// 地址和大小均为十六进制，可带 0x 前缀；以 # 开头的行为注释，
+// "# build-id: <uuid>" 注释行用于声明构建ID。
+func LoadTextMap(r io.Reader) (*AddressTable, error) {
+	var (
+		symbols []Symbol
+		buildID string
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if id, ok := strings.CutPrefix(strings.TrimSpace(line[1:]), "build-id:"); ok {
+				buildID = normalizeBuildID(id)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		addr, err := parseHex(fields[0])
+		if err != nil {
+			continue
+		}
+
+		var size uint64
+		if len(fields[1]) == 1 && !isDigit(fields[1][0]) {
+			// nm 格式，只保留代码段符号；类型 b、d 等同时也是十六进制字符，单个字母一律按类型处理
+			if t := fields[1][0]; t != 'T' && t != 't' {
+				continue
+			}
+		} else if size, err = parseHex(fields[1]); err != nil {
+			continue
+		}
+
+		symbols = append(symbols, Symbol{
+			Name: strings.Join(fields[2:], " "),
+			Addr: addr,
+			Size: size,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(symbols) == 0 {
+		return nil, errors.New("映射文件中没有符号")
+	}
+
+	return NewAddressTable(buildID, symbols), nil
+}
+
+func parseHex(s string) (uint64, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return strconv.ParseUint(s, 16, 64)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// normalizeBuildID 统一构建ID格式，去掉 UUID 中的横线并转为小写
+func normalizeBuildID(id string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(id), "-", ""))
+}
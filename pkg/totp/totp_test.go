@@ -0,0 +1,135 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA-1 使用的密钥 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA-1 测试向量，验证码为 8 位，这里取后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSecretFormat(t *testing.T) {
+	want, err := Code(rfcSecret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 用户手动输入的密钥可能是小写、带空白、没有补齐
+	got, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Code = %s, want %s", got, want)
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Counter(now)
+
+	// 前后各一个周期内的验证码有效，返回验证码所在的周期
+	for offset := int64(-Skew); offset <= Skew; offset++ {
+		code, _ := Code(rfcSecret, current+offset)
+		counter, ok := Validate(rfcSecret, code, now)
+		if !ok || counter != current+offset {
+			t.Errorf("offset %d: Validate = %d, %v, want %d, true", offset, counter, ok, current+offset)
+		}
+	}
+
+	// 超出允许偏差的验证码无效
+	for _, offset := range []int64{-Skew - 1, Skew + 1} {
+		code, _ := Code(rfcSecret, current+offset)
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("offset %d: Validate accepted a code outside the window", offset)
+		}
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, Counter(now))
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"exact", code, true},
+		{"spaces", " " + code[:3] + " " + code[3:] + " ", true},
+		{"too short", code[:5], false},
+		{"too long", code + "0", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(rfcSecret, tt.code, now); ok != tt.ok {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("Validate accepted an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Error("GenerateSecret returned the same secret twice")
+	}
+
+	key, err := encoding.DecodeString(a)
+	if err != nil || len(key) != 20 {
+		t.Errorf("secret %q decodes to %d bytes, %v", a, len(key), err)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI("UniTool", "alice@example.com", "ABC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/UniTool:alice@example.com" {
+		t.Errorf("URI = %s", u)
+	}
+	query := u.Query()
+	if query.Get("secret") != "ABC" || query.Get("issuer") != "UniTool" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("query = %v", query)
+	}
+}
@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// ftpFS FTP 服务器，使用 REST 命令续传
+type ftpFS struct {
+	conn *ftp.ServerConn
+}
+
+func dialFTP(ctx context.Context, cfg Config) (*ftpFS, error) {
+	port := cfg.Port
+	if port == 0 {
+		port = 21
+	}
+	conn, err := ftp.Dial(fmt.Sprintf("%s:%d", cfg.Host, port), ftp.DialWithContext(ctx), ftp.DialWithTimeout(cfg.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("FTP连接失败: %w", err)
+	}
+	if err := conn.Login(cfg.Username, cfg.Password); err != nil {
+		conn.Quit()
+		return nil, fmt.Errorf("FTP登录失败: %w", err)
+	}
+	return &ftpFS{conn: conn}, nil
+}
+
+func (f *ftpFS) Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error) {
+	return resumableUpload(ctx, f, localPath, ftpPath(remotePath), opts)
+}
+
+func (f *ftpFS) Test(ctx context.Context, dir string) error {
+	return f.mkdirAll(ftpPath(dir))
+}
+
+func (f *ftpFS) Close() error {
+	return f.conn.Quit()
+}
+
+func (f *ftpFS) size(remote string) (int64, error) {
+	// 大多数服务器对不存在的文件返回 550，无法与其他错误区分
+	size, err := f.conn.FileSize(remote)
+	if err != nil {
+		return 0, ErrNotExist
+	}
+	return size, nil
+}
+
+func (f *ftpFS) write(remote string, r io.Reader, offset int64) error {
+	if offset == 0 {
+		return f.conn.Stor(remote, r)
+	}
+	return f.conn.StorFrom(remote, r, uint64(offset))
+}
+
+func (f *ftpFS) open(remote string) (io.ReadCloser, error) {
+	return f.conn.Retr(remote)
+}
+
+func (f *ftpFS) rename(from, to string) error {
+	// 部分服务器不允许重命名到已存在的文件
+	f.conn.Delete(to)
+	return f.conn.Rename(from, to)
+}
+
+func (f *ftpFS) remove(remote string) error {
+	return f.conn.Delete(remote)
+}
+
+// mkdirAll 逐级创建远程目录
+func (f *ftpFS) mkdirAll(dir string) error {
+	current := "/"
+	for _, name := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		current = path.Join(current, name)
+		if err := f.conn.ChangeDir(current); err == nil {
+			continue
+		}
+		if err := f.conn.MakeDir(current); err != nil {
+			return fmt.Errorf("无法创建目录 %s: %w", current, err)
+		}
+	}
+	return nil
+}
+
+// ftpPath 远程路径统一使用以 / 开头的正斜杠路径
+func ftpPath(p string) string {
+	return path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+}
@@ -0,0 +1,79 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// localFS 本地目录，也可以是挂载的网络目录
+type localFS struct{}
+
+func (l *localFS) Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error) {
+	return resumableUpload(ctx, l, localPath, filepath.ToSlash(remotePath), opts)
+}
+
+func (l *localFS) Test(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".upload-test-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (l *localFS) Close() error {
+	return nil
+}
+
+func (l *localFS) size(remote string) (int64, error) {
+	info, err := os.Stat(remote)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotExist
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (l *localFS) write(remote string, r io.Reader, offset int64) error {
+	flag := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(remote, flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (l *localFS) open(remote string) (io.ReadCloser, error) {
+	return os.Open(remote)
+}
+
+func (l *localFS) rename(from, to string) error {
+	return os.Rename(from, to)
+}
+
+func (l *localFS) remove(remote string) error {
+	return os.Remove(remote)
+}
+
+func (l *localFS) mkdirAll(dir string) error {
+	return os.MkdirAll(dir, 0755)
+}
@@ -0,0 +1,112 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// rsyncPartialDir 中断的传输保留在目标目录下的该目录中，下次上传以它为基础增量传输，完成前不会出现在正式路径
+const rsyncPartialDir = ".rsync-partial"
+
+// rsyncTarget 调用 rsync 命令上传，使用 --partial-dir 续传，需要校验时再按内容比较一次
+// ssh 方式需要配置免密登录，rsync:// 守护进程方式使用配置的密码
+type rsyncTarget struct {
+	cfg Config
+}
+
+func newRsync(cfg Config) (*rsyncTarget, error) {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		return nil, fmt.Errorf("upload: rsync not found: %w", err)
+	}
+	return &rsyncTarget{cfg: cfg}, nil
+}
+
+func (r *rsyncTarget) Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error) {
+	checksum, size, err := FileChecksum(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--times", "--partial-dir=" + rsyncPartialDir, "--mkpath", localPath, r.dest(remotePath)}
+	if _, err := r.run(ctx, args...); err != nil {
+		return nil, err
+	}
+	if opts.Progress != nil {
+		opts.Progress(size)
+	}
+
+	result := &Result{RemotePath: remotePath, Size: size, Checksum: checksum}
+	if opts.Verify {
+		if err := r.verify(ctx, localPath, remotePath); err != nil {
+			return nil, err
+		}
+		result.Verified = true
+	}
+	return result, nil
+}
+
+// verify 按内容比较本地和远程文件，--checksum --dry-run 有需要传输的文件时说明内容不一致
+func (r *rsyncTarget) verify(ctx context.Context, localPath, remotePath string) error {
+	out, err := r.run(ctx, "--checksum", "--dry-run", "--itemize-changes", localPath, r.dest(remotePath))
+	if err != nil {
+		return fmt.Errorf("校验远程文件失败: %w", err)
+	}
+	if strings.TrimSpace(out) != "" {
+		return ErrChecksum
+	}
+	return nil
+}
+
+func (r *rsyncTarget) Test(ctx context.Context, dir string) error {
+	_, err := r.run(ctx, "--list-only", r.dest(strings.TrimSuffix(dir, "/")+"/"))
+	return err
+}
+
+func (r *rsyncTarget) Close() error {
+	return nil
+}
+
+// dest 拼接 rsync 的目标地址
+func (r *rsyncTarget) dest(remote string) string {
+	host := r.cfg.Host
+	switch {
+	case host == "":
+		return remote
+	case strings.HasPrefix(host, "rsync://"):
+		return strings.TrimSuffix(host, "/") + path.Clean("/"+remote)
+	case r.cfg.Username != "" && !strings.Contains(host, "@"):
+		return r.cfg.Username + "@" + host + ":" + remote
+	default:
+		return host + ":" + remote
+	}
+}
+
+// run 执行 rsync，返回标准输出
+func (r *rsyncTarget) run(ctx context.Context, args ...string) (string, error) {
+	if r.cfg.Host != "" && !strings.HasPrefix(r.cfg.Host, "rsync://") {
+		ssh := "ssh -o BatchMode=yes"
+		if r.cfg.Port != 0 {
+			ssh += fmt.Sprintf(" -p %d", r.cfg.Port)
+		}
+		args = append([]string{"-e", ssh}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, "rsync", args...)
+	cmd.Env = os.Environ()
+	if strings.HasPrefix(r.cfg.Host, "rsync://") && r.cfg.Password != "" {
+		cmd.Env = append(cmd.Env, "RSYNC_PASSWORD="+r.cfg.Password)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("rsync 执行失败: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
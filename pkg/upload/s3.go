@@ -0,0 +1,82 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/andycai/goapi/pkg/blob"
+)
+
+// s3Target S3 兼容存储，对象写入是原子的，不支持续传，大文件由 blob 分片上传
+type s3Target struct {
+	store *blob.S3
+}
+
+func newS3(cfg Config) (*s3Target, error) {
+	store, err := blob.NewS3(blob.Config{
+		Endpoint:  cfg.Host,
+		Region:    cfg.Region,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.Username,
+		SecretKey: cfg.Password,
+		PathStyle: cfg.PathStyle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Target{store: store}, nil
+}
+
+func (s *s3Target) Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error) {
+	checksum, size, err := FileChecksum(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	key := s3Key(remotePath)
+	reader := &progressReader{ctx: ctx, r: file, fn: opts.Progress}
+	if err := s.store.Put(ctx, key, reader, size, ""); err != nil {
+		return nil, err
+	}
+
+	result := &Result{RemotePath: key, Size: size, Checksum: checksum}
+	if opts.Verify {
+		open := func(key string) (io.ReadCloser, error) {
+			rc, _, err := s.store.Get(ctx, key)
+			return rc, err
+		}
+		if err := verify(open, key, checksum); err != nil {
+			return nil, err
+		}
+		result.Verified = true
+	}
+	return result, nil
+}
+
+// Test 读取一个不存在的对象，返回不存在说明地址和密钥可用
+func (s *s3Target) Test(ctx context.Context, dir string) error {
+	_, err := s.store.Stat(ctx, path.Join(s3Key(dir), ".upload-test"))
+	if err == nil || errors.Is(err, blob.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *s3Target) Close() error {
+	return nil
+}
+
+// s3Key 对象键不以 / 开头
+func s3Key(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+}
@@ -0,0 +1,135 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpFS SFTP 服务器，从临时文件末尾继续写入实现续传
+type sftpFS struct {
+	ssh    *ssh.Client
+	client *sftp.Client
+}
+
+func dialSFTP(ctx context.Context, cfg Config) (*sftpFS, error) {
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	config := &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(cfg.Password)},
+		HostKeyCallback: hostKeyCallback(cfg.HostKey),
+		Timeout:         cfg.Timeout,
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("SFTP连接失败: %w", err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SFTP登录失败: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("SFTP子系统启动失败: %w", err)
+	}
+	return &sftpFS{ssh: sshClient, client: client}, nil
+}
+
+// hostKeyCallback 校验服务器公钥指纹，未配置指纹时接受任何公钥
+func hostKeyCallback(fingerprint string) ssh.HostKeyCallback {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if fingerprint == "" {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if actual := ssh.FingerprintSHA256(key); actual != fingerprint {
+			return fmt.Errorf("服务器公钥指纹不匹配，实际为 %s", actual)
+		}
+		return nil
+	}
+}
+
+func (s *sftpFS) Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error) {
+	return resumableUpload(ctx, s, localPath, path.Clean(remotePath), opts)
+}
+
+func (s *sftpFS) Test(ctx context.Context, dir string) error {
+	return s.client.MkdirAll(dir)
+}
+
+func (s *sftpFS) Close() error {
+	s.client.Close()
+	return s.ssh.Close()
+}
+
+func (s *sftpFS) size(remote string) (int64, error) {
+	info, err := s.client.Stat(remote)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotExist
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *sftpFS) write(remote string, r io.Reader, offset int64) error {
+	flag := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	file, err := s.client.OpenFile(remote, flag)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.ReadFrom(r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (s *sftpFS) open(remote string) (io.ReadCloser, error) {
+	return s.client.Open(remote)
+}
+
+func (s *sftpFS) rename(from, to string) error {
+	// posix-rename 扩展可以覆盖已存在的文件，服务器不支持时先删除再重命名
+	if err := s.client.PosixRename(from, to); err == nil {
+		return nil
+	}
+	s.client.Remove(to)
+	return s.client.Rename(from, to)
+}
+
+func (s *sftpFS) remove(remote string) error {
+	return s.client.Remove(remote)
+}
+
+func (s *sftpFS) mkdirAll(dir string) error {
+	return s.client.MkdirAll(dir)
+}
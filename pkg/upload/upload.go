@@ -0,0 +1,215 @@
+// Package upload 提供统一的文件上传目标，支持 FTP、SFTP、S3 兼容存储、本地目录和 rsync
+// FTP、SFTP 和本地目录先写入 .part 临时文件，中断后可以续传，校验通过后再改为正式文件名
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+)
+
+// 上传目标类型
+const (
+	KindFTP   = "ftp"
+	KindSFTP  = "sftp"
+	KindS3    = "s3"
+	KindLocal = "local"
+	KindRsync = "rsync"
+)
+
+// partSuffix 未完成上传的临时文件后缀
+const partSuffix = ".part"
+
+var (
+	ErrNotExist = errors.New("upload: remote file not found")
+	ErrChecksum = errors.New("upload: checksum mismatch after upload")
+)
+
+// Config 上传目标配置
+type Config struct {
+	Kind      string
+	Host      string // ftp/sftp 主机；s3 服务地址；rsync 为 [user@]host 或 rsync://host/module，为空时同步到本机
+	Port      int
+	Username  string
+	Password  string // 已从凭据库解析的密码，s3 为 SecretKey
+	Bucket    string // s3 存储桶
+	Region    string // s3 区域
+	PathStyle bool   // s3 使用 path-style 地址
+	HostKey   string // sftp 服务器公钥指纹（SHA256:...），为空时不校验
+	Timeout   time.Duration
+}
+
+// Options 上传选项
+type Options struct {
+	Verify   bool              // 上传后读回远程文件校验 sha256，续传时总是校验
+	Progress func(total int64) // 已写入的字节数，包括续传前已存在的部分
+}
+
+// Result 上传结果
+type Result struct {
+	RemotePath string
+	Size       int64
+	Offset     int64  // 续传的起始位置，0 表示从头上传
+	Checksum   string // 本地文件的 sha256
+	Verified   bool   // 是否已校验远程文件
+}
+
+// Target 上传目标
+type Target interface {
+	// Upload 上传本地文件到远程路径，远程目录不存在时自动创建
+	Upload(ctx context.Context, localPath, remotePath string, opts Options) (*Result, error)
+	// Test 检查连接和账号，并确认远程目录可以写入
+	Test(ctx context.Context, dir string) error
+	// Close 断开连接
+	Close() error
+}
+
+// Open 根据配置连接上传目标
+func Open(ctx context.Context, cfg Config) (Target, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	switch cfg.Kind {
+	case KindFTP:
+		return dialFTP(ctx, cfg)
+	case KindSFTP:
+		return dialSFTP(ctx, cfg)
+	case KindS3:
+		return newS3(cfg)
+	case KindLocal:
+		return &localFS{}, nil
+	case KindRsync:
+		return newRsync(cfg)
+	}
+	return nil, fmt.Errorf("upload: unsupported target kind: %s", cfg.Kind)
+}
+
+// Resumable 目标类型是否支持断点续传
+func Resumable(kind string) bool {
+	switch kind {
+	case KindFTP, KindSFTP, KindLocal, KindRsync:
+		return true
+	}
+	return false
+}
+
+// remoteFS 支持续传的远程文件系统，由 FTP、SFTP 和本地目录实现
+type remoteFS interface {
+	size(remote string) (int64, error) // 文件不存在时返回 ErrNotExist
+	write(remote string, r io.Reader, offset int64) error
+	open(remote string) (io.ReadCloser, error)
+	rename(from, to string) error // 目标存在时覆盖
+	remove(remote string) error
+	mkdirAll(dir string) error
+}
+
+// resumableUpload 通过 .part 临时文件续传，校验后改为正式文件名
+func resumableUpload(ctx context.Context, fs remoteFS, localPath, remotePath string, opts Options) (*Result, error) {
+	checksum, size, err := FileChecksum(localPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.mkdirAll(path.Dir(remotePath)); err != nil {
+		return nil, fmt.Errorf("创建远程目录失败: %w", err)
+	}
+
+	// 已有的临时文件比本地文件大，说明本地文件已经变化，重新上传
+	part := remotePath + partSuffix
+	offset, err := fs.size(part)
+	if err != nil || offset > size {
+		offset = 0
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader := &progressReader{ctx: ctx, r: file, n: offset, fn: opts.Progress}
+	if err := fs.write(part, reader, offset); err != nil {
+		return nil, fmt.Errorf("写入远程文件失败: %w", err)
+	}
+
+	// 续传时只按大小判断已有部分，已有部分的内容无法确认，必须校验整个文件
+	result := &Result{RemotePath: remotePath, Size: size, Offset: offset, Checksum: checksum}
+	if opts.Verify || offset > 0 {
+		if err := verify(fs.open, part, checksum); err != nil {
+			// 校验失败的临时文件不能用于续传，续传的结果不一致时删除后从头上传一次
+			if rmErr := fs.remove(part); rmErr == nil && offset > 0 {
+				return resumableUpload(ctx, fs, localPath, remotePath, opts)
+			}
+			return nil, err
+		}
+		result.Verified = true
+	}
+
+	if err := fs.rename(part, remotePath); err != nil {
+		return nil, fmt.Errorf("重命名远程文件失败: %w", err)
+	}
+	return result, nil
+}
+
+// verify 读回远程文件并比较 sha256
+func verify(open func(string) (io.ReadCloser, error), remote, checksum string) error {
+	rc, err := open(remote)
+	if err != nil {
+		return fmt.Errorf("读取远程文件失败: %w", err)
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rc); err != nil {
+		return fmt.Errorf("读取远程文件失败: %w", err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != checksum {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// FileChecksum 计算本地文件的 sha256 和大小
+func FileChecksum(localPath string) (string, int64, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// progressReader 统计已读取的字节数，并在取消时中断上传
+type progressReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+	fn  func(int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if p.fn != nil && n > 0 {
+		p.fn(p.n)
+	}
+	return n, err
+}
@@ -394,3 +394,72 @@ func GzipDecompressFile(sourceFile, targetFile string) error {
 	_, err = io.Copy(target, gzipReader)
 	return err
 }
+
+// GzipFileWriter 流式写入Gzip文件，支持分段刷盘
+type GzipFileWriter struct {
+	file *os.File
+	gz   *gzip.Writer
+}
+
+// CreateGzipFile 创建Gzip文件用于流式写入
+func CreateGzipFile(targetFile string) (*GzipFileWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(targetFile), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(targetFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GzipFileWriter{file: file, gz: gzip.NewWriter(file)}, nil
+}
+
+// Write 写入数据
+func (w *GzipFileWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+// Flush 将已写入的数据刷到磁盘，之后即使进程退出也能读出这部分数据
+func (w *GzipFileWriter) Flush() error {
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close 完成压缩并关闭文件
+func (w *GzipFileWriter) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// gzipFileReader 关闭时同时关闭底层文件
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *gzipFileReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
+
+// OpenGzipFile 打开Gzip文件用于流式读取
+func OpenGzipFile(sourceFile string) (io.ReadCloser, error) {
+	file, err := os.Open(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &gzipFileReader{Reader: gz, file: file}, nil
+}
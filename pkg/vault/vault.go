@@ -0,0 +1,152 @@
+// Package vault 提供凭据加密和凭据引用解析，凭据使用 AES-GCM 加密，主密钥来自环境变量或密钥文件
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Scheme 凭据引用前缀，如 secret://svn-main
+const Scheme = "secret://"
+
+// KeySize 主密钥长度（AES-256）
+const KeySize = 32
+
+var (
+	ErrInvalidKey  = errors.New("vault: master key must be 32 bytes (hex or base64 encoded)")
+	ErrDecrypt     = errors.New("vault: decrypt failed, wrong master key or corrupted data")
+	ErrInvalidName = errors.New("vault: invalid secret name")
+
+	nameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,99}$`)
+)
+
+// Cipher 使用主密钥加解密凭据
+type Cipher struct {
+	aead cipher.AEAD
+	key  []byte
+}
+
+// NewCipher 创建加解密器，key 必须为 32 字节
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead, key: key}, nil
+}
+
+// DeriveKey 从主密钥派生用于其他用途的密钥，purpose 不同派生的密钥不同，不会泄露主密钥
+func (c *Cipher) DeriveKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Encrypt 加密明文，返回 base64 编码的 nonce+密文，name 作为附加数据，密文不能挪用到其他凭据
+func (c *Cipher) Encrypt(name, plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，name 必须与加密时一致
+func (c *Cipher) Decrypt(name, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// LoadKey 加载主密钥，优先读取环境变量 env，其次读取密钥文件 file
+// 两者都没有时生成新的密钥并写入密钥文件，generated 为 true
+func LoadKey(env, file string) (key []byte, generated bool, err error) {
+	if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+		key, err = decodeKey(value)
+		return key, false, err
+	}
+
+	data, err := os.ReadFile(file)
+	if err == nil {
+		key, err = decodeKey(strings.TrimSpace(string(data)))
+		return key, false, err
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+
+	key = make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, false, err
+	}
+	// O_EXCL 避免多个进程同时启动时互相覆盖密钥
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, false, err
+	}
+	return key, true, nil
+}
+
+// decodeKey 解析 hex 或 base64 编码的主密钥
+func decodeKey(value string) ([]byte, error) {
+	if key, err := hex.DecodeString(value); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// ParseRef 解析凭据引用，value 不是 secret:// 引用时 ok 为 false
+func ParseRef(value string) (name string, ok bool) {
+	name, ok = strings.CutPrefix(strings.TrimSpace(value), Scheme)
+	return name, ok
+}
+
+// Ref 返回凭据的引用
+func Ref(name string) string {
+	return Scheme + name
+}
+
+// ValidName 校验凭据名称：字母或数字开头，只能包含字母、数字、点、下划线和短横线
+func ValidName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidName, name)
+	}
+	return nil
+}
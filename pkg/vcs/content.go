@@ -0,0 +1,119 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// FileContent 文件内容，二进制文件不返回内容
+type FileContent struct {
+	Path      string `json:"path"`
+	Revision  string `json:"revision"`
+	Size      int    `json:"size"`
+	Language  string `json:"language"`
+	Binary    bool   `json:"binary"`
+	Truncated bool   `json:"truncated"` // 超过大小限制时只返回开头部分
+	Content   string `json:"content"`
+}
+
+// ReadContent 读取 rev 版本中的文件并识别语法类型，文本内容超过 limit 字节时截断
+func ReadContent(ctx context.Context, v VCS, rev, p string, limit int) (*FileContent, error) {
+	data, err := v.ReadFile(ctx, rev, p)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &FileContent{Path: p, Revision: rev, Size: len(data), Language: DetectLanguage(p)}
+	if IsBinary(data) {
+		file.Binary = true
+		return file, nil
+	}
+	if limit > 0 && len(data) > limit {
+		data = data[:limit]
+		for len(data) > 0 && !utf8.Valid(data) {
+			data = data[:len(data)-1]
+		}
+		file.Truncated = true
+	}
+	file.Content = string(data)
+	return file, nil
+}
+
+// languages 文件扩展名对应的语法类型，用于前端高亮
+var languages = map[string]string{
+	".go":         "go",
+	".cs":         "csharp",
+	".lua":        "lua",
+	".js":         "javascript",
+	".mjs":        "javascript",
+	".ts":         "typescript",
+	".py":         "python",
+	".java":       "java",
+	".kt":         "kotlin",
+	".c":          "c",
+	".h":          "c",
+	".cpp":        "cpp",
+	".cc":         "cpp",
+	".hpp":        "cpp",
+	".m":          "objectivec",
+	".mm":         "objectivec",
+	".swift":      "swift",
+	".rs":         "rust",
+	".php":        "php",
+	".rb":         "ruby",
+	".sh":         "shell",
+	".bat":        "bat",
+	".ps1":        "powershell",
+	".sql":        "sql",
+	".html":       "html",
+	".htm":        "html",
+	".css":        "css",
+	".scss":       "scss",
+	".json":       "json",
+	".xml":        "xml",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".toml":       "toml",
+	".ini":        "ini",
+	".md":         "markdown",
+	".proto":      "protobuf",
+	".shader":     "hlsl",
+	".hlsl":       "hlsl",
+	".cginc":      "hlsl",
+	".glsl":       "glsl",
+	".gradle":     "groovy",
+	".properties": "properties",
+}
+
+// specialFiles 没有扩展名的常见文件
+var specialFiles = map[string]string{
+	"dockerfile": "dockerfile",
+	"makefile":   "makefile",
+}
+
+// DetectLanguage 根据文件名判断语法类型，无法识别时返回 plaintext
+func DetectLanguage(name string) string {
+	base := strings.ToLower(path.Base(name))
+	if lang, ok := specialFiles[base]; ok {
+		return lang
+	}
+	if lang, ok := languages[path.Ext(base)]; ok {
+		return lang
+	}
+	return "plaintext"
+}
+
+// IsBinary 判断内容是否为二进制，检查开头部分是否包含空字节或无效的 UTF-8
+func IsBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+		// 截断可能切开多字节字符
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
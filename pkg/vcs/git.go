@@ -0,0 +1,645 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// Git 基于 go-git 的客户端，不依赖本机安装的 git 命令
+type Git struct {
+	dir  string
+	auth Auth
+}
+
+// FileStatus 工作副本中文件的状态
+type FileStatus struct {
+	Path     string `json:"path"`
+	Staging  string `json:"staging,omitempty"`  // 暂存区状态，仅 git
+	Worktree string `json:"worktree,omitempty"` // 工作区状态
+}
+
+// Branch 分支信息
+type Branch struct {
+	Name    string `json:"name"`
+	Hash    string `json:"hash"`
+	Current bool   `json:"current"`
+}
+
+// NewGit 创建 git 客户端
+func NewGit(dir string, auth Auth) *Git {
+	return &Git{dir: dir, auth: auth}
+}
+
+func (g *Git) Kind() string { return KindGit }
+
+func (g *Git) Dir() string { return g.dir }
+
+// authFor 只有 http(s) 地址使用用户名密码认证，ssh 地址使用本机的密钥
+func (g *Git) authFor(url string) transport.AuthMethod {
+	if g.auth.Username == "" || g.auth.Password == "" {
+		return nil
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: g.auth.Username, Password: g.auth.Password}
+}
+
+// remoteAuth 获取 origin 远程仓库的认证信息
+func (g *Git) remoteAuth(repo *git.Repository) transport.AuthMethod {
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return nil
+	}
+	return g.authFor(remote.Config().URLs[0])
+}
+
+func (g *Git) open() (*git.Repository, error) {
+	return git.PlainOpen(g.dir)
+}
+
+func (g *Git) Checkout(ctx context.Context, url string) error {
+	return g.Clone(ctx, url, "")
+}
+
+// Clone 克隆远程仓库，branch 为空时使用远程的默认分支
+func (g *Git) Clone(ctx context.Context, url, branch string) error {
+	opts := &git.CloneOptions{URL: url, Auth: g.authFor(url)}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		opts.SingleBranch = true
+	}
+	_, err := git.PlainCloneContext(ctx, g.dir, false, opts)
+	if !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return err
+	}
+
+	// 远程仓库为空时与 git clone 一样初始化本地仓库并添加远程地址
+	repo, err := git.PlainInit(g.dir, false)
+	if err != nil {
+		return err
+	}
+	if branch != "" {
+		head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+		if err := repo.Storer.SetReference(head); err != nil {
+			return err
+		}
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}})
+	return err
+}
+
+func (g *Git) Update(ctx context.Context) error {
+	return g.Pull(ctx)
+}
+
+// Pull 拉取当前分支，只支持快进合并
+func (g *Git) Pull(ctx context.Context) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	err = wt.PullContext(ctx, &git.PullOptions{RemoteName: git.DefaultRemoteName, Auth: g.remoteAuth(repo)})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) || errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+	return err
+}
+
+// Push 推送到 origin，branch 为空时推送当前分支
+func (g *Git) Push(ctx context.Context, branch string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+
+	opts := &git.PushOptions{RemoteName: git.DefaultRemoteName, Auth: g.remoteAuth(repo)}
+	if branch == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return err
+		}
+		if !head.Name().IsBranch() {
+			return errors.New("vcs: HEAD is detached")
+		}
+		branch = head.Name().Short()
+	}
+	ref := plumbing.NewBranchReferenceName(branch)
+	opts.RefSpecs = []config.RefSpec{config.RefSpec(ref + ":" + ref)}
+
+	err = repo.PushContext(ctx, opts)
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
+
+func (g *Git) Log(ctx context.Context, limit int) ([]Commit, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// 空仓库没有提交记录
+		return []Commit{}, nil
+	}
+
+	iter, err := repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	return collectCommits(ctx, iter, limit)
+}
+
+// collectCommits 从提交迭代器中读取最多 limit 条记录，limit 不大于 0 时读取全部
+func collectCommits(ctx context.Context, iter object.CommitIter, limit int) ([]Commit, error) {
+	commits := []Commit{}
+	for limit <= 0 || len(commits) < limit {
+		c, err := iter.Next()
+		if err != nil {
+			break
+		}
+		changes, err := commitChanges(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, Commit{
+			Revision: c.Hash.String(),
+			Author:   c.Author.Name,
+			Message:  strings.TrimSpace(c.Message),
+			Time:     c.Author.When,
+			Changes:  changes,
+		})
+	}
+
+	return commits, nil
+}
+
+// resolve 解析版本号，支持完整或缩写的提交哈希、分支和标签
+func (g *Git) resolve(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, rev)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// treeChanges 获取两个提交之间的树差异，from 为空时与 to 的第一个父提交比较
+func (g *Git) treeChanges(ctx context.Context, from, to string) (object.Changes, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := g.resolve(repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromCommit *object.Commit
+	if from != "" {
+		if fromCommit, err = g.resolve(repo, from); err != nil {
+			return nil, err
+		}
+	} else if toCommit.NumParents() > 0 {
+		if fromCommit, err = toCommit.Parent(0); err != nil {
+			return nil, err
+		}
+	}
+
+	return diffCommits(ctx, fromCommit, toCommit)
+}
+
+func (g *Git) ChangedFiles(ctx context.Context, from, to string) ([]Change, error) {
+	changes, err := g.treeChanges(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toChanges(changes)
+}
+
+func (g *Git) Diff(ctx context.Context, from, to string, paths ...string) (string, error) {
+	changes, err := g.treeChanges(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	filtered := changes[:0]
+	for _, change := range changes {
+		if underPaths(changePath(change), paths) {
+			filtered = append(filtered, change)
+		}
+	}
+
+	patch, err := filtered.PatchContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return patch.String(), nil
+}
+
+func (g *Git) DiffFiles(ctx context.Context, from, to string, paths ...string) ([]FileDiff, error) {
+	changes, err := g.treeChanges(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []FileDiff{}
+	for _, change := range changes {
+		p := changePath(change)
+		if !underPaths(p, paths) {
+			continue
+		}
+		action, err := toChanges(object.Changes{change})
+		if err != nil {
+			return nil, err
+		}
+		patch, err := object.Changes{change}.PatchContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, FileDiff{Path: p, Action: action[0].Action, Patch: patch.String()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (g *Git) Commit(ctx context.Context, message string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	// 暂存全部修改，包括新增和删除的文件
+	if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return "", err
+	}
+	revision, err := g.CommitLocal(ctx, message)
+	if err != nil {
+		return "", err
+	}
+
+	// 推送到远程仓库，与 svn 提交后即到达服务器保持一致
+	return revision, g.Push(ctx, "")
+}
+
+// CommitLocal 提交暂存区的修改到本地仓库，不推送
+func (g *Git) CommitLocal(ctx context.Context, message string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	sign := g.signature(repo)
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: sign, Committer: sign})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return "", ErrNoChanges
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// signature 使用 git 配置中的用户信息作为提交人，没有配置时使用仓库用户名
+func (g *Git) signature(repo *git.Repository) *object.Signature {
+	sign := &object.Signature{When: time.Now()}
+	if cfg, err := repo.ConfigScoped(config.GlobalScope); err == nil {
+		sign.Name, sign.Email = cfg.User.Name, cfg.User.Email
+	}
+	if sign.Name == "" {
+		sign.Name = g.auth.Username
+	}
+	if sign.Name == "" {
+		sign.Name = "goapi"
+	}
+	if sign.Email == "" {
+		sign.Email = sign.Name + "@localhost"
+	}
+	return sign
+}
+
+// revision 解析浏览使用的版本，为空时使用 HEAD
+func (g *Git) revision(rev string) (*git.Repository, *object.Commit, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	c, err := g.resolve(repo, rev)
+	return repo, c, err
+}
+
+func (g *Git) Blame(ctx context.Context, rev, p string) ([]BlameLine, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+	_, c, err := g.revision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := git.Blame(c, p)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, p)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]BlameLine, 0, len(result.Lines))
+	for i, line := range result.Lines {
+		author := line.AuthorName
+		if author == "" {
+			author = line.Author
+		}
+		lines = append(lines, BlameLine{
+			Line:     i + 1,
+			Revision: line.Hash.String(),
+			Author:   author,
+			Time:     line.Date,
+			Text:     line.Text,
+		})
+	}
+	return lines, nil
+}
+
+func (g *Git) Tree(ctx context.Context, rev, dir string) ([]Entry, error) {
+	dir, err := CleanPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo, c, err := g.revision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if tree, err = tree.Tree(dir); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, dir)
+		}
+	}
+
+	entries := make([]Entry, 0, len(tree.Entries))
+	for _, e := range tree.Entries {
+		entry := Entry{Name: e.Name, Path: path.Join(dir, e.Name), Dir: e.Mode == filemode.Dir}
+		if e.Mode.IsFile() {
+			if blob, err := repo.BlobObject(e.Hash); err == nil {
+				entry.Size = blob.Size
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (g *Git) ReadFile(ctx context.Context, rev, p string) ([]byte, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+	_, c, err := g.revision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := c.File(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, p)
+	}
+	r, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (g *Git) History(ctx context.Context, rev, p string, limit int) ([]Commit, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+	repo, c, err := g.revision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &git.LogOptions{From: c.Hash, Order: git.LogOrderCommitterTime}
+	if p != "" {
+		opts.PathFilter = func(name string) bool {
+			return name == p || strings.HasPrefix(name, p+"/")
+		}
+	}
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	return collectCommits(ctx, iter, limit)
+}
+
+// Status 获取工作副本中有修改的文件
+func (g *Git) Status(ctx context.Context) ([]FileStatus, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileStatus, 0, len(status))
+	for p, s := range status {
+		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
+			continue
+		}
+		files = append(files, FileStatus{Path: p, Staging: string(s.Staging), Worktree: string(s.Worktree)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Branches 列出本地分支
+func (g *Git) Branches(ctx context.Context) ([]Branch, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	current := ""
+	if head, err := repo.Head(); err == nil {
+		current = head.Name().String()
+	}
+
+	iter, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	branches := []Branch{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, Branch{
+			Name:    ref.Name().Short(),
+			Hash:    ref.Hash().String(),
+			Current: ref.Name().String() == current,
+		})
+		return nil
+	})
+	return branches, err
+}
+
+// CreateBranch 基于当前提交创建分支，不切换
+func (g *Git) CreateBranch(ctx context.Context, name string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewBranchReferenceName(name)
+	if _, err := repo.Reference(ref, false); err == nil {
+		return fmt.Errorf("vcs: branch %s already exists", name)
+	}
+	return repo.Storer.SetReference(plumbing.NewHashReference(ref, head.Hash()))
+}
+
+// Switch 切换分支，create 为 true 时基于当前提交创建新分支
+func (g *Git) Switch(ctx context.Context, name string, create bool) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Create: create})
+}
+
+// Reset 将当前分支重置到 HEAD，hard 为 true 时同时丢弃工作区的修改
+func (g *Git) Reset(ctx context.Context, hard bool) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	mode := git.MixedReset
+	if hard {
+		mode = git.HardReset
+	}
+	return wt.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: mode})
+}
+
+// commitChanges 获取提交相对于第一个父提交的变更，根提交的文件全部视为新增
+func commitChanges(ctx context.Context, c *object.Commit) ([]Change, error) {
+	var parent *object.Commit
+	if c.NumParents() > 0 {
+		var err error
+		if parent, err = c.Parent(0); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := diffCommits(ctx, parent, c)
+	if err != nil {
+		return nil, err
+	}
+	return toChanges(changes)
+}
+
+// diffCommits 比较两个提交的文件树，from 为 nil 时与空树比较
+func diffCommits(ctx context.Context, from, to *object.Commit) (object.Changes, error) {
+	var fromTree *object.Tree
+	if from != nil {
+		var err error
+		if fromTree, err = from.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return object.DiffTreeContext(ctx, fromTree, toTree)
+}
+
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+func toChanges(changes object.Changes) ([]Change, error) {
+	result := make([]Change, 0, len(changes))
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, err
+		}
+
+		var code string
+		switch action {
+		case merkletrie.Insert:
+			code = ActionAdd
+		case merkletrie.Delete:
+			code = ActionDelete
+		default:
+			code = ActionModify
+		}
+		result = append(result, Change{Path: changePath(change), Action: code})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
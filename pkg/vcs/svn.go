@@ -0,0 +1,630 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SVN 基于 svn 命令行的客户端，所有查询都使用 --xml 输出，不受语言环境和版本差异影响
+type SVN struct {
+	dir  string
+	auth Auth
+	cmd  string
+}
+
+// Info 工作副本信息
+type Info struct {
+	URL                 string    `json:"url"`
+	RepositoryRoot      string    `json:"repository_root"`
+	RelativeURL         string    `json:"relative_url"`
+	Revision            string    `json:"revision"`
+	LastChangedRevision string    `json:"last_changed_revision"`
+	LastChangedAuthor   string    `json:"last_changed_author"`
+	LastChangedTime     time.Time `json:"last_changed_time"`
+}
+
+// NewSVN 创建 svn 客户端
+func NewSVN(dir string, auth Auth) *SVN {
+	cmd := "svn"
+	if runtime.GOOS == "windows" {
+		cmd = "svn.exe"
+	}
+	return &SVN{dir: dir, auth: auth, cmd: cmd}
+}
+
+func (s *SVN) Kind() string { return KindSVN }
+
+func (s *SVN) Dir() string { return s.dir }
+
+// run 执行 svn 命令，固定使用非交互模式和英文输出
+func (s *SVN) run(ctx context.Context, args ...string) ([]byte, error) {
+	args = append(args, "--non-interactive")
+	if s.auth.Username != "" {
+		args = append(args, "--username", s.auth.Username)
+	}
+	if s.auth.Password != "" {
+		args = append(args, "--password", s.auth.Password)
+	}
+
+	cmd := exec.CommandContext(ctx, s.cmd, args...)
+	cmd.Env = append(cmd.Environ(), "LC_ALL=C")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("svn %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s *SVN) Checkout(ctx context.Context, url string) error {
+	_, err := s.run(ctx, "checkout", url, s.dir, "--trust-server-cert")
+	return err
+}
+
+func (s *SVN) Update(ctx context.Context) error {
+	_, err := s.run(ctx, "update", s.dir)
+	return err
+}
+
+type svnInfo struct {
+	Entries []struct {
+		Revision    string `xml:"revision,attr"`
+		URL         string `xml:"url"`
+		RelativeURL string `xml:"relative-url"`
+		Root        string `xml:"repository>root"`
+		Commit      struct {
+			Revision string `xml:"revision,attr"`
+			Author   string `xml:"author"`
+			Date     string `xml:"date"`
+		} `xml:"commit"`
+	} `xml:"entry"`
+}
+
+// Info 获取工作副本信息
+func (s *SVN) Info(ctx context.Context) (*Info, error) {
+	out, err := s.run(ctx, "info", "--xml", s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc svnInfo
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn info: %v", err)
+	}
+	if len(doc.Entries) == 0 {
+		return nil, fmt.Errorf("svn info: no entry for %s", s.dir)
+	}
+
+	entry := doc.Entries[0]
+	info := &Info{
+		URL:                 entry.URL,
+		RepositoryRoot:      entry.Root,
+		RelativeURL:         entry.RelativeURL,
+		Revision:            entry.Revision,
+		LastChangedRevision: entry.Commit.Revision,
+		LastChangedAuthor:   entry.Commit.Author,
+		LastChangedTime:     parseSvnTime(entry.Commit.Date),
+	}
+	if info.RelativeURL == "" && strings.HasPrefix(info.URL, info.RepositoryRoot) {
+		// svn 1.8 之前的版本没有 relative-url
+		info.RelativeURL = "^" + strings.TrimPrefix(info.URL, info.RepositoryRoot)
+	}
+	return info, nil
+}
+
+// repoPrefix 工作副本在版本库中的路径，日志中的路径以它为前缀
+func (i *Info) repoPrefix() string {
+	rel := strings.TrimPrefix(i.RelativeURL, "^")
+	if unescaped, err := url.PathUnescape(rel); err == nil {
+		rel = unescaped
+	}
+	return "/" + strings.Trim(rel, "/")
+}
+
+type svnLog struct {
+	Entries []struct {
+		Revision string `xml:"revision,attr"`
+		Author   string `xml:"author"`
+		Date     string `xml:"date"`
+		Msg      string `xml:"msg"`
+		Paths    []struct {
+			Action string `xml:"action,attr"`
+			Kind   string `xml:"kind,attr"`
+			Path   string `xml:",chardata"`
+		} `xml:"paths>path"`
+	} `xml:"logentry"`
+}
+
+// log 执行 svn log --xml -v 并转换为提交记录，target 为空时使用工作副本目录
+func (s *SVN) log(ctx context.Context, target string, args ...string) ([]Commit, error) {
+	info, err := s.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target == "" {
+		target = s.dir
+	}
+
+	out, err := s.run(ctx, append([]string{"log", "--xml", "-v"}, append(args, target)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc svnLog
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn log: %v", err)
+	}
+
+	prefix := info.repoPrefix()
+	commits := make([]Commit, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		changes := []Change{}
+		for _, p := range entry.Paths {
+			// 日志中的路径相对于版本库根目录，只保留工作副本内的文件
+			rel, ok := relativeTo(prefix, p.Path)
+			if !ok || rel == "" {
+				continue
+			}
+			action := p.Action
+			if action == "R" {
+				// 替换视为修改
+				action = ActionModify
+			}
+			changes = append(changes, Change{Path: rel, Action: action})
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+		commits = append(commits, Commit{
+			Revision: entry.Revision,
+			Author:   entry.Author,
+			Message:  strings.TrimSpace(entry.Msg),
+			Time:     parseSvnTime(entry.Date),
+			Changes:  changes,
+		})
+	}
+	return commits, nil
+}
+
+func (s *SVN) Log(ctx context.Context, limit int) ([]Commit, error) {
+	// 日志只到工作副本的版本，需要最新记录时先调用 Update
+	var args []string
+	if limit > 0 {
+		args = append(args, "-l", fmt.Sprint(limit))
+	}
+	return s.log(ctx, "", args...)
+}
+
+type svnSummary struct {
+	Paths []struct {
+		Item string `xml:"item,attr"`
+		Kind string `xml:"kind,attr"`
+		Path string `xml:",chardata"`
+	} `xml:"paths>path"`
+}
+
+func (s *SVN) ChangedFiles(ctx context.Context, from, to string) ([]Change, error) {
+	if from == "" {
+		commits, err := s.log(ctx, "", "-r", to)
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) == 0 {
+			return []Change{}, nil
+		}
+		return commits[0].Changes, nil
+	}
+
+	info, err := s.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.run(ctx, "diff", "--summarize", "--xml", "-r", from+":"+to, info.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc svnSummary
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn diff: %v", err)
+	}
+
+	changes := []Change{}
+	for _, p := range doc.Paths {
+		var action string
+		switch p.Item {
+		case "added":
+			action = ActionAdd
+		case "deleted":
+			action = ActionDelete
+		case "modified", "replaced":
+			action = ActionModify
+		default:
+			// 只有属性修改
+			continue
+		}
+
+		// 比较远程地址时输出的是完整 URL
+		rel := strings.TrimPrefix(strings.TrimPrefix(p.Path, info.URL), "/")
+		if unescaped, err := url.PathUnescape(rel); err == nil {
+			rel = unescaped
+		}
+		if rel == "" {
+			continue
+		}
+		changes = append(changes, Change{Path: rel, Action: action})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func (s *SVN) Diff(ctx context.Context, from, to string, paths ...string) (string, error) {
+	info, err := s.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// 比较远程地址，不要求版本存在于工作副本中
+	args := []string{"diff"}
+	if from == "" {
+		args = append(args, "-c", to)
+	} else {
+		args = append(args, "-r", from+":"+to)
+	}
+	args = append(args, info.URL+"@"+to)
+
+	out, err := s.run(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return string(out), nil
+	}
+
+	var b strings.Builder
+	for _, file := range splitSvnDiff(string(out)) {
+		if underPaths(file.Path, paths) {
+			b.WriteString(file.Patch)
+		}
+	}
+	return b.String(), nil
+}
+
+func (s *SVN) DiffFiles(ctx context.Context, from, to string, paths ...string) ([]FileDiff, error) {
+	changes, err := s.ChangedFiles(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.Diff(ctx, from, to, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	patches := make(map[string]string)
+	for _, file := range splitSvnDiff(text) {
+		patches[file.Path] = file.Patch
+	}
+
+	files := []FileDiff{}
+	for _, change := range changes {
+		if underPaths(change.Path, paths) {
+			files = append(files, FileDiff{Path: change.Path, Action: change.Action, Patch: patches[change.Path]})
+		}
+	}
+	return files, nil
+}
+
+// splitSvnDiff 按 Index: 行将 svn diff 的输出拆分为单个文件的差异
+func splitSvnDiff(text string) []FileDiff {
+	var files []FileDiff
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if name, ok := strings.CutPrefix(line, "Index: "); ok {
+			files = append(files, FileDiff{Path: strings.TrimSpace(name)})
+		}
+		if len(files) > 0 {
+			files[len(files)-1].Patch += line
+		}
+	}
+	return files
+}
+
+type svnStatus struct {
+	Entries []struct {
+		Path   string `xml:"path,attr"`
+		Status struct {
+			Item  string `xml:"item,attr"`
+			Props string `xml:"props,attr"`
+		} `xml:"wc-status"`
+	} `xml:"target>entry"`
+}
+
+// Status 获取工作副本中有修改的文件，状态为 svn 的 wc-status（unversioned/missing/modified 等）
+func (s *SVN) Status(ctx context.Context) ([]FileStatus, error) {
+	out, err := s.run(ctx, "status", "--xml", s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc svnStatus
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn status: %v", err)
+	}
+
+	files := make([]FileStatus, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		rel, err := filepath.Rel(s.dir, entry.Path)
+		if err != nil {
+			rel = entry.Path
+		}
+		item := entry.Status.Item
+		if item == "normal" && entry.Status.Props != "none" && entry.Status.Props != "normal" {
+			item = "props-" + entry.Status.Props
+		}
+		files = append(files, FileStatus{Path: filepath.ToSlash(rel), Worktree: item})
+	}
+	return files, nil
+}
+
+// Add 将文件加入版本控制，路径相对于工作副本根目录
+func (s *SVN) Add(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := s.run(ctx, append([]string{"add", "--parents", "--force"}, s.abs(paths)...)...)
+	return err
+}
+
+// Delete 从版本控制中删除文件，路径相对于工作副本根目录
+func (s *SVN) Delete(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := s.run(ctx, append([]string{"delete", "--force"}, s.abs(paths)...)...)
+	return err
+}
+
+// Revert 撤销本地修改，不指定路径时撤销整个工作副本
+func (s *SVN) Revert(ctx context.Context, paths ...string) error {
+	targets := []string{s.dir}
+	if len(paths) > 0 {
+		targets = s.abs(paths)
+	}
+	_, err := s.run(ctx, append([]string{"revert", "-R"}, targets...)...)
+	return err
+}
+
+func (s *SVN) abs(paths []string) []string {
+	result := make([]string, len(paths))
+	for i, p := range paths {
+		result[i] = filepath.Join(s.dir, filepath.FromSlash(p))
+	}
+	return result
+}
+
+func (s *SVN) Commit(ctx context.Context, message string) (string, error) {
+	files, err := s.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// 新增未纳入版本控制的文件，删除已丢失的文件
+	var adds, deletes []string
+	changed := false
+	for _, file := range files {
+		switch file.Worktree {
+		case "unversioned":
+			adds = append(adds, file.Path)
+		case "missing":
+			deletes = append(deletes, file.Path)
+		case "ignored", "external", "normal":
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return "", ErrNoChanges
+	}
+	if err := s.Add(ctx, adds...); err != nil {
+		return "", err
+	}
+	if err := s.Delete(ctx, deletes...); err != nil {
+		return "", err
+	}
+
+	return s.CommitTracked(ctx, message)
+}
+
+// CommitTracked 只提交已纳入版本控制的修改，返回新版本号
+func (s *SVN) CommitTracked(ctx context.Context, message string) (string, error) {
+	if _, err := s.run(ctx, "commit", "-m", message, s.dir); err != nil {
+		return "", err
+	}
+
+	// 提交后更新工作副本，最后修改版本即为新提交的版本
+	if err := s.Update(ctx); err != nil {
+		return "", err
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.LastChangedRevision, nil
+}
+
+// target 返回仓库内路径在 rev 版本的远程地址，rev 为空时使用 HEAD
+func (s *SVN) target(ctx context.Context, rev, p string) (string, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	target := info.URL
+	if p != "" {
+		parts := strings.Split(p, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		target += "/" + strings.Join(parts, "/")
+	}
+	return target + "@" + rev, nil
+}
+
+type svnBlame struct {
+	Entries []struct {
+		Line   int `xml:"line-number,attr"`
+		Commit struct {
+			Revision string `xml:"revision,attr"`
+			Author   string `xml:"author"`
+			Date     string `xml:"date"`
+		} `xml:"commit"`
+	} `xml:"target>entry"`
+}
+
+func (s *SVN) Blame(ctx context.Context, rev, p string) ([]BlameLine, error) {
+	target, err := s.target(ctx, rev, p)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.run(ctx, "blame", "--xml", target)
+	if err != nil {
+		return nil, err
+	}
+	var doc svnBlame
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn blame: %v", err)
+	}
+
+	// xml 输出不包含文件内容，按行号与 svn cat 的结果对应
+	content, err := s.run(ctx, "cat", target)
+	if err != nil {
+		return nil, err
+	}
+	texts := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+
+	lines := make([]BlameLine, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		line := BlameLine{
+			Line:     entry.Line,
+			Revision: entry.Commit.Revision,
+			Author:   entry.Commit.Author,
+			Time:     parseSvnTime(entry.Commit.Date),
+		}
+		if entry.Line > 0 && entry.Line <= len(texts) {
+			line.Text = strings.TrimSuffix(texts[entry.Line-1], "\r")
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+type svnList struct {
+	Entries []struct {
+		Kind   string `xml:"kind,attr"`
+		Name   string `xml:"name"`
+		Size   int64  `xml:"size"`
+		Commit struct {
+			Revision string `xml:"revision,attr"`
+			Author   string `xml:"author"`
+			Date     string `xml:"date"`
+		} `xml:"commit"`
+	} `xml:"list>entry"`
+}
+
+func (s *SVN) Tree(ctx context.Context, rev, dir string) ([]Entry, error) {
+	dir, err := CleanPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.target(ctx, rev, dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.run(ctx, "list", "--xml", target)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc svnList
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("svn list: %v", err)
+	}
+
+	entries := make([]Entry, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		entry := Entry{
+			Name:     e.Name,
+			Path:     path.Join(dir, e.Name),
+			Dir:      e.Kind == "dir",
+			Size:     e.Size,
+			Revision: e.Commit.Revision,
+			Author:   e.Commit.Author,
+		}
+		if t := parseSvnTime(e.Commit.Date); !t.IsZero() {
+			entry.Time = &t
+		}
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (s *SVN) ReadFile(ctx context.Context, rev, p string) ([]byte, error) {
+	target, err := s.target(ctx, rev, p)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, "cat", target)
+}
+
+func (s *SVN) History(ctx context.Context, rev, p string, limit int) ([]Commit, error) {
+	target, err := s.target(ctx, rev, p)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if limit > 0 {
+		args = append(args, "-l", fmt.Sprint(limit))
+	}
+	return s.log(ctx, target, args...)
+}
+
+// relativeTo 计算版本库路径相对于工作副本路径的相对路径
+func relativeTo(prefix, p string) (string, bool) {
+	p = path.Clean("/" + p)
+	if prefix == "/" {
+		return strings.TrimPrefix(p, "/"), true
+	}
+	if p == prefix {
+		return "", true
+	}
+	if strings.HasPrefix(p, prefix+"/") {
+		return strings.TrimPrefix(p, prefix+"/"), true
+	}
+	return "", false
+}
+
+func parseSvnTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,179 @@
+// Package vcs 提供统一的版本控制接口，git 基于 go-git 实现，svn 解析命令行的 --xml 输出
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 仓库类型
+const (
+	KindGit = "git"
+	KindSVN = "svn"
+)
+
+// 变更类型
+const (
+	ActionAdd    = "A"
+	ActionModify = "M"
+	ActionDelete = "D"
+)
+
+var (
+	ErrUnsupported = errors.New("vcs: unsupported repository type")
+	ErrNoChanges   = errors.New("vcs: nothing to commit")
+	ErrNotFound    = errors.New("vcs: revision or path not found")
+)
+
+// Auth 仓库认证信息，git 仅对 http(s) 地址生效
+type Auth struct {
+	Username string
+	Password string
+}
+
+// Change 文件变更，路径相对于工作副本根目录并使用 / 分隔
+type Change struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // A/M/D
+}
+
+// Commit 提交记录
+type Commit struct {
+	Revision string    `json:"revision"`
+	Author   string    `json:"author"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+	Changes  []Change  `json:"changes"`
+}
+
+// BlameLine 逐行追溯结果
+type BlameLine struct {
+	Line     int       `json:"line"`
+	Revision string    `json:"revision"`
+	Author   string    `json:"author"`
+	Time     time.Time `json:"time"`
+	Text     string    `json:"text"`
+}
+
+// FileDiff 单个文件的差异
+type FileDiff struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // A/M/D
+	Patch  string `json:"patch"`  // 统一格式差异
+}
+
+// Entry 目录树中的条目，路径相对于仓库根目录
+type Entry struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	Dir      bool       `json:"dir"`
+	Size     int64      `json:"size"`
+	Revision string     `json:"revision,omitempty"` // 最后修改的版本，仅 svn
+	Author   string     `json:"author,omitempty"`
+	Time     *time.Time `json:"time,omitempty"`
+}
+
+// VCS 版本控制接口，每个实例对应一个本地工作副本
+type VCS interface {
+	// Kind 返回仓库类型
+	Kind() string
+	// Dir 返回工作副本目录
+	Dir() string
+	// Checkout 从远程地址检出到工作副本目录
+	Checkout(ctx context.Context, url string) error
+	// Update 将工作副本更新到远程最新版本
+	Update(ctx context.Context) error
+	// Log 获取最近的提交记录（从新到旧），包含变更文件
+	Log(ctx context.Context, limit int) ([]Commit, error)
+	// ChangedFiles 获取 from（不含）到 to 之间的变更，from 为空时返回 to 本身的变更
+	ChangedFiles(ctx context.Context, from, to string) ([]Change, error)
+	// Diff 获取 from 到 to 之间的统一格式差异，from 为空时返回 to 本身的差异，可按路径过滤
+	Diff(ctx context.Context, from, to string, paths ...string) (string, error)
+	// DiffFiles 与 Diff 相同，但按文件拆分差异
+	DiffFiles(ctx context.Context, from, to string, paths ...string) ([]FileDiff, error)
+	// Commit 将工作副本的全部修改（包括新增和删除）提交到远程，返回新版本号
+	Commit(ctx context.Context, message string) (string, error)
+	// Blame 逐行追溯文件在 rev 版本的最后修改记录，rev 为空时使用最新版本
+	Blame(ctx context.Context, rev, path string) ([]BlameLine, error)
+	// Tree 列出 rev 版本中目录的直接子条目，目录在前，rev 为空时使用最新版本
+	Tree(ctx context.Context, rev, dir string) ([]Entry, error)
+	// ReadFile 读取 rev 版本中文件的内容，rev 为空时使用最新版本
+	ReadFile(ctx context.Context, rev, path string) ([]byte, error)
+	// History 获取 rev 版本及之前修改过文件或目录的提交记录（从新到旧）
+	History(ctx context.Context, rev, path string, limit int) ([]Commit, error)
+}
+
+// New 根据仓库类型创建客户端
+func New(kind, dir string, auth Auth) (VCS, error) {
+	switch strings.ToLower(kind) {
+	case KindGit:
+		return NewGit(dir, auth), nil
+	case KindSVN:
+		return NewSVN(dir, auth), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, kind)
+}
+
+// Detect 根据工作副本中的元数据目录判断仓库类型，不是工作副本时返回空字符串
+func Detect(dir string) string {
+	for _, kind := range []string{KindGit, KindSVN} {
+		if info, err := os.Stat(filepath.Join(dir, "."+kind)); err == nil && info.IsDir() {
+			return kind
+		}
+	}
+	return ""
+}
+
+// Open 打开已检出的工作副本，自动识别仓库类型
+func Open(dir string, auth Auth) (VCS, error) {
+	kind := Detect(dir)
+	if kind == "" {
+		return nil, fmt.Errorf("vcs: %s is not a working copy", dir)
+	}
+	return New(kind, dir, auth)
+}
+
+// CleanPath 规范化仓库内的相对路径，拒绝越界路径，根目录返回空字符串
+func CleanPath(p string) (string, error) {
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if p == "" || p == "." {
+		return "", nil
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("vcs: invalid path %s", p)
+		}
+	}
+	return path.Clean(p), nil
+}
+
+// sortEntries 目录在前，同类按名称排序
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Dir != entries[j].Dir {
+			return entries[i].Dir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// underPaths 判断路径是否位于任一过滤路径之下，没有过滤路径时始终返回 true
+func underPaths(p string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, prefix := range paths {
+		prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+		if prefix == "" || prefix == "." || p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -657,8 +657,8 @@ function adminLayoutManagement() {
             // 检查用户是否存在且有角色
             if (!this.user || !this.user.role) return false;
 
-            // 获取用户权限列表
-            const userPermissions = this.user.role.permissions?.map(p => p.code) || [];
+            // 获取用户所有角色的权限列表
+            const userPermissions = this.user.permissions || this.user.role.permissions?.map(p => p.code) || [];
 
             // 如果用户有 admin 权限，允许访问所有内容
             if (userPermissions.includes('admin')) return true;
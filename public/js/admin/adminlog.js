@@ -9,10 +9,14 @@ function adminLogManagement() {
             username: '',
             action: '',
             resource: '',
+            ip: '',
+            keyword: '',
             startDate: '',
             endDate: ''
         },
         loading: false,
+        verifyResult: null,
+        expanded: null,
         init() {
             this.fetchLogs();
         },
@@ -33,29 +37,62 @@ function adminLogManagement() {
                 ShowError(error.message);
             }
         },
+        // exportLogs 按当前搜索条件导出日志
+        exportLogs(format) {
+            const params = new URLSearchParams({ format, ...this.searchForm });
+            window.location.href = `/api/admin/adminlog/export?${params}`;
+        },
         search() {
             this.currentPage = 1;
             this.fetchLogs();
         },
         async clearLogs() {
-            if (!confirm('确定要清理日志吗？此操作将删除所选日期之前的所有日志。')) return;
+            if (!confirm('确定要清理日志吗？所选结束日期（未选择时为当前时间）之前的日志将先归档到数据保留的归档文件，然后删除。')) return;
 
             try {
                 const response = await fetch(`/api/admin/adminlog?beforeDate=${this.searchForm.endDate || new Date().toISOString()}`, {
                     method: 'DELETE'
                 });
 
+                const data = await response.json();
                 if (!response.ok) {
-                    const error = await response.json();
-                    throw new Error(error.error || '清理日志失败');
+                    throw new Error(data.error || '清理日志失败');
                 }
 
-                ShowMessage('日志清理成功');
+                ShowMessage(data.message);
                 this.fetchLogs();
             } catch (error) {
                 ShowError(error.message);
             }
         },
+        // verifyLogs 校验日志的哈希链，检查日志是否被修改或删除
+        async verifyLogs() {
+            try {
+                const response = await fetch('/api/admin/adminlog/verify');
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '校验日志失败');
+                this.verifyResult = data.data;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+        // parseChanges 修改前后的字段，没有记录时返回空数组
+        parseChanges(log) {
+            if (!log.changes) return [];
+            try {
+                const changes = JSON.parse(log.changes);
+                return Object.keys(changes).sort().map(field => ({
+                    field,
+                    before: changes[field].before === undefined ? '' : JSON.stringify(changes[field].before),
+                    after: changes[field].after === undefined ? '' : JSON.stringify(changes[field].after)
+                }));
+            } catch (error) {
+                return [];
+            }
+        },
+        toggleChanges(log) {
+            this.expanded = this.expanded === log.id ? null : log.id;
+        },
         previousPage() {
             if (this.currentPage > 1) {
                 this.currentPage--;
@@ -77,7 +114,20 @@ function adminLogManagement() {
                 'ftp': 'FTP上传',
                 'upload': '上传',
                 'download': '下载',
-                'view': '查看'
+                'view': '查看',
+                'revoke': '吊销',
+                'enable_2fa': '启用两步验证',
+                'disable_2fa': '关闭两步验证',
+                'reset_2fa': '重置两步验证',
+                'unlock': '解锁',
+                'export': '导出',
+                'apply': '应用',
+                'submit': '提交审批',
+                'approve': '批准',
+                'reject': '驳回',
+                'cancel': '撤回',
+                'execute': '执行',
+                'test': '测试'
             };
             return actionMap[action] || action;
         },
@@ -96,7 +146,17 @@ function adminLogManagement() {
                 'stats': '统计数据',
                 'browse': '目录浏览',
                 'menu': '菜单管理',
-                'adminlog': '操作日志'
+                'adminlog': '操作日志',
+                'secret': '凭据库',
+                'upload_target': '上传目标',
+                'token': 'API 令牌',
+                'login': '登录安全',
+                'session': '登录会话',
+                'shell': 'Shell 脚本',
+                'adminlog_alert': '日志告警',
+                'patch': '补丁管理',
+                'approval': '操作审批',
+                'approval_policy': '审批配置'
             };
             return resourceMap[resource] || resource;
         },
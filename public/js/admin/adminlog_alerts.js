@@ -0,0 +1,153 @@
+// 日志告警
+function alertRuleManagement() {
+    const emptyForm = () => ({
+        name: '',
+        enabled: true,
+        actions: '',
+        resources: '',
+        usernames: '',
+        keyword: '',
+        off_hours: false,
+        work_start: '09:00',
+        work_end: '18:00',
+        threshold: 0,
+        window: 0,
+        cooldown: 0,
+        webhook_url: ''
+    });
+
+    return {
+        rules: [],
+        defaultWebhook: false,
+        showForm: false,
+        editing: null,
+        form: emptyForm(),
+        history: {
+            rule: null,
+            list: [],
+            total: 0,
+            page: 1,
+            pageSize: 20
+        },
+
+        init() {
+            this.fetchRules();
+            this.fetchHistory();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async fetchRules() {
+            try {
+                const data = await this.request('/api/admin/adminlog/alerts');
+                this.rules = data.data || [];
+                this.defaultWebhook = data.default_webhook;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchHistory() {
+            const query = new URLSearchParams({ page: this.history.page, pageSize: this.history.pageSize });
+            if (this.history.rule) query.set('ruleId', this.history.rule.id);
+            try {
+                const data = await this.request(`/api/admin/adminlog/alerts/history?${query}`);
+                this.history.list = data.data || [];
+                this.history.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        showHistory(rule) {
+            this.history.rule = rule;
+            this.history.page = 1;
+            this.fetchHistory();
+        },
+
+        changeHistoryPage(page) {
+            if (page < 1 || (page - 1) * this.history.pageSize >= this.history.total) return;
+            this.history.page = page;
+            this.fetchHistory();
+        },
+
+        openCreate() {
+            this.editing = null;
+            this.form = emptyForm();
+            this.showForm = true;
+        },
+
+        openEdit(rule) {
+            this.editing = rule;
+            this.form = { ...emptyForm(), ...rule };
+            this.form.work_start = rule.work_start || '09:00';
+            this.form.work_end = rule.work_end || '18:00';
+            this.showForm = true;
+        },
+
+        async submitForm() {
+            const url = this.editing ? `/api/admin/adminlog/alerts/${this.editing.id}` : '/api/admin/adminlog/alerts';
+            try {
+                const data = await this.request(url, {
+                    method: this.editing ? 'PUT' : 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(this.form)
+                });
+                ShowMessage(data.message);
+                this.showForm = false;
+                this.fetchRules();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async deleteRule(rule) {
+            if (!confirm(`确定删除规则 ${rule.name} 吗？告警记录会保留。`)) return;
+            try {
+                const data = await this.request(`/api/admin/adminlog/alerts/${rule.id}`, { method: 'DELETE' });
+                ShowMessage(data.message);
+                this.fetchRules();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        // testRule 发送一条测试告警，检查 Webhook 是否可用
+        async testRule(rule) {
+            try {
+                const data = await this.request(`/api/admin/adminlog/alerts/${rule.id}/test`, { method: 'POST' });
+                ShowMessage(data.message);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        describeMatch(rule) {
+            const parts = [];
+            if (rule.actions) parts.push(`操作 ${rule.actions}`);
+            if (rule.resources) parts.push(`资源 ${rule.resources}`);
+            if (rule.usernames) parts.push(`用户 ${rule.usernames}`);
+            if (rule.keyword) parts.push(`详情包含“${rule.keyword}”`);
+            if (rule.off_hours) parts.push(`${rule.work_start || '09:00'}-${rule.work_end || '18:00'} 之外`);
+            return parts.length ? parts.join('，') : '所有日志';
+        },
+
+        describeTrigger(rule) {
+            let text = rule.threshold > 1 ? `${rule.window || 300} 秒内 ${rule.threshold} 次` : '每次匹配';
+            if (rule.cooldown > 0) text += `，冷却 ${rule.cooldown} 秒`;
+            return text;
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
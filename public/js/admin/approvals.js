@@ -0,0 +1,156 @@
+// 操作审批
+function approvalManagement() {
+    return {
+        tab: 'all',
+        actions: [],
+        list: [],
+        total: 0,
+        page: 1,
+        pageSize: 20,
+        filter: {
+            status: 'pending',
+            action: ''
+        },
+        detail: null,
+        comment: '',
+        statusText: {
+            'pending': '待审批',
+            'approved': '执行中',
+            'executed': '已执行',
+            'failed': '执行失败',
+            'rejected': '已驳回',
+            'cancelled': '已撤回',
+            'expired': '已过期'
+        },
+
+        init() {
+            this.fetchActions();
+            this.fetchList();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async fetchActions() {
+            try {
+                const data = await this.request('/api/admin/approvals/actions');
+                this.actions = data.data || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchList() {
+            const query = new URLSearchParams({ page: this.page, pageSize: this.pageSize, ...this.filter });
+            const url = this.tab === 'mine' ? '/api/admin/approvals/mine' : '/api/admin/approvals';
+            try {
+                const data = await this.request(`${url}?${query}`);
+                this.list = data.data || [];
+                this.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        switchTab(tab) {
+            this.tab = tab;
+            this.filter.status = tab === 'mine' ? '' : 'pending';
+            this.search();
+        },
+
+        search() {
+            this.page = 1;
+            this.fetchList();
+        },
+
+        changePage(page) {
+            if (page < 1 || (page - 1) * this.pageSize >= this.total) return;
+            this.page = page;
+            this.fetchList();
+        },
+
+        async togglePolicy(action, enabled) {
+            try {
+                const data = await this.request('/api/admin/approvals/actions', {
+                    method: 'PUT',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ action: action.code, enabled })
+                });
+                ShowMessage(data.message);
+            } catch (error) {
+                ShowError(error.message);
+            }
+            this.fetchActions();
+        },
+
+        openDetail(item) {
+            this.detail = item;
+            this.comment = '';
+        },
+
+        // review 批准或驳回，批准后立即以申请人的身份执行
+        async review(decision) {
+            const id = this.detail.id;
+            if (decision === 'approve' && !confirm(`确定批准并执行审批单 #${id} 吗？`)) return;
+            try {
+                const data = await this.request(`/api/admin/approvals/${id}/${decision}`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ comment: this.comment })
+                });
+                if (data.data && data.data.status === 'failed') {
+                    ShowError(data.message);
+                } else {
+                    ShowMessage(data.message);
+                }
+                this.detail = data.data || null;
+                this.fetchList();
+                this.fetchActions();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async cancelRequest(item) {
+            if (!confirm(`确定撤回审批单 #${item.id} 吗？`)) return;
+            try {
+                const data = await this.request(`/api/admin/approvals/${item.id}/cancel`, { method: 'POST' });
+                ShowMessage(data.message);
+                this.fetchList();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        statusClass(status) {
+            return {
+                'pending': 'bg-yellow-100 text-yellow-800 dark:bg-yellow-900 dark:text-yellow-200',
+                'approved': 'bg-blue-100 text-blue-800 dark:bg-blue-900 dark:text-blue-200',
+                'executed': 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200',
+                'failed': 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200',
+                'rejected': 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200'
+            }[status] || 'bg-gray-100 text-gray-600 dark:bg-gray-700 dark:text-gray-300';
+        },
+
+        // formatPayload JSON 内容格式化显示
+        formatPayload(payload) {
+            if (!payload) return '';
+            try {
+                return JSON.stringify(JSON.parse(payload), null, 2);
+            } catch (error) {
+                return payload;
+            }
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
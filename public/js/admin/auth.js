@@ -7,6 +7,9 @@ function loginManagement() {
         },
         loading: false,
         error: '',
+        // 两步验证
+        mfaStep: false,
+        mfaCode: '',
         showChangePasswordModal: false,
         passwordForm: {
             oldPassword: '',
@@ -17,6 +20,18 @@ function loginManagement() {
         passwordError: '',
 
         init() {
+            // 单点登录回调后回到登录页面
+            const params = new URLSearchParams(window.location.search);
+            if (params.get('sso_error')) {
+                this.error = params.get('sso_error');
+            } else if (params.get('mfa') === '1') {
+                this.mfaStep = true;
+                return;
+            } else if (params.get('sso') === '1') {
+                this.finishSSO();
+                return;
+            }
+
             // 从localStorage读取上次保存的用户名
             const savedUsername = localStorage.getItem('saved_username');
             if (savedUsername) {
@@ -49,26 +64,45 @@ function loginManagement() {
                     throw new Error(data.message || '登录失败');
                 }
 
-                const { token, user } = data.data;
-
-                // 根据记住我选项保存或清除用户名
-                if (this.form.remember) {
-                    localStorage.setItem('saved_username', this.form.username);
-                } else {
-                    localStorage.removeItem('saved_username');
+                // 启用了两步验证，继续输入验证码
+                if (data.data.mfa_required) {
+                    this.mfaStep = true;
+                    this.mfaCode = '';
+                    return;
                 }
 
-                // 保存 token 和用户信息到 localStorage
-                localStorage.setItem('token', token);
-                localStorage.setItem('user', JSON.stringify(user));
+                this.finishLogin(data.data);
+            } catch (error) {
+                this.error = error.message;
+            } finally {
+                this.loading = false;
+            }
+        },
+
+        // submitMfa 提交两步验证码或恢复码
+        async submitMfa() {
+            this.loading = true;
+            this.error = '';
+
+            try {
+                const response = await fetch('/login/2fa', {
+                    method: 'POST',
+                    headers: {
+                        'Content-Type': 'application/json',
+                    },
+                    body: JSON.stringify({ code: this.mfaCode })
+                });
 
-                // 检查是否需要修改密码
-                if (user.has_changed_pwd === false) {
-                    this.showChangePasswordModal = true;
-                    this.passwordForm.oldPassword = this.form.password;
-                } else {
-                    window.location.href = '/admin';
+                const data = await response.json();
+                if (!response.ok) {
+                    // 验证超时或错误次数过多，需要重新输入密码
+                    if (data.expired) {
+                        this.backToPassword();
+                    }
+                    throw new Error(data.error || '验证失败');
                 }
+
+                this.finishLogin(data.data);
             } catch (error) {
                 this.error = error.message;
             } finally {
@@ -76,14 +110,58 @@ function loginManagement() {
             }
         },
 
-        async changePassword() {
-            if (this.passwordForm.newPassword !== this.passwordForm.confirmPassword) {
-                this.passwordError = '两次输入的密码不一致';
-                return;
+        backToPassword() {
+            this.mfaStep = false;
+            this.mfaCode = '';
+            this.form.password = '';
+            history.replaceState(null, '', '/login');
+        },
+
+        // finishLogin 保存登录信息并进入后台
+        finishLogin({ token, user, mfa_setup_required }) {
+            // 根据记住我选项保存或清除用户名，单点登录时没有填写用户名
+            if (this.form.remember) {
+                localStorage.setItem('saved_username', this.form.username);
+            } else if (this.form.username) {
+                localStorage.removeItem('saved_username');
+            }
+
+            // 保存 token 和用户信息到 localStorage
+            localStorage.setItem('token', token);
+            localStorage.setItem('user', JSON.stringify(user));
+
+            // 检查是否需要修改密码
+            if (user.has_changed_pwd === false) {
+                this.showChangePasswordModal = true;
+                this.passwordForm.oldPassword = this.form.password;
+            } else if (mfa_setup_required) {
+                // 角色要求两步验证，先去个人信息页面启用
+                window.location.href = '/admin/profile?setup2fa=1';
+            } else {
+                window.location.href = '/admin';
+            }
+        },
+
+        // finishSSO 读取单点登录的用户信息并进入后台
+        async finishSSO() {
+            this.loading = true;
+            try {
+                const response = await fetch('/api/admin/me');
+                const data = await response.json();
+                if (!response.ok) {
+                    throw new Error(data.error || '登录失败');
+                }
+                localStorage.setItem('user', JSON.stringify(data.data));
+                window.location.href = '/admin';
+            } catch (error) {
+                this.error = error.message;
+                this.loading = false;
             }
+        },
 
-            if (this.passwordForm.newPassword.length < 6) {
-                this.passwordError = '新密码长度不能小于6位';
+                async changePassword() {
+            if (this.passwordForm.newPassword !== this.passwordForm.confirmPassword) {
+                this.passwordError = '两次输入的密码不一致';
                 return;
             }
 
@@ -5,6 +5,7 @@ function bugtrackerManagement() {
         currentProjectIssues: [],
         currentIssue: null,
         currentIssueComments: [],
+        currentIssueCommits: [],
         showProjectModal: false,
         showProjectDetailModal: false,
         showIssueModal: false,
@@ -192,15 +193,27 @@ function bugtrackerManagement() {
 
         async viewIssue(issue) {
             this.currentIssue = issue;
-            await this.fetchIssueComments(issue.id);
+            await Promise.all([this.fetchIssueComments(issue.id), this.fetchIssueCommits(issue.id)]);
             this.showIssueDetailModal = true;
         },
 
+        async fetchIssueCommits(issueId) {
+            try {
+                const response = await fetch(`/api/admin/bugtracker/issues/${issueId}/commits`);
+                if (!response.ok) throw new Error('获取关联提交失败');
+                const data = await response.json();
+                this.currentIssueCommits = data.commits || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
         async fetchIssueComments(issueId) {
             try {
                 const response = await fetch(`/api/admin/bugtracker/issues/${issueId}/comments`);
                 if (!response.ok) throw new Error('获取评论列表失败');
-                this.currentIssueComments = await response.json();
+                const data = await response.json();
+                this.currentIssueComments = data.comments || [];
             } catch (error) {
                 ShowError(error.message);
             }
@@ -25,6 +25,7 @@ function taskManagement() {
             id: '',
             name: '',
             description: '',
+            tags: '',
             type: 'script',
             script: '',
             url: '',
@@ -72,6 +73,7 @@ function taskManagement() {
                 id: '',
                 name: '',
                 description: '',
+                tags: '',
                 type: 'script',
                 script: '',
                 url: '',
@@ -123,7 +125,7 @@ function taskManagement() {
                 ShowMessage(this.editMode ? '任务更新成功' : '任务创建成功');
             } catch (error) {
                 console.error('保存任务失败:', error);
-                ShowError('保存任务失败');
+                ShowError(error.message || '保存任务失败');
             }
         },
         async deleteTask(id) {
@@ -149,6 +151,12 @@ function taskManagement() {
                     method: 'POST'
                 });
                 if (!response.ok) throw new Error('启动任务失败');
+                // 需要审批时已提交审批单，批准后自动执行
+                if (response.status === 202) {
+                    const data = await response.json();
+                    ShowMessage(data.message);
+                    return;
+                }
                 const taskLog = await response.json();
                 
                 this.currentTask = task;
@@ -242,6 +250,7 @@ function taskManagement() {
                 id: '',
                 name: '',
                 description: '',
+                tags: '',
                 type: 'script',
                 script: '',
                 url: '',
@@ -0,0 +1,338 @@
+// 代码评审
+function codeReview() {
+    return {
+        reviews: [],
+        total: 0,
+        page: 1,
+        pageSize: 10,
+        filter: {
+            status: '',
+            mine: ''
+        },
+        repos: [],
+        reviewers: [],
+        showCreate: false,
+        form: {
+            title: '',
+            description: '',
+            source: 'reposync',
+            repo: '',
+            from_rev: '',
+            to_rev: '',
+            reviewer_id: 0
+        },
+        showDetail: false,
+        review: null,
+        comments: [],
+        commits: [],
+        files: [],
+        diffLoading: false,
+        commentBox: null, // {path, line}
+        commentText: '',
+        verdictText: '',
+
+        init() {
+            this.fetchOptions();
+            this.fetchReviews();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        post(url, body, method = 'POST') {
+            return this.request(url, {
+                method: method,
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(body || {})
+            });
+        },
+
+        async fetchOptions() {
+            try {
+                const data = await this.request('/api/admin/codereview/options');
+                this.repos = data.repos || [];
+                this.reviewers = data.reviewers || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchReviews() {
+            const query = new URLSearchParams({ page: this.page, pageSize: this.pageSize });
+            if (this.filter.status) query.set('status', this.filter.status);
+            if (this.filter.mine) query.set(this.filter.mine, 'me');
+            try {
+                const data = await this.request(`/api/admin/codereview?${query}`);
+                this.reviews = data.data || [];
+                this.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        get totalPages() {
+            return Math.max(1, Math.ceil(this.total / this.pageSize));
+        },
+
+        changePage(page) {
+            if (page < 1 || page > this.totalPages) return;
+            this.page = page;
+            this.fetchReviews();
+        },
+
+        openCreate() {
+            this.form = {
+                title: '',
+                description: '',
+                source: 'reposync',
+                repo: this.repos.length > 0 ? this.repos[0].repo : '',
+                from_rev: '',
+                to_rev: '',
+                reviewer_id: 0
+            };
+            this.showCreate = true;
+        },
+
+        async submitCreate() {
+            try {
+                const body = { ...this.form, reviewer_id: Number(this.form.reviewer_id) };
+                const data = await this.post('/api/admin/codereview', body);
+                ShowMessage('评审已创建');
+                this.showCreate = false;
+                this.fetchReviews();
+                this.openReview(data.data);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async openReview(review) {
+            this.review = review;
+            this.comments = [];
+            this.commits = [];
+            this.files = [];
+            this.commentBox = null;
+            this.verdictText = '';
+            this.showDetail = true;
+            await this.loadReview();
+            this.loadDiff();
+        },
+
+        async loadReview() {
+            try {
+                const data = await this.request(`/api/admin/codereview/${this.review.id}`);
+                this.comments = data.data.comments || [];
+                delete data.data.comments;
+                this.review = data.data;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async loadDiff() {
+            this.diffLoading = true;
+            try {
+                const data = await this.request(`/api/admin/codereview/${this.review.id}/diff`);
+                this.commits = data.data.commits || [];
+                this.files = (data.data.files || []).map(file => ({ ...file, rows: this.parsePatch(file.patch) }));
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.diffLoading = false;
+            }
+        },
+
+        // 解析统一格式差异，计算每行在新文件中的行号，评论定位到新文件行号
+        parsePatch(patch) {
+            const rows = [];
+            let oldLine = 0;
+            let newLine = 0;
+            let inHunk = false;
+            for (const text of (patch || '').split('\n')) {
+                const hunk = text.match(/^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@/);
+                if (hunk) {
+                    oldLine = Number(hunk[1]);
+                    newLine = Number(hunk[2]);
+                    inHunk = true;
+                    rows.push({ text, type: 'hunk', oldLine: null, newLine: null });
+                } else if (!inHunk) {
+                    continue;
+                } else if (text.startsWith('+')) {
+                    rows.push({ text, type: 'add', oldLine: null, newLine: newLine++ });
+                } else if (text.startsWith('-')) {
+                    rows.push({ text, type: 'del', oldLine: oldLine++, newLine: null });
+                } else if (text.startsWith(' ')) {
+                    rows.push({ text, type: 'ctx', oldLine: oldLine++, newLine: newLine++ });
+                }
+            }
+            return rows;
+        },
+
+        rowClass(row) {
+            switch (row.type) {
+                case 'add':
+                    return 'bg-green-50 text-green-800 dark:bg-green-900 dark:text-green-200';
+                case 'del':
+                    return 'bg-red-50 text-red-800 dark:bg-red-900 dark:text-red-200';
+                case 'hunk':
+                    return 'bg-blue-50 text-blue-600 dark:bg-gray-700 dark:text-blue-400';
+                default:
+                    return 'text-gray-800 dark:text-gray-200';
+            }
+        },
+
+        lineComments(path, line) {
+            return this.comments.filter(c => c.kind === 'comment' && c.path === path && c.line === line);
+        },
+
+        generalComments() {
+            return this.comments.filter(c => c.kind !== 'comment' || !c.path);
+        },
+
+        // 文件中不在差异行上的评论，例如行号在旧版本中的评论
+        orphanComments(file) {
+            const lines = new Set(file.rows.filter(r => r.newLine !== null).map(r => r.newLine));
+            return this.comments.filter(c => c.kind === 'comment' && c.path === file.path && (c.line === 0 || !lines.has(c.line)));
+        },
+
+        isOutdated(comment) {
+            return this.review && comment.revision !== this.review.to_rev;
+        },
+
+        openCommentBox(path, line) {
+            if (this.review.status === 'closed') return;
+            this.commentBox = { path, line };
+            this.commentText = '';
+        },
+
+        isCommentBox(path, line) {
+            return this.commentBox && this.commentBox.path === path && this.commentBox.line === line;
+        },
+
+        async submitComment() {
+            if (!this.commentText.trim()) return;
+            const box = this.commentBox || { path: '', line: 0 };
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}/comments`, {
+                    path: box.path,
+                    line: box.line,
+                    content: this.commentText
+                });
+                this.commentBox = null;
+                this.commentText = '';
+                this.loadReview();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async toggleResolved(comment) {
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}/comments/${comment.id}/resolve`, {}, 'PUT');
+                comment.resolved = !comment.resolved;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async submitVerdict(action) {
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}/${action}`, { comment: this.verdictText });
+                ShowMessage('操作成功');
+                this.verdictText = '';
+                this.loadReview();
+                this.fetchReviews();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async assignReviewer(reviewerId) {
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}/reviewer`, { reviewer_id: Number(reviewerId) }, 'PUT');
+                ShowMessage('评审人已更新');
+                this.loadReview();
+                this.fetchReviews();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async updateRange() {
+            const to = prompt('新的结束版本', this.review.to_rev);
+            if (!to) return;
+            const from = prompt('起始版本（不含），留空只评审结束版本本身', this.review.from_rev);
+            if (from === null) return;
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}`, { from_rev: from, to_rev: to }, 'PUT');
+                ShowMessage('版本范围已更新');
+                await this.loadReview();
+                this.loadDiff();
+                this.fetchReviews();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async setClosed(closed) {
+            try {
+                await this.post(`/api/admin/codereview/${this.review.id}/${closed ? 'close' : 'reopen'}`);
+                this.loadReview();
+                this.fetchReviews();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        statusText(status) {
+            return {
+                open: '待评审',
+                approved: '已通过',
+                changes_requested: '需要修改',
+                closed: '已关闭'
+            }[status] || status;
+        },
+
+        statusClass(status) {
+            return {
+                open: 'bg-blue-100 text-blue-800 dark:bg-blue-900 dark:text-blue-200',
+                approved: 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200',
+                changes_requested: 'bg-yellow-100 text-yellow-800 dark:bg-yellow-900 dark:text-yellow-200',
+                closed: 'bg-gray-100 text-gray-800 dark:bg-gray-900 dark:text-gray-200'
+            }[status] || '';
+        },
+
+        kindText(kind) {
+            return {
+                approve: '通过',
+                request_changes: '要求修改'
+            }[kind] || '评论';
+        },
+
+        actionClass(action) {
+            switch (action) {
+                case 'A':
+                    return 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200';
+                case 'D':
+                    return 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200';
+                default:
+                    return 'bg-yellow-100 text-yellow-800 dark:bg-yellow-900 dark:text-yellow-200';
+            }
+        },
+
+        shortRev(rev) {
+            return rev && rev.length === 40 ? rev.substring(0, 7) : rev;
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
@@ -22,31 +22,121 @@ function deleteFile(path, event) {
         });
 }
 
-function uploadToFTP(path, type, event) {
+// 选择上传目标并上传文件，上传在后台进行，进度在文件上传页面查看
+function openUploadDialog(path, event) {
     event.preventDefault();
     event.stopPropagation();
 
-    if (!confirm('确定要上传这个' + type.toUpperCase() + '文件吗？')) {
-        return;
-    }
-
-    fetch('/api/admin/ftp/upload?file=' + encodeURIComponent(path) + '&type=' + type, {
-        method: 'POST'
-    })
-        .then(response => response.json())
-        .then(data => {
-            if (data.success) {
-                ShowMessage('上传成功');
-            } else {
-                ShowError('上传失败: ' + data.error);
+    fetch('/api/admin/upload/targets')
+        .then(response => response.json().then(data => ({ ok: response.ok, data })))
+        .then(({ ok, data }) => {
+            if (!ok) {
+                ShowError('获取上传目标失败: ' + data.error);
+                return;
+            }
+            const targets = (data.data || []).filter(target => target.enabled);
+            if (targets.length === 0) {
+                ShowError('没有可用的上传目标，请先在文件上传页面添加');
+                return;
             }
+            showUploadDialog(path, targets);
         })
         .catch(error => {
             console.error('Error:', error);
-            ShowError('上传失败: ' + error.message);
+            ShowError('获取上传目标失败: ' + error.message);
         });
 }
 
+function showUploadDialog(path, targets) {
+    const modal = document.createElement('div');
+    modal.className = 'fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50';
+
+    const form = document.createElement('form');
+    form.className = 'bg-white dark:bg-gray-800 rounded-lg shadow-xl w-full max-w-md p-6 space-y-4';
+
+    const title = document.createElement('h3');
+    title.className = 'text-lg font-semibold dark:text-gray-200 break-all';
+    title.textContent = '上传 ' + path.split('/').pop();
+
+    const inputClass = 'mt-1 block w-full rounded-md border-gray-300 shadow-sm text-sm dark:bg-gray-700 dark:border-gray-600 dark:text-white';
+
+    const select = document.createElement('select');
+    select.className = inputClass;
+    targets.forEach(target => {
+        const option = document.createElement('option');
+        option.value = target.id;
+        option.textContent = (target.title || target.name) + ' (' + target.kind + ')';
+        select.appendChild(option);
+    });
+
+    const dir = document.createElement('input');
+    dir.type = 'text';
+    dir.className = inputClass;
+    dir.placeholder = '留空则按上传目标的配置选择目录';
+
+    const field = (label, input) => {
+        const wrapper = document.createElement('div');
+        const text = document.createElement('label');
+        text.className = 'block text-sm font-medium text-gray-700 dark:text-gray-300';
+        text.textContent = label;
+        wrapper.appendChild(text);
+        wrapper.appendChild(input);
+        return wrapper;
+    };
+
+    const buttons = document.createElement('div');
+    buttons.className = 'flex justify-end gap-3';
+    const cancel = document.createElement('button');
+    cancel.type = 'button';
+    cancel.className = 'px-4 py-2 text-sm text-gray-700 dark:text-gray-300 bg-gray-100 dark:bg-gray-700 rounded-md hover:bg-gray-200 dark:hover:bg-gray-600';
+    cancel.textContent = '取消';
+    cancel.onclick = () => modal.remove();
+    const submit = document.createElement('button');
+    submit.type = 'submit';
+    submit.className = 'px-4 py-2 text-sm text-white bg-blue-600 rounded-md hover:bg-blue-700';
+    submit.textContent = '上传';
+    buttons.appendChild(cancel);
+    buttons.appendChild(submit);
+
+    form.appendChild(title);
+    form.appendChild(field('上传目标', select));
+    form.appendChild(field('远程目录', dir));
+    form.appendChild(buttons);
+    modal.appendChild(form);
+    document.body.appendChild(modal);
+
+    form.addEventListener('submit', e => {
+        e.preventDefault();
+        submit.disabled = true;
+        fetch('/api/admin/upload', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ file: path, target_id: parseInt(select.value), dir: dir.value.trim() })
+        })
+            .then(response => response.json().then(data => ({ ok: response.ok, data })))
+            .then(({ ok, data }) => {
+                if (!ok) {
+                    submit.disabled = false;
+                    ShowError('上传失败: ' + data.error);
+                    return;
+                }
+                modal.remove();
+                ShowMessage('已开始上传，可在文件上传页面查看进度');
+            })
+            .catch(error => {
+                submit.disabled = false;
+                console.error('Error:', error);
+                ShowError('上传失败: ' + error.message);
+            });
+    });
+
+    modal.addEventListener('click', e => {
+        if (e.target === modal) {
+            modal.remove();
+        }
+    });
+}
+
 // 处理文件点击事件
 function handleFileClick(filePath) {
     // 显示加载提示
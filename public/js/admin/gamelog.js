@@ -2,9 +2,12 @@
 function gameLogManagement() {
     return {
         logs: [],
-        total: 0,
         currentPage: 1,
         pageSize: 50,
+        cursors: [''],
+        nextCursor: '',
+        savedSearches: [],
+        query: '',
         showModal: false,
         currentLog: null,
         currentLogIndex: -1,
@@ -16,8 +19,9 @@ function gameLogManagement() {
         },
 
         init() {
-            this.fetchLogs();
             this.initDateRange();
+            this.fetchLogs();
+            this.fetchSavedSearches();
             this.watchFilters();
         },
 
@@ -34,17 +38,21 @@ function gameLogManagement() {
         async fetchLogs() {
             try {
                 const params = new URLSearchParams({
-                    page: this.currentPage,
                     pageSize: this.pageSize,
+                    cursor: this.cursors[this.currentPage - 1],
+                    q: this.query,
                     ...this.filters
                 });
 
                 const response = await fetch(`/api/admin/gamelog?${params}`);
-                if (!response.ok) throw new Error('获取日志列表失败');
+                if (!response.ok) {
+                    const error = await response.json();
+                    throw new Error(error.error || '获取日志列表失败');
+                }
                 const data = await response.json();
                 
                 this.logs = data.logs;
-                this.total = data.total;
+                this.nextCursor = data.next_cursor;
             } catch (error) {
                 ShowError(error.message);
             }
@@ -125,9 +133,21 @@ function gameLogManagement() {
         },
 
         refreshData() {
+            this.resetPaging();
+            this.fetchLogs();
+        },
+
+        search() {
+            this.resetPaging();
             this.fetchLogs();
         },
 
+        resetPaging() {
+            this.currentPage = 1;
+            this.cursors = [''];
+            this.nextCursor = '';
+        },
+
         previousPage() {
             if (this.currentPage > 1) {
                 this.currentPage--;
@@ -136,12 +156,72 @@ function gameLogManagement() {
         },
 
         nextPage() {
-            if (this.currentPage * this.pageSize < this.total) {
+            if (this.nextCursor) {
+                this.cursors[this.currentPage] = this.nextCursor;
                 this.currentPage++;
                 this.fetchLogs();
             }
         },
 
+        async fetchSavedSearches() {
+            try {
+                const response = await fetch('/api/admin/gamelog/searches');
+                if (!response.ok) throw new Error('获取保存的查询失败');
+                const data = await response.json();
+                this.savedSearches = data.searches;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        applySavedSearch(search) {
+            this.query = search.query;
+            this.search();
+        },
+
+        async saveSearch() {
+            if (!this.query) return;
+            const name = prompt('请输入查询名称');
+            if (!name) return;
+
+            try {
+                const response = await fetch('/api/admin/gamelog/searches', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ name, query: this.query })
+                });
+
+                if (!response.ok) {
+                    const error = await response.json();
+                    throw new Error(error.error || '保存失败');
+                }
+
+                ShowMessage('查询已保存');
+                this.fetchSavedSearches();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async deleteSavedSearch(id) {
+            if (!confirm('确定要删除这个查询吗？')) return;
+
+            try {
+                const response = await fetch(`/api/admin/gamelog/searches/${id}`, {
+                    method: 'DELETE'
+                });
+
+                if (!response.ok) {
+                    const error = await response.json();
+                    throw new Error(error.error || '删除失败');
+                }
+
+                this.fetchSavedSearches();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
         formatDateTime(timestamp) {
             if (!timestamp) return '';
             const date = new Date(timestamp);
@@ -161,7 +241,7 @@ function gameLogManagement() {
 
         watchFilters() {
             this.$watch('filters', () => {
-                this.fetchLogs();
+                this.search();
             });
         }
     }
@@ -0,0 +1,144 @@
+// Game log issue grouping functionality
+function gameLogIssues() {
+    return {
+        issues: [],
+        total: 0,
+        currentPage: 1,
+        pageSize: 20,
+        showModal: false,
+        detail: null,
+        closeVersion: '',
+        bugIssueID: null,
+        tagTitles: {
+            device: '影响设备',
+            role: '影响角色',
+            version: '影响版本'
+        },
+        filters: {
+            search: '',
+            app_id: '',
+            status: '',
+            sort: 'last_seen'
+        },
+
+        init() {
+            this.fetchIssues();
+            this.$watch('filters', () => {
+                this.currentPage = 1;
+                this.fetchIssues();
+            });
+        },
+
+        async fetchIssues() {
+            try {
+                const params = new URLSearchParams({
+                    page: this.currentPage,
+                    pageSize: this.pageSize,
+                    ...this.filters
+                });
+
+                const response = await fetch(`/api/admin/gamelog/issues?${params}`);
+                if (!response.ok) throw new Error('获取异常列表失败');
+                const data = await response.json();
+
+                this.issues = data.issues;
+                this.total = data.total;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async viewIssue(issue) {
+            try {
+                const response = await fetch(`/api/admin/gamelog/issues/${issue.id}`);
+                if (!response.ok) throw new Error('获取异常详情失败');
+                this.detail = await response.json();
+                this.closeVersion = '';
+                this.bugIssueID = null;
+                this.showModal = true;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async postAction(action, body) {
+            const response = await fetch(`/api/admin/gamelog/issues/${this.detail.issue.id}/${action}`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(body || {})
+            });
+
+            if (!response.ok) {
+                const error = await response.json();
+                throw new Error(error.error || '操作失败');
+            }
+        },
+
+        async closeIssue() {
+            try {
+                await this.postAction('close', { version: this.closeVersion });
+                ShowMessage('问题已关闭');
+                await this.viewIssue(this.detail.issue);
+                this.fetchIssues();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async reopenIssue() {
+            try {
+                await this.postAction('reopen');
+                ShowMessage('问题已重新打开');
+                await this.viewIssue(this.detail.issue);
+                this.fetchIssues();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async linkIssue() {
+            if (!this.bugIssueID) return;
+            try {
+                await this.postAction('link', { bug_issue_id: this.bugIssueID });
+                ShowMessage('已关联缺陷');
+                await this.viewIssue(this.detail.issue);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        statusText(status) {
+            return { open: '未处理', closed: '已关闭', regressed: '回归' }[status] || status;
+        },
+
+        statusClass(status) {
+            return {
+                'px-2 py-1 rounded text-xs font-medium': true,
+                'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-300': status === 'open',
+                'bg-purple-100 text-purple-800 dark:bg-purple-900 dark:text-purple-300': status === 'regressed',
+                'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-300': status === 'closed'
+            };
+        },
+
+        previousPage() {
+            if (this.currentPage > 1) {
+                this.currentPage--;
+                this.fetchIssues();
+            }
+        },
+
+        nextPage() {
+            if (this.currentPage * this.pageSize < this.total) {
+                this.currentPage++;
+                this.fetchIssues();
+            }
+        },
+
+        formatDateTime(timestamp) {
+            if (!timestamp) return '';
+            const date = new Date(timestamp);
+            const pad = (n) => String(n).padStart(2, '0');
+            return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())} ${pad(date.getHours())}:${pad(date.getMinutes())}:${pad(date.getSeconds())}`;
+        }
+    }
+}
@@ -1,5 +1,6 @@
 function gitManagement() {
     return {
+        ...repoBrowser('/api/admin/git'),
         repositories: [],
         showCloneModal: false,
         showCommitModal: false,
@@ -50,6 +51,16 @@ function gitManagement() {
             return statusMap[status] || status;
         },
 
+        formatStatus(files) {
+            if (!files || files.length === 0) return '没有修改';
+            return files.map(f => `${f.staging || ' '}${f.worktree || ' '} ${f.path}`).join('\n');
+        },
+
+        formatLog(commits) {
+            if (!commits || commits.length === 0) return '没有提交记录';
+            return commits.map(c => `${c.revision.substring(0, 7)} ${c.author} ${new Date(c.time).toLocaleString()}\n    ${c.message}`).join('\n');
+        },
+
         formatDate(timestamp) {
             if (!timestamp) return '';
             return new Date(timestamp * 1000).toLocaleString();
@@ -114,9 +125,9 @@ function gitManagement() {
             try {
                 const response = await fetch(`/api/admin/git/status?path=${encodeURIComponent(repo.path)}`);
                 if (!response.ok) throw new Error('Failed to get status');
-                const status = await response.text();
+                const data = await response.json();
                 this.detailsTitle = '仓库状态';
-                this.detailsContent = status;
+                this.detailsContent = this.formatStatus(data.status);
                 this.showDetailsModal = true;
             } catch (error) {
                 console.error('Error getting status:', error);
@@ -128,9 +139,9 @@ function gitManagement() {
             try {
                 const response = await fetch(`/api/admin/git/log?path=${encodeURIComponent(repo.path)}`);
                 if (!response.ok) throw new Error('Failed to get log');
-                const log = await response.text();
+                const data = await response.json();
                 this.detailsTitle = '提交日志';
-                this.detailsContent = log;
+                this.detailsContent = this.formatLog(data.log);
                 this.showDetailsModal = true;
             } catch (error) {
                 console.error('Error getting log:', error);
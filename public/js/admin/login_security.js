@@ -0,0 +1,100 @@
+// 登录安全
+function loginSecurity() {
+    return {
+        lockedUsers: [],
+        throttled: [],
+        attempts: {
+            username: '',
+            ip: '',
+            result: '',
+            logs: [],
+            total: 0,
+            page: 1,
+            pageSize: 20
+        },
+
+        init() {
+            this.fetchLocks();
+            this.fetchAttempts();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async fetchLocks() {
+            try {
+                const data = await this.request('/api/admin/login/locks');
+                this.lockedUsers = data.users || [];
+                this.throttled = data.throttled || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchAttempts() {
+            const query = new URLSearchParams({ page: this.attempts.page, pageSize: this.attempts.pageSize });
+            if (this.attempts.username) query.set('username', this.attempts.username);
+            if (this.attempts.ip) query.set('ip', this.attempts.ip);
+            if (this.attempts.result) query.set('result', this.attempts.result);
+            try {
+                const data = await this.request(`/api/admin/login/attempts?${query}`);
+                this.attempts.logs = data.data || [];
+                this.attempts.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        search() {
+            this.attempts.page = 1;
+            this.fetchAttempts();
+        },
+
+        filterIP(ip) {
+            this.attempts.ip = ip;
+            this.search();
+        },
+
+        changePage(page) {
+            if (page < 1 || (page - 1) * this.attempts.pageSize >= this.attempts.total) return;
+            this.attempts.page = page;
+            this.fetchAttempts();
+        },
+
+        async unlockUser(user) {
+            if (!confirm(`确定解锁账号 ${user.username} 吗？`)) return;
+            try {
+                const data = await this.request(`/api/admin/login/locks/${user.id}/unlock`, { method: 'POST' });
+                ShowMessage(data.message);
+                this.fetchLocks();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async unblock(item) {
+            try {
+                const data = await this.request('/api/admin/login/unblock', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ kind: item.kind, value: item.value })
+                });
+                ShowMessage(data.message);
+                this.fetchLocks();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
@@ -149,6 +149,12 @@ function patchManagement() {
                     const error = await response.json();
                     throw new Error(error.error || '应用补丁包失败');
                 }
+                // 需要审批时已提交审批单，批准后自动执行
+                if (response.status === 202) {
+                    const data = await response.json();
+                    ShowMessage(data.message);
+                    return;
+                }
 
                 await this.loadRecords();
                 ShowMessage('补丁包应用成功');
@@ -0,0 +1,85 @@
+// 个人信息页面的两步验证
+function twoFactorPanel() {
+    return {
+        status: { enabled: false, required: false, recovery_codes: 0 },
+        setup: null,
+        code: '',
+        action: '',
+        recoveryCodes: [],
+        setupRequired: new URLSearchParams(window.location.search).get('setup2fa') === '1',
+
+        init() {
+            this.load();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async load() {
+            try {
+                const data = await this.request('/api/admin/2fa');
+                this.status = data.data;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async startSetup() {
+            try {
+                const data = await this.request('/api/admin/2fa/setup', { method: 'POST' });
+                this.setup = data.data;
+                this.code = '';
+                this.action = 'enable';
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        openAction(action) {
+            this.action = action;
+            this.code = '';
+        },
+
+        cancel() {
+            this.action = '';
+            this.setup = null;
+            this.code = '';
+        },
+
+        // submit 使用验证码确认启用、关闭或重新生成恢复码
+        async submit() {
+            const urls = {
+                enable: '/api/admin/2fa/enable',
+                disable: '/api/admin/2fa/disable',
+                regenerate: '/api/admin/2fa/recovery-codes'
+            };
+            try {
+                const data = await this.request(urls[this.action], {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ code: this.code })
+                });
+                this.recoveryCodes = data.recovery_codes || [];
+                if (this.action === 'enable') {
+                    this.setupRequired = false;
+                }
+                this.cancel();
+                ShowMessage(data.message);
+                this.load();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        copyRecoveryCodes() {
+            navigator.clipboard.writeText(this.recoveryCodes.join('\n'));
+            ShowMessage('已复制');
+        }
+    };
+}
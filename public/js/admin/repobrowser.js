@@ -0,0 +1,182 @@
+// 仓库浏览器，git 和 svn 页面共用，api 为接口前缀，如 /api/admin/git
+function repoBrowser(api) {
+    return {
+        showBrowser: false,
+        browser: {
+            repo: null,
+            rev: '',
+            revInput: '',
+            dir: '',
+            entries: [],
+            file: null,
+            tab: 'file',
+            history: [],
+            blame: [],
+            diffs: [],
+            diffTitle: '',
+            loading: false
+        },
+
+        async browserFetch(action, params) {
+            const query = new URLSearchParams({ path: this.browser.repo.path, ...params });
+            const response = await fetch(`${api}/${action}?${query}`);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        openBrowser(repo) {
+            this.browser = {
+                repo: repo,
+                rev: '',
+                revInput: '',
+                dir: '',
+                entries: [],
+                file: null,
+                tab: 'file',
+                history: [],
+                blame: [],
+                diffs: [],
+                diffTitle: '',
+                loading: false
+            };
+            this.showBrowser = true;
+            this.loadTree('');
+        },
+
+        async loadTree(dir) {
+            this.browser.loading = true;
+            try {
+                const data = await this.browserFetch('tree', { rev: this.browser.rev, dir: dir });
+                this.browser.dir = dir;
+                this.browser.entries = data.entries || [];
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.browser.loading = false;
+            }
+        },
+
+        browserCrumbs() {
+            const crumbs = [{ name: '根目录', path: '' }];
+            let current = '';
+            for (const part of this.browser.dir.split('/').filter(p => p)) {
+                current = current ? `${current}/${part}` : part;
+                crumbs.push({ name: part, path: current });
+            }
+            return crumbs;
+        },
+
+        openEntry(entry) {
+            if (entry.dir) {
+                this.loadTree(entry.path);
+            } else {
+                this.openFile(entry.path);
+            }
+        },
+
+        async openFile(file) {
+            this.browser.loading = true;
+            try {
+                const data = await this.browserFetch('file', { rev: this.browser.rev, file: file });
+                this.browser.file = data.file;
+                this.browser.tab = 'file';
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.browser.loading = false;
+            }
+        },
+
+        // 跳转到指定版本，为空时浏览最新版本
+        browseRevision(rev) {
+            this.browser.rev = rev;
+            this.browser.revInput = rev;
+            this.browser.file = null;
+            this.loadTree(this.browser.dir);
+        },
+
+        async showHistory() {
+            this.browser.loading = true;
+            try {
+                const file = this.browser.file ? this.browser.file.path : this.browser.dir;
+                const data = await this.browserFetch('history', { rev: this.browser.rev, file: file, limit: 30 });
+                this.browser.history = data.commits || [];
+                this.browser.tab = 'history';
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.browser.loading = false;
+            }
+        },
+
+        async showBlame() {
+            if (!this.browser.file) return;
+            this.browser.loading = true;
+            try {
+                const data = await this.browserFetch('blame', { rev: this.browser.rev, file: this.browser.file.path });
+                this.browser.blame = data.lines || [];
+                this.browser.tab = 'blame';
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.browser.loading = false;
+            }
+        },
+
+        // 查看提交本身的差异，from 为空时与上一版本比较
+        async showDiff(to, from = '') {
+            this.browser.loading = true;
+            try {
+                const data = await this.browserFetch('diff', { from: from, to: to });
+                this.browser.diffs = data.files || [];
+                this.browser.diffTitle = from ? `${this.shortRev(from)} → ${this.shortRev(to)}` : this.shortRev(to);
+                this.browser.tab = 'diff';
+            } catch (error) {
+                ShowError(error.message);
+            } finally {
+                this.browser.loading = false;
+            }
+        },
+
+        shortRev(rev) {
+            return rev && rev.length === 40 ? rev.substring(0, 7) : rev;
+        },
+
+        formatBrowserTime(time) {
+            if (!time) return '';
+            return new Date(time).toLocaleString('zh-CN', { hour12: false });
+        },
+
+        formatSize(size) {
+            if (size < 1024) return `${size} B`;
+            if (size < 1024 * 1024) return `${(size / 1024).toFixed(1)} KB`;
+            return `${(size / 1024 / 1024).toFixed(1)} MB`;
+        },
+
+        diffLines(patch) {
+            return (patch || '').split('\n');
+        },
+
+        diffLineClass(line) {
+            if (line.startsWith('+++') || line.startsWith('---')) return 'text-gray-500';
+            if (line.startsWith('+')) return 'bg-green-50 text-green-800 dark:bg-green-900 dark:text-green-200';
+            if (line.startsWith('-')) return 'bg-red-50 text-red-800 dark:bg-red-900 dark:text-red-200';
+            if (line.startsWith('@@')) return 'text-blue-600 dark:text-blue-400';
+            return '';
+        },
+
+        getActionClass(action) {
+            switch (action) {
+                case 'A':
+                    return 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200';
+                case 'D':
+                    return 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200';
+                default:
+                    return 'bg-yellow-100 text-yellow-800 dark:bg-yellow-900 dark:text-yellow-200';
+            }
+        }
+    };
+}
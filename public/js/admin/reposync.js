@@ -1,17 +1,9 @@
 function reposyncManagement() {
     return {
-        config: {
-            repo_type1: 'svn',
-            repo_url1: '',
-            local_path1: '',
-            username1: '',
-            password1: '',
-            repo_type2: 'svn',
-            repo_url2: '',
-            local_path2: '',
-            username2: '',
-            password2: ''
-        },
+        profiles: [],
+        currentProfile: '',
+        profile: null,
+        isNew: false,
         commits: [],
         selectedCommits: [],
         selectAll: false,
@@ -21,49 +13,148 @@ function reposyncManagement() {
         totalPages: 1,
         refreshLimit: 100,
         openFileLists: new Set(), // 跟踪哪些提交的文件列表是展开的
+        report: null,             // 同步计划
+        reportRevisions: [],      // 同步计划对应的版本号
+        showReport: false,
 
         init() {
-            this.loadConfig();
-            this.loadCommits();
+            this.profile = this.emptyProfile();
+            this.loadProfiles();
+        },
+
+        emptyRepo() {
+            return { type: 'svn', url: '', local_path: '', username: '', password: '' };
         },
 
-        async loadConfig() {
+        emptyProfile() {
+            return { name: '', title: '', source: this.emptyRepo(), targets: [this.emptyRepo()], mappings: [] };
+        },
+
+        // 将方案转换为表单数据，文件模式使用逗号分隔的文本编辑
+        toForm(p) {
+            const form = JSON.parse(JSON.stringify(p));
+            form.targets = form.targets && form.targets.length ? form.targets : [this.emptyRepo()];
+            form.mappings = (form.mappings || []).map(m => ({
+                source: m.source || '',
+                target: m.target || '',
+                includeText: (m.include || []).join(', '),
+                excludeText: (m.exclude || []).join(', ')
+            }));
+            return form;
+        },
+
+        fromForm(form) {
+            const split = text => (text || '').split(',').map(s => s.trim()).filter(s => s);
+            return {
+                name: form.name,
+                title: form.title,
+                source: form.source,
+                targets: form.targets,
+                mappings: form.mappings.map(m => ({
+                    source: m.source,
+                    target: m.target,
+                    include: split(m.includeText),
+                    exclude: split(m.excludeText)
+                }))
+            };
+        },
+
+        async loadProfiles() {
             try {
-                const response = await fetch('/api/admin/reposync/config');
-                if (!response.ok) throw new Error('加载配置失败');
+                const response = await fetch('/api/admin/reposync/profiles');
+                if (!response.ok) throw new Error('加载同步方案失败');
                 const data = await response.json();
-                if (data) {
-                    this.config = data;
+                this.profiles = data.profiles || [];
+                if (this.profiles.length === 0) {
+                    this.newProfile();
+                    return;
                 }
+                const name = this.profiles.some(p => p.name === this.currentProfile) ? this.currentProfile : this.profiles[0].name;
+                await this.selectProfile(name);
             } catch (error) {
                 ShowError(error.message);
             }
         },
 
-        async saveConfig() {
+        async selectProfile(name) {
+            const p = this.profiles.find(p => p.name === name);
+            if (!p) return;
+            this.currentProfile = name;
+            this.profile = this.toForm(p);
+            this.isNew = false;
+            this.currentPage = 1;
+            await this.loadCommits();
+        },
+
+        newProfile() {
+            this.profile = this.emptyProfile();
+            this.isNew = true;
+            this.commits = [];
+            this.totalRecords = 0;
+            this.totalPages = 1;
+        },
+
+        addTarget() {
+            this.profile.targets.push(this.emptyRepo());
+        },
+
+        addMapping() {
+            this.profile.mappings.push({ source: '', target: '', includeText: '', excludeText: '' });
+        },
+
+        async saveProfile() {
             try {
-                const response = await fetch('/api/admin/reposync/config', {
+                const response = await fetch('/api/admin/reposync/profiles', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json',
                     },
-                    body: JSON.stringify(this.config)
+                    body: JSON.stringify(this.fromForm(this.profile))
                 });
 
                 if (!response.ok) {
                     const error = await response.json();
-                    throw new Error(error.error || '保存配置失败');
+                    throw new Error(error.error || '保存方案失败');
                 }
 
-                ShowMessage('配置保存成功');
+                this.currentProfile = this.profile.name;
+                await this.loadProfiles();
+                ShowMessage('方案保存成功');
             } catch (error) {
                 ShowError(error.message);
             }
         },
 
+        async deleteProfile() {
+            if (!confirm(`确定要删除方案 ${this.currentProfile} 及其同步记录吗？`)) {
+                return;
+            }
+
+            try {
+                const response = await fetch(`/api/admin/reposync/profiles/${encodeURIComponent(this.currentProfile)}`, {
+                    method: 'DELETE'
+                });
+
+                if (!response.ok) {
+                    const error = await response.json();
+                    throw new Error(error.error || '删除方案失败');
+                }
+
+                this.currentProfile = '';
+                await this.loadProfiles();
+                ShowMessage('方案删除成功');
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        profileQuery() {
+            return `profile=${encodeURIComponent(this.currentProfile)}`;
+        },
+
         async checkoutRepos() {
             try {
-                const response = await fetch('/api/admin/reposync/checkout', {
+                const response = await fetch(`/api/admin/reposync/checkout?${this.profileQuery()}`, {
                     method: 'POST'
                 });
 
@@ -80,8 +171,9 @@ function reposyncManagement() {
         },
 
         async loadCommits() {
+            if (!this.currentProfile) return;
             try {
-                const response = await fetch(`/api/admin/reposync/commits?page=${this.currentPage}&pageSize=${this.pageSize}`);
+                const response = await fetch(`/api/admin/reposync/commits?${this.profileQuery()}&page=${this.currentPage}&pageSize=${this.pageSize}`);
                 if (!response.ok) throw new Error('加载提交记录失败');
                 const data = await response.json();
                 this.commits = data.commits;
@@ -116,60 +208,66 @@ function reposyncManagement() {
             this.selectAll = this.selectedCommits.length === this.commits.filter(commit => !commit.synced).length;
         },
 
-        async syncSelectedCommits() {
+        sortRevisions(revisions) {
+            // 对版本号进行排序（从小到大）
+            return [...revisions].sort((a, b) => {
+                // 如果是数字版本号，按数字大小排序
+                const numA = parseInt(a);
+                const numB = parseInt(b);
+                if (!isNaN(numA) && !isNaN(numB)) {
+                    return numA - numB;
+                }
+                // 如果不是数字，按字符串排序
+                return a.localeCompare(b);
+            });
+        },
+
+        async postSync(revisions, options) {
+            const response = await fetch('/api/admin/reposync/sync', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ profile: this.currentProfile, revisions: this.sortRevisions(revisions), ...options })
+            });
+            return { response, data: await response.json() };
+        },
+
+        previewSelectedCommits() {
             if (this.selectedCommits.length === 0) {
                 ShowError('请选择要同步的提交记录');
                 return;
             }
+            this.previewCommits(this.selectedCommits);
+        },
 
+        // 预览同步计划
+        async previewCommits(revisions) {
             try {
-                // 对版本号进行排序（从小到大）
-                const sortedRevisions = [...this.selectedCommits].sort((a, b) => {
-                    // 如果是数字版本号，按数字大小排序
-                    const numA = parseInt(a);
-                    const numB = parseInt(b);
-                    if (!isNaN(numA) && !isNaN(numB)) {
-                        return numA - numB;
-                    }
-                    // 如果不是数字，按字符串排序
-                    return a.localeCompare(b);
-                });
-
-                const response = await fetch('/api/admin/reposync/sync', {
-                    method: 'POST',
-                    headers: {
-                        'Content-Type': 'application/json',
-                    },
-                    body: JSON.stringify({ revisions: sortedRevisions })
-                });
+                const { response, data } = await this.postSync(revisions, { dry_run: true });
+                if (!response.ok) throw new Error(data.error || '预览失败');
 
-                if (!response.ok) {
-                    const error = await response.json();
-                    throw new Error(error.error || '批量同步失败');
-                }
-
-                await this.loadCommits();
-                ShowMessage('批量同步成功');
+                this.report = data.report;
+                this.reportRevisions = revisions;
+                this.showReport = true;
             } catch (error) {
                 ShowError(error.message);
             }
         },
 
-        async syncCommit(revision) {
+        // 执行同步，存在冲突时显示冲突报告
+        async runSync(revisions, force) {
             try {
-                const response = await fetch('/api/admin/reposync/sync', {
-                    method: 'POST',
-                    headers: {
-                        'Content-Type': 'application/json',
-                    },
-                    body: JSON.stringify({ revisions: [revision] })
-                });
-
-                if (!response.ok) {
-                    const error = await response.json();
-                    throw new Error(error.error || '同步失败');
+                const { response, data } = await this.postSync(revisions, { force });
+                if (response.status === 409) {
+                    this.report = data.report;
+                    this.reportRevisions = revisions;
+                    this.showReport = true;
+                    throw new Error(data.error);
                 }
+                if (!response.ok) throw new Error(data.error || '同步失败');
 
+                this.showReport = false;
                 await this.loadCommits();
                 ShowMessage('同步成功');
             } catch (error) {
@@ -177,6 +275,39 @@ function reposyncManagement() {
             }
         },
 
+        async syncSelectedCommits() {
+            if (this.selectedCommits.length === 0) {
+                ShowError('请选择要同步的提交记录');
+                return;
+            }
+            await this.runSync(this.selectedCommits, false);
+        },
+
+        async syncCommit(revision) {
+            await this.runSync([revision], false);
+        },
+
+        getActionText(action) {
+            const actions = {
+                'add': '新增',
+                'modify': '修改',
+                'delete': '删除',
+                'mkdir': '新建目录',
+                'unchanged': '无变化',
+                'skip': '跳过'
+            };
+            return actions[action] || action;
+        },
+
+        getActionClass(action) {
+            const classes = {
+                'add': 'text-green-600',
+                'modify': 'text-blue-600',
+                'delete': 'text-red-600'
+            };
+            return classes[action] || 'text-gray-500';
+        },
+
         formatDate(timestamp) {
             if (!timestamp) return '';
             return new Date(timestamp).toLocaleString('zh-CN', {
@@ -224,7 +355,7 @@ function reposyncManagement() {
                     headers: {
                         'Content-Type': 'application/json',
                     },
-                    body: JSON.stringify({ limit: this.refreshLimit })
+                    body: JSON.stringify({ profile: this.currentProfile, limit: this.refreshLimit })
                 });
 
                 if (!response.ok) {
@@ -240,12 +371,12 @@ function reposyncManagement() {
         },
 
         async clearSyncData() {
-            if (!confirm('确定要清空所有同步数据吗？此操作不可恢复！')) {
+            if (!confirm(`确定要清空方案 ${this.currentProfile} 的同步数据吗？此操作不可恢复！`)) {
                 return;
             }
 
             try {
-                const response = await fetch('/api/admin/reposync/clear', {
+                const response = await fetch(`/api/admin/reposync/clear?${this.profileQuery()}`, {
                     method: 'POST'
                 });
 
@@ -7,7 +7,9 @@ function roleManagement() {
             id: null,
             name: '',
             description: '',
-            permissions: []
+            require_2fa: false,
+            permissions: [],
+            scopes: []
         },
         showPanel: false,
         isEditing: false,
@@ -46,7 +48,9 @@ function roleManagement() {
                 id: null,
                 name: '',
                 description: '',
-                permissions: []
+                require_2fa: false,
+                permissions: [],
+                scopes: []
             };
             this.isEditing = false;
             this.panelTitle = '创建角色';
@@ -58,7 +62,13 @@ function roleManagement() {
                 id: role.id,
                 name: role.name,
                 description: role.description,
-                permissions: role.permissions.map(p => parseInt(p.id))
+                require_2fa: role.require_2fa,
+                permissions: role.permissions.map(p => parseInt(p.id)),
+                scopes: (role.scopes || []).map(s => ({
+                    permission: s.permission,
+                    kind: s.resource_id ? 'id' : 'tag',
+                    value: s.resource_id ? String(s.resource_id) : s.tag
+                }))
             };
             this.isEditing = true;
             this.panelTitle = '编辑角色';
@@ -69,11 +79,46 @@ function roleManagement() {
             this.showPanel = false;
         },
 
+        selectedPermissions() {
+            const ids = this.currentRole.permissions.map(id => parseInt(id));
+            return this.permissions.filter(p => ids.includes(p.id));
+        },
+
+        addScope() {
+            this.currentRole.scopes.push({ permission: '', kind: 'tag', value: '' });
+        },
+
+        removeScope(index) {
+            this.currentRole.scopes.splice(index, 1);
+        },
+
+        scopeText(role) {
+            return (role.scopes || []).map(s => s.permission + ': ' + (s.resource_id ? 'ID ' + s.resource_id : s.tag)).join('\n');
+        },
+
+        formScopes() {
+            return this.currentRole.scopes.map(s => ({
+                permission: s.permission,
+                resource_id: s.kind === 'id' ? parseInt(s.value) || 0 : 0,
+                tag: s.kind === 'tag' ? s.value.trim() : ''
+            }));
+        },
+
+        async responseError(response, fallback) {
+            try {
+                const data = await response.json();
+                return data.error || fallback;
+            } catch (e) {
+                return fallback;
+            }
+        },
+
         async createRole() {
             try {
                 const formData = {
                     ...this.currentRole,
-                    permissions: this.currentRole.permissions.map(id => parseInt(id))
+                    permissions: this.currentRole.permissions.map(id => parseInt(id)),
+                    scopes: this.formScopes()
                 };
                 const response = await fetch('/api/admin/roles', {
                     method: 'POST',
@@ -83,14 +128,14 @@ function roleManagement() {
                     body: JSON.stringify(formData)
                 });
 
-                if (!response.ok) throw new Error('Failed to create role');
+                if (!response.ok) throw new Error(await this.responseError(response, '创建角色失败'));
                 
                 await this.loadRoles();
                 this.closePanel();
                 ShowMessage('角色创建成功');
             } catch (error) {
                 console.error('Error creating role:', error);
-                ShowError('创建角色失败');
+                ShowError(error.message);
             }
         },
 
@@ -98,7 +143,8 @@ function roleManagement() {
             try {
                 const formData = {
                     ...this.currentRole,
-                    permissions: this.currentRole.permissions.map(id => parseInt(id))
+                    permissions: this.currentRole.permissions.map(id => parseInt(id)),
+                    scopes: this.formScopes()
                 };
                 const response = await fetch(`/api/admin/roles/${this.currentRole.id}`, {
                     method: 'PUT',
@@ -108,14 +154,14 @@ function roleManagement() {
                     body: JSON.stringify(formData)
                 });
 
-                if (!response.ok) throw new Error('Failed to update role');
+                if (!response.ok) throw new Error(await this.responseError(response, '更新角色失败'));
                 
                 await this.loadRoles();
                 this.closePanel();
                 ShowMessage('角色更新成功');
             } catch (error) {
                 console.error('Error updating role:', error);
-                ShowError('更新角色失败');
+                ShowError(error.message);
             }
         },
 
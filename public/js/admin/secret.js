@@ -0,0 +1,119 @@
+// 凭据库
+function secretManagement() {
+    return {
+        secrets: [],
+        vaultError: '',
+        showForm: false,
+        editing: null,
+        form: {
+            name: '',
+            value: '',
+            description: ''
+        },
+        access: {
+            name: '',
+            consumer: '',
+            failed: false,
+            logs: [],
+            total: 0,
+            page: 1,
+            pageSize: 20
+        },
+
+        init() {
+            this.fetchSecrets();
+            this.fetchAccess();
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async fetchSecrets() {
+            try {
+                const data = await this.request('/api/admin/secrets');
+                this.secrets = data.data || [];
+                this.vaultError = data.vault_error || '';
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchAccess() {
+            const query = new URLSearchParams({ page: this.access.page, pageSize: this.access.pageSize });
+            if (this.access.name) query.set('name', this.access.name);
+            if (this.access.consumer) query.set('consumer', this.access.consumer);
+            if (this.access.failed) query.set('failed', '1');
+            try {
+                const data = await this.request(`/api/admin/secrets/access?${query}`);
+                this.access.logs = data.data || [];
+                this.access.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        showAccess(name) {
+            this.access.name = name;
+            this.access.page = 1;
+            this.fetchAccess();
+        },
+
+        changeAccessPage(page) {
+            if (page < 1 || (page - 1) * this.access.pageSize >= this.access.total) return;
+            this.access.page = page;
+            this.fetchAccess();
+        },
+
+        openCreate() {
+            this.editing = null;
+            this.form = { name: '', value: '', description: '' };
+            this.showForm = true;
+        },
+
+        openEdit(item) {
+            this.editing = item;
+            this.form = { name: item.name, value: '', description: item.description };
+            this.showForm = true;
+        },
+
+        async submitForm() {
+            const url = this.editing ? `/api/admin/secrets/${this.editing.id}` : '/api/admin/secrets';
+            try {
+                await this.request(url, {
+                    method: this.editing ? 'PUT' : 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(this.form)
+                });
+                // 提交后立即清除页面中的明文
+                this.form.value = '';
+                this.showForm = false;
+                ShowMessage('保存成功');
+                this.fetchSecrets();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async deleteSecret(item) {
+            if (!confirm(`确定删除凭据 ${item.ref} 吗？引用该凭据的配置将无法读取密码。`)) return;
+            try {
+                await this.request(`/api/admin/secrets/${item.id}`, { method: 'DELETE' });
+                ShowMessage('删除成功');
+                this.fetchSecrets();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
@@ -243,6 +243,12 @@ function serverConfig() {
                     body: JSON.stringify(this.serverList),
                 });
                 if (!response.ok) throw new Error('保存失败');
+                // 需要审批时已提交审批单，批准后自动执行
+                if (response.status === 202) {
+                    const data = await response.json();
+                    ShowMessage(data.message);
+                    return;
+                }
                 ShowMessage('服务器列表保存成功');
             } catch (error) {
                 console.error('保存服务器列表失败:', error);
@@ -0,0 +1,71 @@
+// 登录会话，个人信息页面和用户管理页面共用
+function sessionPanel() {
+    return {
+        baseUrl: '',
+        self: false,
+        sessions: [],
+
+        // load 加载会话，baseUrl 为 /api/admin/sessions 或 /api/admin/users/:id/sessions，self 表示当前用户自己的会话
+        async load(baseUrl, self) {
+            this.baseUrl = baseUrl;
+            this.self = self;
+            try {
+                const response = await fetch(baseUrl);
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '获取会话失败');
+                this.sessions = data.data || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async revokeSession(session) {
+            const message = session.current ? '确定退出当前会话吗？' : `确定下线 ${session.ip} 上的会话吗？`;
+            if (!confirm(message)) return;
+            await this.request(`${this.baseUrl}/${session.id}`, { method: 'DELETE' }, '下线会话失败');
+            if (session.current) {
+                window.location.href = '/login';
+            }
+        },
+
+        // revokeOthers 下线当前用户的其他会话
+        async revokeOthers() {
+            if (!confirm('确定下线除当前会话外的所有会话吗？')) return;
+            await this.request(`${this.baseUrl}/revoke-others`, { method: 'POST' }, '下线会话失败');
+        },
+
+        // revokeAll 强制用户在所有设备上下线
+        async revokeAll() {
+            if (!confirm('确定强制该用户在所有设备上下线吗？API 令牌不受影响。')) return;
+            await this.request(this.baseUrl, { method: 'DELETE' }, '下线会话失败');
+        },
+
+        async request(url, options, fallback) {
+            try {
+                const response = await fetch(url, options);
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || fallback);
+                ShowMessage(data.message);
+                await this.load(this.baseUrl, this.self);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        // browser 从 User-Agent 中识别浏览器和系统，识别不了时显示原文
+        browser(userAgent) {
+            if (!userAgent) return '未知';
+            const browsers = [['Edg/', 'Edge'], ['Chrome/', 'Chrome'], ['Firefox/', 'Firefox'], ['Safari/', 'Safari'], ['curl/', 'curl']];
+            const systems = [['Windows', 'Windows'], ['Mac OS', 'macOS'], ['Android', 'Android'], ['iPhone', 'iOS'], ['Linux', 'Linux']];
+            const name = browsers.find(([key]) => userAgent.includes(key));
+            const system = systems.find(([key]) => userAgent.includes(key));
+            if (!name) return userAgent;
+            return system ? `${name[1]} · ${system[1]}` : name[1];
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
@@ -1,6 +1,7 @@
 // SVN management functionality
 function svnManagement() {
     return {
+        ...repoBrowser('/api/admin/svn'),
         repositories: [],
         showCheckoutModal: false,
         showCommitModal: false,
@@ -92,7 +93,7 @@ function svnManagement() {
 
                 const data = await response.json();
                 this.detailsTitle = '仓库状态';
-                this.detailsContent = data.status;
+                this.detailsContent = this.formatStatus(data.status);
                 this.showDetailsModal = true;
             } catch (error) {
                 ShowError(error.message);
@@ -108,7 +109,7 @@ function svnManagement() {
 
                 const data = await response.json();
                 this.detailsTitle = '提交日志';
-                this.detailsContent = data.log;
+                this.detailsContent = this.formatLog(data.log);
                 this.showDetailsModal = true;
             } catch (error) {
                 ShowError(error.message);
@@ -181,6 +182,14 @@ function svnManagement() {
                     return '未知';
             }
         },
+        formatStatus(files) {
+            if (!files || files.length === 0) return '没有修改';
+            return files.map(f => `${f.worktree.padEnd(12)} ${f.path}`).join('\n');
+        },
+        formatLog(commits) {
+            if (!commits || commits.length === 0) return '没有提交记录';
+            return commits.map(c => `r${c.revision} | ${c.author} | ${this.formatDate(c.time)}\n${c.message}`).join('\n\n');
+        },
         formatDate(date) {
             if (!date) return '';
             return new Date(date).toLocaleString('zh-CN', {
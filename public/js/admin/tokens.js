@@ -0,0 +1,89 @@
+// API 令牌，个人信息页面和用户管理页面共用
+function tokenPanel() {
+    return {
+        baseUrl: '',
+        canCreate: false,
+        tokens: [],
+        scopes: [],
+        showCreate: false,
+        newToken: '',
+        form: {
+            name: '',
+            scopes: [],
+            expires_days: 90
+        },
+
+        // load 加载令牌，baseUrl 为 /api/admin/tokens 或 /api/admin/users/:id/tokens
+        async load(baseUrl, canCreate) {
+            this.baseUrl = baseUrl;
+            this.canCreate = canCreate;
+            this.showCreate = false;
+            this.newToken = '';
+            try {
+                const response = await fetch(baseUrl);
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '获取令牌失败');
+                this.tokens = data.data || [];
+                this.scopes = data.scopes || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        openCreate() {
+            this.form = { name: '', scopes: [], expires_days: 90 };
+            this.newToken = '';
+            this.showCreate = true;
+        },
+
+        async createToken() {
+            try {
+                const response = await fetch(this.baseUrl, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ ...this.form, expires_days: parseInt(this.form.expires_days) })
+                });
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '创建令牌失败');
+                await this.load(this.baseUrl, this.canCreate);
+                // 令牌明文只显示这一次
+                this.newToken = data.token;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async copyToken() {
+            try {
+                await navigator.clipboard.writeText(this.newToken);
+                ShowMessage('已复制');
+            } catch (error) {
+                ShowError('复制失败，请手动复制');
+            }
+        },
+
+        async revokeToken(token) {
+            if (!confirm(`确定吊销令牌 ${token.name} 吗？使用该令牌的脚本将无法访问。`)) return;
+            try {
+                const response = await fetch(`${this.baseUrl}/${token.id}`, { method: 'DELETE' });
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '吊销令牌失败');
+                ShowMessage(data.message);
+                await this.load(this.baseUrl, this.canCreate);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        tokenStatus(token) {
+            if (token.revoked_at) return '已吊销';
+            if (token.expires_at && new Date(token.expires_at) < new Date()) return '已过期';
+            return '有效';
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
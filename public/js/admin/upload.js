@@ -0,0 +1,174 @@
+// 文件上传
+function uploadManagement() {
+    return {
+        targets: [],
+        records: [],
+        total: 0,
+        page: 1,
+        pageSize: 20,
+        filter: {
+            status: '',
+            targetId: ''
+        },
+        showForm: false,
+        editing: null,
+        form: {},
+        timer: null,
+
+        kinds: [
+            { value: 'ftp', label: 'FTP' },
+            { value: 'sftp', label: 'SFTP' },
+            { value: 's3', label: 'S3 兼容存储' },
+            { value: 'local', label: '本地目录' },
+            { value: 'rsync', label: 'rsync' }
+        ],
+
+        init() {
+            this.fetchTargets();
+            this.fetchRecords();
+            // 有上传中的记录时刷新进度
+            this.timer = setInterval(() => {
+                if (this.records.some(record => record.status === 'running')) {
+                    this.fetchRecords();
+                }
+            }, 2000);
+        },
+
+        async request(url, options = {}) {
+            const response = await fetch(url, options);
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || '请求失败');
+            }
+            return data;
+        },
+
+        async fetchTargets() {
+            try {
+                const data = await this.request('/api/admin/upload/targets');
+                this.targets = data.data || [];
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async fetchRecords() {
+            const query = new URLSearchParams({ page: this.page, pageSize: this.pageSize });
+            if (this.filter.status) query.set('status', this.filter.status);
+            if (this.filter.targetId) query.set('target_id', this.filter.targetId);
+            try {
+                const data = await this.request(`/api/admin/upload/records?${query}`);
+                this.records = data.data || [];
+                this.total = data.total || 0;
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        changePage(page) {
+            if (page < 1 || (page - 1) * this.pageSize >= this.total) return;
+            this.page = page;
+            this.fetchRecords();
+        },
+
+        kindLabel(kind) {
+            const item = this.kinds.find(k => k.value === kind);
+            return item ? item.label : kind;
+        },
+
+        openCreate() {
+            this.editing = null;
+            this.form = {
+                name: '', title: '', kind: 'ftp', host: '', port: 0, username: '', password: '',
+                bucket: '', region: '', path_style: false, host_key: '', dir: '', ext_dirs: '',
+                verify: true, enabled: true
+            };
+            this.showForm = true;
+        },
+
+        openEdit(target) {
+            this.editing = target;
+            this.form = {
+                name: target.name, title: target.title, kind: target.kind, host: target.host, port: target.port,
+                username: target.username, password: '', bucket: target.bucket, region: target.region,
+                path_style: target.path_style, host_key: target.host_key, dir: target.dir, ext_dirs: target.ext_dirs,
+                verify: target.verify, enabled: target.enabled
+            };
+            this.showForm = true;
+        },
+
+        async submitForm() {
+            const url = this.editing ? `/api/admin/upload/targets/${this.editing.id}` : '/api/admin/upload/targets';
+            try {
+                await this.request(url, {
+                    method: this.editing ? 'PUT' : 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ ...this.form, port: parseInt(this.form.port) || 0 })
+                });
+                this.form.password = '';
+                this.showForm = false;
+                ShowMessage('保存成功');
+                this.fetchTargets();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async testTarget(target) {
+            try {
+                const data = await this.request(`/api/admin/upload/targets/${target.id}/test`, { method: 'POST' });
+                ShowMessage(data.message);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async deleteTarget(target) {
+            if (!confirm(`确定删除上传目标 ${target.name} 吗？`)) return;
+            try {
+                await this.request(`/api/admin/upload/targets/${target.id}`, { method: 'DELETE' });
+                ShowMessage('删除成功');
+                this.fetchTargets();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        async retry(record) {
+            try {
+                await this.request(`/api/admin/upload/records/${record.id}/retry`, { method: 'POST' });
+                ShowMessage('已开始上传');
+                this.page = 1;
+                this.fetchRecords();
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
+        progress(record) {
+            if (!record.size) return '';
+            return Math.floor(record.transferred * 100 / record.size) + '%';
+        },
+
+        formatSize(size) {
+            if (!size) return '0 B';
+            const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+            let i = 0;
+            while (size >= 1024 && i < units.length - 1) {
+                size /= 1024;
+                i++;
+            }
+            return size.toFixed(i === 0 ? 0 : 2) + ' ' + units[i];
+        },
+
+        formatDuration(ms) {
+            if (!ms) return '';
+            return ms < 1000 ? ms + 'ms' : (ms / 1000).toFixed(1) + 's';
+        },
+
+        formatDate(date) {
+            if (!date) return '';
+            return new Date(date).toLocaleString('zh-CN', { hour12: false });
+        }
+    };
+}
@@ -9,9 +9,13 @@ function userManagement() {
             password: '',
             nickname: '',
             role_id: '',
-            status: 1
+            role_ids: [],
+            status: 1,
+            is_service: false
         },
         showPanel: false,
+        tokenUser: null,
+        sessionUser: null,
         isEditing: false,
         panelTitle: '创建用户',
         currentPage: 1,
@@ -66,7 +70,9 @@ function userManagement() {
                 password: '',
                 nickname: '',
                 role_id: '',
-                status: 1
+                role_ids: [],
+                status: 1,
+                is_service: false
             };
             this.isEditing = false;
             this.panelTitle = '创建用户';
@@ -80,6 +86,7 @@ function userManagement() {
                 password: '',
                 nickname: user.nickname,
                 role_id: user.role_id,
+                role_ids: (user.roles || []).map(r => r.id),
                 status: user.status
             };
             this.isEditing = true;
@@ -87,6 +94,18 @@ function userManagement() {
             this.showPanel = true;
         },
 
+        // openTokens 查看用户的 API 令牌，只能为服务账号创建令牌
+        openTokens(user) {
+            this.tokenUser = user;
+            this.$dispatch('open-tokens', { url: `/api/admin/users/${user.id}/tokens`, canCreate: user.is_service });
+        },
+
+        // openSessions 查看用户的登录会话
+        openSessions(user) {
+            this.sessionUser = user;
+            this.$dispatch('open-sessions', { url: `/api/admin/users/${user.id}/sessions` });
+        },
+
         closePanel() {
             this.showPanel = false;
         },
@@ -98,7 +117,7 @@ function userManagement() {
                     headers: {
                         'Content-Type': 'application/json'
                     },
-                    body: JSON.stringify(this.currentUser)
+                    body: JSON.stringify({ ...this.currentUser, role_ids: this.currentUser.role_ids.map(id => parseInt(id)) })
                 });
 
                 if (!response.ok) throw new Error('Failed to create user');
@@ -119,7 +138,7 @@ function userManagement() {
                     headers: {
                         'Content-Type': 'application/json'
                     },
-                    body: JSON.stringify(this.currentUser)
+                    body: JSON.stringify({ ...this.currentUser, role_ids: this.currentUser.role_ids.map(id => parseInt(id)) })
                 });
 
                 if (!response.ok) throw new Error('Failed to update user');
@@ -133,6 +152,24 @@ function userManagement() {
             }
         },
 
+        // resetTwoFactor 用户丢失身份验证器和恢复码时，由管理员重置两步验证
+        async resetTwoFactor(user) {
+            if (!confirm(`确定要重置用户 ${user.username} 的两步验证吗？重置后该用户只需要密码即可登录。`)) return;
+
+            try {
+                const response = await fetch(`/api/admin/users/${user.id}/2fa`, {
+                    method: 'DELETE'
+                });
+                const data = await response.json();
+                if (!response.ok) throw new Error(data.error || '重置两步验证失败');
+
+                await this.loadUsers();
+                ShowMessage(data.message);
+            } catch (error) {
+                ShowError(error.message);
+            }
+        },
+
         async deleteUser(id) {
             if (!confirm('确定要删除这个用户吗？')) return;
 